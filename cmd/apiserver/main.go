@@ -3,7 +3,9 @@ package main
 import (
 	"eastwh/internal/apiserver"
 	"flag"
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/BurntSushi/toml"
 )
@@ -14,6 +16,11 @@ var (
 
 func init() {
 	flag.StringVar(&configPath, "config-path", "config/apiserver.toml", "path to config file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-config-path file] [command]\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintln(flag.CommandLine.Output(), apiserver.Usage)
+	}
 }
 
 func main() {
@@ -23,8 +30,10 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	config.LoadEnv()
 
-	if err := apiserver.Start(config); err != nil {
+	// Без команды запускается сервер, остальные команды - см. apiserver.Usage
+	if err := apiserver.Run(config, flag.Args()); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -1,13 +1,19 @@
 package apiserver
 
 import (
+	"context"
+	"eastwh/internal/migrate"
 	"eastwh/internal/model"
+	"eastwh/internal/store"
 	"eastwh/internal/store/sqlstore"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -21,7 +27,13 @@ func Start(config *Config) error {
 	f, _ := os.Create("log\\gin.log")
 	gin.DefaultWriter = io.MultiWriter(f)
 
-	db, err := newDB(config.DatabaseURL)
+	loc, err := time.LoadLocation(config.TimeZone)
+	if err != nil {
+		return fmt.Errorf("time_zone: %w", err)
+	}
+	model.Location = loc
+
+	db, err := newDB(config.DatabaseURL, logger.Info)
 	if err != nil {
 		return err
 	}
@@ -37,21 +49,30 @@ func Start(config *Config) error {
 	sqlDB.SetConnMaxLifetime(24 * time.Hour)
 
 	store := sqlstore.New(db)
-	srv := newServer(store)
+	srv, err := newServer(store, config)
+	if err != nil {
+		return err
+	}
+
+	if err := dbMigrate(context.Background(), db); err != nil {
+		return err
+	}
 
-	dbMigrate(db)
+	if err := seedAdminRole(context.Background(), store); err != nil {
+		return err
+	}
 
 	fmt.Println("Start OrdersBuid server")
 
 	return srv.router.Run(config.BindAddr)
 }
 
-func newDB(databaseURL string) (*gorm.DB, error) {
+func newDB(databaseURL string, logLevel logger.LogLevel) (*gorm.DB, error) {
 	newLogger := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags), // io writer
 		logger.Config{
 			SlowThreshold:             time.Second, // Slow SQL threshold
-			LogLevel:                  logger.Info, // Log level
+			LogLevel:                  logLevel,    // Log level
 			IgnoreRecordNotFoundError: true,        // Ignore ErrRecordNotFound error for logger
 			ParameterizedQueries:      true,        // Don't include params in the SQL log
 			Colorful:                  false,       // Disable color
@@ -65,12 +86,174 @@ func newDB(databaseURL string) (*gorm.DB, error) {
 	return db, err
 }
 
-func dbMigrate(db *gorm.DB) {
-	db.AutoMigrate(&model.User{}, &model.UserRole{}, &model.UserProject{}, &model.UserTeam{})
-	db.AutoMigrate(&model.Order{})
-	db.AutoMigrate(&model.Employee{})
-	db.AutoMigrate(&model.Role{})
-	db.AutoMigrate(&model.Team{})
-	db.AutoMigrate(&model.Project{})
-	db.AutoMigrate(&model.EmployeeTeam{})
+// dbMigrate приводит схему базы к последней версии миграций.
+func dbMigrate(ctx context.Context, db *gorm.DB) error {
+	m, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+	applied, err := m.Up(ctx)
+	for _, mig := range applied {
+		log.Printf("Применена миграция %d_%s", mig.Version, mig.Name)
+	}
+	return err
+}
+
+// newMigrator возвращает миграции схемы базы для сервера и команды migrate.
+// База, которую до появления миграций создавал AutoMigrate, перед первой
+// миграцией дополняется прежним способом: 0001_create_tables не меняет
+// существующие таблицы.
+func newMigrator(db *gorm.DB) (*migrate.Migrator, error) {
+	migrations, err := migrate.Embedded()
+	if err != nil {
+		return nil, err
+	}
+	m := migrate.New(db, migrations)
+	m.Baseline = func(db *gorm.DB) error {
+		if !db.Migrator().HasTable(&model.User{}) {
+			return nil
+		}
+		if err := migrateLegacy(db); err != nil {
+			return fmt.Errorf("legacy schema: %w", err)
+		}
+		return nil
+	}
+	return m, nil
+}
+
+// migrateLegacy дополняет схему, созданную AutoMigrate, до состояния
+// первой миграции: переводит даты заказов в DATETIME, добавляет недостающие
+// колонки и индексы и заполняет статусы заказов.
+func migrateLegacy(db *gorm.DB) error {
+	if err := migrateOrderDates(db); err != nil {
+		return fmt.Errorf("migrate order dates: %w", err)
+	}
+	err := db.AutoMigrate(&model.User{}, &model.UserRole{}, &model.UserProject{}, &model.UserTeam{},
+		&model.RefreshToken{}, &model.Order{}, &model.OrderEvent{}, &model.OrderLine{}, &model.Employee{},
+		&model.Role{}, &model.RolePermission{}, &model.Team{}, &model.Project{}, &model.EmployeeTeam{},
+		&model.Tariff{}, &model.PayrollPeriod{}, &model.PayrollEntry{}, &model.SLARule{})
+	if err != nil {
+		return err
+	}
+	if err := migrateOrderStatus(db); err != nil {
+		return fmt.Errorf("migrate order status: %w", err)
+	}
+	return sqlstore.CreateOrderSearchIndex(db)
+}
+
+// orderDateColumns - даты заказа, которые раньше хранились строками.
+var orderDateColumns = []string{"folio_date", "order_date", "start_at", "finish_at"}
+
+// migrateOrderDates переводит строковые даты заказов в DATETIME. Значения
+// разбираются model.ParseTime в часовом поясе склада и пишутся во временную
+// колонку, которая затем заменяет исходную. Пустые и нераспознанные значения
+// становятся NULL, нераспознанные выводятся в лог.
+func migrateOrderDates(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.Order{}) {
+		return nil
+	}
+	columns, err := db.Migrator().ColumnTypes(&model.Order{})
+	if err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		typ := strings.ToUpper(column.DatabaseTypeName())
+		if !slices.Contains(orderDateColumns, column.Name()) || !(strings.Contains(typ, "CHAR") || strings.Contains(typ, "TEXT")) {
+			continue
+		}
+		if err := migrateOrderDateColumn(db, column.Name()); err != nil {
+			return fmt.Errorf("%s: %w", column.Name(), err)
+		}
+	}
+	return nil
+}
+
+func migrateOrderDateColumn(db *gorm.DB, column string) error {
+	tmp := column + "_ts"
+	if !db.Migrator().HasColumn(&model.Order{}, tmp) {
+		if err := db.Exec("ALTER TABLE orders ADD COLUMN " + tmp + " DATETIME(3) NULL").Error; err != nil {
+			return err
+		}
+	}
+
+	var lastID uint
+	var failed int
+	for {
+		var rows []struct {
+			ID    uint
+			Value *string
+		}
+		err := db.Table("orders").Select("id, "+column+" AS value").
+			Where("id > ?", lastID).Order("id").Limit(1000).Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		lastID = rows[len(rows)-1].ID
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, row := range rows {
+				if row.Value == nil || strings.TrimSpace(*row.Value) == "" {
+					continue
+				}
+				t, err := model.ParseTime(*row.Value)
+				if err != nil {
+					failed++
+					log.Printf("Заказ id=%d: %s = %q не распознана как дата", row.ID, column, *row.Value)
+					continue
+				}
+				if err := tx.Table("orders").Where("id = ?", row.ID).Update(tmp, t).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		log.Printf("Колонка orders.%s: %d значений не распознано и заменено на NULL", column, failed)
+	}
+
+	return db.Exec("ALTER TABLE orders DROP COLUMN " + column + ", CHANGE COLUMN " + tmp + " " + column + " DATETIME(3) NULL").Error
+}
+
+// migrateOrderStatus заполняет статус заказов, загруженных до появления
+// жизненного цикла, по прежним признакам done и check.
+func migrateOrderStatus(db *gorm.DB) error {
+	err := db.Model(&model.Order{}).Where("status = ? AND `check` = 1", model.OrderStatusNew).
+		Update("status", model.OrderStatusChecked).Error
+	if err != nil {
+		return err
+	}
+	return db.Model(&model.Order{}).Where("status = ? AND done = 1", model.OrderStatusNew).
+		Update("status", model.OrderStatusAssembled).Error
+}
+
+// seedAdminRole создает роль администратора и выдает ей все права каталога,
+// чтобы после добавления новых прав администратор не терял к ним доступ.
+func seedAdminRole(ctx context.Context, st store.Store) error {
+	role, err := st.Role().ByName(ctx, model.AdminRoleName)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		role, err = st.Role().Add(ctx, model.Role{
+			Name:        model.AdminRoleName,
+			Description: "Администратор",
+			Priority:    model.AdminRolePriority,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	perms := make([]model.Permission, 0, len(model.Permissions))
+	for _, p := range model.Permissions {
+		perms = append(perms, p.Code)
+	}
+
+	_, err = st.RolePermission().SetForRole(ctx, role.ID, perms)
+	return err
 }
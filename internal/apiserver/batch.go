@@ -0,0 +1,79 @@
+package apiserver
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errBatchFailed = errors.New("batch failed")
+
+// atomicBatch читает параметр atomic пакетного запроса, при ошибке ответ 400 уже отправлен.
+func atomicBatch(ctx *gin.Context) (atomic, ok bool) {
+	raw := ctx.Query("atomic")
+	if raw == "" {
+		return false, true
+	}
+	atomic, err := strconv.ParseBool(raw)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность atomic",
+			"error": err.Error()})
+		return false, false
+	}
+	return atomic, true
+}
+
+// runBatch обрабатывает элементы пакета по порядку. В атомарном режиме все
+// элементы выполняются в одной транзакции: ошибка любого элемента откатывает
+// весь пакет, но остальные элементы все равно проверяются, чтобы отчет содержал
+// все ошибки. Иначе каждый элемент фиксируется независимо.
+func runBatch[T any](ctx context.Context, st store.Store, atomic bool, items []T, fn func(store.Store, T) (any, error)) (model.BatchReport, error) {
+	report := model.BatchReport{Atomic: atomic, Results: make([]model.BatchResult, 0, len(items))}
+	process := func(st store.Store) {
+		for i, item := range items {
+			saved, err := fn(st, item)
+			if err != nil {
+				saved = item
+			}
+			report.Add(i, saved, err)
+		}
+	}
+
+	if !atomic {
+		process(st)
+		return report, nil
+	}
+
+	err := st.WithTx(ctx, func(tx store.Store) error {
+		process(tx)
+		if report.Failed > 0 {
+			return errBatchFailed
+		}
+		return nil
+	})
+	if err != nil {
+		report.RollBack()
+		if !errors.Is(err, errBatchFailed) {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// writeBatch отправляет отчет пакета: 207, если часть элементов не обработана,
+// 422, если атомарный пакет отменен.
+func writeBatch(ctx *gin.Context, okStatus int, report model.BatchReport) {
+	status := okStatus
+	switch {
+	case report.Failed > 0 && report.Atomic:
+		status = http.StatusUnprocessableEntity
+	case report.Failed > 0:
+		status = http.StatusMultiStatus
+	}
+	ctx.JSON(status, report)
+}
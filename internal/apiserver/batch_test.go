@@ -0,0 +1,252 @@
+package apiserver
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"eastwh/internal/store/teststore"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// newStoreUser создает в хранилище в памяти пользователя с ролью, дающей права perms.
+func newStoreUser(t *testing.T, st *teststore.Store, perms ...model.Permission) model.User {
+	t.Helper()
+	ctx := context.Background()
+	u, err := st.User().Add(ctx, model.User{Email: "picker@eastwh.local", Password: "secret", Name: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	role, err := st.Role().Add(ctx, model.Role{Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.RolePermission().SetForRole(ctx, role.ID, perms); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.UserRole().Add(ctx, model.UserRole{UserID: u.ID, RoleID: role.ID}); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func decodeReport(t *testing.T, body []byte) model.BatchReport {
+	t.Helper()
+	var report model.BatchReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func TestAddEmployee_AtomicBatchRollsBack(t *testing.T) {
+	st := teststore.New()
+	u := newStoreUser(t, st, model.PermEmployeesManage)
+	s := newTestServer(t, st)
+
+	body := `[{"code":"E1","first_name":"Иванов","name":"Иван"},{"code":"E1","first_name":"Петров","name":"Петр"}]`
+	rec := serve(s, authorized(t, s, http.MethodPost, "/api/v1/employees?atomic=true", body, u.ID))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", rec.Code, rec.Body)
+	}
+	report := decodeReport(t, rec.Body.Bytes())
+	if report.Committed != 0 || report.Results[0].Status != model.BatchRolledBack || report.Results[1].Status != model.BatchFailed {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	employees, err := st.Employee().All(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(employees) != 0 {
+		t.Fatalf("atomic batch was not rolled back: %+v", employees)
+	}
+}
+
+func TestAddEmployee_BatchCommitsValidItems(t *testing.T) {
+	st := teststore.New()
+	u := newStoreUser(t, st, model.PermEmployeesManage)
+	s := newTestServer(t, st)
+
+	body := `[{"code":"E1","first_name":"Иванов","name":"Иван"},{"code":"E1","first_name":"Петров","name":"Петр"}]`
+	rec := serve(s, authorized(t, s, http.MethodPost, "/api/v1/employees", body, u.ID))
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("expected 207, got %d: %s", rec.Code, rec.Body)
+	}
+	if report := decodeReport(t, rec.Body.Bytes()); report.Committed != 1 || report.Failed != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	if _, err := st.Employee().ByCode(context.Background(), "E1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// failingAssembleStore - хранилище, в котором перевод заказа в "собран" завершается ошибкой.
+type failingAssembleStore struct {
+	*teststore.Store
+}
+
+func (s failingAssembleStore) WithTx(ctx context.Context, fn func(store.Store) error) error {
+	return s.Store.WithTx(ctx, func(tx store.Store) error {
+		return fn(failingAssembleStore{tx.(*teststore.Store)})
+	})
+}
+
+func (s failingAssembleStore) Order() store.OrderRepository {
+	return failingAssembleOrders{s.Store.Order()}
+}
+
+type failingAssembleOrders struct {
+	store.OrderRepository
+}
+
+var errAssemble = errors.New("assemble failed")
+
+func (r failingAssembleOrders) Transition(ctx context.Context, t model.OrderTransition) (model.Order, error) {
+	if t.Status == model.OrderStatusAssembled {
+		return model.Order{}, errAssemble
+	}
+	return r.OrderRepository.Transition(ctx, t)
+}
+
+func TestCollectOrder_FailureLeavesOrderUnassigned(t *testing.T) {
+	ctx := context.Background()
+	st := teststore.New()
+	if _, err := st.Order().Add(ctx, model.Order{OrderUid: 1, FolioDate: time.Now(), OrderDate: time.Now()}, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := collectOrder(ctx, failingAssembleStore{st}, 1, 5, 6, 9); !errors.Is(err, errAssemble) {
+		t.Fatalf("expected %v, got %v", errAssemble, err)
+	}
+	orders, err := st.Order().ByOrderUID(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orders[0].Status != model.OrderStatusNew || orders[0].UserID != 0 {
+		t.Fatalf("order was left half-collected: %+v", orders[0])
+	}
+
+	o, err := collectOrder(ctx, st, 1, 5, 6, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Status != model.OrderStatusAssembled || o.UserID != 5 || o.EmployeeID != 6 {
+		t.Fatalf("unexpected collected order %+v", o)
+	}
+}
+
+func addEmployeeItem(st store.Store, code string) (any, error) {
+	if code == "" {
+		return nil, errors.New("code is required")
+	}
+	return st.Employee().Add(context.Background(), model.Employee{Code: code, FirstName: code, Name: code})
+}
+
+func TestRunBatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		atomic    bool
+		codes     []string
+		statuses  []model.BatchStatus
+		committed int
+		saved     int
+	}{
+		{"atomic", true, []string{"E1", "E2"}, []model.BatchStatus{model.BatchOK, model.BatchOK}, 2, 2},
+		{"atomic rolls back", true, []string{"E1", "", "E2"},
+			[]model.BatchStatus{model.BatchRolledBack, model.BatchFailed, model.BatchRolledBack}, 0, 0},
+		{"independent", false, []string{"E1", "", "E2"},
+			[]model.BatchStatus{model.BatchOK, model.BatchFailed, model.BatchOK}, 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := teststore.New()
+			report, err := runBatch(context.Background(), st, tt.atomic, tt.codes, addEmployeeItem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Atomic != tt.atomic || report.Committed != tt.committed || len(report.Results) != len(tt.statuses) {
+				t.Fatalf("unexpected report %+v", report)
+			}
+			for i, want := range tt.statuses {
+				if report.Results[i].Index != i || report.Results[i].Status != want {
+					t.Fatalf("result %d: expected %s, got %+v", i, want, report.Results[i])
+				}
+			}
+
+			employees, err := st.Employee().All(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(employees) != tt.saved {
+				t.Fatalf("expected %d saved employees, got %+v", tt.saved, employees)
+			}
+		})
+	}
+}
+
+func TestRunBatch_TransactionError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report, err := runBatch(ctx, teststore.New(), true, []string{"E1"}, addEmployeeItem)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	if report.Committed != 0 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestUpsertOrders(t *testing.T) {
+	valid := func(uid int) model.Order {
+		return model.Order{OrderUid: uid, FolioDate: time.Now(), OrderDate: time.Now()}
+	}
+	tests := []struct {
+		name     string
+		atomic   bool
+		statuses []model.OrderImportStatus
+		saved    int
+	}{
+		{"atomic rolls back", true,
+			[]model.OrderImportStatus{model.OrderImportRolledBack, model.OrderImportFailed, model.OrderImportRolledBack}, 0},
+		{"independent", false,
+			[]model.OrderImportStatus{model.OrderImportCreated, model.OrderImportFailed, model.OrderImportCreated}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := teststore.New()
+			orders := []model.Order{valid(1), {OrderUid: 2}, valid(3)}
+			results, err := upsertOrders(context.Background(), st, tt.atomic, orders, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.statuses {
+				if results[i].Status != want {
+					t.Fatalf("order %d: expected %s, got %+v", i, want, results[i])
+				}
+			}
+
+			all, err := st.Order().All(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != tt.saved {
+				t.Fatalf("expected %d saved orders, got %d", tt.saved, len(all))
+			}
+		})
+	}
+
+	st := teststore.New()
+	results, err := upsertOrders(context.Background(), st, true, []model.Order{valid(1), valid(2)}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != model.OrderImportCreated || results[1].Status != model.OrderImportCreated {
+		t.Fatalf("unexpected results %+v", results)
+	}
+}
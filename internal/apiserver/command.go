@@ -0,0 +1,417 @@
+package apiserver
+
+import (
+	"bufio"
+	"context"
+	"eastwh/internal/export"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"eastwh/internal/store/sqlstore"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Usage - справка по командам apiserver.
+const Usage = `Команды:
+  serve                                 запустить сервер (по умолчанию)
+  migrate up|down [n]|status            миграции схемы базы
+  create-admin --email E                создать пользователя с ролью администратора
+  reset-password --email E [--temporary]
+                                        сменить пароль; с --temporary выдается временный
+  import-orders <file> [--atomic]       загрузить заказы из JSON, как POST /api/v1/orders
+  import-employees <file> [--atomic]    загрузить сотрудников из JSON, как POST /api/v1/employees
+  report assembly --from D --to D [--format xlsx|csv|json] [--out file]
+                                        выгрузить собранные заказы за период
+
+Пароль для create-admin и reset-password берется из переменной ` + PasswordEnv + `,
+иначе читается первой строкой стандартного ввода.`
+
+// PasswordEnv - переменная окружения с паролем для create-admin и reset-password.
+// Пароль не принимается флагом, чтобы он не попадал в список процессов и историю команд.
+const PasswordEnv = "EASTWH_PASSWORD"
+
+// passwordInput - ввод, из которого читается пароль, если PasswordEnv не задана.
+var passwordInput io.Reader = os.Stdin
+
+// command - административная команда, работающая с хранилищем напрямую.
+// Результат выводится в w.
+type command func(ctx context.Context, st store.Store, w io.Writer, args []string) error
+
+var commands = map[string]command{
+	"create-admin":     createAdminCommand,
+	"reset-password":   resetPasswordCommand,
+	"import-orders":    importOrdersCommand,
+	"import-employees": importEmployeesCommand,
+	"report":           reportCommand,
+}
+
+// Run выполняет команду командной строки с настройками config. Без команды
+// запускается сервер.
+func Run(config *Config, args []string) error {
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "serve" {
+		return Start(config)
+	}
+
+	cmd, ok := commands[name]
+	if !ok && name != "migrate" {
+		return fmt.Errorf("unknown command %q\n%s", name, Usage)
+	}
+
+	db, err := openDB(config)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	ctx := context.Background()
+	if name == "migrate" {
+		return runMigrate(ctx, db, os.Stdout, args)
+	}
+	return cmd(ctx, sqlstore.New(db), os.Stdout, args)
+}
+
+// openDB подключается к базе для команды: устанавливает часовой пояс склада,
+// запросы в лог не выводятся, чтобы не смешиваться с выводом команды.
+func openDB(config *Config) (*gorm.DB, error) {
+	loc, err := time.LoadLocation(config.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("time_zone: %w", err)
+	}
+	model.Location = loc
+
+	return newDB(config.DatabaseURL, logger.Silent)
+}
+
+// parseFlags разбирает флаги команды, которые могут стоять и после
+// позиционных аргументов, и возвращает позиционные аргументы.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// validatePassword проверяет длину пароля так же, как смена пароля через API.
+func validatePassword(password string) error {
+	if utf8.RuneCountInString(password) <= 6 {
+		return errors.New("password must be longer than 6 characters")
+	}
+	return nil
+}
+
+// readPassword возвращает пароль из PasswordEnv или первую строку passwordInput.
+// Если ввод - терминал, пароль запрашивается в w.
+func readPassword(w io.Writer) (string, error) {
+	if password, ok := os.LookupEnv(PasswordEnv); ok {
+		return password, nil
+	}
+	if f, ok := passwordInput.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			fmt.Fprint(w, "password: ")
+		}
+	}
+
+	line, err := bufio.NewReader(passwordInput).ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// createAdminCommand создает пользователя и назначает ему роль администратора.
+// Существующему пользователю роль назначается без смены пароля.
+func createAdminCommand(ctx context.Context, st store.Store, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	var user model.User
+	fs.StringVar(&user.Email, "email", "", "email пользователя")
+	fs.StringVar(&user.FirstName, "first-name", "", "фамилия")
+	fs.StringVar(&user.Name, "name", "", "имя")
+	fs.StringVar(&user.LastName, "last-name", "", "отчество")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return errors.New("create-admin: --email is required")
+	}
+
+	if err := seedAdminRole(ctx, st); err != nil {
+		return err
+	}
+	role, err := st.Role().ByName(ctx, model.AdminRoleName)
+	if err != nil {
+		return err
+	}
+
+	existing, err := st.User().ByEmail(ctx, user.Email)
+	switch {
+	case err == nil:
+		user = existing
+		fmt.Fprintf(w, "user %s already exists, id %d\n", user.Email, user.ID)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if user.Password, err = readPassword(w); err != nil {
+			return fmt.Errorf("create-admin: %w", err)
+		}
+		if err := validatePassword(user.Password); err != nil {
+			return fmt.Errorf("create-admin: %w", err)
+		}
+		user, err = st.User().Add(ctx, user)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "user %s created, id %d\n", user.Email, user.ID)
+	default:
+		return err
+	}
+
+	roles, err := st.UserRole().ByUserID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	for _, ur := range roles {
+		if ur.RoleID == role.ID {
+			fmt.Fprintf(w, "role %s is already assigned\n", role.Name)
+			return nil
+		}
+	}
+	if _, err := st.UserRole().Add(ctx, model.UserRole{UserID: user.ID, RoleID: role.ID}); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "role %s assigned\n", role.Name)
+	return nil
+}
+
+// resetPasswordCommand меняет пароль пользователя. С --temporary пользователю
+// выдается временный пароль, как при восстановлении через API.
+func resetPasswordCommand(ctx context.Context, st store.Store, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ContinueOnError)
+	email := fs.String("email", "", "email пользователя")
+	temporary := fs.Bool("temporary", false, "выдать временный пароль")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return errors.New("reset-password: --email is required")
+	}
+
+	if *temporary {
+		password, err := st.User().Restore(ctx, *email)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "temporary password for %s: %s\n", *email, password)
+		return nil
+	}
+
+	user, err := st.User().ByEmail(ctx, *email)
+	if err != nil {
+		return err
+	}
+	password, err := readPassword(w)
+	if err != nil {
+		return fmt.Errorf("reset-password: %w", err)
+	}
+	if err := validatePassword(password); err != nil {
+		return fmt.Errorf("reset-password: %w", err)
+	}
+	if err := st.User().ChangePassword(ctx, user.ID, password); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "password for %s changed\n", *email)
+	return nil
+}
+
+// batchArgs разбирает аргументы загрузки: файл JSON ("-" - стандартный ввод)
+// и флаг --atomic.
+func batchArgs(name string, args []string) (file string, atomic bool, err error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.BoolVar(&atomic, "atomic", false, "загрузить все элементы или ни одного")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return "", false, err
+	}
+	if len(positional) != 1 {
+		return "", false, fmt.Errorf("%s: exactly one file is required", name)
+	}
+	return positional[0], atomic, nil
+}
+
+func readJSON(file string, v any) error {
+	r := os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// importOrdersCommand загружает заказы так же, как POST /api/v1/orders.
+// Команда завершается ошибкой, если хотя бы один заказ не загружен.
+func importOrdersCommand(ctx context.Context, st store.Store, w io.Writer, args []string) error {
+	file, atomic, err := batchArgs("import-orders", args)
+	if err != nil {
+		return err
+	}
+	var orders []model.Order
+	if err := readJSON(file, &orders); err != nil {
+		return err
+	}
+
+	results, err := upsertOrders(ctx, st, atomic, orders, 0)
+	if err != nil {
+		return err
+	}
+
+	summary := make(map[model.OrderImportStatus]int)
+	for _, r := range results {
+		summary[r.Status]++
+		if r.Error != "" {
+			fmt.Fprintf(w, "order %d: %s: %s\n", r.OrderUID, r.Status, r.Error)
+		}
+	}
+	statuses := make([]string, 0, len(summary))
+	for status := range summary {
+		statuses = append(statuses, string(status))
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(w, "%s: %d\n", status, summary[model.OrderImportStatus(status)])
+	}
+
+	if failed := summary[model.OrderImportFailed]; failed > 0 {
+		return fmt.Errorf("import-orders: %d of %d orders failed", failed, len(orders))
+	}
+	return nil
+}
+
+// importEmployeesCommand загружает сотрудников так же, как POST /api/v1/employees.
+func importEmployeesCommand(ctx context.Context, st store.Store, w io.Writer, args []string) error {
+	file, atomic, err := batchArgs("import-employees", args)
+	if err != nil {
+		return err
+	}
+	var employees []model.Employee
+	if err := readJSON(file, &employees); err != nil {
+		return err
+	}
+
+	report, err := runBatch(ctx, st, atomic, employees, func(st store.Store, emp model.Employee) (any, error) {
+		return st.Employee().Add(ctx, emp)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range report.Results {
+		if r.Error != "" {
+			fmt.Fprintf(w, "employee %s: %s: %s\n", employees[r.Index].Code, r.Status, r.Error)
+		}
+	}
+	fmt.Fprintf(w, "committed: %d, failed: %d\n", report.Committed, report.Failed)
+
+	if report.Failed > 0 {
+		return fmt.Errorf("import-employees: %d of %d employees failed", report.Failed, len(employees))
+	}
+	return nil
+}
+
+// reportCommand выгружает отчет. Пока доступен только отчет assembly -
+// собранные заказы, как POST /api/v1/orders/assembly/.
+func reportCommand(ctx context.Context, st store.Store, w io.Writer, args []string) error {
+	if len(args) == 0 || args[0] != "assembly" {
+		return errors.New("report: unknown report, expected assembly")
+	}
+
+	fs := flag.NewFlagSet("report assembly", flag.ContinueOnError)
+	var filter model.AssemblyFilter
+	fs.StringVar(&filter.StartDT, "from", "", "начало периода")
+	fs.StringVar(&filter.FinishDT, "to", "", "конец периода")
+	fs.StringVar(&filter.VidDoc, "vid-doc", "", "вид документа")
+	fs.UintVar(&filter.TeamID, "team-id", 0, "бригада")
+	fs.UintVar(&filter.EmployeeID, "employee-id", 0, "сотрудник")
+	formatFlag := fs.String("format", string(export.FormatXLSX), "формат: xlsx, csv или json")
+	delimiter := fs.String("delimiter", "", "разделитель CSV")
+	encoding := fs.String("encoding", "", "кодировка CSV: utf-8 или windows-1251")
+	out := fs.String("out", "", "файл отчета, по умолчанию стандартный вывод")
+	if _, err := parseFlags(fs, args[1:]); err != nil {
+		return err
+	}
+
+	format, err := export.Negotiate(*formatFlag, "")
+	if err != nil {
+		return err
+	}
+	if _, err := filter.DateRange(); err != nil {
+		return err
+	}
+
+	orders, err := st.Order().AssemblyOrder(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	var opts export.CSVOptions
+	if format == export.FormatCSV {
+		if opts, err = export.ParseCSVOptions(*delimiter, *encoding); err != nil {
+			return err
+		}
+	}
+
+	if *out == "" {
+		return writeReport(w, format, opts, orders)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := writeReport(f, format, opts, orders); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeReport(w io.Writer, format export.Format, opts export.CSVOptions, orders []model.AssemblyOrder) error {
+	switch format {
+	case export.FormatXLSX:
+		return export.WriteXLSX(w, assemblyTable(orders))
+	case export.FormatCSV:
+		return export.WriteCSV(w, assemblyTable(orders), opts)
+	default:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(orders)
+	}
+}
@@ -0,0 +1,226 @@
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store/teststore"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseFlags_AfterPositional(t *testing.T) {
+	_, atomic, err := batchArgs("import-orders", []string{"orders.json", "--atomic"})
+	if err != nil || !atomic {
+		t.Fatalf("atomic = %v, err = %v", atomic, err)
+	}
+	if _, _, err := batchArgs("import-orders", []string{"a.json", "b.json"}); err == nil {
+		t.Fatal("expected error for two files")
+	}
+}
+
+// withPasswordInput подменяет ввод пароля команд на input, переменная PasswordEnv не задана.
+func withPasswordInput(t *testing.T, input string) {
+	t.Helper()
+	t.Setenv(PasswordEnv, "")
+	os.Unsetenv(PasswordEnv)
+	prev := passwordInput
+	passwordInput = strings.NewReader(input)
+	t.Cleanup(func() { passwordInput = prev })
+}
+
+func TestReadPassword(t *testing.T) {
+	var out bytes.Buffer
+	withPasswordInput(t, "secret-pass\r\nnext line\n")
+	if password, err := readPassword(&out); err != nil || password != "secret-pass" {
+		t.Fatalf("password = %q, err = %v", password, err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("unexpected prompt %q for non-terminal input", out.String())
+	}
+
+	withPasswordInput(t, "no newline")
+	if password, err := readPassword(&out); err != nil || password != "no newline" {
+		t.Fatalf("password = %q, err = %v", password, err)
+	}
+	withPasswordInput(t, "")
+	if _, err := readPassword(&out); err == nil {
+		t.Fatal("expected error for empty input")
+	}
+
+	t.Setenv(PasswordEnv, "from-env")
+	if password, err := readPassword(&out); err != nil || password != "from-env" {
+		t.Fatalf("password = %q, err = %v", password, err)
+	}
+}
+
+func TestCreateAdminCommand(t *testing.T) {
+	st := teststore.New()
+	ctx := context.Background()
+	args := []string{"--email", "admin@eastwh.local", "--name", "Admin"}
+	withPasswordInput(t, "secret-pass\n")
+
+	var out bytes.Buffer
+	if err := createAdminCommand(ctx, st, &out, []string{"--email", "admin@eastwh.local", "--password", "secret-pass"}); err == nil {
+		t.Fatal("expected error for password in arguments")
+	}
+	if err := createAdminCommand(ctx, st, &out, args); err != nil {
+		t.Fatal(err)
+	}
+	u, err := st.User().Login(ctx, "admin@eastwh.local", "secret-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := st.Role().ByName(ctx, model.AdminRoleName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// повторный запуск не дублирует роль и не запрашивает пароль
+	withPasswordInput(t, "")
+	if err := createAdminCommand(ctx, st, &out, args); err != nil {
+		t.Fatal(err)
+	}
+	roles, err := st.UserRole().ByUserID(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 1 || roles[0].RoleID != admin.ID {
+		t.Fatalf("unexpected user roles %+v", roles)
+	}
+
+	t.Setenv(PasswordEnv, "123")
+	err = createAdminCommand(ctx, st, &out, []string{"--email", "short@eastwh.local"})
+	if err == nil {
+		t.Fatal("expected error for short password")
+	}
+}
+
+func TestResetPasswordCommand(t *testing.T) {
+	st := teststore.New()
+	ctx := context.Background()
+	u := newStoreUser(t, st)
+
+	var out bytes.Buffer
+	withPasswordInput(t, "new-secret\n")
+	if err := resetPasswordCommand(ctx, st, &out, []string{"--email", u.Email}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.User().Login(ctx, u.Email, "new-secret"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(PasswordEnv, "env-secret")
+	if err := resetPasswordCommand(ctx, st, &out, []string{"--email", u.Email}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.User().Login(ctx, u.Email, "env-secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := resetPasswordCommand(ctx, st, &out, []string{"--email", u.Email, "--temporary"}); err != nil {
+		t.Fatal(err)
+	}
+	temporary := strings.TrimSpace(out.String()[strings.LastIndex(out.String(), ":")+1:])
+	logged, err := st.User().Login(ctx, u.Email, temporary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !logged.Restore {
+		t.Fatal("temporary password must require change")
+	}
+
+	if err := resetPasswordCommand(ctx, st, &out, []string{"--email", "nobody@eastwh.local"}); err == nil {
+		t.Fatal("expected error for unknown user")
+	}
+}
+
+func TestImportEmployeesCommand(t *testing.T) {
+	st := teststore.New()
+	ctx := context.Background()
+	file := writeFile(t, "employees.json",
+		`[{"code":"E1","first_name":"Иванов","name":"Иван"},{"code":"E1","first_name":"Петров","name":"Петр"}]`)
+
+	var out bytes.Buffer
+	if err := importEmployeesCommand(ctx, st, &out, []string{file, "--atomic"}); err == nil {
+		t.Fatal("expected error for duplicate code")
+	}
+	employees, err := st.Employee().All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(employees) != 0 {
+		t.Fatalf("atomic import was not rolled back: %+v", employees)
+	}
+
+	out.Reset()
+	if err := importEmployeesCommand(ctx, st, &out, []string{file}); err == nil {
+		t.Fatal("expected error for duplicate code")
+	}
+	if !strings.Contains(out.String(), "committed: 1, failed: 1") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
+
+func TestImportOrdersCommand(t *testing.T) {
+	st := teststore.New()
+	ctx := context.Background()
+	file := writeFile(t, "orders.json",
+		`[{"order_uid":1,"vid_doc":"R","folio_date":"2024-03-01T00:00:00Z","order_date":"2024-03-01T00:00:00Z"}]`)
+
+	var out bytes.Buffer
+	if err := importOrdersCommand(ctx, st, &out, []string{file}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "created: 1") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+	if orders, err := st.Order().ByOrderUID(ctx, 1); err != nil || len(orders) != 1 {
+		t.Fatalf("order was not imported: %+v, %v", orders, err)
+	}
+
+	out.Reset()
+	if err := importOrdersCommand(ctx, st, &out, []string{file}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "unchanged: 1") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
+
+func TestReportCommand(t *testing.T) {
+	st := teststore.New()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "assembly.xlsx")
+
+	var out bytes.Buffer
+	args := []string{"assembly", "--from", "2024-03-01", "--to", "2024-03-31", "--out", path}
+	if err := reportCommand(ctx, st, &out, args); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("PK")) {
+		t.Fatal("report is not an xlsx file")
+	}
+
+	if err := reportCommand(ctx, st, &out, []string{"assembly", "--from", "01.03"}); err == nil {
+		t.Fatal("expected error for invalid period")
+	}
+	if err := reportCommand(ctx, st, &out, []string{"payroll"}); err == nil {
+		t.Fatal("expected error for unknown report")
+	}
+}
@@ -1,9 +1,61 @@
 package apiserver
 
+import (
+	"os"
+	"time"
+)
+
+// JWTSecretEnv - переменная окружения с секретом подписи токенов. Секрет
+// не хранится в файле конфигурации, значение переменной заменяет jwt.secret.
+const JWTSecretEnv = "EASTWH_JWT_SECRET"
+
 type Config struct {
-	BindAddr    string `toml:"bind_addr"`
-	LogLevel    string `toml:"log_level"`
-	DatabaseURL string `toml:"database_url"`
+	BindAddr    string    `toml:"bind_addr"`
+	LogLevel    string    `toml:"log_level"`
+	DatabaseURL string    `toml:"database_url"`
+	JWT         JWTConfig `toml:"jwt"`
+	// TimeZone - часовой пояс склада (имя из базы IANA или Local). В нем
+	// разбираются даты без смещения и выводятся даты заказов.
+	TimeZone string `toml:"time_zone"`
+	// DispatchStrategy - порядок выдачи заказов сборщикам по умолчанию:
+	// fifo, priority, client или route
+	DispatchStrategy string `toml:"dispatch_strategy"`
+	// Timeouts - предельное время обработки запросов
+	Timeouts TimeoutConfig `toml:"timeouts"`
+}
+
+// TimeoutConfig - сроки обработки запросов. Routes задает срок для отдельных
+// маршрутов в виде "МЕТОД /путь" по шаблону маршрута, остальным применяется
+// Default. По истечении срока контекст запроса отменяется вместе с запросами
+// к базе; нулевой срок не ограничивает запрос.
+type TimeoutConfig struct {
+	Default time.Duration            `toml:"default"`
+	Routes  map[string]time.Duration `toml:"routes"`
+}
+
+// For возвращает срок обработки маршрута.
+func (c TimeoutConfig) For(method, path string) time.Duration {
+	if d, ok := c.Routes[method+" "+path]; ok {
+		return d
+	}
+	return c.Default
+}
+
+// JWTConfig - ключи подписи токенов. Новые токены подписываются ключом KeyID,
+// ключи из PreviousKeys принимаются только для проверки, пока не истекут выданные ими токены.
+type JWTConfig struct {
+	KeyID           string            `toml:"key_id"`
+	Secret          string            `toml:"secret"`
+	PreviousKeys    map[string]string `toml:"previous_keys"`
+	AccessTokenTTL  time.Duration     `toml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration     `toml:"refresh_token_ttl"`
+}
+
+// LoadEnv дополняет конфигурацию значениями из переменных окружения.
+func (c *Config) LoadEnv() {
+	if secret := os.Getenv(JWTSecretEnv); secret != "" {
+		c.JWT.Secret = secret
+	}
 }
 
 func NewConfig() *Config {
@@ -11,5 +63,18 @@ func NewConfig() *Config {
 		DatabaseURL: "pmp:pmp1226@(nor.ru:3306)/eastwh?parseTime=true",
 		BindAddr:    "127.0.0.1:8091",
 		LogLevel:    "debug",
+		TimeZone:    "Local",
+		JWT: JWTConfig{
+			KeyID:           "default",
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+		},
+		Timeouts: TimeoutConfig{
+			Default: 30 * time.Second,
+			Routes: map[string]time.Duration{
+				"POST /api/v1/orders":           5 * time.Minute,
+				"POST /api/v1/orders/assembly/": 2 * time.Minute,
+			},
+		},
 	}
 }
@@ -0,0 +1,117 @@
+package apiserver
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// blockingOrderRepository ведет себя как запрос к базе, который не успевает
+// выполниться: ждет отмены контекста и сообщает, чем он был отменен.
+type blockingOrderRepository struct {
+	store.OrderRepository
+	started chan struct{}
+	aborted chan error
+}
+
+func newBlockingOrderRepository() *blockingOrderRepository {
+	return &blockingOrderRepository{started: make(chan struct{}, 1), aborted: make(chan error, 1)}
+}
+
+func (r *blockingOrderRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.Order], error) {
+	r.started <- struct{}{}
+	select {
+	case <-ctx.Done():
+		r.aborted <- ctx.Err()
+		return model.Page[model.Order]{}, ctx.Err()
+	case <-time.After(5 * time.Second):
+		r.aborted <- nil
+		return model.Page[model.Order]{}, nil
+	}
+}
+
+func waitAborted(t *testing.T, orders *blockingOrderRepository, want error) {
+	t.Helper()
+	select {
+	case err := <-orders.aborted:
+		if !errors.Is(err, want) {
+			t.Fatalf("expected query aborted with %v, got %v", want, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("query was not aborted")
+	}
+}
+
+func TestTimeoutConfig_For(t *testing.T) {
+	c := TimeoutConfig{
+		Default: time.Second,
+		Routes:  map[string]time.Duration{"POST /api/v1/orders": time.Minute},
+	}
+
+	if d := c.For(http.MethodPost, "/api/v1/orders"); d != time.Minute {
+		t.Fatalf("expected route timeout, got %v", d)
+	}
+	if d := c.For(http.MethodGet, "/api/v1/orders"); d != time.Second {
+		t.Fatalf("expected default timeout, got %v", d)
+	}
+}
+
+func TestDeadline_RouteTimeoutAbortsQuery(t *testing.T) {
+	st := newFakeStore(testUser(1))
+	st.grant(1, 1, model.PermOrdersView)
+	orders := newBlockingOrderRepository()
+	st.orders = orders
+
+	config := testConfig()
+	config.Timeouts = TimeoutConfig{
+		Default: time.Minute,
+		Routes:  map[string]time.Duration{"GET /api/v1/orders": 20 * time.Millisecond},
+	}
+	s, err := newServer(st, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	rec := serve(s, authorized(t, s, http.MethodGet, "/api/v1/orders", "", 1))
+	waitAborted(t, orders, context.DeadlineExceeded)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("request took %v, expected to stop at the route deadline", elapsed)
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected %d, got %d: %s", http.StatusInternalServerError, rec.Code, rec.Body.String())
+	}
+}
+
+func TestDeadline_CancelledRequestAbortsQuery(t *testing.T) {
+	st := newFakeStore(testUser(1))
+	st.grant(1, 1, model.PermOrdersView)
+	orders := newBlockingOrderRepository()
+	st.orders = orders
+	s := newTestServer(t, st)
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req := authorized(t, s, http.MethodGet, "/api/v1/orders", "", 1).WithContext(reqCtx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		serve(s, req)
+	}()
+
+	select {
+	case <-orders.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("query was not started")
+	}
+	// Клиент разорвал соединение
+	cancel()
+
+	waitAborted(t, orders, context.Canceled)
+	<-done
+}
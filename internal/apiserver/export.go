@@ -0,0 +1,124 @@
+package apiserver
+
+import (
+	"eastwh/internal/export"
+	"eastwh/internal/model"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFormat определяет формат ответа списка по ?format= и заголовку Accept.
+// При ошибке ответ 400 уже отправлен.
+func exportFormat(ctx *gin.Context) (export.Format, bool) {
+	format, err := export.Negotiate(ctx.Query("format"), ctx.GetHeader("Accept"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестный формат выгрузки",
+			"error": err.Error()})
+		return "", false
+	}
+	return format, true
+}
+
+// writeExport выгружает отчет в XLSX или CSV. Для CSV учитываются параметры
+// delimiter и encoding (windows-1251 для 1С).
+func writeExport(ctx *gin.Context, format export.Format, table export.Table, name string) {
+	switch format {
+	case export.FormatCSV:
+		opts, err := export.ParseCSVOptions(ctx.Query("delimiter"), ctx.Query("encoding"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте параметры выгрузки CSV",
+				"error": err.Error()})
+			return
+		}
+		ctx.Header("Content-Type", opts.ContentType())
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+		ctx.Status(http.StatusOK)
+		if err := export.WriteCSV(ctx.Writer, table, opts); err != nil {
+			ctx.Error(err)
+		}
+	case export.FormatXLSX:
+		ctx.Header("Content-Type", export.XLSXContentType)
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xlsx"`, name))
+		ctx.Status(http.StatusOK)
+		if err := export.WriteXLSX(ctx.Writer, table); err != nil {
+			ctx.Error(err)
+		}
+	}
+}
+
+func yesNo(v bool) string {
+	if v {
+		return "Да"
+	}
+	return "Нет"
+}
+
+func ordersTable(name string, orders []model.Order) export.Table {
+	t := export.Table{
+		Name: name,
+		Columns: []export.Column{
+			{Header: "Код заказа", Type: export.Integer},
+			{Header: "Уникальный номер", Type: export.Integer},
+			{Header: "Номер накладной", Type: export.Integer},
+			{Header: "Дата накладной", Type: export.Date},
+			{Header: "Дата заказа", Type: export.Date},
+			{Header: "Сумма заказа", Type: export.Number, Total: true},
+			{Header: "Сумма накладной", Type: export.Number, Total: true},
+			{Header: "Код клиента", Type: export.Integer},
+			{Header: "Клиент"},
+			{Header: "Адрес"},
+			{Header: "Водитель"},
+			{Header: "Агент"},
+			{Header: "Организация"},
+			{Header: "Вид документа"},
+			{Header: "Статус"},
+			{Header: "Пользователь", Type: export.Integer},
+			{Header: "Сборщик", Type: export.Integer},
+			{Header: "Недостача"},
+		},
+	}
+
+	for _, o := range orders {
+		t.Rows = append(t.Rows, []any{
+			o.OrderUid, o.UnicumNum, o.FolioNum, o.FolioDate, o.OrderDate, o.OrderSum, o.FolioSum,
+			o.ClientId, o.ClientName, o.ClientAddress, o.Driver, o.Agent, o.Brieforg, o.VidDoc,
+			o.Status.Title(), o.UserID, o.EmployeeID, yesNo(o.Shortage),
+		})
+	}
+	return t
+}
+
+func assemblyTable(orders []model.AssemblyOrder) export.Table {
+	t := export.Table{
+		Name: "Собранные заказы",
+		Columns: []export.Column{
+			{Header: "Код заказа", Type: export.Integer},
+			{Header: "Номер накладной", Type: export.Integer},
+			{Header: "Дата накладной", Type: export.Date},
+			{Header: "Дата заказа", Type: export.Date},
+			{Header: "Сумма заказа", Type: export.Number, Total: true},
+			{Header: "Сумма накладной", Type: export.Number, Total: true},
+			{Header: "Клиент"},
+			{Header: "Вид документа"},
+			{Header: "Статус"},
+			{Header: "Пользователь"},
+			{Header: "Сборщик"},
+			{Header: "Загружен", Type: export.Date},
+			{Header: "Собран", Type: export.Date},
+			{Header: "Сборка, мин", Type: export.Number, Total: true},
+			{Header: "Сборка, ч", Type: export.Number, Total: true},
+			{Header: "Недостача"},
+		},
+	}
+
+	for _, o := range orders {
+		t.Rows = append(t.Rows, []any{
+			o.OrderUid, o.FolioNum, o.FolioDate, o.OrderDate, o.OrderSum, o.FolioSum,
+			o.ClientName, o.VidDoc, o.Status.Title(), o.UserName, o.EmployeeName,
+			o.CreatedAt, o.AssemblyDate, o.DurationMinutes, o.DurationHours, yesNo(o.Shortage),
+		})
+	}
+	return t
+}
@@ -0,0 +1,82 @@
+package apiserver
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store/teststore"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetOrders_ExportsAllPages(t *testing.T) {
+	st := teststore.New()
+	u := newStoreUser(t, st, model.PermOrdersView)
+
+	// Больше model.MaxListLimit подходящих заказов: выгрузка идет несколькими порциями
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	var orders []model.Order
+	for uid := 1; uid <= 1500; uid++ {
+		vidDoc := "R"
+		if uid%5 == 0 {
+			vidDoc = "X"
+		}
+		orders = append(orders, model.Order{OrderUid: uid, FolioNum: uid, VidDoc: vidDoc,
+			FolioDate: date, OrderDate: date, OrderSum: 1})
+	}
+	if _, err := st.Order().Upsert(context.Background(), orders, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestServer(t, st)
+	w := serve(s, authorized(t, s, http.MethodGet, "/api/v1/orders?format=csv&delimiter=,&vid_doc=R&sort=-order_uid&limit=5", "", u.ID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Заголовок, 1200 заказов вида R и итоги
+	if len(records) != 1202 {
+		t.Fatalf("got %d records", len(records))
+	}
+	rows := records[1 : len(records)-1]
+	prev := 1501
+	for _, r := range rows {
+		uid, err := strconv.Atoi(r[0])
+		if err != nil || uid >= prev || uid%5 == 0 {
+			t.Fatalf("unexpected order %q after %d", r[0], prev)
+		}
+		prev = uid
+	}
+	if totals := records[len(records)-1]; totals[0] != "Итого" || totals[5] != "1200.00" {
+		t.Fatalf("unexpected totals %q", totals)
+	}
+}
+
+func TestGetOrders_JSONKeepsPageLimit(t *testing.T) {
+	st := teststore.New()
+	u := newStoreUser(t, st, model.PermOrdersView)
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	var orders []model.Order
+	for uid := 1; uid <= 10; uid++ {
+		orders = append(orders, model.Order{OrderUid: uid, FolioNum: uid, VidDoc: "R", FolioDate: date, OrderDate: date})
+	}
+	if _, err := st.Order().Upsert(context.Background(), orders, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestServer(t, st)
+	w := serve(s, authorized(t, s, http.MethodGet, "/api/v1/orders?limit=5", "", u.ID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"limit":5`) || !strings.Contains(w.Body.String(), `"next_cursor"`) {
+		t.Fatalf("unexpected page %s", w.Body.String())
+	}
+}
@@ -0,0 +1,98 @@
+package apiserver
+
+import (
+	"bytes"
+	"eastwh/internal/label"
+	"eastwh/internal/model"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func renderLabels(ctx *gin.Context, format label.Format, name string, orders []model.Order) {
+	labels := make([]label.Label, 0, len(orders))
+	for _, o := range orders {
+		labels = append(labels, label.New(o))
+	}
+
+	var buf bytes.Buffer
+	if err := label.Render(&buf, format, labels); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка формирования этикетки",
+			"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.%s"`, name, format))
+	ctx.Data(http.StatusOK, format.ContentType(), buf.Bytes())
+}
+
+func (s *server) GetOrderLabel(ctx *gin.Context) {
+	format, err := label.ParseFormat(ctx.Query("format"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестный формат этикетки",
+			"error": err.Error()})
+		return
+	}
+
+	OrderUID, err := strconv.Atoi(ctx.Query("order_uid"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность OrderUID",
+			"error": err.Error()})
+		return
+	}
+
+	orders, err := s.store.Order().ByOrderUID(ctx.Request.Context(), uint(OrderUID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения заказа по OrderUID",
+			"error": err.Error()})
+		return
+	}
+	if len(orders) == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Заказ не найден"})
+		return
+	}
+
+	renderLabels(ctx, format, fmt.Sprintf("label_%d", OrderUID), orders)
+}
+
+// GetOrderLabels печатает этикетки всех собранных заказов за период одним файлом.
+func (s *server) GetOrderLabels(ctx *gin.Context) {
+	format, err := label.ParseFormat(ctx.Query("format"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестный формат этикетки",
+			"error": err.Error()})
+		return
+	}
+
+	dtStart, dtFinish := ctx.Query("dt_start"), ctx.Query("dt_finish")
+	if dtStart == "" || dtFinish == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Укажите период dt_start и dt_finish"})
+		return
+	}
+	period, ok := dateRange(ctx, dtStart, dtFinish)
+	if !ok {
+		return
+	}
+
+	orders, err := s.store.Order().ByDateRange(ctx.Request.Context(), period)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения заказов",
+			"error": err.Error()})
+		return
+	}
+
+	var assembled []model.Order
+	for _, o := range orders {
+		if o.Status.Assembled() {
+			assembled = append(assembled, o)
+		}
+	}
+	if len(assembled) == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Собранные заказы за период не найдены"})
+		return
+	}
+
+	renderLabels(ctx, format, "labels", assembled)
+}
@@ -0,0 +1,45 @@
+package apiserver
+
+import (
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// listQuery разбирает параметры постраничного списка и фильтры, разрешенные
+// спецификацией. При ошибке ответ уже отправлен.
+func listQuery(ctx *gin.Context, spec model.ListSpec) (model.ListQuery, bool) {
+	var q model.ListQuery
+	if err := ctx.ShouldBindQuery(&q); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте параметры списка",
+			"error": err.Error()})
+		return q, false
+	}
+
+	q.Filters = make(map[string]string)
+	for name := range spec.Filters {
+		if v := ctx.Query(name); v != "" {
+			q.Filters[name] = v
+		}
+	}
+
+	if err := q.Normalize(spec); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте параметры списка",
+			"error": err.Error()})
+		return q, false
+	}
+	return q, true
+}
+
+// listError отвечает на ошибку получения списка; устаревший или чужой курсор - ошибка клиента.
+func listError(ctx *gin.Context, message string, err error) {
+	if errors.Is(err, store.ErrInvalidCursor) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте параметр cursor",
+			"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"message": message, "error": err.Error()})
+}
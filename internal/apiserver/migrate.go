@@ -0,0 +1,77 @@
+package apiserver
+
+import (
+	"context"
+	"eastwh/internal/migrate"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// runMigrate выполняет команду миграций схемы базы:
+//
+//	up        применить все новые миграции
+//	down [n]  откатить n последних миграций, по умолчанию одну
+//	status    вывести состояние миграций
+func runMigrate(ctx context.Context, db *gorm.DB, w io.Writer, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("migrate: command is required: up, down [n] or status")
+	}
+
+	m, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		printMigrations(w, "applied", applied)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("migrate down: invalid number of steps %q", args[1])
+			}
+		}
+		rolledBack, err := m.Down(ctx, steps)
+		printMigrations(w, "rolled back", rolledBack)
+		return err
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printStatus(w, statuses)
+	default:
+		return fmt.Errorf("migrate: unknown command %q", args[0])
+	}
+}
+
+func printMigrations(w io.Writer, action string, migrations []migrate.Migration) {
+	if len(migrations) == 0 {
+		fmt.Fprintln(w, "no migrations", action)
+	}
+	for _, m := range migrations {
+		fmt.Fprintf(w, "%s %04d_%s\n", action, m.Version, m.Name)
+	}
+}
+
+func printStatus(w io.Writer, statuses []migrate.Status) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, s := range statuses {
+		appliedAt := ""
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format(time.DateTime)
+		}
+		fmt.Fprintf(tw, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, s.State, appliedAt)
+	}
+	return tw.Flush()
+}
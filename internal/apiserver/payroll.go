@@ -0,0 +1,205 @@
+package apiserver
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPayroll возвращает начисления за период. Для закрытого периода отдаются
+// сохраненные при закрытии суммы, для открытого - расчет по текущим данным.
+func (s *server) GetPayroll(ctx *gin.Context) {
+	var req model.PayrollRange
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Укажите период start_dt и finish_dt",
+			"error": err.Error()})
+		return
+	}
+	if err := req.Normalize(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте даты периода",
+			"error": err.Error()})
+		return
+	}
+
+	closed, err := s.store.Payroll().Overlapping(ctx.Request.Context(), req.StartDT, req.FinishDT)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения закрытых периодов",
+			"error": err.Error()})
+		return
+	}
+	for _, p := range closed {
+		if p.StartDT == req.StartDT && p.FinishDT == req.FinishDT {
+			ctx.JSON(http.StatusOK, model.Payroll{StartDT: p.StartDT, FinishDT: p.FinishDT,
+				Closed: true, Total: p.Total, Entries: p.Entries})
+			return
+		}
+	}
+	if len(closed) > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{"message": "Период пересекается с закрытым периодом",
+			"periods": closed})
+		return
+	}
+
+	payroll, err := s.calculatePayroll(ctx.Request.Context(), req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка расчета оплаты",
+			"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, payroll)
+}
+
+// ClosePayroll фиксирует начисления за период. После закрытия заказы периода
+// больше не влияют на выплаченные суммы.
+func (s *server) ClosePayroll(ctx *gin.Context) {
+	var req model.PayrollRange
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Укажите период start_dt и finish_dt",
+			"error": err.Error()})
+		return
+	}
+	if err := req.Normalize(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте даты периода",
+			"error": err.Error()})
+		return
+	}
+
+	payroll, err := s.calculatePayroll(ctx.Request.Context(), req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка расчета оплаты",
+			"error": err.Error()})
+		return
+	}
+
+	period, err := s.store.Payroll().Close(ctx.Request.Context(), model.PayrollPeriod{
+		StartDT:  req.StartDT,
+		FinishDT: req.FinishDT,
+		ClosedAt: time.Now(),
+		ClosedBy: currentUser(ctx).ID,
+		Total:    payroll.Total,
+		Entries:  payroll.Entries,
+		Orders:   payrollOrders(payroll.Orders),
+	})
+	if errors.Is(err, store.ErrPeriodClosed) {
+		ctx.JSON(http.StatusConflict, gin.H{"message": "Период пересекается с закрытым периодом",
+			"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка закрытия периода",
+			"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"message": "Период закрыт", "period": period,
+		"unassigned": payroll.Unassigned})
+}
+
+func (s *server) GetPayrollPeriods(ctx *gin.Context) {
+	periods, err := s.store.Payroll().Periods(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения закрытых периодов",
+			"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, periods)
+}
+
+func (s *server) GetTariffs(ctx *gin.Context) {
+	tariffs, err := s.store.Payroll().Tariffs(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения тарифов",
+			"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, tariffs)
+}
+
+func (s *server) UpdateTariffs(ctx *gin.Context) {
+	req, ok := bindVidDocSettings[model.Tariff](ctx, "Тариф для вида документа указан дважды",
+		"Расценки не могут быть отрицательными")
+	if !ok {
+		return
+	}
+
+	tariffs, err := s.store.Payroll().SetTariffs(ctx.Request.Context(), req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сохранения тарифов",
+			"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, tariffs)
+}
+
+// calculatePayroll рассчитывает оплату заказов, собранных в периоде.
+// Заказы, уже оплаченные в закрытых периодах, повторно не оплачиваются.
+func (s *server) calculatePayroll(ctx context.Context, r model.PayrollRange) (model.Payroll, error) {
+	orders, err := s.store.Order().AssemblyOrder(ctx, r.AssemblyFilter())
+	if err != nil {
+		return model.Payroll{}, err
+	}
+	uids := make([]int, 0, len(orders))
+	for _, o := range orders {
+		uids = append(uids, o.OrderUid)
+	}
+	paid, err := s.store.Payroll().Paid(ctx, uids)
+	if err != nil {
+		return model.Payroll{}, err
+	}
+	orders = slices.DeleteFunc(orders, func(o model.AssemblyOrder) bool { return slices.Contains(paid, o.OrderUid) })
+	tariffs, err := s.store.Payroll().Tariffs(ctx)
+	if err != nil {
+		return model.Payroll{}, err
+	}
+	shares, err := s.payrollShares(ctx, orders)
+	if err != nil {
+		return model.Payroll{}, err
+	}
+
+	payroll := model.CalculatePayroll(orders, tariffs, shares)
+	payroll.StartDT, payroll.FinishDT = r.StartDT, r.FinishDT
+	return payroll, nil
+}
+
+func payrollOrders(uids []int) []model.PayrollOrder {
+	orders := make([]model.PayrollOrder, 0, len(uids))
+	for _, uid := range uids {
+		orders = append(orders, model.PayrollOrder{OrderUID: uid})
+	}
+	return orders
+}
+
+// payrollShares возвращает функцию, распределяющую оплату заказа по долям,
+// записанным при сборке: последующая смена состава команды на них не влияет.
+// Заказ без записанных долей оплачивается сборщику.
+func (s *server) payrollShares(ctx context.Context, orders []model.AssemblyOrder) (func(model.AssemblyOrder) []model.PayrollShare, error) {
+	uids := make([]int, 0, len(orders))
+	for _, o := range orders {
+		uids = append(uids, o.OrderUid)
+	}
+	recorded, err := s.store.Payroll().Shares(ctx, uids)
+	if err != nil {
+		return nil, err
+	}
+
+	byOrder := make(map[int][]model.PayrollShare)
+	for _, r := range recorded {
+		byOrder[r.OrderUID] = append(byOrder[r.OrderUID],
+			model.PayrollShare{EmployeeID: r.EmployeeID, Name: r.EmployeeName, Share: r.Share})
+	}
+
+	return func(o model.AssemblyOrder) []model.PayrollShare {
+		if shares, ok := byOrder[o.OrderUid]; ok {
+			return shares
+		}
+		if o.EmployeeID == 0 {
+			return nil
+		}
+		return []model.PayrollShare{{EmployeeID: o.EmployeeID, Name: o.EmployeeName, Share: 1}}
+	}, nil
+}
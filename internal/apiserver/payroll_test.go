@@ -0,0 +1,127 @@
+package apiserver
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store/teststore"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPayroll_PaysOrdersInPeriodOfAssembly(t *testing.T) {
+	st := teststore.New()
+	u := newStoreUser(t, st, model.PermReportsView, model.PermPayrollManage)
+	s := newTestServer(t, st)
+
+	ctx := context.Background()
+	e, err := st.Employee().Add(ctx, model.Employee{Code: "E1", Name: "Петр"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Payroll().SetTariffs(ctx, []model.Tariff{{PerOrder: 10}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// накладная выставлена в феврале, заказ собран в марте
+	folio := time.Date(2024, time.February, 28, 0, 0, 0, 0, model.Location)
+	start := time.Date(2024, time.March, 4, 10, 0, 0, 0, model.Location)
+	if _, err := st.Order().Add(ctx, model.Order{OrderUid: 1, FolioNum: 5001, FolioDate: folio, OrderDate: folio}, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = st.Order().Transition(ctx, model.OrderTransition{OrderUID: 1, Status: model.OrderStatusAssigned, UserID: u.ID, EmployeeID: e.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []model.OrderWork{{Action: model.WorkStart, At: start}, {Action: model.WorkFinish, At: start.Add(time.Hour)}} {
+		w.OrderUID, w.UserID = 1, u.ID
+		if _, err := st.Order().Work(ctx, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := serve(s, authorized(t, s, http.MethodPost, "/api/v1/payroll/close", `{"start_dt":"2024-02-01","finish_dt":"2024-02-29"}`, u.ID))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("february was not closed: %d %s", rec.Code, rec.Body.String())
+	}
+	var closed struct {
+		Period model.PayrollPeriod `json:"period"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &closed); err != nil {
+		t.Fatal(err)
+	}
+	if closed.Period.Total != 0 {
+		t.Fatalf("order assembled in march was paid in february: %+v", closed.Period)
+	}
+
+	rec = serve(s, authorized(t, s, http.MethodGet, "/api/v1/payroll?start_dt=2024-03-01&finish_dt=2024-03-31", "", u.ID))
+	var payroll model.Payroll
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected march payroll %d %s", rec.Code, rec.Body.String())
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &payroll); err != nil {
+		t.Fatal(err)
+	}
+	if payroll.Total != 10 || len(payroll.Entries) != 1 || payroll.Entries[0].EmployeeID != e.ID {
+		t.Fatalf("order assembled in march was not paid in march: %+v", payroll)
+	}
+}
+
+func TestPayroll_DoesNotPayReassembledOrderTwice(t *testing.T) {
+	st := teststore.New()
+	u := newStoreUser(t, st, model.PermReportsView, model.PermPayrollManage)
+	s := newTestServer(t, st)
+
+	ctx := context.Background()
+	e, err := st.Employee().Add(ctx, model.Employee{Code: "E1", Name: "Петр"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Payroll().SetTariffs(ctx, []model.Tariff{{PerOrder: 10}}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, time.February, 20, 10, 0, 0, 0, model.Location)
+	if _, err := st.Order().Add(ctx, model.Order{OrderUid: 1, FolioNum: 5001, FolioDate: start, OrderDate: start}, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = st.Order().Transition(ctx, model.OrderTransition{OrderUID: 1, Status: model.OrderStatusAssigned, UserID: u.ID, EmployeeID: e.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	work := func(actions ...model.WorkAction) {
+		t.Helper()
+		for _, action := range actions {
+			start = start.Add(time.Hour)
+			if _, err := st.Order().Work(ctx, model.OrderWork{OrderUID: 1, UserID: u.ID, Action: action, At: start}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	work(model.WorkStart, model.WorkFinish)
+
+	rec := serve(s, authorized(t, s, http.MethodPost, "/api/v1/payroll/close", `{"start_dt":"2024-02-01","finish_dt":"2024-02-29"}`, u.ID))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("february was not closed: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// после закрытия февраля заказ вернули в сборку и собрали заново в марте
+	if _, err := st.Order().Transition(ctx, model.OrderTransition{OrderUID: 1, Status: model.OrderStatusPicking}); err != nil {
+		t.Fatal(err)
+	}
+	start = time.Date(2024, time.March, 4, 10, 0, 0, 0, model.Location)
+	work(model.WorkResume, model.WorkFinish)
+
+	rec = serve(s, authorized(t, s, http.MethodGet, "/api/v1/payroll?start_dt=2024-03-01&finish_dt=2024-03-31", "", u.ID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected march payroll %d %s", rec.Code, rec.Body.String())
+	}
+	var payroll model.Payroll
+	if err := json.Unmarshal(rec.Body.Bytes(), &payroll); err != nil {
+		t.Fatal(err)
+	}
+	if payroll.Total != 0 {
+		t.Fatalf("order paid in february was paid again in march: %+v", payroll)
+	}
+}
@@ -0,0 +1,205 @@
+package apiserver
+
+import (
+	"eastwh/internal/model"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errAccessDenied = errors.New("access denied")
+
+// access - роли пользователя, сведенные к максимальному приоритету и набору прав.
+type access struct {
+	priority    int
+	permissions map[model.Permission]bool
+}
+
+func (a access) has(perms ...model.Permission) bool {
+	for _, p := range perms {
+		if !a.permissions[p] {
+			return false
+		}
+	}
+	return true
+}
+
+func currentUser(ctx *gin.Context) model.User {
+	user, _ := ctx.MustGet("user").(model.User)
+	return user
+}
+
+func (s *server) userAccess(ctx *gin.Context) (access, error) {
+	if a, ok := ctx.Get("access"); ok {
+		return a.(access), nil
+	}
+
+	a := access{permissions: make(map[model.Permission]bool)}
+
+	userRoles, err := s.store.UserRole().ByUserID(ctx.Request.Context(), currentUser(ctx).ID)
+	if err != nil {
+		return a, err
+	}
+
+	roleIDs := make([]uint, 0, len(userRoles))
+	for _, ur := range userRoles {
+		role, err := s.store.Role().ByID(ctx.Request.Context(), ur.RoleID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return a, err
+		}
+		if role.Priority > a.priority {
+			a.priority = role.Priority
+		}
+		roleIDs = append(roleIDs, role.ID)
+	}
+
+	rolePermissions, err := s.store.RolePermission().ByRoleIDs(ctx.Request.Context(), roleIDs)
+	if err != nil {
+		return a, err
+	}
+	for _, rp := range rolePermissions {
+		a.permissions[rp.Permission] = true
+	}
+
+	ctx.Set("access", a)
+	return a, nil
+}
+
+// RequirePermission пропускает запрос, только если роли пользователя дают все перечисленные права.
+func (s *server) RequirePermission(perms ...model.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		a, err := s.userAccess(ctx)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения прав пользователя",
+				"error": err.Error()})
+			return
+		}
+
+		if !a.has(perms...) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Недостаточно прав",
+				"error": errAccessDenied.Error(), "required": perms})
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// RequireSelfOrPermission разрешает пользователю работать со своими данными
+// (param совпадает с его ID), а с чужими - только при наличии прав.
+func (s *server) RequireSelfOrPermission(param string, perms ...model.Permission) gin.HandlerFunc {
+	requirePermission := s.RequirePermission(perms...)
+	return func(ctx *gin.Context) {
+		if ctx.Query(param) == strconv.FormatUint(uint64(currentUser(ctx).ID), 10) {
+			ctx.Next()
+			return
+		}
+		requirePermission(ctx)
+	}
+}
+
+// canManageRole запрещает управлять ролями с приоритетом выше собственного.
+// При отказе ответ уже записан в ctx.
+func (s *server) canManageRole(ctx *gin.Context, priority int) bool {
+	a, err := s.userAccess(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения прав пользователя",
+			"error": err.Error()})
+		return false
+	}
+
+	if priority > a.priority {
+		ctx.JSON(http.StatusForbidden, gin.H{"message": "Нельзя управлять ролью с приоритетом выше собственного",
+			"error": errAccessDenied.Error()})
+		return false
+	}
+	return true
+}
+
+func (s *server) canManageRoleID(ctx *gin.Context, roleID uint) bool {
+	role, err := s.store.Role().ByID(ctx.Request.Context(), roleID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Роль не найдена",
+			"error": err.Error()})
+		return false
+	}
+	return s.canManageRole(ctx, role.Priority)
+}
+
+func (s *server) GetPermissions(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"permissions": model.Permissions})
+}
+
+func (s *server) GetRolePermissions(ctx *gin.Context) {
+	pRoleID := ctx.Query("role_id")
+	RoleID, err := strconv.Atoi(pRoleID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность role_id",
+			"error": err.Error()})
+		return
+	}
+
+	rp, err := s.store.RolePermission().ByRoleID(ctx.Request.Context(), uint(RoleID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения прав роли",
+			"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"role_id": RoleID, "permissions": rp})
+}
+
+func (s *server) UpdateRolePermissions(ctx *gin.Context) {
+	pRoleID := ctx.Query("role_id")
+	RoleID, err := strconv.Atoi(pRoleID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность role_id",
+			"error": err.Error()})
+		return
+	}
+
+	type request struct {
+		Permissions []model.Permission `json:"permissions"`
+	}
+
+	var req request
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность передаваемых данных",
+			"error": err.Error()})
+		return
+	}
+
+	seen := make(map[model.Permission]bool)
+	var perms []model.Permission
+	for _, p := range req.Permissions {
+		if !p.Valid() {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "Неизвестное право " + string(p)})
+			return
+		}
+		if !seen[p] {
+			seen[p] = true
+			perms = append(perms, p)
+		}
+	}
+
+	if !s.canManageRoleID(ctx, uint(RoleID)) {
+		return
+	}
+
+	rp, err := s.store.RolePermission().SetForRole(ctx.Request.Context(), uint(RoleID), perms)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обновления прав роли",
+			"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Права роли успешно обновлены",
+		"permissions": rp})
+}
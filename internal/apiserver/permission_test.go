@@ -0,0 +1,292 @@
+package apiserver
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store/teststore"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var protectedRoutes = []struct {
+	method string
+	path   string
+	perm   model.Permission
+}{
+	{http.MethodPost, "/api/v1/user/block/", model.PermUsersBlock},
+	{http.MethodPost, "/api/v1/user/projects", model.PermUsersManage},
+	{http.MethodGet, "/api/v1/user/projects", model.PermUsersView},
+	{http.MethodGet, "/api/v1/user/projects/project/", model.PermUsersView},
+	{http.MethodGet, "/api/v1/user/project/", model.PermUsersView},
+	{http.MethodPut, "/api/v1/user/project/", model.PermUsersManage},
+	{http.MethodDelete, "/api/v1/user/project/", model.PermUsersManage},
+	{http.MethodDelete, "/api/v1/user/project/user/", model.PermUsersManage},
+	{http.MethodPost, "/api/v1/user/roles", model.PermRolesManage},
+	{http.MethodGet, "/api/v1/user/roles", model.PermUsersView},
+	{http.MethodGet, "/api/v1/user/roles/role/", model.PermUsersView},
+	{http.MethodGet, "/api/v1/user/role/", model.PermUsersView},
+	{http.MethodPut, "/api/v1/user/role/", model.PermRolesManage},
+	{http.MethodDelete, "/api/v1/user/role/", model.PermRolesManage},
+	{http.MethodPost, "/api/v1/user/teams", model.PermUsersManage},
+	{http.MethodGet, "/api/v1/user/teams", model.PermUsersView},
+	{http.MethodGet, "/api/v1/user/teams/team/", model.PermUsersView},
+	{http.MethodDelete, "/api/v1/user/teams/", model.PermUsersManage},
+	{http.MethodGet, "/api/v1/user/team/", model.PermUsersView},
+	{http.MethodPut, "/api/v1/user/team/", model.PermUsersManage},
+	{http.MethodDelete, "/api/v1/user/team/", model.PermUsersManage},
+	{http.MethodPost, "/api/v1/users", model.PermUsersManage},
+	{http.MethodPost, "/api/v1/users/password/restore", model.PermUsersManage},
+	{http.MethodGet, "/api/v1/users", model.PermUsersView},
+	{http.MethodPost, "/api/v1/employees", model.PermEmployeesManage},
+	{http.MethodPut, "/api/v1/employee/", model.PermEmployeesManage},
+	{http.MethodDelete, "/api/v1/employee/", model.PermEmployeesManage},
+	{http.MethodPost, "/api/v1/employee/teams", model.PermTeamsManage},
+	{http.MethodPut, "/api/v1/employee/team/", model.PermTeamsManage},
+	{http.MethodDelete, "/api/v1/employee/team/id/", model.PermTeamsManage},
+	{http.MethodDelete, "/api/v1/employee/team/", model.PermTeamsManage},
+	{http.MethodPut, "/api/v1/order/collector/", model.PermOrdersAssign},
+	{http.MethodPut, "/api/v1/order/check", model.PermOrdersCheck},
+	{http.MethodPost, "/api/v1/order/pick", model.PermOrdersPick},
+	{http.MethodPost, "/api/v1/order/start", model.PermOrdersPick},
+	{http.MethodPost, "/api/v1/order/pause", model.PermOrdersPick},
+	{http.MethodPost, "/api/v1/order/resume", model.PermOrdersPick},
+	{http.MethodPost, "/api/v1/order/finish", model.PermOrdersPick},
+	{http.MethodGet, "/api/v1/order/", model.PermOrdersView},
+	{http.MethodGet, "/api/v1/order/uid/", model.PermOrdersView},
+	{http.MethodGet, "/api/v1/order/history", model.PermOrdersView},
+	{http.MethodGet, "/api/v1/order/picklist", model.PermOrdersView},
+	{http.MethodGet, "/api/v1/order/label", model.PermOrdersView},
+	{http.MethodPost, "/api/v1/order/check", model.PermOrdersView},
+	{http.MethodGet, "/api/v1/orders", model.PermOrdersView},
+	{http.MethodGet, "/api/v1/orders/daterange/", model.PermOrdersView},
+	{http.MethodGet, "/api/v1/orders/search", model.PermOrdersView},
+	{http.MethodGet, "/api/v1/orders/labels", model.PermOrdersView},
+	{http.MethodPost, "/api/v1/scan", model.PermOrdersView},
+	{http.MethodPost, "/api/v1/orders/access/", model.PermOrdersView},
+	{http.MethodGet, "/api/v1/orders/queue", model.PermOrdersPick},
+	{http.MethodPost, "/api/v1/orders/next", model.PermOrdersPick},
+	{http.MethodGet, "/api/v1/orders/sla", model.PermReportsView},
+	{http.MethodGet, "/api/v1/sla/rules", model.PermReportsView},
+	{http.MethodPut, "/api/v1/sla/rules", model.PermOrdersManage},
+	{http.MethodPost, "/api/v1/orders", model.PermOrdersImport},
+	{http.MethodPost, "/api/v1/orders/assembly/", model.PermReportsView},
+	{http.MethodGet, "/api/v1/reports/productivity", model.PermReportsView},
+	{http.MethodGet, "/api/v1/payroll", model.PermReportsView},
+	{http.MethodPut, "/api/v1/payroll/tariffs", model.PermPayrollManage},
+	{http.MethodPost, "/api/v1/payroll/close", model.PermPayrollManage},
+	{http.MethodPut, "/api/v1/team/", model.PermTeamsManage},
+	{http.MethodDelete, "/api/v1/team/", model.PermTeamsManage},
+	{http.MethodPost, "/api/v1/teams", model.PermTeamsManage},
+	{http.MethodPut, "/api/v1/project/", model.PermProjectsManage},
+	{http.MethodDelete, "/api/v1/project/", model.PermProjectsManage},
+	{http.MethodPost, "/api/v1/projects", model.PermProjectsManage},
+	{http.MethodPost, "/api/v1/roles", model.PermRolesManage},
+	{http.MethodPut, "/api/v1/role/", model.PermRolesManage},
+	{http.MethodDelete, "/api/v1/role/", model.PermRolesManage},
+	{http.MethodGet, "/api/v1/role/permissions/", model.PermRolesManage},
+	{http.MethodPut, "/api/v1/role/permissions/", model.PermRolesManage},
+}
+
+func authorized(t *testing.T, s *server, method, path, body string, userID uint) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+signedToken(t, s, userID, time.Hour))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestRequirePermission_Routes(t *testing.T) {
+	for _, route := range protectedRoutes {
+		t.Run(route.method+" "+route.path+" without "+string(route.perm), func(t *testing.T) {
+			st := newFakeStore(testUser(1))
+			var other []model.Permission
+			for _, p := range model.Permissions {
+				if p.Code != route.perm {
+					other = append(other, p.Code)
+				}
+			}
+			st.grant(1, model.AdminRolePriority, other...)
+
+			s := newTestServer(t, st)
+			rec := serve(s, authorized(t, s, route.method, route.path, "", 1))
+			if rec.Code != http.StatusForbidden {
+				t.Fatalf("expected %d, got %d: %s", http.StatusForbidden, rec.Code, rec.Body.String())
+			}
+		})
+
+		t.Run(route.method+" "+route.path+" with "+string(route.perm), func(t *testing.T) {
+			st := newFakeStore(testUser(1))
+			st.grant(1, model.AdminRolePriority, route.perm)
+
+			s := newTestServer(t, st)
+			rec := serve(s, authorized(t, s, route.method, route.path, "", 1))
+			if rec.Code == http.StatusForbidden || rec.Code == http.StatusUnauthorized {
+				t.Fatalf("expected access, got %d: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequirePermission_RoutesCoverCatalog(t *testing.T) {
+	covered := make(map[model.Permission]bool)
+	for _, route := range protectedRoutes {
+		covered[route.perm] = true
+	}
+	// Права на смену статуса проверяются обработчиком по паре статусов
+	for _, perm := range transitionPermissions {
+		covered[perm] = true
+	}
+
+	for _, p := range model.Permissions {
+		if !covered[p.Code] {
+			t.Errorf("permission %s is not used by any route", p.Code)
+		}
+	}
+}
+
+func TestTransitionOrder_UnknownStatus(t *testing.T) {
+	st := newFakeStore(testUser(1))
+	var all []model.Permission
+	for _, p := range model.Permissions {
+		all = append(all, p.Code)
+	}
+	st.grant(1, model.AdminRolePriority, all...)
+	s := newTestServer(t, st)
+
+	rec := serve(s, authorized(t, s, http.MethodPost, "/api/v1/order/transition", `{"order_uid":1,"status":99}`, 1))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d: %s", http.StatusBadRequest, rec.Code, rec.Body.String())
+	}
+}
+
+func TestTransitionPermissions_CoverStateMachine(t *testing.T) {
+	for from := model.OrderStatusNew; from.Valid(); from++ {
+		for _, to := range from.Next() {
+			if _, ok := transitionPermissions[statusChange{from, to}]; !ok {
+				t.Errorf("transition %s -> %s has no permission", from, to)
+			}
+		}
+	}
+	for change := range transitionPermissions {
+		if !change.from.CanTransitionTo(change.to) {
+			t.Errorf("permission for impossible transition %s -> %s", change.from, change.to)
+		}
+	}
+}
+
+func TestTransitionOrder_Permissions(t *testing.T) {
+	ctx := context.Background()
+	st := teststore.New()
+	picker := newStoreUser(t, st, model.PermOrdersPick)
+	other, err := st.User().Add(ctx, model.User{Email: "other@eastwh.local", Password: "secret", Name: "Other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(t, st)
+
+	now := time.Now()
+	for uid, collector := range map[int]uint{1: picker.ID, 2: other.ID} {
+		if _, err := st.Order().Add(ctx, model.Order{OrderUid: uid, FolioDate: now, OrderDate: now}, picker.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.Order().Transition(ctx, model.OrderTransition{OrderUID: uint(uid), Status: model.OrderStatusAssigned, UserID: collector}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"missing status", `{"order_uid":1}`, http.StatusBadRequest},
+		{"foreign order", `{"order_uid":2,"status":"assembled"}`, http.StatusForbidden},
+		{"assign to other", fmt.Sprintf(`{"order_uid":1,"status":"assembled","user_id":%d}`, other.ID), http.StatusForbidden},
+		{"invalid transition", `{"order_uid":1,"status":"shipped"}`, http.StatusConflict},
+		{"own order", `{"order_uid":1,"status":"assembled"}`, http.StatusOK},
+		{"check", `{"order_uid":1,"status":"checked"}`, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		rec := serve(s, authorized(t, s, http.MethodPost, "/api/v1/order/transition", tt.body, picker.ID))
+		if rec.Code != tt.code {
+			t.Fatalf("%s: expected %d, got %d: %s", tt.name, tt.code, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Снять проверку сборщик не может
+	if _, err := st.Order().Transition(ctx, model.OrderTransition{OrderUID: 1, Status: model.OrderStatusChecked, UserID: picker.ID}); err != nil {
+		t.Fatal(err)
+	}
+	rec := serve(s, authorized(t, s, http.MethodPost, "/api/v1/order/transition", `{"order_uid":1,"status":"assembled"}`, picker.ID))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("uncheck: expected %d, got %d: %s", http.StatusForbidden, rec.Code, rec.Body.String())
+	}
+}
+
+func TestRequireSelfOrPermission(t *testing.T) {
+	st := newFakeStore(testUser(1), testUser(2))
+	s := newTestServer(t, st)
+
+	rec := serve(s, authorized(t, s, http.MethodGet, "/api/v1/user/roles/user/?user_id=2", "", 1))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("foreign user: expected %d, got %d", http.StatusForbidden, rec.Code)
+	}
+
+	rec = serve(s, authorized(t, s, http.MethodGet, "/api/v1/user/roles/user/?user_id=1", "", 1))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("own user: expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	// заказы проектов другого пользователя видны только с правом просмотра заказов
+	rec = serve(s, authorized(t, s, http.MethodPost, "/api/v1/orders/access/?user_id=2", "", 1))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("foreign orders: expected %d, got %d", http.StatusForbidden, rec.Code)
+	}
+	rec = serve(s, authorized(t, s, http.MethodPost, "/api/v1/orders/access/?user_id=1", "", 1))
+	if rec.Code == http.StatusForbidden {
+		t.Fatalf("own orders: unexpected %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = serve(s, authorized(t, s, http.MethodGet, "/api/v1/orders/user/?user_id=2", "", 1))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("foreign collector orders: expected %d, got %d", http.StatusForbidden, rec.Code)
+	}
+	rec = serve(s, authorized(t, s, http.MethodGet, "/api/v1/orders/user/?user_id=1", "", 1))
+	if rec.Code == http.StatusForbidden {
+		t.Fatalf("own collector orders: unexpected %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestUpdateRolePermissions(t *testing.T) {
+	st := newFakeStore(testUser(1))
+	st.grant(1, 10, model.PermRolesManage)
+	higher := st.grant(2, 20)
+	lower := st.grant(2, 5)
+	s := newTestServer(t, st)
+
+	rec := serve(s, authorized(t, s, http.MethodPut, "/api/v1/role/permissions/?role_id=1",
+		`{"permissions":["orders.unknown"]}`, 1))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown permission: expected %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	rec = serve(s, authorized(t, s, http.MethodPut, "/api/v1/role/permissions/?role_id="+fmt.Sprint(higher.ID),
+		`{"permissions":["users.block"]}`, 1))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("higher priority role: expected %d, got %d", http.StatusForbidden, rec.Code)
+	}
+
+	rec = serve(s, authorized(t, s, http.MethodPut, "/api/v1/role/permissions/?role_id="+fmt.Sprint(lower.ID),
+		`{"permissions":["users.block","users.block","orders.check"]}`, 1))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("lower priority role: expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	got := st.rolePermissions[lower.ID]
+	if len(got) != 2 || got[0] != model.PermUsersBlock || got[1] != model.PermOrdersCheck {
+		t.Fatalf("unexpected permissions: %v", got)
+	}
+}
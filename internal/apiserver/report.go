@@ -0,0 +1,81 @@
+package apiserver
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *server) GetProductivity(ctx *gin.Context) {
+	req := model.ProductivityFilter{GroupBy: model.ProductivityByEmployee}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность передаваемых данных",
+			"error": err.Error()})
+		return
+	}
+	if req.StartDT == "" || req.FinishDT == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Укажите период start_dt и finish_dt"})
+		return
+	}
+	if _, ok := dateRange(ctx, req.StartDT, req.FinishDT); !ok {
+		return
+	}
+	if err := req.Validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте параметры отчета",
+			"error": err.Error()})
+		return
+	}
+
+	// Производительность считается по времени сборки, а не по дате накладной
+	req.ByAssemblyDate = true
+	orders, err := s.store.Order().AssemblyOrder(ctx.Request.Context(), req.AssemblyFilter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка собранных заказов",
+			"error": err.Error()})
+		return
+	}
+
+	keys, err := s.productivityKeys(ctx.Request.Context(), req.GroupBy)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения состава команд",
+			"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Отчет о производительности сформирован",
+		"rows": model.Productivity(orders, req, keys)})
+}
+
+// productivityKeys возвращает функцию, относящую заказ к сотруднику, пользователю
+// или команде. Заказ относится к команде, записанной в нем при сборке:
+// последующая смена состава команд отчет не меняет.
+func (s *server) productivityKeys(ctx context.Context, group model.ProductivityGroup) (func(model.AssemblyOrder) []model.ProductivityKey, error) {
+	switch group {
+	case model.ProductivityByUser:
+		return func(o model.AssemblyOrder) []model.ProductivityKey {
+			return []model.ProductivityKey{{ID: o.UserID, Name: o.UserName}}
+		}, nil
+	case model.ProductivityByEmployee:
+		return func(o model.AssemblyOrder) []model.ProductivityKey {
+			return []model.ProductivityKey{{ID: o.EmployeeID, Name: o.EmployeeName}}
+		}, nil
+	}
+
+	teams, err := s.store.Team().All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(teams))
+	for _, t := range teams {
+		names[t.ID] = t.Name
+	}
+
+	return func(o model.AssemblyOrder) []model.ProductivityKey {
+		if o.TeamID == 0 {
+			return nil
+		}
+		return []model.ProductivityKey{{ID: o.TeamID, Name: names[o.TeamID]}}
+	}, nil
+}
@@ -0,0 +1,123 @@
+package apiserver
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errBarcodeNotFound = errors.New("barcode not found")
+
+// Scan определяет, что отсканировал терминал, и подсказывает следующее действие.
+// Штрихкод проверяется по порядку: бейдж сотрудника, товар открытого заказа, накладная, товар.
+func (s *server) Scan(ctx *gin.Context) {
+	var req model.ScanRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность передаваемых данных",
+			"error": err.Error()})
+		return
+	}
+	req.Barcode = strings.TrimSpace(req.Barcode)
+	if req.Barcode == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Штрихкод не указан"})
+		return
+	}
+
+	result, err := s.resolveBarcode(ctx.Request.Context(), req)
+	switch {
+	case errors.Is(err, errBarcodeNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Штрихкод не распознан",
+			"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обработки штрихкода",
+			"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (s *server) resolveBarcode(ctx context.Context, req model.ScanRequest) (model.ScanResult, error) {
+	result := model.ScanResult{Barcode: req.Barcode}
+
+	employee, err := s.store.Employee().ByCode(ctx, req.Barcode)
+	if err == nil {
+		result.Type = model.ScanEntityEmployee
+		result.Action = model.ScanActionSelectOrder
+		result.Employee = &employee
+		return result, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, err
+	}
+
+	// Товар в контексте открытого заказа важнее совпадения с номером накладной
+	if req.OrderUID != 0 {
+		found, err := s.resolveProduct(ctx, &result, req.Barcode, req.OrderUID)
+		if found || err != nil {
+			return result, err
+		}
+	}
+
+	if code, err := strconv.Atoi(req.Barcode); err == nil {
+		order, err := s.store.Order().ByBarcode(ctx, code)
+		if err == nil {
+			result.Type = model.ScanEntityOrder
+			result.Action = order.Status.ScanAction()
+			result.Order = &order
+			return result, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return result, err
+		}
+	}
+
+	found, err := s.resolveProduct(ctx, &result, req.Barcode, 0)
+	if err != nil {
+		return result, err
+	}
+	if !found {
+		return result, errBarcodeNotFound
+	}
+	return result, nil
+}
+
+// resolveProduct ищет товар по EAN. Подтверждение строки предлагается, только если
+// товар найден в заказе, который сейчас собирается; иначе нужно отсканировать накладную.
+func (s *server) resolveProduct(ctx context.Context, result *model.ScanResult, barcode string, orderUID uint) (bool, error) {
+	line, err := s.store.OrderLine().ByBarcode(ctx, barcode, orderUID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	result.Type = model.ScanEntityProduct
+	result.Line = &line
+	result.Action = model.ScanActionSelectOrder
+
+	if orderUID == 0 {
+		return true, nil
+	}
+
+	orders, err := s.store.Order().ByOrderUID(ctx, orderUID)
+	if err != nil {
+		return false, err
+	}
+	if len(orders) > 0 {
+		result.Order = &orders[0]
+		result.Action = model.ScanActionNone
+		if orders[0].Status.ScanAction() == model.ScanActionPick {
+			result.Action = model.ScanActionConfirmLine
+		}
+	}
+	return true, nil
+}
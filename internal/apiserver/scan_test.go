@@ -0,0 +1,79 @@
+package apiserver
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store/teststore"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func scan(t *testing.T, s *server, userID uint, body string) (int, model.ScanResult) {
+	t.Helper()
+	rec := serve(s, authorized(t, s, http.MethodPost, "/api/v1/scan", body, userID))
+	var result model.ScanResult
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Code, result
+}
+
+func TestScan_ResolvesBarcodes(t *testing.T) {
+	st := teststore.New()
+	u := newStoreUser(t, st, model.PermOrdersView)
+	s := newTestServer(t, st)
+
+	ctx := context.Background()
+	for _, code := range []string{"E100", "E200"} {
+		if _, err := st.Employee().Add(ctx, model.Employee{Code: code, Name: code}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, model.Location)
+	order := model.Order{OrderUid: 7, FolioNum: 5501, FolioDate: day, OrderDate: day,
+		Lines: []model.OrderLine{{ProductCode: "P1", Barcode: "4601234567890", Quantity: 1}}}
+	if _, err := st.Order().Add(ctx, order, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Order().Transition(ctx, model.OrderTransition{OrderUID: 7, Status: model.OrderStatusAssigned, UserID: u.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// бейдж находит именно своего сотрудника, а не первого в справочнике
+	code, result := scan(t, s, u.ID, `{"barcode":" E200 "}`)
+	if code != http.StatusOK || result.Type != model.ScanEntityEmployee || result.Employee.Code != "E200" {
+		t.Fatalf("unexpected employee scan %d %+v", code, result)
+	}
+
+	code, result = scan(t, s, u.ID, `{"barcode":"5501"}`)
+	if code != http.StatusOK || result.Type != model.ScanEntityOrder || result.Order.OrderUid != 7 ||
+		result.Action != model.ScanActionPick {
+		t.Fatalf("unexpected order scan %d %+v", code, result)
+	}
+
+	code, result = scan(t, s, u.ID, `{"barcode":"4601234567890","order_uid":7}`)
+	if code != http.StatusOK || result.Type != model.ScanEntityProduct || result.Action != model.ScanActionConfirmLine {
+		t.Fatalf("unexpected product scan %d %+v", code, result)
+	}
+
+	code, result = scan(t, s, u.ID, `{"barcode":"4601234567890"}`)
+	if code != http.StatusOK || result.Action != model.ScanActionSelectOrder {
+		t.Fatalf("product without order must ask for an order: %d %+v", code, result)
+	}
+
+	if code, _ := scan(t, s, u.ID, `{"barcode":"UNKNOWN"}`); code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", code)
+	}
+
+	// пробелы не находят сотрудника без кода
+	if _, err := st.Employee().Add(ctx, model.Employee{Name: "Без кода"}); err != nil {
+		t.Fatal(err)
+	}
+	if code, result := scan(t, s, u.ID, `{"barcode":"   "}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for blank barcode, got %d %+v", code, result)
+	}
+}
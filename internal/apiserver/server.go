@@ -2,19 +2,21 @@ package apiserver
 
 import (
 	"context"
+	"eastwh/internal/export"
 	"eastwh/internal/model"
 	"eastwh/internal/store"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
-	"sync"
+	"strings"
 	"time"
 	"unicode/utf8"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
 var (
@@ -22,27 +24,36 @@ var (
 	errNotAuthenticated         = errors.New("not autenticated")
 )
 
-var (
-	hmacSampleSecret = "8a046a6b436496d9c7af3e196a73ee9948677eb30b251706667ad59d6261bd78d2f6f501a6dea0118cfb3b0dcd62d6c9eb88142e2c24c2c686133a935cd65651"
-)
-
 type server struct {
 	router *gin.Engine
 	store  store.Store
+	tokens *tokenIssuer
+	// dispatch - стратегия выдачи заказов, если сборщик не указал свою
+	dispatch model.DispatchStrategy
+	timeouts TimeoutConfig
 }
 
-func newServer(store store.Store) *server {
+func newServer(store store.Store, config *Config) (*server, error) {
+	tokens, err := newTokenIssuer(config.JWT)
+	if err != nil {
+		return nil, err
+	}
+
+	dispatch, err := model.ParseDispatchStrategy(config.DispatchStrategy)
+	if err != nil {
+		return nil, fmt.Errorf("dispatch_strategy: %w", err)
+	}
+
 	s := &server{
-		router: gin.Default(),
-		store:  store,
+		router:   gin.Default(),
+		store:    store,
+		tokens:   tokens,
+		dispatch: dispatch,
+		timeouts: config.Timeouts,
 	}
 
 	s.router.Use()
 
-	s.router.Use(func(ctx *gin.Context) {
-		fmt.Println("Запрос с сайта: ", ctx.Request.Header.Get("Origin"))
-		ctx.Next()
-	})
 	confCors := cors.DefaultConfig()
 	confCors.AllowMethods = []string{"POST", "GET", "PUT", "OPTIONS"}
 	confCors.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Accept", "User-Agent", "Cache-Control", "Pragma"}
@@ -54,10 +65,27 @@ func newServer(store store.Store) *server {
 	}
 
 	s.router.Use(cors.New(confCors))
+	s.router.Use(s.deadline)
 
 	s.configureRouter()
 
-	return s
+	return s, nil
+}
+
+// deadline ограничивает время обработки запроса сроком маршрута. Обработчики
+// передают контекст запроса в хранилище, поэтому по истечении срока или при
+// разрыве соединения клиентом запросы к базе прерываются.
+func (s *server) deadline(ctx *gin.Context) {
+	timeout := s.timeouts.For(ctx.Request.Method, ctx.FullPath())
+	if timeout <= 0 {
+		ctx.Next()
+		return
+	}
+
+	c, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+	defer cancel()
+	ctx.Request = ctx.Request.WithContext(c)
+	ctx.Next()
 }
 
 func (s *server) configureRouter() {
@@ -67,88 +95,91 @@ func (s *server) configureRouter() {
 		apiGroup.GET("/ping", func(ctx *gin.Context) {
 			ctx.JSON(http.StatusOK, gin.H{"message": "pong"})
 		})
+		apiGroup.POST("/users/login", s.Login)
+		apiGroup.POST("/users/token/refresh", s.RefreshTokens)
+
+		authGroup := apiGroup.Group("", s.AuthMW)
 
-		userGroup := apiGroup.Group("/user") //, s.AuthMW
+		userGroup := authGroup.Group("/user")
 		{
 			userGroup.POST("/logout/", s.Logout)
-			userGroup.PUT("/", s.UpdateUser)
-			userGroup.POST("/update/password/", s.UpdatePassword)
-			userGroup.POST("/block/", s.BlockedUser)
-			userGroup.GET("/profile/", s.GetUserProfile)
-			userGroup.GET("/employees", s.GetEmployeeByUserID)
+			userGroup.PUT("/", s.RequireSelfOrPermission("id", model.PermUsersManage), s.UpdateUser)
+			userGroup.POST("/update/password/", s.RequireSelfOrPermission("id", model.PermUsersManage), s.UpdatePassword)
+			userGroup.POST("/block/", s.RequirePermission(model.PermUsersBlock), s.BlockedUser)
+			userGroup.GET("/profile/", s.RequireSelfOrPermission("id", model.PermUsersView), s.GetUserProfile)
+			userGroup.GET("/employees", s.RequireSelfOrPermission("user_id", model.PermUsersView), s.GetEmployeeByUserID)
 
 			userProjectsGroup := userGroup.Group("/projects")
 			{
-				userProjectsGroup.POST("", s.AddUserProjects)
-				userProjectsGroup.GET("", s.GetUserProjects)
-				userProjectsGroup.GET("/user/", s.GetUserProjectsByUserId)
-				userProjectsGroup.GET("/project/", s.GetUserProjectsByProjectId)
+				userProjectsGroup.POST("", s.RequirePermission(model.PermUsersManage), s.AddUserProjects)
+				userProjectsGroup.GET("", s.RequirePermission(model.PermUsersView), s.GetUserProjects)
+				userProjectsGroup.GET("/user/", s.RequireSelfOrPermission("user_id", model.PermUsersView), s.GetUserProjectsByUserId)
+				userProjectsGroup.GET("/project/", s.RequirePermission(model.PermUsersView), s.GetUserProjectsByProjectId)
 			}
 
 			userProjectGroup := userGroup.Group("/project")
 			{
-				userProjectGroup.GET("/", s.GetUserProjectById)
-				userProjectGroup.PUT("/", s.UpdateUserProject)
-				userProjectGroup.DELETE("/", s.DeleteUserProject)
-				userProjectGroup.DELETE("/user/", s.DeleteProjectByUserID)
+				userProjectGroup.GET("/", s.RequirePermission(model.PermUsersView), s.GetUserProjectById)
+				userProjectGroup.PUT("/", s.RequirePermission(model.PermUsersManage), s.UpdateUserProject)
+				userProjectGroup.DELETE("/", s.RequirePermission(model.PermUsersManage), s.DeleteUserProject)
+				userProjectGroup.DELETE("/user/", s.RequirePermission(model.PermUsersManage), s.DeleteProjectByUserID)
 			}
 
 			userRolesGroup := userGroup.Group("/roles")
 			{
-				userRolesGroup.POST("", s.AddUserRoles)
-				userRolesGroup.GET("", s.GetUserRoles)
-				userRolesGroup.GET("/user/", s.GetUserRolesByUserId)
-				userRolesGroup.GET("/role/", s.GetUserRolesByRoleId)
+				userRolesGroup.POST("", s.RequirePermission(model.PermRolesManage), s.AddUserRoles)
+				userRolesGroup.GET("", s.RequirePermission(model.PermUsersView), s.GetUserRoles)
+				userRolesGroup.GET("/user/", s.RequireSelfOrPermission("user_id", model.PermUsersView), s.GetUserRolesByUserId)
+				userRolesGroup.GET("/role/", s.RequirePermission(model.PermUsersView), s.GetUserRolesByRoleId)
 			}
 
 			userRoleGroup := userGroup.Group("/role")
 			{
-				userRoleGroup.GET("/", s.GetUserRoleById)
-				userRoleGroup.PUT("/", s.UpdateUserRole)
-				userRoleGroup.DELETE("/", s.DeleteUserRole)
+				userRoleGroup.GET("/", s.RequirePermission(model.PermUsersView), s.GetUserRoleById)
+				userRoleGroup.PUT("/", s.RequirePermission(model.PermRolesManage), s.UpdateUserRole)
+				userRoleGroup.DELETE("/", s.RequirePermission(model.PermRolesManage), s.DeleteUserRole)
 			}
 
 			userTeamsGroup := userGroup.Group("/teams")
 			{
-				userTeamsGroup.POST("", s.AddUserTeams)
-				userTeamsGroup.GET("", s.GetUserTeams)
-				userTeamsGroup.GET("/user/", s.GetUserTeamsByUserId)
-				userTeamsGroup.GET("/team/", s.GetUserTeamsByTeamId)
-				userTeamsGroup.DELETE("/", s.DeleteUserTeamByID)
+				userTeamsGroup.POST("", s.RequirePermission(model.PermUsersManage), s.AddUserTeams)
+				userTeamsGroup.GET("", s.RequirePermission(model.PermUsersView), s.GetUserTeams)
+				userTeamsGroup.GET("/user/", s.RequireSelfOrPermission("user_id", model.PermUsersView), s.GetUserTeamsByUserId)
+				userTeamsGroup.GET("/team/", s.RequirePermission(model.PermUsersView), s.GetUserTeamsByTeamId)
+				userTeamsGroup.DELETE("/", s.RequirePermission(model.PermUsersManage), s.DeleteUserTeamByID)
 			}
 
 			userTeamGroup := userGroup.Group("team")
 			{
-				userTeamGroup.GET("/", s.GetUserTeamById)
-				userTeamGroup.PUT("/", s.UpdateUserTeam)
-				userTeamGroup.DELETE("/", s.DeleteUserTeam)
+				userTeamGroup.GET("/", s.RequirePermission(model.PermUsersView), s.GetUserTeamById)
+				userTeamGroup.PUT("/", s.RequirePermission(model.PermUsersManage), s.UpdateUserTeam)
+				userTeamGroup.DELETE("/", s.RequirePermission(model.PermUsersManage), s.DeleteUserTeam)
 			}
 		}
 
-		usersGroup := apiGroup.Group("/users")
+		usersGroup := authGroup.Group("/users")
 		{
-			usersGroup.POST("", s.AddUser)
-			usersGroup.POST("/login", s.Login)
-			usersGroup.GET("", s.GetUsers)
-			usersGroup.POST("/password/restore", s.RestoreUserPassword)
+			usersGroup.POST("", s.RequirePermission(model.PermUsersManage), s.AddUser)
+			usersGroup.POST("/password/restore", s.RequirePermission(model.PermUsersManage), s.RestoreUserPassword)
+			usersGroup.GET("", s.RequirePermission(model.PermUsersView), s.GetUsers)
 		}
 
-		employeesGroup := apiGroup.Group("/employees")
+		employeesGroup := authGroup.Group("/employees")
 		{
-			employeesGroup.POST("", s.AddEmployee)
+			employeesGroup.POST("", s.RequirePermission(model.PermEmployeesManage), s.AddEmployee)
 			employeesGroup.GET("", s.GetEmployees)
 		}
 
-		employeeGroup := apiGroup.Group("/employee")
+		employeeGroup := authGroup.Group("/employee")
 		{
 			employeeGroup.GET("/", s.GetEmployeeByID)
 			employeeGroup.GET("/code/", s.GetEmployeeByCode)
-			employeeGroup.PUT("/", s.UpdateEmployee)
-			employeeGroup.DELETE("/", s.DeleteEmployee)
+			employeeGroup.PUT("/", s.RequirePermission(model.PermEmployeesManage), s.UpdateEmployee)
+			employeeGroup.DELETE("/", s.RequirePermission(model.PermEmployeesManage), s.DeleteEmployee)
 
 			employeeTeamsGroup := employeeGroup.Group("/teams")
 			{
-				employeeTeamsGroup.POST("", s.AddEmployeeTeams)
+				employeeTeamsGroup.POST("", s.RequirePermission(model.PermTeamsManage), s.AddEmployeeTeams)
 				employeeTeamsGroup.GET("", s.GetEmployeeTeams)
 				employeeTeamsGroup.GET("/employee/", s.GetEmployeeTeamsByEmployeeId)
 				employeeTeamsGroup.GET("/team/", s.GetEmployeeTeamsByTeamId)
@@ -156,132 +187,179 @@ func (s *server) configureRouter() {
 			employeeTeamGroup := employeeGroup.Group("/team")
 			{
 				employeeTeamGroup.GET("/", s.GetEmployeeTeamById)
-				employeeTeamGroup.PUT("/", s.UpdateEmployeeTeam)
-				employeeTeamGroup.DELETE("/id/", s.DeleteEmployeeTeamByID)
-				employeeTeamGroup.DELETE("/", s.DeleteEmployeeTeam)
+				employeeTeamGroup.PUT("/", s.RequirePermission(model.PermTeamsManage), s.UpdateEmployeeTeam)
+				employeeTeamGroup.DELETE("/id/", s.RequirePermission(model.PermTeamsManage), s.DeleteEmployeeTeamByID)
+				employeeTeamGroup.DELETE("/", s.RequirePermission(model.PermTeamsManage), s.DeleteEmployeeTeam)
 
 			}
 		}
 
-		orderGroup := apiGroup.Group("/order")
+		orderGroup := authGroup.Group("/order")
 		{
-			orderGroup.GET("/", s.GetOrderByID)
-			orderGroup.GET("/uid/", s.GetOrderByUID)
-			orderGroup.PUT("/collector/", s.UpdateOrderCollector)
-			orderGroup.PUT("/check", s.UpdateOrderCheck)
+			orderGroup.GET("/", s.RequirePermission(model.PermOrdersView), s.GetOrderByID)
+			orderGroup.GET("/uid/", s.RequirePermission(model.PermOrdersView), s.GetOrderByUID)
+			orderGroup.PUT("/collector/", s.RequirePermission(model.PermOrdersAssign), s.UpdateOrderCollector)
+			orderGroup.PUT("/check", s.RequirePermission(model.PermOrdersCheck), s.UpdateOrderCheck)
+			orderGroup.POST("/transition", s.TransitionOrder)
+			orderGroup.GET("/history", s.RequirePermission(model.PermOrdersView), s.GetOrderHistory)
+			orderGroup.GET("/picklist", s.RequirePermission(model.PermOrdersView), s.GetOrderPickList)
+			orderGroup.POST("/pick", s.RequirePermission(model.PermOrdersPick), s.ConfirmOrderPick)
+			orderGroup.POST("/start", s.RequirePermission(model.PermOrdersPick), s.OrderWork(model.WorkStart))
+			orderGroup.POST("/pause", s.RequirePermission(model.PermOrdersPick), s.OrderWork(model.WorkPause))
+			orderGroup.POST("/resume", s.RequirePermission(model.PermOrdersPick), s.OrderWork(model.WorkResume))
+			orderGroup.POST("/finish", s.RequirePermission(model.PermOrdersPick), s.OrderWork(model.WorkFinish))
+			orderGroup.GET("/label", s.RequirePermission(model.PermOrdersView), s.GetOrderLabel)
 		}
 
-		ordersGroup := apiGroup.Group("/orders")
+		ordersGroup := authGroup.Group("/orders")
 		{
-			ordersGroup.GET("/user/", s.GetOrdersByUserId)
-			ordersGroup.GET("/daterange/", s.GetOrdersByDateRange)
-			ordersGroup.POST("/access/", s.GetOrdersByAccessUser)
-			ordersGroup.POST("", s.AddOrders)
-			ordersGroup.GET("", s.GetOrders)
-			ordersGroup.POST("/assembly/", s.GetAssemblyOrders)
-			orderGroup.POST("/check", s.GetOrdersChecked)
+			ordersGroup.GET("/user/", s.RequireSelfOrPermission("user_id", model.PermOrdersView), s.GetOrdersByUserId)
+			ordersGroup.GET("/daterange/", s.RequirePermission(model.PermOrdersView), s.GetOrdersByDateRange)
+			ordersGroup.GET("/labels", s.RequirePermission(model.PermOrdersView), s.GetOrderLabels)
+			ordersGroup.GET("/search", s.RequirePermission(model.PermOrdersView), s.SearchOrders)
+			ordersGroup.GET("/queue", s.RequirePermission(model.PermOrdersPick), s.GetOrderQueue)
+			ordersGroup.POST("/next", s.RequirePermission(model.PermOrdersPick), s.NextOrder)
+			ordersGroup.GET("/sla", s.RequirePermission(model.PermReportsView), s.GetOrdersSLA)
+			ordersGroup.POST("/access/", s.RequireSelfOrPermission("user_id", model.PermOrdersView), s.GetOrdersByAccessUser)
+			ordersGroup.POST("", s.RequirePermission(model.PermOrdersImport), s.AddOrders)
+			ordersGroup.GET("", s.RequirePermission(model.PermOrdersView), s.GetOrders)
+			ordersGroup.POST("/assembly/", s.RequirePermission(model.PermReportsView), s.GetAssemblyOrders)
+			orderGroup.POST("/check", s.RequirePermission(model.PermOrdersView), s.GetOrdersChecked)
 		}
 
-		teamGroup := apiGroup.Group("/team")
+		teamGroup := authGroup.Group("/team")
 		{
 			teamGroup.GET("/", s.GetTeamByID)
-			teamGroup.PUT("/", s.UpdateTeam)
-			teamGroup.DELETE("/", s.DeleteTeam)
+			teamGroup.PUT("/", s.RequirePermission(model.PermTeamsManage), s.UpdateTeam)
+			teamGroup.DELETE("/", s.RequirePermission(model.PermTeamsManage), s.DeleteTeam)
 		}
 
-		teamsGroup := apiGroup.Group("/teams")
+		teamsGroup := authGroup.Group("/teams")
 		{
-			teamsGroup.POST("", s.AddTeams)
+			teamsGroup.POST("", s.RequirePermission(model.PermTeamsManage), s.AddTeams)
 			teamsGroup.GET("", s.GetTeams)
 		}
 
-		projectGroup := apiGroup.Group("/project")
+		projectGroup := authGroup.Group("/project")
 		{
 			projectGroup.GET("/", s.GetProjectById)
-			projectGroup.DELETE("/", s.DeleteProject)
-			projectGroup.PUT("/", s.UpdateProject)
+			projectGroup.DELETE("/", s.RequirePermission(model.PermProjectsManage), s.DeleteProject)
+			projectGroup.PUT("/", s.RequirePermission(model.PermProjectsManage), s.UpdateProject)
 		}
 
-		projectsGroup := apiGroup.Group("/projects")
+		projectsGroup := authGroup.Group("/projects")
 		{
-			projectsGroup.POST("", s.AddProject)
+			projectsGroup.POST("", s.RequirePermission(model.PermProjectsManage), s.AddProject)
 			projectsGroup.GET("", s.GetProjects)
 
 		}
 
-		rolesGroup := apiGroup.Group("/roles")
+		rolesGroup := authGroup.Group("/roles")
 		{
-			rolesGroup.POST("", s.AddRoles)
+			rolesGroup.POST("", s.RequirePermission(model.PermRolesManage), s.AddRoles)
 			rolesGroup.GET("", s.GetRoles)
 		}
 
-		roleGroup := apiGroup.Group("/role")
+		roleGroup := authGroup.Group("/role")
 		{
 			roleGroup.GET("/", s.GetRoleByID)
-			roleGroup.PUT("/", s.UpdateRole)
-			roleGroup.DELETE("/", s.DeleteRole)
+			roleGroup.PUT("/", s.RequirePermission(model.PermRolesManage), s.UpdateRole)
+			roleGroup.DELETE("/", s.RequirePermission(model.PermRolesManage), s.DeleteRole)
+			roleGroup.GET("/permissions/", s.RequirePermission(model.PermRolesManage), s.GetRolePermissions)
+			roleGroup.PUT("/permissions/", s.RequirePermission(model.PermRolesManage), s.UpdateRolePermissions)
 		}
+
+		reportsGroup := authGroup.Group("/reports", s.RequirePermission(model.PermReportsView))
+		{
+			reportsGroup.GET("/productivity", s.GetProductivity)
+		}
+
+		payrollGroup := authGroup.Group("/payroll")
+		{
+			payrollGroup.GET("", s.RequirePermission(model.PermReportsView), s.GetPayroll)
+			payrollGroup.GET("/periods", s.RequirePermission(model.PermReportsView), s.GetPayrollPeriods)
+			payrollGroup.POST("/close", s.RequirePermission(model.PermPayrollManage), s.ClosePayroll)
+			payrollGroup.GET("/tariffs", s.RequirePermission(model.PermReportsView), s.GetTariffs)
+			payrollGroup.PUT("/tariffs", s.RequirePermission(model.PermPayrollManage), s.UpdateTariffs)
+		}
+
+		slaGroup := authGroup.Group("/sla")
+		{
+			slaGroup.GET("/rules", s.RequirePermission(model.PermReportsView), s.GetSLARules)
+			slaGroup.PUT("/rules", s.RequirePermission(model.PermOrdersManage), s.UpdateSLARules)
+		}
+
+		authGroup.GET("/permissions", s.GetPermissions)
+		authGroup.POST("/scan", s.RequirePermission(model.PermOrdersView), s.Scan)
 	}
 }
 
-func setCookie(ctx *gin.Context, token string) {
+const refreshCookiePath = "/api/v1/users/token"
+
+func setAuthCookies(ctx *gin.Context, pair tokenPair) {
 	ctx.SetSameSite(http.SameSiteLaxMode)
-	ctx.SetCookie("Auth", token, 3600*24*100, "", "", false, true)
+	ctx.SetCookie("Auth", pair.AccessToken, int(time.Until(pair.AccessExpiresAt).Seconds()), "", "", false, true)
+	ctx.SetCookie("Refresh", pair.RefreshToken, int(time.Until(pair.RefreshExpiresAt).Seconds()), refreshCookiePath, "", false, true)
+}
+
+func clearAuthCookies(ctx *gin.Context) {
+	ctx.SetCookie("Auth", "deleted", -1, "", "", false, true)
+	ctx.SetCookie("Refresh", "deleted", -1, refreshCookiePath, "", false, true)
 }
 
 func (s *server) AuthMW(ctx *gin.Context) {
-	// Получение токена из куки
-	tokenStr, err := ctx.Cookie("Auth")
-	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "No auth token"})
-		ctx.Abort()
+	// Токен берется из заголовка Authorization: Bearer либо из куки
+	tokenStr := bearerToken(ctx)
+	if tokenStr == "" {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "No auth token"})
+		return
 	}
 
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		// Don't forget to validate the alg is what you expect:
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
-		}
-
-		// hmacSampleSecret is a []byte containing your secret, e.g. []byte("my_secret_key")
-		return []byte(hmacSampleSecret), nil
-	})
-
+	claims, err := s.tokens.parseAccess(tokenStr)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to parse JWT"})
-		ctx.Abort()
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Failed to parse JWT"})
+		return
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "JWT Claims failed"})
-		ctx.Abort()
+	// Сессия могла быть отозвана выходом из аккаунта или повторным использованием refresh-токена
+	active, err := s.store.RefreshToken().FamilyActive(ctx.Request.Context(), claims.SessionID)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
-
-	if claims["ttl"].(float64) < float64(time.Now().Unix()) {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "JWT token expired"})
-		ctx.Abort()
+	if !active {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session revoked"})
+		return
 	}
 
-	user, err := s.store.User().ByID(uint((claims["userID"].(float64))))
+	user, err := s.store.User().ByID(ctx.Request.Context(), claims.UserID)
+	if err != nil || user.ID == 0 {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Could not find the user!"})
+		return
+	}
 
-	if user.ID == 0 {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Could not find the user!"})
-		ctx.Abort()
+	if user.Blocked {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "User is blocked"})
+		return
 	}
 
 	ctx.Set("user", user)
+	ctx.Set("session", claims.SessionID)
 
 	ctx.Next()
 }
 
-func createAndSignJWT(user *model.User) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userID": user.ID,
-		"ttl":    time.Now().Add(time.Hour * 24 * 100).Unix(),
-	})
+func bearerToken(ctx *gin.Context) string {
+	header := ctx.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
 
-	return token.SignedString([]byte(hmacSampleSecret))
+	tokenStr, err := ctx.Cookie("Auth")
+	if err != nil {
+		return ""
+	}
+	return tokenStr
 }
 
 // User ...
@@ -295,7 +373,7 @@ func (s *server) AddUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err = s.store.User().Add(user)
+	user, err = s.store.User().Add(ctx.Request.Context(), user)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка создания пользователя",
 			"error": err.Error()})
@@ -325,7 +403,7 @@ func (s *server) UpdateUser(ctx *gin.Context) {
 
 	user.ID = uint(ID)
 
-	user, err = s.store.User().Update(user)
+	user, err = s.store.User().Update(ctx.Request.Context(), user)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обновления данных пользователя",
 			"error": err.Error(),
@@ -351,33 +429,90 @@ func (s *server) Login(ctx *gin.Context) {
 		return
 	}
 
-	user, err := s.store.User().Login(req.Email, req.Password)
+	user, err := s.store.User().Login(ctx.Request.Context(), req.Email, req.Password)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Ошибка авторизации",
 			"error": errIncorrectEmailOrPassword})
 		return
 	}
 
-	tokenString, err := createAndSignJWT(&user)
+	if user.Blocked {
+		ctx.JSON(http.StatusForbidden, gin.H{"message": "Пользователь заблокирован",
+			"error": errAccessDenied.Error()})
+		return
+	}
+
+	familyID, err := randomString(16)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"messge": "Ошибка создания JWT токена",
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка создания JWT токена",
 			"error": err.Error()})
 		return
 	}
 
-	err = s.store.User().UpdateToken(user.ID, tokenString)
+	pair, err := s.issueTokens(ctx.Request.Context(), user, familyID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обновления JWT токена",
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка создания JWT токена",
 			"error": err.Error()})
 		return
 	}
 
-	user.Token = tokenString
-	setCookie(ctx, tokenString)
+	user.Token = pair.AccessToken
+	setAuthCookies(ctx, pair)
 	ctx.JSON(http.StatusOK, gin.H{"message": "Вы успешно авторизованы",
-		"user": user})
+		"user":   user,
+		"tokens": pair})
+}
+
+func (s *server) RefreshTokens(ctx *gin.Context) {
+	type request struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	var req request
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность передаваемых данных",
+				"error": err.Error()})
+			return
+		}
+	}
+
+	if req.RefreshToken == "" {
+		req.RefreshToken, _ = ctx.Cookie("Refresh")
+	}
+	if req.RefreshToken == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"message": "Не передан refresh-токен",
+			"error": errNotAuthenticated.Error()})
+		return
+	}
+
+	_, pair, err := s.rotateRefreshToken(ctx.Request.Context(), req.RefreshToken)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, errTokenReused), errors.Is(err, errTokenExpired):
+		clearAuthCookies(ctx)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"message": "Refresh-токен недействителен",
+			"error": err.Error()})
+		return
+	case errors.Is(err, errAccessDenied):
+		clearAuthCookies(ctx)
+		ctx.JSON(http.StatusForbidden, gin.H{"message": "Пользователь заблокирован",
+			"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обновления токенов",
+			"error": err.Error()})
+		return
+	}
+
+	setAuthCookies(ctx, pair)
+	ctx.JSON(http.StatusOK, gin.H{"message": "Токены успешно обновлены",
+		"tokens": pair})
 }
 
+// RestoreUserPassword устанавливает пользователю временный пароль. Пароль
+// получает администратор и передает пользователю; для восстановления доступа
+// без администратора есть команда reset-password --temporary.
+// Сессии пользователя со старым паролем завершаются.
 func (s *server) RestoreUserPassword(ctx *gin.Context) {
 	email := ctx.Query("email")
 
@@ -386,44 +521,65 @@ func (s *server) RestoreUserPassword(ctx *gin.Context) {
 		return
 	}
 
-	password, err := s.store.User().Restore(email)
+	password, err := s.store.User().Restore(ctx.Request.Context(), email)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка установки временного пароля",
 			"error": err.Error()})
 		return
 	}
 
+	user, err := s.store.User().ByEmail(ctx.Request.Context(), email)
+	if err == nil {
+		err = s.store.RefreshToken().RevokeUser(ctx.Request.Context(), user.ID)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка отзыва токенов",
+			"error": err.Error()})
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{"password": password})
 }
 
 func (s *server) GetUsers(ctx *gin.Context) {
-	users, err := s.store.User().All()
+	q, ok := listQuery(ctx, model.UserListSpec)
+	if !ok {
+		return
+	}
+
+	page, err := s.store.User().List(ctx.Request.Context(), q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка пользователей",
-			"error": err.Error()})
+		listError(ctx, "Ошибка получения списка пользователей", err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, users)
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (s *server) Logout(ctx *gin.Context) {
-	pID := ctx.Query("id")
-	ID, err := strconv.Atoi(pID)
+	user := currentUser(ctx)
+
+	// ?all=true завершает все сессии пользователя, иначе только текущую
+	var err error
+	if ctx.Query("all") == "true" {
+		err = s.store.RefreshToken().RevokeUser(ctx.Request.Context(), user.ID)
+	} else {
+		err = s.store.RefreshToken().RevokeFamily(ctx.Request.Context(), ctx.GetString("session"))
+	}
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность ID",
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка отзыва токенов",
 			"error": err.Error()})
 		return
 	}
 
-	err = s.store.User().Logout(uint(ID))
+	err = s.store.User().Logout(ctx.Request.Context(), user.ID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка выхода из аккаунта",
 			"error": err.Error()})
 		return
 	}
 
-	ctx.SetCookie("Auth", "deleted", 0, "", "", false, false)
+	clearAuthCookies(ctx)
 
 	ctx.JSON(http.StatusAccepted, gin.H{"message": "Вы успешно вышли из аккаунта"})
 }
@@ -453,15 +609,21 @@ func (s *server) UpdatePassword(ctx *gin.Context) {
 		return
 	}
 
-	fmt.Println(ID, " ", req.Password)
-
-	err = s.store.User().ChangePassword(uint(ID), req.Password)
+	err = s.store.User().ChangePassword(ctx.Request.Context(), uint(ID), req.Password)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка изменения пароля пользователя",
 			"error": err.Error()})
 		return
 	}
 
+	// Сессии, открытые со старым паролем, завершаются
+	err = s.store.RefreshToken().RevokeUser(ctx.Request.Context(), uint(ID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка отзыва токенов",
+			"error": err.Error()})
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{"message": "Пароль успешно изменен"})
 }
 
@@ -486,12 +648,20 @@ func (s *server) BlockedUser(ctx *gin.Context) {
 		return
 	}
 
-	err = s.store.User().BlockedUser(uint(ID), req.Blocked)
+	err = s.store.User().BlockedUser(ctx.Request.Context(), uint(ID), req.Blocked)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка блокировки пользователя",
 			"error": err.Error()})
 		return
 	}
+	if req.Blocked {
+		err = s.store.RefreshToken().RevokeUser(ctx.Request.Context(), uint(ID))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка отзыва токенов",
+				"error": err.Error()})
+			return
+		}
+	}
 
 	var msg string
 	if req.Blocked {
@@ -511,7 +681,7 @@ func (s *server) GetUserProfile(ctx *gin.Context) {
 		return
 	}
 
-	user, err := s.store.User().Profile(uint(ID))
+	user, err := s.store.User().Profile(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения профиля пользователя",
 			"error": err.Error()})
@@ -529,7 +699,7 @@ func (s *server) GetEmployeeByUserID(ctx *gin.Context) {
 			"error": err.Error()})
 		return
 	}
-	employee, err := s.store.User().EmployeeByUserID(uint(ID))
+	employee, err := s.store.User().EmployeeByUserID(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения сотрудников",
 			"error": err.Error()})
@@ -540,6 +710,11 @@ func (s *server) GetEmployeeByUserID(ctx *gin.Context) {
 
 // Employee...
 func (s *server) AddEmployee(ctx *gin.Context) {
+	atomic, ok := atomicBatch(ctx)
+	if !ok {
+		return
+	}
+
 	var employees []model.Employee
 
 	err := ctx.ShouldBindJSON(&employees)
@@ -551,87 +726,31 @@ func (s *server) AddEmployee(ctx *gin.Context) {
 		return
 	}
 
-	// Создаем каналы для результатов и ошибок
-	results := make(chan model.Employee, len(employees))
-	errors := make(chan error, len(employees))
-
-	// WaitGroup для отслеживания завершения всех горутин
-	var wg sync.WaitGroup
-
-	// Запускаем горутину для каждого сотрудника
-	for _, emp := range employees {
-		wg.Add(1)
-		go func(emp model.Employee) {
-			defer wg.Done()
-			employee, err := s.store.Employee().Add(emp)
-			if err != nil {
-				errors <- err
-				return
-			}
-			results <- employee
-		}(emp)
-	}
-
-	// Горутина для закрытия каналов после завершения всех операций
-	go func() {
-		wg.Wait()
-		close(results)
-		close(errors)
-	}()
-
-	// Собираем результаты и ошибки
-	var addedEmployees []model.Employee
-	var errorMessages []string
-
-	// Читаем из каналов, пока они не закрыты
-	for {
-		select {
-		case employee, ok := <-results:
-			if !ok {
-				results = nil
-				continue
-			}
-			addedEmployees = append(addedEmployees, employee)
-
-		case err, ok := <-errors:
-			if !ok {
-				errors = nil
-				continue
-			}
-			errorMessages = append(errorMessages, err.Error())
-
-		// Если оба канала закрыты, завершаем цикл
-		default:
-			if results == nil && errors == nil {
-				goto Done
-			}
-		}
-	}
-
-Done:
-	// Формируем ответ
-	response := gin.H{
-		"added_employees": addedEmployees,
-	}
-
-	if len(errorMessages) > 0 {
-		response["errors"] = errorMessages
-		ctx.JSON(http.StatusMultiStatus, response)
+	report, err := runBatch(ctx.Request.Context(), s.store, atomic, employees, func(st store.Store, emp model.Employee) (any, error) {
+		return st.Employee().Add(ctx.Request.Context(), emp)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка добавления сотрудников",
+			"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, addedEmployees)
+	writeBatch(ctx, http.StatusCreated, report)
 }
 
 func (s *server) GetEmployees(ctx *gin.Context) {
-	employees, err := s.store.Employee().All()
+	q, ok := listQuery(ctx, model.EmployeeListSpec)
+	if !ok {
+		return
+	}
+
+	page, err := s.store.Employee().List(ctx.Request.Context(), q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка сотрудников",
-			"error": err.Error()})
+		listError(ctx, "Ошибка получения списка сотрудников", err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, employees)
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (s *server) GetEmployeeByID(ctx *gin.Context) {
@@ -643,7 +762,7 @@ func (s *server) GetEmployeeByID(ctx *gin.Context) {
 		return
 	}
 
-	employee, err := s.store.Employee().ByID(uint(ID))
+	employee, err := s.store.Employee().ByID(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения сотрудника по ID",
 			"error": err.Error()})
@@ -655,7 +774,7 @@ func (s *server) GetEmployeeByID(ctx *gin.Context) {
 func (s *server) GetEmployeeByCode(ctx *gin.Context) {
 	pCode := ctx.Query("code")
 
-	employee, err := s.store.Employee().ByCode(pCode)
+	employee, err := s.store.Employee().ByCode(ctx.Request.Context(), pCode)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения сотрудника по Code",
 			"error": err.Error()})
@@ -682,7 +801,7 @@ func (s *server) UpdateEmployee(ctx *gin.Context) {
 	}
 
 	employee.ID = uint(ID)
-	employee, err = s.store.Employee().Update(employee)
+	employee, err = s.store.Employee().Update(ctx.Request.Context(), employee)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обновления данных сотрудника",
 			"error": err.Error()})
@@ -702,7 +821,7 @@ func (s *server) DeleteEmployee(ctx *gin.Context) {
 		return
 	}
 
-	err = s.store.Employee().Delete(uint(ID))
+	err = s.store.Employee().Delete(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка удаления сотрудника по ID",
 			"error": err.Error()})
@@ -712,7 +831,15 @@ func (s *server) DeleteEmployee(ctx *gin.Context) {
 }
 
 // Order...
+
+// AddOrders загружает заказы из ERP. Повторная отправка пакета безопасна:
+// существующие заказы обновляются, результат сообщается по каждому заказу.
 func (s *server) AddOrders(ctx *gin.Context) {
+	atomic, ok := atomicBatch(ctx)
+	if !ok {
+		return
+	}
+
 	var orders []model.Order
 
 	err := ctx.ShouldBindJSON(&orders)
@@ -724,127 +851,148 @@ func (s *server) AddOrders(ctx *gin.Context) {
 		return
 	}
 
-	// Создаем контекст с таймаутом
-	ctxTimeout, cancel := context.WithTimeout(ctx.Request.Context(), 30*time.Second)
-	defer cancel()
+	results, err := upsertOrders(ctx.Request.Context(), s.store, atomic, orders, currentUser(ctx).ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка загрузки заказов",
+			"error": err.Error()})
+		return
+	}
 
-	// Каналы для результатов и ошибок
-	results := make(chan model.Order, len(orders))
-	errors := make(chan struct {
-		order model.Order
-		err   error
-	}, len(orders))
-
-	// WaitGroup для отслеживания завершения всех горутин
-	var wg sync.WaitGroup
-
-	// Запускаем горутину для каждого заказа
-	for _, order := range orders {
-		wg.Add(1)
-		go func(order model.Order) {
-			defer wg.Done()
-
-			// Проверяем контекст перед обработкой
-			select {
-			case <-ctxTimeout.Done():
-				errors <- struct {
-					order model.Order
-					err   error
-				}{order, fmt.Errorf("timeout processing order")}
-				return
-			default:
-			}
+	summary := make(map[model.OrderImportStatus]int)
+	for _, r := range results {
+		summary[r.Status]++
+	}
 
-			createdOrder, err := s.store.Order().Add(order)
-			if err != nil {
-				errors <- struct {
-					order model.Order
-					err   error
-				}{order, err}
-				return
-			}
-			results <- createdOrder
-		}(order)
-	}
-
-	// Горутина для закрытия каналов после завершения всех операций
-	go func() {
-		wg.Wait()
-		close(results)
-		close(errors)
-	}()
-
-	// Собираем результаты
-	var addedOrders []model.Order
-	var failedOrders []struct {
-		Order   model.Order `json:"order"`
-		Message string      `json:"error"`
-	}
-
-	// Читаем из каналов до их закрытия
-	for {
-		select {
-		case <-ctxTimeout.Done():
-			ctx.JSON(http.StatusGatewayTimeout, gin.H{
-				"message":       "Превышено время ожидания при создании заказов",
-				"added_orders":  addedOrders,
-				"failed_orders": failedOrders,
-				"error":         "timeout",
-			})
-			return
+	response := gin.H{
+		"message": "Обработка заказов завершена",
+		"atomic":  atomic,
+		"summary": summary,
+		"results": results,
+	}
 
-		case order, ok := <-results:
-			if !ok {
-				results = nil
-				continue
-			}
-			addedOrders = append(addedOrders, order)
+	switch {
+	case summary[model.OrderImportFailed] > 0 && atomic:
+		response["message"] = "Загрузка заказов отменена"
+		ctx.JSON(http.StatusUnprocessableEntity, response)
+		return
+	case summary[model.OrderImportFailed] > 0:
+		ctx.JSON(http.StatusMultiStatus, response)
+		return
+	}
 
-		case errorData, ok := <-errors:
-			if !ok {
-				errors = nil
-				continue
-			}
-			failedOrders = append(failedOrders, struct {
-				Order   model.Order `json:"order"`
-				Message string      `json:"error"`
-			}{
-				Order:   errorData.order,
-				Message: errorData.err.Error(),
-			})
-
-		default:
-			if results == nil && errors == nil {
-				goto Done
+	ctx.JSON(http.StatusOK, response)
+}
+
+// upsertOrders загружает заказы. Атомарная загрузка выполняется в одной
+// транзакции и при ошибке любого заказа отменяется целиком.
+func upsertOrders(ctx context.Context, st store.Store, atomic bool, orders []model.Order, actorID uint) (results []model.OrderImportResult, err error) {
+	if !atomic {
+		return st.Order().Upsert(ctx, orders, actorID)
+	}
+
+	err = st.WithTx(ctx, func(tx store.Store) error {
+		if results, err = tx.Order().Upsert(ctx, orders, actorID); err != nil {
+			return err
+		}
+		for _, r := range results {
+			if r.Status == model.OrderImportFailed {
+				return errBatchFailed
 			}
 		}
+		return nil
+	})
+	if !errors.Is(err, errBatchFailed) {
+		return results, err
 	}
 
-Done:
-	// Формируем итоговый ответ
-	response := gin.H{
-		"message":      "Обработка заказов завершена",
-		"added_orders": addedOrders,
+	for i := range results {
+		if results[i].Status != model.OrderImportFailed {
+			results[i].Status = model.OrderImportRolledBack
+		}
+	}
+	return results, nil
+}
+
+func (s *server) GetOrders(ctx *gin.Context) {
+	format, ok := exportFormat(ctx)
+	if !ok {
+		return
 	}
 
-	if len(failedOrders) > 0 {
-		response["failed_orders"] = failedOrders
-		ctx.JSON(http.StatusMultiStatus, response)
+	q, ok := listQuery(ctx, model.OrderListSpec)
+	if !ok {
+		return
+	}
+
+	if format != export.FormatJSON {
+		s.exportOrders(ctx, format, q)
+		return
+	}
+
+	page, err := s.store.Order().List(ctx.Request.Context(), q)
+	if err == nil {
+		err = s.setSLA(ctx.Request.Context(), page.Items)
+	}
+	if err != nil {
+		listError(ctx, "Ошибка получения списка заказов", err)
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, response)
+	ctx.JSON(http.StatusOK, page)
 }
 
-func (s *server) GetOrders(ctx *gin.Context) {
-	orders, err := s.store.Order().All()
+// exportOrders выгружает все заказы, подходящие под фильтры и сортировку q,
+// без ограничения страницы списка. Заказы читаются порциями по курсору
+// и пишутся в ответ по мере чтения.
+func (s *server) exportOrders(ctx *gin.Context, format export.Format, q model.ListQuery) {
+	q.Page, q.Size, q.Cursor, q.Limit = 0, 0, "", model.MaxListLimit
+
+	// Ошибку первой порции еще можно вернуть ответом 4xx/5xx
+	page, err := s.store.Order().List(ctx.Request.Context(), q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка заказов",
+		listError(ctx, "Ошибка получения списка заказов", err)
+		return
+	}
+
+	table := ordersTable("Заказы", page.Items)
+	table.More = func() ([][]any, error) {
+		if page.NextCursor == "" {
+			return nil, nil
+		}
+		q.Cursor = page.NextCursor
+		if page, err = s.store.Order().List(ctx.Request.Context(), q); err != nil {
+			return nil, err
+		}
+		return ordersTable("", page.Items).Rows, nil
+	}
+	writeExport(ctx, format, table, "orders")
+}
+
+// SearchOrders ищет заказы по клиенту, адресу, водителю, агенту и номерам документа.
+func (s *server) SearchOrders(ctx *gin.Context) {
+	var req model.OrderSearch
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Укажите строку поиска q",
+			"error": err.Error()})
+		return
+	}
+	if err := req.Normalize(); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте параметры поиска",
 			"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, orders)
+	page, err := s.store.Order().Search(ctx.Request.Context(), req)
+	if err == nil {
+		err = s.setHitsSLA(ctx.Request.Context(), page.Items)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка поиска заказов",
+			"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (s *server) GetOrderByID(ctx *gin.Context) {
@@ -856,7 +1004,10 @@ func (s *server) GetOrderByID(ctx *gin.Context) {
 		return
 	}
 
-	order, err := s.store.Order().ByID(uint(ID))
+	order, err := s.store.Order().ByID(ctx.Request.Context(), uint(ID))
+	if err == nil {
+		err = s.setSLA(ctx.Request.Context(), order)
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения заказа по ID",
 			"error": err.Error()})
@@ -874,7 +1025,10 @@ func (s *server) GetOrderByUID(ctx *gin.Context) {
 		return
 	}
 
-	order, err := s.store.Order().ByOrderUID(uint(OrderUID))
+	order, err := s.store.Order().ByOrderUID(ctx.Request.Context(), uint(OrderUID))
+	if err == nil {
+		err = s.setSLA(ctx.Request.Context(), order)
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения заказа по OrderUID",
 			"error": err.Error()})
@@ -883,6 +1037,42 @@ func (s *server) GetOrderByUID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, order)
 }
 
+func (s *server) GetOrderHistory(ctx *gin.Context) {
+	pID := ctx.Query("order_uid")
+	OrderUID, err := strconv.Atoi(pID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность OrderUID",
+			"error": err.Error()})
+		return
+	}
+
+	events, err := s.store.OrderEvent().ByOrderUID(ctx.Request.Context(), uint(OrderUID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения истории заказа",
+			"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, events)
+}
+
+func (s *server) GetOrderPickList(ctx *gin.Context) {
+	pID := ctx.Query("order_uid")
+	OrderUID, err := strconv.Atoi(pID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность OrderUID",
+			"error": err.Error()})
+		return
+	}
+
+	lines, err := s.store.OrderLine().ByOrderUID(ctx.Request.Context(), uint(OrderUID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения строк заказа",
+			"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, model.NewPickList(uint(OrderUID), lines))
+}
+
 func (s *server) GetOrdersByUserId(ctx *gin.Context) {
 	pID := ctx.Query("user_id")
 	UserID, err := strconv.Atoi(pID)
@@ -892,7 +1082,10 @@ func (s *server) GetOrdersByUserId(ctx *gin.Context) {
 		return
 	}
 
-	order, err := s.store.Order().ByUserID(uint(UserID))
+	order, err := s.store.Order().ByUserID(ctx.Request.Context(), uint(UserID))
+	if err == nil {
+		err = s.setSLA(ctx.Request.Context(), order)
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения заказа по UserUID",
 			"error": err.Error()})
@@ -902,6 +1095,11 @@ func (s *server) GetOrdersByUserId(ctx *gin.Context) {
 }
 
 func (s *server) UpdateOrderCheck(ctx *gin.Context) {
+	atomic, ok := atomicBatch(ctx)
+	if !ok {
+		return
+	}
+
 	type request struct {
 		OrderUID uint `json:"order_uid"`
 		UserID   uint `json:"user_id"`
@@ -916,18 +1114,36 @@ func (s *server) UpdateOrderCheck(ctx *gin.Context) {
 		return
 	}
 
-	for _, req := range reqs {
-		err := s.store.Order().SetCheck(req.OrderUID, req.UserID, req.Check)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обновления информации",
-				"error": err.Error()})
-			continue
+	actorID := currentUser(ctx).ID
+	report, err := runBatch(ctx.Request.Context(), s.store, atomic, reqs, func(st store.Store, req request) (any, error) {
+		// Снятие отметки о проверке возвращает заказ в статус "собран"
+		status := model.OrderStatusAssembled
+		if req.Check {
+			status = model.OrderStatusChecked
 		}
+
+		return st.Order().Transition(ctx.Request.Context(), model.OrderTransition{
+			OrderUID: req.OrderUID,
+			Status:   status,
+			UserID:   req.UserID,
+			ActorID:  actorID,
+		})
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обновления информации",
+			"error": err.Error()})
+		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{"message": "Обновление выполнено успешно"})
+
+	writeBatch(ctx, http.StatusOK, report)
 }
 
 func (s *server) UpdateOrderCollector(ctx *gin.Context) {
+	atomic, ok := atomicBatch(ctx)
+	if !ok {
+		return
+	}
+
 	type request struct {
 		OrderUID   uint `json:"order_uid"`
 		UserID     uint `json:"user_id"`
@@ -943,38 +1159,299 @@ func (s *server) UpdateOrderCollector(ctx *gin.Context) {
 		return
 	}
 
-	errors := make(chan error, len(reqs))
-	var wg sync.WaitGroup
+	actorID := currentUser(ctx).ID
+	report, err := runBatch(ctx.Request.Context(), s.store, atomic, reqs, func(st store.Store, req request) (any, error) {
+		return collectOrder(ctx.Request.Context(), st, req.OrderUID, req.UserID, req.EmployeeID, actorID)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обновления информации",
+			"error": err.Error()})
+		return
+	}
 
-	for _, req := range reqs {
-		wg.Add(1)
-		go func(req request) {
-			defer wg.Done()
-			if err := s.store.Order().SetCollector(req.OrderUID, req.UserID, req.EmployeeID); err != nil {
-				errors <- err
-			}
-		}(req)
+	writeBatch(ctx, http.StatusOK, report)
+}
+
+// collectOrder отмечает заказ собранным указанным сборщиком. Новый заказ
+// предварительно назначается сборщику, уже назначенный сразу переводится в "собран".
+// Оба перехода выполняются в одной транзакции: при ошибке заказ не остается назначенным.
+func collectOrder(ctx context.Context, st store.Store, orderUID, userID, employeeID, actorID uint) (order model.Order, err error) {
+	t := model.OrderTransition{
+		OrderUID:   orderUID,
+		Status:     model.OrderStatusAssigned,
+		UserID:     userID,
+		EmployeeID: employeeID,
+		ActorID:    actorID,
 	}
 
-	// Закрываем канал после завершения всех горутин
-	go func() {
-		wg.Wait()
-		close(errors)
-	}()
+	err = st.WithTx(ctx, func(tx store.Store) error {
+		_, err := tx.Order().Transition(ctx, t)
+		if err != nil && !errors.Is(err, store.ErrIllegalTransition) {
+			return err
+		}
 
-	// Собираем ошибки
-	var errorMessages []string
-	for err := range errors {
-		errorMessages = append(errorMessages, err.Error())
+		t.Status = model.OrderStatusAssembled
+		order, err = tx.Order().Transition(ctx, t)
+		return err
+	})
+	return order, err
+}
+
+// statusChange - переход заказа из статуса в статус.
+type statusChange struct {
+	from, to model.OrderStatus
+}
+
+// transitionPermissions - права, необходимые для перехода заказа между статусами.
+// Снятие проверки требует того же права, что и проверка.
+var transitionPermissions = map[statusChange]model.Permission{
+	{model.OrderStatusNew, model.OrderStatusAssigned}:        model.PermOrdersAssign,
+	{model.OrderStatusNew, model.OrderStatusCancelled}:       model.PermOrdersManage,
+	{model.OrderStatusAssigned, model.OrderStatusPicking}:    model.PermOrdersPick,
+	{model.OrderStatusAssigned, model.OrderStatusAssembled}:  model.PermOrdersPick,
+	{model.OrderStatusAssigned, model.OrderStatusNew}:        model.PermOrdersAssign,
+	{model.OrderStatusAssigned, model.OrderStatusCancelled}:  model.PermOrdersManage,
+	{model.OrderStatusPicking, model.OrderStatusAssembled}:   model.PermOrdersPick,
+	{model.OrderStatusPicking, model.OrderStatusAssigned}:    model.PermOrdersAssign,
+	{model.OrderStatusPicking, model.OrderStatusCancelled}:   model.PermOrdersManage,
+	{model.OrderStatusAssembled, model.OrderStatusChecked}:   model.PermOrdersCheck,
+	{model.OrderStatusAssembled, model.OrderStatusPicking}:   model.PermOrdersPick,
+	{model.OrderStatusAssembled, model.OrderStatusCancelled}: model.PermOrdersManage,
+	{model.OrderStatusChecked, model.OrderStatusShipped}:     model.PermOrdersManage,
+	{model.OrderStatusChecked, model.OrderStatusAssembled}:   model.PermOrdersCheck,
+	{model.OrderStatusShipped, model.OrderStatusReturned}:    model.PermOrdersManage,
+	{model.OrderStatusCancelled, model.OrderStatusNew}:       model.PermOrdersManage,
+}
+
+// TransitionOrder переводит заказ в другой статус. Право определяется парой
+// статусов; сборщик без права назначения может двигать только свои
+// или еще не назначенные заказы и не может назначить заказ другому пользователю.
+func (s *server) TransitionOrder(ctx *gin.Context) {
+	type request struct {
+		model.OrderTransition
+		Status *model.OrderStatus `json:"status" binding:"required"`
+	}
+	var body request
+	err := ctx.ShouldBindJSON(&body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность передаваемых данных",
+			"error": err.Error()})
+		return
 	}
+	req := body.OrderTransition
+	req.Status = *body.Status
 
-	if len(errorMessages) > 0 {
-		ctx.JSON(http.StatusMultiStatus, gin.H{"errors": errorMessages})
+	orders, err := s.store.Order().ByOrderUID(ctx.Request.Context(), req.OrderUID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения заказа",
+			"error": err.Error()})
+		return
+	}
+	if len(orders) == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Заказ не найден"})
 		return
 	}
+	current := orders[0]
+
+	perm, ok := transitionPermissions[statusChange{current.Status, req.Status}]
+	if !ok {
+		ctx.JSON(http.StatusConflict, gin.H{"message": "Недопустимый переход статуса заказа",
+			"from": current.Status, "to": req.Status})
+		return
+	}
+	s.RequirePermission(perm)(ctx)
+	if ctx.IsAborted() {
+		return
+	}
+
+	user := currentUser(ctx)
+	if perm == model.PermOrdersPick {
+		a, err := s.userAccess(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения прав пользователя",
+				"error": err.Error()})
+			return
+		}
+		foreign := func(id uint) bool { return id != 0 && id != user.ID }
+		if !a.has(model.PermOrdersAssign) && (foreign(current.UserID) || foreign(req.UserID)) {
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "Заказ назначен другому сборщику",
+				"error": errAccessDenied.Error(), "required": []model.Permission{model.PermOrdersAssign}})
+			return
+		}
+	}
+	req.ActorID = user.ID
+
+	order, err := s.store.Order().Transition(ctx.Request.Context(), req)
+	switch {
+	case errors.Is(err, store.ErrIllegalTransition):
+		ctx.JSON(http.StatusConflict, gin.H{"message": "Недопустимый переход статуса заказа",
+			"error": err.Error()})
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Заказ не найден",
+			"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка изменения статуса заказа",
+			"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Статус заказа изменен",
+		"order": order, "next": order.Status.Next()})
+}
+
+func (s *server) ConfirmOrderPick(ctx *gin.Context) {
+	var req model.PickConfirmation
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность передаваемых данных",
+			"error": err.Error()})
+		return
+	}
+	req.ActorID = currentUser(ctx).ID
+
+	order, err := s.store.Order().ConfirmPick(ctx.Request.Context(), req)
+	switch {
+	case errors.Is(err, store.ErrInvalidPick):
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Некорректное подтверждение сборки",
+			"error": err.Error()})
+		return
+	case errors.Is(err, store.ErrIllegalTransition):
+		ctx.JSON(http.StatusConflict, gin.H{"message": "Заказ недоступен для сборки",
+			"error": err.Error()})
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Заказ не найден",
+			"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка подтверждения сборки",
+			"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Сборка подтверждена", "order": order})
+}
+
+// GetOrderQueue возвращает очередь сборки текущего пользователя в порядке выдачи.
+func (s *server) GetOrderQueue(ctx *gin.Context) {
+	var req model.DispatchRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность параметров",
+			"error": err.Error()})
+		return
+	}
+	if err := req.Normalize(s.dispatch); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность параметров",
+			"error": err.Error()})
+		return
+	}
+	req.UserID = currentUser(ctx).ID
+
+	orders, err := s.store.Order().Queue(ctx.Request.Context(), req)
+	if err == nil {
+		err = s.setSLA(ctx.Request.Context(), orders)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения очереди сборки",
+			"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"strategy": req.Strategy, "orders": orders})
+}
+
+// NextOrder назначает текущему пользователю следующий заказ из очереди сборки.
+// Тело запроса необязательно: без него заказ выдается по стратегии по умолчанию.
+func (s *server) NextOrder(ctx *gin.Context) {
+	var req model.DispatchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность передаваемых данных",
+			"error": err.Error()})
+		return
+	}
+	if err := req.Normalize(s.dispatch); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность передаваемых данных",
+			"error": err.Error()})
+		return
+	}
+	req.UserID = currentUser(ctx).ID
+
+	order, err := s.store.Order().Next(ctx.Request.Context(), req)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"message": "Нет заказов для сборки",
+			"error": err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка выдачи заказа",
+			"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Заказ назначен", "strategy": req.Strategy, "order": order})
+}
+
+// workMessages - ответы на отметки времени сборки.
+var workMessages = map[model.WorkAction]string{
+	model.WorkStart:  "Сборка начата",
+	model.WorkPause:  "Сборка приостановлена",
+	model.WorkResume: "Сборка продолжена",
+	model.WorkFinish: "Сборка завершена",
+}
+
+// OrderWork возвращает обработчик отметки времени сборки текущим пользователем.
+func (s *server) OrderWork(action model.WorkAction) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req model.OrderWork
+		err := ctx.ShouldBindJSON(&req)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность передаваемых данных",
+				"error": err.Error()})
+			return
+		}
+		req.Action, req.UserID, req.At = action, currentUser(ctx).ID, time.Now()
+
+		order, err := s.store.Order().Work(ctx.Request.Context(), req)
+		switch {
+		case errors.Is(err, store.ErrOtherCollector):
+			ctx.JSON(http.StatusForbidden, gin.H{"message": "Заказ назначен другому сборщику",
+				"error": err.Error()})
+			return
+		case errors.Is(err, store.ErrNotAssigned):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "Заказ не назначен, получите его через выдачу заказов",
+				"error": err.Error()})
+			return
+		case errors.Is(err, store.ErrIllegalTransition):
+			ctx.JSON(http.StatusConflict, gin.H{"message": "Действие недоступно для заказа",
+				"error": err.Error()})
+			return
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"message": "Заказ не найден",
+				"error": err.Error()})
+			return
+		case err != nil:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка отметки времени сборки",
+				"error": err.Error()})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{"message": workMessages[action], "order": order,
+			"work_seconds": int64(order.WorkDuration(req.At) / time.Second)})
+	}
+}
 
-	// Возвращаем успешный статус, если ошибок нет
-	ctx.JSON(http.StatusOK, gin.H{"message": "Обновление выполнено успешно"})
+// dateRange разбирает период запроса. Даты принимаются в RFC3339 и дд.мм.гггг,
+// при ошибке ответ 400 уже отправлен.
+func dateRange(ctx *gin.Context, start, finish string) (model.DateRange, bool) {
+	period, err := model.ParseDateRange(start, finish)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте даты периода",
+			"error": err.Error()})
+		return period, false
+	}
+	return period, true
 }
 
 func (s *server) GetOrdersByDateRange(ctx *gin.Context) {
@@ -984,6 +1461,11 @@ func (s *server) GetOrdersByDateRange(ctx *gin.Context) {
 		DtFinish string `json:"dt_finish"`
 	}
 
+	format, ok := exportFormat(ctx)
+	if !ok {
+		return
+	}
+
 	var req request
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
@@ -991,22 +1473,35 @@ func (s *server) GetOrdersByDateRange(ctx *gin.Context) {
 		return
 	}
 
-	findedOrders, err := s.store.Order().ByDateRange(req.DtStart, req.DtFinish)
+	period, ok := dateRange(ctx, req.DtStart, req.DtFinish)
+	if !ok {
+		return
+	}
+
+	findedOrders, err := s.store.Order().ByDateRange(ctx.Request.Context(), period)
+	if err == nil {
+		err = s.setSLA(ctx.Request.Context(), findedOrders)
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if format != export.FormatJSON {
+		writeExport(ctx, format, ordersTable("Заказы за период", findedOrders), "orders_daterange")
+		return
+	}
+
 	ctx.JSON(http.StatusOK, findedOrders)
 }
 
 func (s *server) GetAssemblyOrders(ctx *gin.Context) {
-	type request struct {
-		StartDT  string `json:"start_dt"`
-		FinishDT string `json:"finish_dt"`
+	format, ok := exportFormat(ctx)
+	if !ok {
+		return
 	}
 
-	var req request
+	var req model.AssemblyFilter
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность передаваемых данных",
@@ -1014,13 +1509,22 @@ func (s *server) GetAssemblyOrders(ctx *gin.Context) {
 		return
 	}
 
-	assemblyOrders, err := s.store.Order().AssemblyOrder(req.StartDT, req.FinishDT)
+	if _, ok := dateRange(ctx, req.StartDT, req.FinishDT); !ok {
+		return
+	}
+
+	assemblyOrders, err := s.store.Order().AssemblyOrder(ctx.Request.Context(), req)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка собранных заказов",
 			"error": err.Error()})
 		return
 	}
 
+	if format != export.FormatJSON {
+		writeExport(ctx, format, assemblyTable(assemblyOrders), "assembly_orders")
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{"message": "Собранные заказы успешно получены",
 		"orders": assemblyOrders})
 }
@@ -1047,7 +1551,15 @@ func (s *server) GetOrdersByAccessUser(ctx *gin.Context) {
 		return
 	}
 
-	orders, err := s.store.Order().ByAccessUser(uint(UserID), req.StartDT, req.FinishDT)
+	period, ok := dateRange(ctx, req.StartDT, req.FinishDT)
+	if !ok {
+		return
+	}
+
+	orders, err := s.store.Order().ByAccessUser(ctx.Request.Context(), uint(UserID), period)
+	if err == nil {
+		err = s.setSLA(ctx.Request.Context(), orders)
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка заказов",
 			"error": err.Error()})
@@ -1064,6 +1576,11 @@ func (s *server) GetOrdersChecked(ctx *gin.Context) {
 		Check    bool   `json:"check"`
 	}
 
+	format, ok := exportFormat(ctx)
+	if !ok {
+		return
+	}
+
 	var req request
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
@@ -1072,13 +1589,26 @@ func (s *server) GetOrdersChecked(ctx *gin.Context) {
 		return
 	}
 
-	ChekedOrders, err := s.store.Order().CheckedList(req.StartDT, req.FinishDT, req.Check)
+	period, ok := dateRange(ctx, req.StartDT, req.FinishDT)
+	if !ok {
+		return
+	}
+
+	ChekedOrders, err := s.store.Order().CheckedList(ctx.Request.Context(), period, req.Check)
+	if err == nil {
+		err = s.setSLA(ctx.Request.Context(), ChekedOrders)
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка собранных заказов",
 			"error": err.Error()})
 		return
 	}
 
+	if format != export.FormatJSON {
+		writeExport(ctx, format, ordersTable("Проверка заказов", ChekedOrders), "orders_checked")
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{"message": "Собранные заказы успешно получены",
 		"orders": ChekedOrders})
 }
@@ -1096,7 +1626,7 @@ func (s *server) AddTeams(ctx *gin.Context) {
 
 	var addedTeams []model.Team
 	for _, team := range teams {
-		team, err = s.store.Team().Add(team)
+		team, err = s.store.Team().Add(ctx.Request.Context(), team)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка добавления команды " + team.Name,
 				"error": err.Error()})
@@ -1111,14 +1641,18 @@ func (s *server) AddTeams(ctx *gin.Context) {
 }
 
 func (s *server) GetTeams(ctx *gin.Context) {
-	teams, err := s.store.Team().All()
+	q, ok := listQuery(ctx, model.TeamListSpec)
+	if !ok {
+		return
+	}
+
+	page, err := s.store.Team().List(ctx.Request.Context(), q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка команд",
-			"error": err.Error()})
+		listError(ctx, "Ошибка получения списка команд", err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, teams)
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (s *server) GetTeamByID(ctx *gin.Context) {
@@ -1130,7 +1664,7 @@ func (s *server) GetTeamByID(ctx *gin.Context) {
 		return
 	}
 
-	team, err := s.store.Team().ByID(uint(ID))
+	team, err := s.store.Team().ByID(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения заказа по ID",
 			"error": err.Error()})
@@ -1158,7 +1692,7 @@ func (s *server) UpdateTeam(ctx *gin.Context) {
 
 	team.ID = uint(ID)
 
-	team, err = s.store.Team().Update(team)
+	team, err = s.store.Team().Update(ctx.Request.Context(), team)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обновления информации о команде",
 			"error": err.Error()})
@@ -1178,7 +1712,7 @@ func (s *server) DeleteTeam(ctx *gin.Context) {
 		return
 	}
 
-	err = s.store.Team().Delete(uint(ID))
+	err = s.store.Team().Delete(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка удаления команды",
 			"error": err.Error()})
@@ -1202,7 +1736,7 @@ func (s *server) AddProject(ctx *gin.Context) {
 
 	var addedProject []model.Project
 	for _, req := range project {
-		project, err := s.store.Project().Add(req)
+		project, err := s.store.Project().Add(ctx.Request.Context(), req)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка добавления проекта",
 				"error": err.Error()})
@@ -1215,14 +1749,18 @@ func (s *server) AddProject(ctx *gin.Context) {
 }
 
 func (s *server) GetProjects(ctx *gin.Context) {
-	projects, err := s.store.Project().All()
+	q, ok := listQuery(ctx, model.ProjectListSpec)
+	if !ok {
+		return
+	}
+
+	page, err := s.store.Project().List(ctx.Request.Context(), q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка проектов",
-			"error": err.Error()})
+		listError(ctx, "Ошибка получения списка проектов", err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, projects)
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (s *server) GetProjectById(ctx *gin.Context) {
@@ -1234,7 +1772,7 @@ func (s *server) GetProjectById(ctx *gin.Context) {
 		return
 	}
 
-	project, err := s.store.Project().ByID(uint(ID))
+	project, err := s.store.Project().ByID(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения проекта по ID",
 			"error": err.Error()})
@@ -1252,7 +1790,7 @@ func (s *server) DeleteProject(ctx *gin.Context) {
 		return
 	}
 
-	err = s.store.Project().Delete(uint(ID))
+	err = s.store.Project().Delete(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка удаления проекта по ID",
 			"error": err.Error()})
@@ -1283,7 +1821,7 @@ func (s *server) DeleteProjectByUserID(ctx *gin.Context) {
 		return
 	}
 
-	err = s.store.UserProject().DeleteUserProject(uint(UserID), req.ProjectID)
+	err = s.store.UserProject().DeleteUserProject(ctx.Request.Context(), uint(UserID), req.ProjectID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка удаления проекта пользователя",
 			"error": err.Error()})
@@ -1312,7 +1850,7 @@ func (s *server) UpdateProject(ctx *gin.Context) {
 
 	project.ID = uint(ID)
 
-	project, err = s.store.Project().Update(project)
+	project, err = s.store.Project().Update(ctx.Request.Context(), project)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обновления данных проекта",
 			"error": err.Error()})
@@ -1337,7 +1875,7 @@ func (s *server) AddUserProjects(ctx *gin.Context) {
 
 	var addedUP []model.UserProject
 	for _, req := range userProjects {
-		userProjects, err := s.store.UserProject().Add(req)
+		userProjects, err := s.store.UserProject().Add(ctx.Request.Context(), req)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка добавления проекта пользователя",
 				"error": err.Error()})
@@ -1350,13 +1888,17 @@ func (s *server) AddUserProjects(ctx *gin.Context) {
 }
 
 func (s *server) GetUserProjects(ctx *gin.Context) {
-	up, err := s.store.UserProject().All()
+	q, ok := listQuery(ctx, model.UserProjectListSpec)
+	if !ok {
+		return
+	}
+
+	page, err := s.store.UserProject().List(ctx.Request.Context(), q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка проектов пользователей",
-			"error": err.Error()})
+		listError(ctx, "Ошибка получения списка проектов пользователей", err)
 		return
 	}
-	ctx.JSON(http.StatusOK, up)
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (s *server) GetUserProjectsByUserId(ctx *gin.Context) {
@@ -1368,7 +1910,7 @@ func (s *server) GetUserProjectsByUserId(ctx *gin.Context) {
 		return
 	}
 
-	UserProject, err := s.store.UserProject().ByUserID(uint(UserID))
+	UserProject, err := s.store.UserProject().ByUserID(ctx.Request.Context(), uint(UserID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка проектов пользователей по UserId",
 			"error": err.Error()})
@@ -1386,7 +1928,7 @@ func (s *server) GetUserProjectsByProjectId(ctx *gin.Context) {
 		return
 	}
 
-	UserProject, err := s.store.UserProject().ByProjectID(uint(ProjectID))
+	UserProject, err := s.store.UserProject().ByProjectID(ctx.Request.Context(), uint(ProjectID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка проектов пользователей по ProjectId",
 			"error": err.Error()})
@@ -1404,7 +1946,7 @@ func (s *server) GetUserProjectById(ctx *gin.Context) {
 		return
 	}
 
-	userProject, err := s.store.UserProject().ByID(uint(ID))
+	userProject, err := s.store.UserProject().ByID(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения проекта пользователя по ID",
 			"error": err.Error()})
@@ -1432,7 +1974,7 @@ func (s *server) UpdateUserProject(ctx *gin.Context) {
 
 	userProject.ID = uint(ID)
 
-	userProject, err = s.store.UserProject().Update(userProject)
+	userProject, err = s.store.UserProject().Update(ctx.Request.Context(), userProject)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обновления информации о проекте пользователя",
 			"error": err.Error()})
@@ -1452,7 +1994,7 @@ func (s *server) DeleteUserProject(ctx *gin.Context) {
 		return
 	}
 
-	err = s.store.UserProject().Delete(uint(ID))
+	err = s.store.UserProject().Delete(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка удаления проекта пользователя",
 			"error": err.Error()})
@@ -1474,9 +2016,15 @@ func (s *server) AddRoles(ctx *gin.Context) {
 		return
 	}
 
+	for _, role := range roles {
+		if !s.canManageRole(ctx, role.Priority) {
+			return
+		}
+	}
+
 	var addedRoles []model.Role
 	for _, role := range roles {
-		role, err = s.store.Role().Add(role)
+		role, err = s.store.Role().Add(ctx.Request.Context(), role)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка добавления роли - " + role.Name,
 				"error": err.Error()})
@@ -1492,14 +2040,18 @@ func (s *server) AddRoles(ctx *gin.Context) {
 }
 
 func (s *server) GetRoles(ctx *gin.Context) {
-	roles, err := s.store.Role().All()
+	q, ok := listQuery(ctx, model.RoleListSpec)
+	if !ok {
+		return
+	}
+
+	page, err := s.store.Role().List(ctx.Request.Context(), q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка ролей",
-			"error": err.Error()})
+		listError(ctx, "Ошибка получения списка ролей", err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"roles": roles})
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (s *server) GetRoleByID(ctx *gin.Context) {
@@ -1511,7 +2063,7 @@ func (s *server) GetRoleByID(ctx *gin.Context) {
 		return
 	}
 
-	role, err := s.store.Role().ByID(uint(ID))
+	role, err := s.store.Role().ByID(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения роли по ID",
 			"error": err.Error()})
@@ -1537,8 +2089,12 @@ func (s *server) UpdateRole(ctx *gin.Context) {
 		return
 	}
 
+	if !s.canManageRoleID(ctx, uint(ID)) || !s.canManageRole(ctx, role.Priority) {
+		return
+	}
+
 	role.ID = uint(ID)
-	role, err = s.store.Role().Update(role)
+	role, err = s.store.Role().Update(ctx.Request.Context(), role)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обновления данных роли",
 			"error": err.Error()})
@@ -1558,7 +2114,11 @@ func (s *server) DeleteRole(ctx *gin.Context) {
 		return
 	}
 
-	err = s.store.Role().Delete(uint(ID))
+	if !s.canManageRoleID(ctx, uint(ID)) {
+		return
+	}
+
+	err = s.store.Role().Delete(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка удаления роли",
 			"error": err.Error()})
@@ -1580,9 +2140,15 @@ func (s *server) AddUserRoles(ctx *gin.Context) {
 		return
 	}
 
+	for _, req := range userRoles {
+		if !s.canManageRoleID(ctx, req.RoleID) {
+			return
+		}
+	}
+
 	var addedUP []model.UserRole
 	for _, req := range userRoles {
-		userRoles, err := s.store.UserRole().Add(req)
+		userRoles, err := s.store.UserRole().Add(ctx.Request.Context(), req)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка добавления роли пользователя",
 
@@ -1596,14 +2162,17 @@ func (s *server) AddUserRoles(ctx *gin.Context) {
 }
 
 func (s *server) GetUserRoles(ctx *gin.Context) {
-	up, err := s.store.UserRole().All()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка ролей пользователей",
+	q, ok := listQuery(ctx, model.UserRoleListSpec)
+	if !ok {
+		return
+	}
 
-			"error": err.Error()})
+	page, err := s.store.UserRole().List(ctx.Request.Context(), q)
+	if err != nil {
+		listError(ctx, "Ошибка получения списка ролей пользователей", err)
 		return
 	}
-	ctx.JSON(http.StatusOK, up)
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (s *server) GetUserRolesByUserId(ctx *gin.Context) {
@@ -1615,7 +2184,7 @@ func (s *server) GetUserRolesByUserId(ctx *gin.Context) {
 		return
 	}
 
-	UserRole, err := s.store.UserRole().ByUserID(uint(UserID))
+	UserRole, err := s.store.UserRole().ByUserID(ctx.Request.Context(), uint(UserID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка проектов ролей по UserId",
 
@@ -1634,7 +2203,7 @@ func (s *server) GetUserRolesByRoleId(ctx *gin.Context) {
 		return
 	}
 
-	UserRole, err := s.store.UserRole().ByRoleID(uint(RoleID))
+	UserRole, err := s.store.UserRole().ByRoleID(ctx.Request.Context(), uint(RoleID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка ролей пользователей по RoleId",
 
@@ -1653,7 +2222,7 @@ func (s *server) GetUserRoleById(ctx *gin.Context) {
 		return
 	}
 
-	userRole, err := s.store.UserRole().ByID(uint(ID))
+	userRole, err := s.store.UserRole().ByID(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения роли пользователя по ID",
 
@@ -1681,9 +2250,20 @@ func (s *server) UpdateUserRole(ctx *gin.Context) {
 		return
 	}
 
+	current, err := s.store.UserRole().ByID(ctx.Request.Context(), uint(ID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения роли пользователя по ID",
+			"error": err.Error()})
+		return
+	}
+
+	if !s.canManageRoleID(ctx, current.RoleID) || !s.canManageRoleID(ctx, userRole.RoleID) {
+		return
+	}
+
 	userRole.ID = uint(ID)
 
-	userRole, err = s.store.UserRole().Update(userRole)
+	userRole, err = s.store.UserRole().Update(ctx.Request.Context(), userRole)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обновления информации о роли пользователя",
 
@@ -1704,7 +2284,18 @@ func (s *server) DeleteUserRole(ctx *gin.Context) {
 		return
 	}
 
-	err = s.store.UserRole().Delete(uint(ID))
+	userRole, err := s.store.UserRole().ByID(ctx.Request.Context(), uint(ID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения роли пользователя по ID",
+			"error": err.Error()})
+		return
+	}
+
+	if !s.canManageRoleID(ctx, userRole.RoleID) {
+		return
+	}
+
+	err = s.store.UserRole().Delete(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка удаления роли пользователя",
 
@@ -1729,7 +2320,7 @@ func (s *server) AddUserTeams(ctx *gin.Context) {
 
 	var addedUP []model.UserTeam
 	for _, req := range userTeams {
-		userTeams, err := s.store.UserTeam().Add(req)
+		userTeams, err := s.store.UserTeam().Add(ctx.Request.Context(), req)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка добавления команды пользователя",
 
@@ -1743,14 +2334,17 @@ func (s *server) AddUserTeams(ctx *gin.Context) {
 }
 
 func (s *server) GetUserTeams(ctx *gin.Context) {
-	up, err := s.store.UserTeam().All()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка ролей пользователей",
+	q, ok := listQuery(ctx, model.UserTeamListSpec)
+	if !ok {
+		return
+	}
 
-			"error": err.Error()})
+	page, err := s.store.UserTeam().List(ctx.Request.Context(), q)
+	if err != nil {
+		listError(ctx, "Ошибка получения списка ролей пользователей", err)
 		return
 	}
-	ctx.JSON(http.StatusOK, up)
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (s *server) GetUserTeamsByUserId(ctx *gin.Context) {
@@ -1762,7 +2356,7 @@ func (s *server) GetUserTeamsByUserId(ctx *gin.Context) {
 		return
 	}
 
-	UserTeam, err := s.store.UserTeam().ByUserID(uint(UserID))
+	UserTeam, err := s.store.UserTeam().ByUserID(ctx.Request.Context(), uint(UserID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка команд ролей по UserId",
 
@@ -1781,7 +2375,7 @@ func (s *server) GetUserTeamsByTeamId(ctx *gin.Context) {
 		return
 	}
 
-	UserRole, err := s.store.UserTeam().ByTeamID(uint(TeamID))
+	UserRole, err := s.store.UserTeam().ByTeamID(ctx.Request.Context(), uint(TeamID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка команд пользователей по TeamId",
 
@@ -1800,7 +2394,7 @@ func (s *server) GetUserTeamById(ctx *gin.Context) {
 		return
 	}
 
-	userRole, err := s.store.UserTeam().ByID(uint(ID))
+	userRole, err := s.store.UserTeam().ByID(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения команды пользователя по ID",
 
@@ -1830,7 +2424,7 @@ func (s *server) UpdateUserTeam(ctx *gin.Context) {
 
 	userTeam.ID = uint(ID)
 
-	userTeam, err = s.store.UserTeam().Update(userTeam)
+	userTeam, err = s.store.UserTeam().Update(ctx.Request.Context(), userTeam)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка обновления информации о команде пользователя",
 
@@ -1852,7 +2446,7 @@ func (s *server) DeleteUserTeamByID(ctx *gin.Context) {
 		return
 	}
 
-	err = s.store.UserTeam().Delete(uint(ID))
+	err = s.store.UserTeam().Delete(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка удаления команды пользователя",
 
@@ -1872,7 +2466,7 @@ func (s *server) DeleteUserTeam(ctx *gin.Context) {
 		return
 	}
 
-	err = s.store.UserTeam().DeleteUserTeam(user_team.TeamID, user_team.UserID)
+	err = s.store.UserTeam().DeleteUserTeam(ctx.Request.Context(), user_team.TeamID, user_team.UserID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка удаления команды пользователя",
 
@@ -1896,7 +2490,7 @@ func (s *server) AddEmployeeTeams(ctx *gin.Context) {
 
 	var addedET []model.EmployeeTeam
 	for _, req := range employeeTeams {
-		employeeTeams, err := s.store.EmployeeTeam().Add(req)
+		employeeTeams, err := s.store.EmployeeTeam().Add(ctx.Request.Context(), req)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка добавления команды пользователя",
 
@@ -1910,14 +2504,17 @@ func (s *server) AddEmployeeTeams(ctx *gin.Context) {
 }
 
 func (s *server) GetEmployeeTeams(ctx *gin.Context) {
-	et, err := s.store.EmployeeTeam().All()
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка ролей пользователей",
+	q, ok := listQuery(ctx, model.EmployeeTeamListSpec)
+	if !ok {
+		return
+	}
 
-			"error": err.Error()})
+	page, err := s.store.EmployeeTeam().List(ctx.Request.Context(), q)
+	if err != nil {
+		listError(ctx, "Ошибка получения списка ролей пользователей", err)
 		return
 	}
-	ctx.JSON(http.StatusOK, et)
+	ctx.JSON(http.StatusOK, page)
 }
 
 func (s *server) GetEmployeeTeamsByEmployeeId(ctx *gin.Context) {
@@ -1929,7 +2526,7 @@ func (s *server) GetEmployeeTeamsByEmployeeId(ctx *gin.Context) {
 		return
 	}
 
-	EmployeeTeam, err := s.store.EmployeeTeam().ByEmployeeID(uint(EmployeeID))
+	EmployeeTeam, err := s.store.EmployeeTeam().ByEmployeeID(ctx.Request.Context(), uint(EmployeeID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка команд по EmployeeID",
 			"error": err.Error()})
@@ -1947,7 +2544,7 @@ func (s *server) GetEmployeeTeamsByTeamId(ctx *gin.Context) {
 		return
 	}
 
-	EmployeeTeam, err := s.store.EmployeeTeam().ByEmployeeID(uint(TeamID))
+	EmployeeTeam, err := s.store.EmployeeTeam().ByEmployeeID(ctx.Request.Context(), uint(TeamID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения списка команд ролей по TeamID",
 			"error": err.Error()})
@@ -1965,7 +2562,7 @@ func (s *server) GetEmployeeTeamById(ctx *gin.Context) {
 		return
 	}
 
-	et, err := s.store.Employee().ByID(uint(ID))
+	et, err := s.store.Employee().ByID(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения команды пользователя по ID",
 
@@ -1995,7 +2592,7 @@ func (s *server) UpdateEmployeeTeam(ctx *gin.Context) {
 	}
 
 	et.ID = uint(ID)
-	et, err = s.store.EmployeeTeam().Update(et)
+	et, err = s.store.EmployeeTeam().Update(ctx.Request.Context(), et)
 
 	ctx.JSON(http.StatusOK, et)
 }
@@ -2009,7 +2606,7 @@ func (s *server) DeleteEmployeeTeamByID(ctx *gin.Context) {
 		return
 	}
 
-	err = s.store.EmployeeTeam().Delete(uint(ID))
+	err = s.store.EmployeeTeam().Delete(ctx.Request.Context(), uint(ID))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка удаления команды пользователя",
 
@@ -2029,7 +2626,7 @@ func (s *server) DeleteEmployeeTeam(ctx *gin.Context) {
 		return
 	}
 
-	err = s.store.EmployeeTeam().DeleteEmployeeTeam(et.EmployeeID, et.TeamID)
+	err = s.store.EmployeeTeam().DeleteEmployeeTeam(ctx.Request.Context(), et.EmployeeID, et.TeamID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка удаления команды сотрудника",
 			"error": err.Error()})
@@ -0,0 +1,670 @@
+package apiserver
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"eastwh/internal/store/teststore"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	gin.DefaultErrorWriter = io.Discard
+}
+
+// fakeStore реализует только те методы store.Store, которые нужны тестам;
+// вызов любого другого метода приводит к панике из встроенного nil-интерфейса.
+type fakeStore struct {
+	store.Store
+	users           map[uint]model.User
+	roles           map[uint]model.Role
+	userRoles       []model.UserRole
+	rolePermissions map[uint][]model.Permission
+	refreshTokens   []model.RefreshToken
+	orders          store.OrderRepository
+}
+
+func newFakeStore(users ...model.User) *fakeStore {
+	s := &fakeStore{
+		users:           make(map[uint]model.User),
+		roles:           make(map[uint]model.Role),
+		rolePermissions: make(map[uint][]model.Permission),
+	}
+	for _, u := range users {
+		s.users[u.ID] = u
+	}
+	return s
+}
+
+// grant создает роль с заданным приоритетом и правами и назначает ее пользователю.
+func (s *fakeStore) grant(userID uint, priority int, perms ...model.Permission) model.Role {
+	id := uint(len(s.roles) + 1)
+	role := model.Role{Model: gorm.Model{ID: id}, Name: fmt.Sprintf("role-%d", id), Priority: priority}
+	s.roles[id] = role
+	s.rolePermissions[id] = perms
+	s.userRoles = append(s.userRoles, model.UserRole{Model: gorm.Model{ID: uint(len(s.userRoles) + 1)}, RoleID: id, UserID: userID})
+	return role
+}
+
+func (s *fakeStore) User() store.UserRepository {
+	return &fakeUserRepository{store: s}
+}
+
+func (s *fakeStore) Order() store.OrderRepository {
+	return s.orders
+}
+
+func (s *fakeStore) Role() store.RoleRepository {
+	return &fakeRoleRepository{store: s}
+}
+
+func (s *fakeStore) UserRole() store.UserRoleRepository {
+	return &fakeUserRoleRepository{store: s}
+}
+
+func (s *fakeStore) RolePermission() store.RolePermissionRepository {
+	return &fakeRolePermissionRepository{store: s}
+}
+
+func (s *fakeStore) RefreshToken() store.RefreshTokenRepository {
+	return &fakeRefreshTokenRepository{store: s}
+}
+
+type fakeUserRepository struct {
+	store.UserRepository
+	store *fakeStore
+}
+
+func (r *fakeUserRepository) ByID(ctx context.Context, id uint) (model.User, error) {
+	u, ok := r.store.users[id]
+	if !ok {
+		return model.User{}, gorm.ErrRecordNotFound
+	}
+	return u, nil
+}
+
+func (r *fakeUserRepository) Login(ctx context.Context, email, password string) (model.User, error) {
+	for _, u := range r.store.users {
+		if u.Email == email && password == "secret" {
+			return u, nil
+		}
+	}
+	return model.User{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) Logout(context.Context, uint) error {
+	return nil
+}
+
+func (r *fakeUserRepository) ByEmail(ctx context.Context, email string) (model.User, error) {
+	for _, u := range r.store.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return model.User{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) Restore(context.Context, string) (string, error) {
+	return "temporary", nil
+}
+
+func (r *fakeUserRepository) ChangePassword(context.Context, uint, string) error {
+	return nil
+}
+
+func (r *fakeUserRepository) BlockedUser(ctx context.Context, id uint, blocked bool) error {
+	u := r.store.users[id]
+	u.Blocked = blocked
+	r.store.users[id] = u
+	return nil
+}
+
+type fakeRoleRepository struct {
+	store.RoleRepository
+	store *fakeStore
+}
+
+func (r *fakeRoleRepository) ByID(ctx context.Context, id uint) (model.Role, error) {
+	role, ok := r.store.roles[id]
+	if !ok {
+		return model.Role{}, gorm.ErrRecordNotFound
+	}
+	return role, nil
+}
+
+type fakeUserRoleRepository struct {
+	store.UserRoleRepository
+	store *fakeStore
+}
+
+func (r *fakeUserRoleRepository) ByUserID(ctx context.Context, userID uint) (urs []model.UserRole, err error) {
+	for _, ur := range r.store.userRoles {
+		if ur.UserID == userID {
+			urs = append(urs, ur)
+		}
+	}
+	return urs, nil
+}
+
+type fakeRolePermissionRepository struct {
+	store.RolePermissionRepository
+	store *fakeStore
+}
+
+func (r *fakeRolePermissionRepository) ByRoleID(ctx context.Context, roleID uint) (rp []model.RolePermission, err error) {
+	for _, p := range r.store.rolePermissions[roleID] {
+		rp = append(rp, model.RolePermission{RoleID: roleID, Permission: p})
+	}
+	return rp, nil
+}
+
+func (r *fakeRolePermissionRepository) ByRoleIDs(ctx context.Context, roleIDs []uint) (rp []model.RolePermission, err error) {
+	for _, id := range roleIDs {
+		perms, _ := r.ByRoleID(ctx, id)
+		rp = append(rp, perms...)
+	}
+	return rp, nil
+}
+
+func (r *fakeRolePermissionRepository) SetForRole(ctx context.Context, roleID uint, perms []model.Permission) ([]model.RolePermission, error) {
+	r.store.rolePermissions[roleID] = perms
+	return r.ByRoleID(ctx, roleID)
+}
+
+type fakeRefreshTokenRepository struct {
+	store.RefreshTokenRepository
+	store *fakeStore
+}
+
+func (r *fakeRefreshTokenRepository) Add(ctx context.Context, t model.RefreshToken) (model.RefreshToken, error) {
+	t.ID = uint(len(r.store.refreshTokens) + 1)
+	r.store.refreshTokens = append(r.store.refreshTokens, t)
+	return t, nil
+}
+
+func (r *fakeRefreshTokenRepository) ByHash(ctx context.Context, hash string) (model.RefreshToken, error) {
+	for _, t := range r.store.refreshTokens {
+		if t.TokenHash == hash {
+			return t, nil
+		}
+	}
+	return model.RefreshToken{}, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRefreshTokenRepository) Use(ctx context.Context, id uint) (bool, error) {
+	t := &r.store.refreshTokens[id-1]
+	if t.UsedAt != nil || t.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.UsedAt = &now
+	return true, nil
+}
+
+func (r *fakeRefreshTokenRepository) FamilyActive(ctx context.Context, familyID string) (bool, error) {
+	for _, t := range r.store.refreshTokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil && t.ExpiresAt.After(time.Now()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	now := time.Now()
+	for i := range r.store.refreshTokens {
+		if r.store.refreshTokens[i].FamilyID == familyID && r.store.refreshTokens[i].RevokedAt == nil {
+			r.store.refreshTokens[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshTokenRepository) RevokeUser(ctx context.Context, userID uint) error {
+	now := time.Now()
+	for i := range r.store.refreshTokens {
+		if r.store.refreshTokens[i].UserID == userID && r.store.refreshTokens[i].RevokedAt == nil {
+			r.store.refreshTokens[i].RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func testConfig() *Config {
+	config := NewConfig()
+	config.JWT.KeyID = "test"
+	config.JWT.Secret = strings.Repeat("s", minSecretLength)
+	return config
+}
+
+func newTestServer(t *testing.T, st store.Store) *server {
+	t.Helper()
+	s, err := newServer(st, testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func testUser(id uint) model.User {
+	return model.User{Model: gorm.Model{ID: id}, Email: "user@eastwh.local", Name: "Test"}
+}
+
+// signedToken открывает сессию пользователя и возвращает access-токен,
+// действующий еще ttl (отрицательное значение - уже истекший токен).
+func signedToken(t *testing.T, s *server, userID uint, ttl time.Duration) string {
+	t.Helper()
+	familyID := fmt.Sprintf("session-%d-%d", userID, time.Now().UnixNano())
+	_, err := s.store.RefreshToken().Add(context.Background(), model.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: familyID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, _, err := s.tokens.signAccess(userID, familyID, time.Now().Add(ttl-s.tokens.accessTTL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func serve(s *server, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+var publicRoutes = map[string]bool{
+	"GET /api/v1/ping":         true,
+	"POST /api/v1/users/login": true,
+}
+
+func TestAuthMW_RejectsAnonymousCalls(t *testing.T) {
+	s := newTestServer(t, newFakeStore(testUser(1)))
+
+	routes := s.router.Routes()
+	if len(routes) == 0 {
+		t.Fatal("no routes registered")
+	}
+
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		if publicRoutes[key] {
+			continue
+		}
+
+		t.Run(key, func(t *testing.T) {
+			rec := serve(s, httptest.NewRequest(route.Method, route.Path, strings.NewReader("{}")))
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("expected %d, got %d: %s", http.StatusUnauthorized, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMW_PublicRoutesSkipAuth(t *testing.T) {
+	s := newTestServer(t, newFakeStore())
+
+	rec := serve(s, httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestAuthMW_AcceptsBearerHeader(t *testing.T) {
+	s := newTestServer(t, newFakeStore(testUser(1)))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/permissions", nil)
+	req.Header.Set("Authorization", "Bearer "+signedToken(t, s, 1, time.Hour))
+
+	rec := serve(s, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+}
+
+func TestAuthMW_AcceptsCookie(t *testing.T) {
+	s := newTestServer(t, newFakeStore(testUser(1)))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/permissions", nil)
+	req.AddCookie(&http.Cookie{Name: "Auth", Value: signedToken(t, s, 1, time.Hour)})
+
+	rec := serve(s, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+}
+
+func TestAuthMW_RejectsBadTokens(t *testing.T) {
+	blocked := testUser(2)
+	blocked.Blocked = true
+	s := newTestServer(t, newFakeStore(testUser(1), blocked))
+
+	sign := func(kid string, key []byte, claims jwt.Claims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	valid := accessClaims{UserID: 1, SessionID: "session", RegisteredClaims: jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}}
+
+	wrongSecret := sign(s.tokens.keyID, []byte(strings.Repeat("x", minSecretLength)), valid)
+	unknownKey := sign("unknown", s.tokens.keys[s.tokens.keyID], valid)
+	noClaims := sign(s.tokens.keyID, s.tokens.keys[s.tokens.keyID], jwt.MapClaims{})
+	noSession := sign(s.tokens.keyID, s.tokens.keys[s.tokens.keyID], valid)
+
+	revoked := signedToken(t, s, 1, time.Hour)
+	claims, err := s.tokens.parseAccess(revoked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.store.RefreshToken().RevokeFamily(context.Background(), claims.SessionID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		code  int
+	}{
+		{"garbage", "not-a-jwt", http.StatusUnauthorized},
+		{"wrong secret", wrongSecret, http.StatusUnauthorized},
+		{"unknown key id", unknownKey, http.StatusUnauthorized},
+		{"missing claims", noClaims, http.StatusUnauthorized},
+		{"unknown session", noSession, http.StatusUnauthorized},
+		{"revoked session", revoked, http.StatusUnauthorized},
+		{"expired", signedToken(t, s, 1, -time.Hour), http.StatusUnauthorized},
+		{"unknown user", signedToken(t, s, 42, time.Hour), http.StatusUnauthorized},
+		{"blocked user", signedToken(t, s, 2, time.Hour), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/permissions", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			rec := serve(s, req)
+			if rec.Code != tt.code {
+				t.Fatalf("expected %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestBearerToken_PrefersHeader(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx.Request.Header.Set("Authorization", "bearer header-token")
+	ctx.Request.AddCookie(&http.Cookie{Name: "Auth", Value: "cookie-token"})
+
+	if got := bearerToken(ctx); got != "header-token" {
+		t.Fatalf("expected header token, got %q", got)
+	}
+}
+
+func TestNextOrder_AssignsFromQueue(t *testing.T) {
+	st := teststore.New()
+	u := newStoreUser(t, st, model.PermOrdersPick)
+	s := newTestServer(t, st)
+
+	ctx := context.Background()
+	p, err := st.Project().Add(ctx, model.Project{Name: "Розница", VidDoc: "R"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.UserProject().Add(ctx, model.UserProject{UserID: u.ID, ProjectID: p.ID}); err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, model.Location)
+	for uid, vidDoc := range map[int]string{1: "R", 2: "W"} {
+		_, err := st.Order().Add(ctx, model.Order{OrderUid: uid, VidDoc: vidDoc, FolioDate: day, OrderDate: day}, u.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := serve(s, authorized(t, s, http.MethodPost, "/api/v1/orders/next", "", u.ID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	orders, err := st.Order().ByUserID(ctx, u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 || orders[0].OrderUid != 1 || orders[0].Status != model.OrderStatusAssigned {
+		t.Fatalf("unexpected assigned orders %+v", orders)
+	}
+
+	// Повторный запрос возвращает уже назначенный заказ, заказы других проектов не выдаются
+	rec = serve(s, authorized(t, s, http.MethodPost, "/api/v1/orders/next", "", u.ID))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"order_uid":1`) {
+		t.Fatalf("expected assigned order again, got %d: %s", rec.Code, rec.Body)
+	}
+	if _, err := st.Order().Transition(ctx, model.OrderTransition{OrderUID: 1, Status: model.OrderStatusPicking}); err != nil {
+		t.Fatal(err)
+	}
+	rec = serve(s, authorized(t, s, http.MethodPost, "/api/v1/orders/next", "", u.ID))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestGetOrdersByDateRange_Dates(t *testing.T) {
+	st := teststore.New()
+	u := newStoreUser(t, st, model.PermOrdersView)
+	s := newTestServer(t, st)
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"dt_start":"01.03.2024","dt_finish":"31.03.2024"}`, http.StatusOK},
+		{`{"dt_start":"2024-03-01T00:00:00+03:00","dt_finish":"2024-03-31T23:59:59+03:00"}`, http.StatusOK},
+		{`{"dt_start":"вчера","dt_finish":"31.03.2024"}`, http.StatusBadRequest},
+		{`{"dt_start":"01.03.2024","dt_finish":"2024/03/31"}`, http.StatusBadRequest},
+		{`{"dt_start":"31.03.2024","dt_finish":"01.03.2024"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := serve(s, authorized(t, s, http.MethodGet, "/api/v1/orders/daterange/", tt.body, u.ID))
+		if rec.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d: %s", tt.body, tt.code, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestUpdateVidDocSettings(t *testing.T) {
+	st := teststore.New()
+	u := newStoreUser(t, st, model.PermOrdersManage, model.PermPayrollManage)
+	s := newTestServer(t, st)
+
+	tests := []struct {
+		path    string
+		body    string
+		code    int
+		message string
+	}{
+		{"/api/v1/sla/rules", `[{"vid_doc":"A","assemble_minutes":60},{"vid_doc":"A"}]`, http.StatusBadRequest, "указано дважды"},
+		{"/api/v1/sla/rules", `[{"vid_doc":"A","at_risk_minutes":-1}]`, http.StatusBadRequest, "отрицательными"},
+		{"/api/v1/sla/rules", `[{"ID":7,"vid_doc":"A","assemble_minutes":60},{"ID":7,"vid_doc":""}]`, http.StatusOK, `"vid_doc":"A"`},
+		{"/api/v1/payroll/tariffs", `[{"vid_doc":"B"},{"vid_doc":"B","per_line":1}]`, http.StatusBadRequest, "указан дважды"},
+		{"/api/v1/payroll/tariffs", `[{"vid_doc":"B","per_1000_sum":-0.5}]`, http.StatusBadRequest, "отрицательными"},
+		{"/api/v1/payroll/tariffs", `[{"ID":3,"vid_doc":"B","per_order":2},{"ID":3,"vid_doc":"C"}]`, http.StatusOK, `"vid_doc":"C"`},
+	}
+	for _, tt := range tests {
+		rec := serve(s, authorized(t, s, http.MethodPut, tt.path, tt.body, u.ID))
+		if rec.Code != tt.code || !strings.Contains(rec.Body.String(), tt.message) {
+			t.Errorf("%s %s: expected %d with %q, got %d: %s", tt.path, tt.body, tt.code, tt.message, rec.Code, rec.Body.String())
+		}
+	}
+
+	rules, err := st.SLA().Rules(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tariffs, err := st.Payroll().Tariffs(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || rules[0].ID == rules[1].ID || len(tariffs) != 2 || tariffs[0].ID == tariffs[1].ID {
+		t.Fatalf("expected rows with new ids, got %+v %+v", rules, tariffs)
+	}
+}
+
+func TestGetOrdersSLA_Paginates(t *testing.T) {
+	st := teststore.New()
+	u := newStoreUser(t, st, model.PermReportsView)
+	s := newTestServer(t, st)
+
+	ctx := context.Background()
+	if _, err := st.SLA().SetRules(ctx, []model.SLARule{{AssembleMinutes: 10}, {VidDoc: "B", AssembleMinutes: 1000}}); err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, model.Location)
+	for uid, vidDoc := range map[int]string{1: "A", 2: "B", 3: "A", 4: "A"} {
+		if _, err := st.Order().Add(ctx, model.Order{OrderUid: uid, FolioDate: day, OrderDate: day, VidDoc: vidDoc}, u.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := st.Order().Transition(ctx, model.OrderTransition{OrderUID: 3, Status: model.OrderStatusCancelled}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(query string) slaPage {
+		t.Helper()
+		rec := serve(s, authorized(t, s, http.MethodGet, "/api/v1/orders/sla?"+query, "", u.ID))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+		}
+		if strings.Contains(rec.Body.String(), `"total"`) {
+			t.Fatalf("sla page reports total %s", rec.Body.String())
+		}
+		var page slaPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		return page
+	}
+
+	// заказ 2 в норме, а 3 отменен: страница набирается из следующих совпадений
+	page := get("limit=2")
+	if len(page.Items) != 2 || page.Items[0].OrderUid != 1 || page.Items[1].OrderUid != 4 ||
+		page.Items[0].SLA.State != model.SLAAtRisk || page.NextCursor != "" {
+		t.Fatalf("unexpected sla page %+v", page)
+	}
+
+	page = get("limit=1")
+	if len(page.Items) != 1 || page.Items[0].OrderUid != 1 || page.NextCursor == "" {
+		t.Fatalf("unexpected first sla page %+v", page)
+	}
+	page = get("limit=1&cursor=" + page.NextCursor)
+	if len(page.Items) != 1 || page.Items[0].OrderUid != 4 || page.NextCursor != "" {
+		t.Fatalf("unexpected second sla page %+v", page)
+	}
+
+	for _, query := range []string{"limit=5000", "page=2"} {
+		rec := serve(s, authorized(t, s, http.MethodGet, "/api/v1/orders/sla?"+query, "", u.ID))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected %d, got %d", query, http.StatusBadRequest, rec.Code)
+		}
+	}
+}
+
+func TestGetUsers_HidesSecrets(t *testing.T) {
+	st := teststore.New()
+	u := newStoreUser(t, st, model.PermUsersView)
+	s := newTestServer(t, st)
+	if err := st.User().UpdateToken(context.Background(), u.ID, "access-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/api/v1/users", "/api/v1/users?page=1"} {
+		rec := serve(s, authorized(t, s, http.MethodGet, path, "", u.ID))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected response %d %s", path, rec.Code, rec.Body.String())
+		}
+		body := rec.Body.String()
+		if !strings.Contains(body, u.Email) || strings.Contains(body, `"password"`) || strings.Contains(body, `"token"`) {
+			t.Fatalf("%s: user list exposes secrets: %s", path, body)
+		}
+	}
+}
+
+func TestGetProductivity_ByTeamAtAssembly(t *testing.T) {
+	st := teststore.New()
+	u := newStoreUser(t, st, model.PermReportsView)
+	s := newTestServer(t, st)
+
+	ctx := context.Background()
+	e, err := st.Employee().Add(ctx, model.Employee{Code: "E1", Name: "Петр"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var teams []model.Team
+	for _, name := range []string{"Смена 1", "Смена 2"} {
+		team, err := st.Team().Add(ctx, model.Team{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		teams = append(teams, team)
+	}
+	// сотрудник перешел во вторую смену после сборки заказа первой сменой
+	if _, err := st.EmployeeTeam().Add(ctx, model.EmployeeTeam{TeamID: teams[1].ID, EmployeeID: e.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// накладная выставлена в феврале, заказ собран в марте
+	folio := time.Date(2024, time.February, 28, 0, 0, 0, 0, model.Location)
+	start := time.Date(2024, time.March, 4, 10, 0, 0, 0, model.Location)
+	if _, err := st.Order().Add(ctx, model.Order{OrderUid: 1, FolioDate: folio, OrderDate: folio}, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = st.Order().Transition(ctx, model.OrderTransition{OrderUID: 1, Status: model.OrderStatusAssigned,
+		UserID: u.ID, EmployeeID: e.ID, TeamID: teams[0].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []model.OrderWork{{Action: model.WorkStart, At: start}, {Action: model.WorkFinish, At: start.Add(time.Hour)}} {
+		w.OrderUID, w.UserID = 1, u.ID
+		if _, err := st.Order().Work(ctx, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := serve(s, authorized(t, s, http.MethodGet, "/api/v1/reports/productivity?group_by=team&start_dt=2024-03-01&finish_dt=2024-03-31", "", u.ID))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+	var report struct {
+		Rows []model.ProductivityRow `json:"rows"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Rows) != 1 || report.Rows[0].ID != teams[0].ID || report.Rows[0].Orders != 1 {
+		t.Fatalf("expected order of the first team assembled in march, got %+v", report.Rows)
+	}
+}
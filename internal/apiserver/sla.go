@@ -0,0 +1,165 @@
+package apiserver
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setSLA вычисляет состояние норматива сборки для заказов ответа.
+func (s *server) setSLA(ctx context.Context, orders []model.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	rules, err := s.store.SLA().Rules(ctx)
+	if err != nil {
+		return err
+	}
+	assembled, err := s.store.OrderEvent().AssembledAt(ctx, model.AssembledUIDs(orders))
+	if err != nil {
+		return err
+	}
+	model.NewSLARules(rules).Apply(orders, assembled, time.Now())
+	return nil
+}
+
+// setHitsSLA вычисляет состояние норматива для найденных заказов.
+func (s *server) setHitsSLA(ctx context.Context, hits []model.OrderSearchHit) error {
+	if len(hits) == 0 {
+		return nil
+	}
+	orders := make([]model.Order, len(hits))
+	for i := range hits {
+		orders[i] = hits[i].Order
+	}
+	if err := s.setSLA(ctx, orders); err != nil {
+		return err
+	}
+	for i := range hits {
+		hits[i].Order.SLA = orders[i].SLA
+	}
+	return nil
+}
+
+// GetOrdersSLA возвращает страницу заказов под угрозой срыва норматива
+// и просроченных. Заказы перебираются в порядке общих параметров списка
+// заказов, без фильтра status - из несобранных, пока не наберется limit
+// совпадений; внутри страницы ближайший срок - первым. Следующая страница
+// запрашивается по next_cursor, номер страницы и общее число совпадений
+// не поддерживаются: состояние норматива вычисляется при переборе.
+// Параметр state сужает выборку до перечисленных через запятую состояний.
+func (s *server) GetOrdersSLA(ctx *gin.Context) {
+	states := []model.SLAState{model.SLAAtRisk, model.SLABreached}
+	if raw := ctx.Query("state"); raw != "" {
+		states = states[:0]
+		for _, name := range strings.Split(raw, ",") {
+			state, err := model.ParseSLAState(strings.TrimSpace(name))
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность state",
+					"error": err.Error()})
+				return
+			}
+			states = append(states, state)
+		}
+	}
+
+	q, ok := listQuery(ctx, model.OrderListSpec)
+	if !ok {
+		return
+	}
+	if q.Page > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Список SLA листается только по next_cursor"})
+		return
+	}
+	if _, ok := q.Filters["status"]; !ok {
+		q.Filters["status"] = openStatusFilter
+	}
+
+	items, next, err := s.scanSLA(ctx.Request.Context(), q, states)
+	if err != nil {
+		listError(ctx, "Ошибка получения заказов", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, slaPage{Items: model.SLAOrders(items, states...), Limit: q.Limit, NextCursor: next})
+}
+
+// slaPage - страница списка SLA: конверт списка без общего числа совпадений.
+type slaPage struct {
+	Items      []model.Order `json:"items"`
+	Limit      int           `json:"limit"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// scanSLA перебирает заказы списка q, пока не наберет q.Limit заказов
+// в состояниях states. next - курсор сразу за последним отобранным заказом,
+// пустой, если список исчерпан.
+func (s *server) scanSLA(ctx context.Context, q model.ListQuery, states []model.SLAState) (items []model.Order, next string, err error) {
+	items = make([]model.Order, 0, q.Limit)
+	batch := q
+	batch.Limit = max(q.Limit, model.DefaultListLimit)
+	for {
+		page, err := s.store.Order().List(ctx, batch)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := s.setSLA(ctx, page.Items); err != nil {
+			return nil, "", err
+		}
+		for i, o := range page.Items {
+			if o.SLA == nil || !slices.Contains(states, o.SLA.State) {
+				continue
+			}
+			items = append(items, o)
+			if len(items) < q.Limit {
+				continue
+			}
+			if i == len(page.Items)-1 {
+				return items, page.NextCursor, nil
+			}
+			// Курсор за отобранным заказом дает тот же список, обрезанный на нем
+			cut := batch
+			cut.Limit = i + 1
+			page, err := s.store.Order().List(ctx, cut)
+			return items, page.NextCursor, err
+		}
+		if page.NextCursor == "" {
+			return items, "", nil
+		}
+		batch.Cursor = page.NextCursor
+	}
+}
+
+// openStatusFilter - фильтр списка по статусам несобранных заказов.
+var openStatusFilter = strings.Join([]string{model.OrderStatusNew.String(), model.OrderStatusAssigned.String(),
+	model.OrderStatusPicking.String()}, ",")
+
+func (s *server) GetSLARules(ctx *gin.Context) {
+	rules, err := s.store.SLA().Rules(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка получения правил SLA",
+			"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, rules)
+}
+
+func (s *server) UpdateSLARules(ctx *gin.Context) {
+	req, ok := bindVidDocSettings[model.SLARule](ctx, "Правило для вида документа указано дважды",
+		"Нормативы не могут быть отрицательными")
+	if !ok {
+		return
+	}
+
+	rules, err := s.store.SLA().SetRules(ctx.Request.Context(), req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "Ошибка сохранения правил SLA",
+			"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, rules)
+}
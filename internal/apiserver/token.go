@@ -0,0 +1,220 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"eastwh/internal/model"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minSecretLength = 32
+
+// sampleSecret - секрет из прежнего примера конфигурации. Он опубликован
+// в репозитории, поэтому подписывать им токены нельзя.
+const sampleSecret = "dev-only-secret-replace-before-deploying-eastwh"
+
+var (
+	errTokenReused  = errors.New("refresh token reuse detected")
+	errTokenExpired = errors.New("refresh token expired")
+)
+
+type accessClaims struct {
+	UserID    uint   `json:"userID"`
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+type tokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+type tokenIssuer struct {
+	keyID      string
+	keys       map[string][]byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func newTokenIssuer(c JWTConfig) (*tokenIssuer, error) {
+	if c.KeyID == "" {
+		return nil, errors.New("jwt: key_id is required")
+	}
+	if c.Secret == "" {
+		return nil, fmt.Errorf("jwt: secret is required, set %s", JWTSecretEnv)
+	}
+	if len(c.Secret) < minSecretLength {
+		return nil, fmt.Errorf("jwt: secret must be at least %d characters", minSecretLength)
+	}
+	if c.Secret == sampleSecret {
+		return nil, fmt.Errorf("jwt: sample secret must be replaced, set %s", JWTSecretEnv)
+	}
+	if c.AccessTokenTTL <= 0 || c.RefreshTokenTTL <= 0 {
+		return nil, errors.New("jwt: token ttl must be positive")
+	}
+
+	keys := map[string][]byte{c.KeyID: []byte(c.Secret)}
+	for kid, secret := range c.PreviousKeys {
+		if secret == sampleSecret {
+			return nil, fmt.Errorf("jwt: previous key %q is the sample secret", kid)
+		}
+		if kid == c.KeyID {
+			return nil, fmt.Errorf("jwt: previous key %q duplicates current key_id", kid)
+		}
+		if len(secret) < minSecretLength {
+			return nil, fmt.Errorf("jwt: previous key %q must be at least %d characters", kid, minSecretLength)
+		}
+		keys[kid] = []byte(secret)
+	}
+
+	return &tokenIssuer{
+		keyID:      c.KeyID,
+		keys:       keys,
+		accessTTL:  c.AccessTokenTTL,
+		refreshTTL: c.RefreshTokenTTL,
+	}, nil
+}
+
+func (t *tokenIssuer) signAccess(userID uint, sessionID string, now time.Time) (string, time.Time, error) {
+	expiresAt := now.Add(t.accessTTL)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims{
+		UserID:    userID,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	token.Header["kid"] = t.keyID
+
+	signed, err := token.SignedString(t.keys[t.keyID])
+	return signed, expiresAt, err
+}
+
+func (t *tokenIssuer) parseAccess(tokenStr string) (*accessClaims, error) {
+	var claims accessClaims
+	_, err := jwt.ParseWithClaims(tokenStr, &claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := t.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.UserID == 0 || claims.SessionID == "" {
+		return nil, errors.New("token claims are incomplete")
+	}
+	return &claims, nil
+}
+
+// newRefreshToken возвращает токен для клиента и хеш для хранения в БД.
+func newRefreshToken() (raw, hash string, err error) {
+	raw, err = randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	return raw, hashRefreshToken(raw), nil
+}
+
+func hashRefreshToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// issueTokens выдает пару токенов в рамках сессии familyID.
+func (s *server) issueTokens(ctx context.Context, user model.User, familyID string) (tokenPair, error) {
+	now := time.Now()
+
+	access, accessExpiresAt, err := s.tokens.signAccess(user.ID, familyID, now)
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	raw, hash, err := newRefreshToken()
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	rt, err := s.store.RefreshToken().Add(ctx, model.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(s.tokens.refreshTTL),
+	})
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	return tokenPair{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     raw,
+		RefreshExpiresAt: rt.ExpiresAt,
+	}, nil
+}
+
+// rotateRefreshToken погашает предъявленный refresh-токен и выдает новую пару в той же сессии.
+// Повторное предъявление уже погашенного токена считается кражей и отзывает всю сессию.
+func (s *server) rotateRefreshToken(ctx context.Context, raw string) (model.User, tokenPair, error) {
+	rt, err := s.store.RefreshToken().ByHash(ctx, hashRefreshToken(raw))
+	if err != nil {
+		return model.User{}, tokenPair{}, err
+	}
+
+	if rt.UsedAt != nil || rt.RevokedAt != nil {
+		if err := s.store.RefreshToken().RevokeFamily(ctx, rt.FamilyID); err != nil {
+			return model.User{}, tokenPair{}, err
+		}
+		return model.User{}, tokenPair{}, errTokenReused
+	}
+
+	if time.Now().After(rt.ExpiresAt) {
+		return model.User{}, tokenPair{}, errTokenExpired
+	}
+
+	ok, err := s.store.RefreshToken().Use(ctx, rt.ID)
+	if err != nil {
+		return model.User{}, tokenPair{}, err
+	}
+	if !ok {
+		if err := s.store.RefreshToken().RevokeFamily(ctx, rt.FamilyID); err != nil {
+			return model.User{}, tokenPair{}, err
+		}
+		return model.User{}, tokenPair{}, errTokenReused
+	}
+
+	user, err := s.store.User().ByID(ctx, rt.UserID)
+	if err != nil {
+		return model.User{}, tokenPair{}, err
+	}
+	if user.Blocked {
+		if err := s.store.RefreshToken().RevokeFamily(ctx, rt.FamilyID); err != nil {
+			return model.User{}, tokenPair{}, err
+		}
+		return model.User{}, tokenPair{}, errAccessDenied
+	}
+
+	pair, err := s.issueTokens(ctx, user, rt.FamilyID)
+	return user, pair, err
+}
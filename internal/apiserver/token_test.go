@@ -0,0 +1,240 @@
+package apiserver
+
+import (
+	"eastwh/internal/model"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func login(t *testing.T, s *server) tokenPair {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login",
+		strings.NewReader(`{"email":"user@eastwh.local","password":"secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := serve(s, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Tokens tokenPair `json:"tokens"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Tokens
+}
+
+func refresh(s *server, refreshToken string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/token/refresh",
+		strings.NewReader(`{"refresh_token":"`+refreshToken+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	return serve(s, req)
+}
+
+func withAccess(s *server, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/permissions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return serve(s, req)
+}
+
+func TestNewTokenIssuer_Validation(t *testing.T) {
+	config := testConfig()
+	config.JWT.Secret = "short"
+	if _, err := newTokenIssuer(config.JWT); err == nil {
+		t.Fatal("expected error for short secret")
+	}
+
+	config = testConfig()
+	config.JWT.Secret = ""
+	if _, err := newTokenIssuer(config.JWT); err == nil {
+		t.Fatal("expected error for missing secret")
+	}
+
+	config = testConfig()
+	config.JWT.Secret = sampleSecret
+	if _, err := newTokenIssuer(config.JWT); err == nil {
+		t.Fatal("expected error for sample secret")
+	}
+
+	config = testConfig()
+	config.JWT.PreviousKeys = map[string]string{config.JWT.KeyID: config.JWT.Secret}
+	if _, err := newTokenIssuer(config.JWT); err == nil {
+		t.Fatal("expected error for duplicated key id")
+	}
+}
+
+func TestLogin_IssuesShortLivedAccessToken(t *testing.T) {
+	s := newTestServer(t, newFakeStore(testUser(1)))
+
+	pair := login(t, s)
+	if pair.AccessToken == "" || pair.RefreshToken == "" {
+		t.Fatalf("expected both tokens, got %+v", pair)
+	}
+	if ttl := time.Until(pair.AccessExpiresAt); ttl > 15*time.Minute || ttl <= 0 {
+		t.Fatalf("unexpected access token ttl %s", ttl)
+	}
+
+	if rec := withAccess(s, pair.AccessToken); rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestRefreshTokens_Rotation(t *testing.T) {
+	st := newFakeStore(testUser(1))
+	s := newTestServer(t, st)
+	first := login(t, s)
+
+	rec := refresh(s, first.RefreshToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh: expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Tokens tokenPair `json:"tokens"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	second := resp.Tokens
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	for _, rt := range st.refreshTokens {
+		if rt.TokenHash == first.RefreshToken || rt.TokenHash == second.RefreshToken {
+			t.Fatal("refresh token is stored in plain text")
+		}
+	}
+
+	if rec := withAccess(s, second.AccessToken); rec.Code != http.StatusOK {
+		t.Fatalf("new access token: expected %d, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestRefreshTokens_ReuseRevokesFamily(t *testing.T) {
+	s := newTestServer(t, newFakeStore(testUser(1)))
+	first := login(t, s)
+	other := login(t, s)
+
+	rec := refresh(s, first.RefreshToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("refresh: expected %d, got %d", http.StatusOK, rec.Code)
+	}
+	var resp struct {
+		Tokens tokenPair `json:"tokens"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	// Повторное предъявление погашенного токена
+	if rec := refresh(s, first.RefreshToken); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("reuse: expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	if rec := refresh(s, resp.Tokens.RefreshToken); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("rotated token after reuse: expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+	if rec := withAccess(s, resp.Tokens.AccessToken); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("access token after reuse: expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+
+	// Другая сессия пользователя не затронута
+	if rec := withAccess(s, other.AccessToken); rec.Code != http.StatusOK {
+		t.Fatalf("other session: expected %d, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestRefreshTokens_Unknown(t *testing.T) {
+	s := newTestServer(t, newFakeStore(testUser(1)))
+
+	if rec := refresh(s, "unknown"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
+
+func TestLogout_RevokesSession(t *testing.T) {
+	s := newTestServer(t, newFakeStore(testUser(1)))
+	pair := login(t, s)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/user/logout/", nil)
+	req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+	if rec := serve(s, req); rec.Code != http.StatusAccepted {
+		t.Fatalf("logout: expected %d, got %d: %s", http.StatusAccepted, rec.Code, rec.Body.String())
+	}
+
+	if rec := withAccess(s, pair.AccessToken); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("access after logout: expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+	if rec := refresh(s, pair.RefreshToken); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("refresh after logout: expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
+
+func TestCredentialsChange_RevokesSessions(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"password change", http.MethodPost, "/api/v1/user/update/password/?id=1", `{"password":"new-secret"}`},
+		{"password restore", http.MethodPost, "/api/v1/users/password/restore?email=user@eastwh.local", ""},
+		{"block", http.MethodPost, "/api/v1/user/block/?id=1", `{"blocked":true}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newFakeStore(testUser(1))
+			st.grant(1, 1, model.PermUsersManage, model.PermUsersBlock)
+			s := newTestServer(t, st)
+			pair := login(t, s)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+signedToken(t, s, 1, time.Hour))
+			req.Header.Set("Content-Type", "application/json")
+			if rec := serve(s, req); rec.Code != http.StatusOK {
+				t.Fatalf("expected %d, got %d: %s", http.StatusOK, rec.Code, rec.Body.String())
+			}
+
+			if rec := withAccess(s, pair.AccessToken); rec.Code != http.StatusUnauthorized && rec.Code != http.StatusForbidden {
+				t.Fatalf("access after change: expected rejection, got %d", rec.Code)
+			}
+			if rec := refresh(s, pair.RefreshToken); rec.Code != http.StatusUnauthorized && rec.Code != http.StatusForbidden {
+				t.Fatalf("refresh after change: expected rejection, got %d", rec.Code)
+			}
+		})
+	}
+}
+
+func TestAccessToken_PreviousKeyAccepted(t *testing.T) {
+	st := newFakeStore(testUser(1))
+	old := newTestServer(t, st)
+	token := signedToken(t, old, 1, time.Hour)
+
+	config := testConfig()
+	config.JWT.PreviousKeys = map[string]string{config.JWT.KeyID: config.JWT.Secret}
+	config.JWT.KeyID = "next"
+	config.JWT.Secret = strings.Repeat("n", minSecretLength)
+	rotated, err := newServer(st, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rec := withAccess(rotated, token); rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestConfig_LoadEnvSecret(t *testing.T) {
+	t.Setenv(JWTSecretEnv, strings.Repeat("e", minSecretLength))
+	config := NewConfig()
+	config.LoadEnv()
+	if config.JWT.Secret != strings.Repeat("e", minSecretLength) {
+		t.Fatalf("secret was not read from %s", JWTSecretEnv)
+	}
+}
@@ -0,0 +1,35 @@
+package apiserver
+
+import (
+	"eastwh/internal/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindVidDocSettings разбирает таблицу настроек по видам документов (тарифы,
+// правила SLA) для замены целиком: каждый вид документа указывается один раз,
+// значения не могут быть отрицательными. duplicate и negative - сообщения
+// об этих ошибках. При ошибке ответ 400 уже отправлен.
+func bindVidDocSettings[T model.VidDocSetting](ctx *gin.Context, duplicate, negative string) ([]T, bool) {
+	var req []T
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"message": "Проверьте корректность передаваемых данных",
+			"error": err.Error()})
+		return nil, false
+	}
+
+	seen := make(map[string]bool, len(req))
+	for _, r := range req {
+		if seen[r.Scope()] {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": duplicate, "vid_doc": r.Scope()})
+			return nil, false
+		}
+		if r.Negative() {
+			ctx.JSON(http.StatusBadRequest, gin.H{"message": negative, "vid_doc": r.Scope()})
+			return nil, false
+		}
+		seen[r.Scope()] = true
+	}
+	return req, true
+}
@@ -0,0 +1,154 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+)
+
+// CSVOptions - настройки CSV. По умолчанию - разделитель ";" и UTF-8;
+// для загрузки в 1С указывается кодировка Windows-1251.
+type CSVOptions struct {
+	Delimiter   rune
+	Windows1251 bool
+}
+
+func (o CSVOptions) ContentType() string {
+	if o.Windows1251 {
+		return csvContentType + "; charset=windows-1251"
+	}
+	return csvContentType + "; charset=utf-8"
+}
+
+// ParseCSVOptions разбирает параметры запроса delimiter и encoding.
+func ParseCSVOptions(delimiter, encoding string) (CSVOptions, error) {
+	opts := CSVOptions{Delimiter: ';'}
+
+	switch delimiter {
+	case "":
+	case "tab", `\t`:
+		opts.Delimiter = '\t'
+	default:
+		r := []rune(delimiter)
+		if len(r) != 1 || r[0] == '"' || r[0] == '\r' || r[0] == '\n' {
+			return opts, fmt.Errorf("invalid csv delimiter %q", delimiter)
+		}
+		opts.Delimiter = r[0]
+	}
+
+	switch encoding {
+	case "", "utf-8", "utf8":
+	case "windows-1251", "cp1251":
+		opts.Windows1251 = true
+	default:
+		return opts, fmt.Errorf("unsupported csv encoding %q", encoding)
+	}
+	return opts, nil
+}
+
+// WriteCSV выводит отчет построчно. Символы, отсутствующие в Windows-1251,
+// заменяются на "?".
+func WriteCSV(w io.Writer, t Table, opts CSVOptions) error {
+	if opts.Windows1251 {
+		// Кодировщик сам заменил бы такие символы на управляющий 0x1A
+		unsupported := runes.Map(func(r rune) rune {
+			if _, ok := charmap.Windows1251.EncodeRune(r); !ok {
+				return '?'
+			}
+			return r
+		})
+		tw := transform.NewWriter(w, transform.Chain(unsupported, charmap.Windows1251.NewEncoder()))
+		if err := writeCSV(tw, t, opts); err != nil {
+			return err
+		}
+		return tw.Close()
+	}
+	return writeCSV(w, t, opts)
+}
+
+func writeCSV(w io.Writer, t Table, opts CSVOptions) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.Delimiter
+
+	record := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		record[i] = c.Header
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+
+	sums := make([]float64, len(t.Columns))
+	err := t.each(func(row []any) error {
+		t.addTotals(sums, row)
+		for i, c := range t.Columns {
+			record[i] = csvValue(c, row[i], opts.Delimiter)
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	if t.hasTotals() {
+		for i, c := range t.Columns {
+			record[i] = ""
+			if c.Total {
+				record[i] = csvValue(c, sums[i], opts.Delimiter)
+			}
+		}
+		record[0] = "Итого"
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvValue форматирует значение; при разделителе ";" дробная часть отделяется
+// запятой, как ожидают Excel и 1С в русской локали.
+func csvValue(c Column, v any, delimiter rune) string {
+	switch c.Type {
+	case Number:
+		if f, ok := toFloat(v); ok {
+			s := strconv.FormatFloat(f, 'f', 2, 64)
+			if delimiter != ',' {
+				s = replaceDot(s)
+			}
+			return s
+		}
+	case Integer:
+		if f, ok := toFloat(v); ok {
+			return strconv.FormatInt(int64(f), 10)
+		}
+	case Date:
+		if t, ok := toTime(v); ok {
+			return t.Format("02.01.2006 15:04:05")
+		}
+		if s, ok := v.(string); ok {
+			return s
+		}
+		return ""
+	}
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func replaceDot(s string) string {
+	b := []byte(s)
+	for i := range b {
+		if b[i] == '.' {
+			b[i] = ','
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,152 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testTable() Table {
+	finish := time.Date(2024, 3, 1, 14, 5, 0, 0, time.UTC)
+	return Table{
+		Name: "Собранные заказы",
+		Columns: []Column{
+			{Header: "Клиент", Type: Text},
+			{Header: "Позиций", Type: Integer, Total: true},
+			{Header: "Вес", Type: Number, Total: true},
+			{Header: "Собран", Type: Date},
+		},
+		Rows: [][]any{
+			{"ООО «Восток» ✓", 3, 1234.5, finish},
+			{`ИП "Петров"; склад`, uint(2), 0.25, (*time.Time)(nil)},
+		},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CSVOptions
+		want string
+	}{
+		{
+			"semicolon uses decimal comma",
+			CSVOptions{Delimiter: ';'},
+			"Клиент;Позиций;Вес;Собран\n" +
+				"ООО «Восток» ✓;3;1234,50;01.03.2024 14:05:00\n" +
+				"\"ИП \"\"Петров\"\"; склад\";2;0,25;\n" +
+				"Итого;5;1234,75;\n",
+		},
+		{
+			"comma keeps decimal point",
+			CSVOptions{Delimiter: ','},
+			"Клиент,Позиций,Вес,Собран\n" +
+				"ООО «Восток» ✓,3,1234.50,01.03.2024 14:05:00\n" +
+				"\"ИП \"\"Петров\"\"; склад\",2,0.25,\n" +
+				"Итого,5,1234.75,\n",
+		},
+		{
+			"tab uses decimal comma",
+			CSVOptions{Delimiter: '\t'},
+			"Клиент\tПозиций\tВес\tСобран\n" +
+				"ООО «Восток» ✓\t3\t1234,50\t01.03.2024 14:05:00\n" +
+				"\"ИП \"\"Петров\"\"; склад\"\t2\t0,25\t\n" +
+				"Итого\t5\t1234,75\t\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteCSV(&buf, testTable(), tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Fatalf("got\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCSV_Windows1251(t *testing.T) {
+	table := Table{
+		Columns: []Column{{Header: "Клиент"}, {Header: "Сумма", Type: Number, Total: true}},
+		Rows:    [][]any{{"Ёлка ✓", 1.5}},
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, table, CSVOptions{Delimiter: ';', Windows1251: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Символ, которого нет в Windows-1251, заменяется на "?"
+	want := []byte("\xCA\xEB\xE8\xE5\xED\xF2;\xD1\xF3\xEC\xEC\xE0\n" +
+		"\xA8\xEB\xEA\xE0 ?;1,50\n" +
+		"\xC8\xF2\xEE\xE3\xEE;1,50\n")
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("got % X\nwant % X", buf.Bytes(), want)
+	}
+}
+
+func TestWriteCSV_NoTotals(t *testing.T) {
+	table := Table{Columns: []Column{{Header: "Код"}}, Rows: [][]any{{"A1"}}}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, table, CSVOptions{Delimiter: ';'}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "Итого") {
+		t.Fatalf("unexpected totals row in %q", buf.String())
+	}
+}
+
+func TestParseCSVOptions(t *testing.T) {
+	tests := []struct {
+		delimiter, encoding string
+		want                CSVOptions
+	}{
+		{"", "", CSVOptions{Delimiter: ';'}},
+		{",", "utf-8", CSVOptions{Delimiter: ','}},
+		{"tab", "cp1251", CSVOptions{Delimiter: '\t', Windows1251: true}},
+		{`\t`, "windows-1251", CSVOptions{Delimiter: '\t', Windows1251: true}},
+	}
+	for _, tt := range tests {
+		got, err := ParseCSVOptions(tt.delimiter, tt.encoding)
+		if err != nil || got != tt.want {
+			t.Fatalf("ParseCSVOptions(%q, %q) = %+v, %v", tt.delimiter, tt.encoding, got, err)
+		}
+	}
+
+	for _, invalid := range [][2]string{{`"`, ""}, {";;", ""}, {"\n", ""}, {"", "koi8-r"}} {
+		if _, err := ParseCSVOptions(invalid[0], invalid[1]); err == nil {
+			t.Fatalf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestWriteCSV_More(t *testing.T) {
+	batches := [][][]any{{{"B", 2}}, {{"C", 3}, {"D", 4}}}
+	table := Table{
+		Columns: []Column{{Header: "Код"}, {Header: "Кол-во", Type: Integer, Total: true}},
+		Rows:    [][]any{{"A", 1}},
+		More: func() ([][]any, error) {
+			if len(batches) == 0 {
+				return nil, nil
+			}
+			rows := batches[0]
+			batches = batches[1:]
+			return rows, nil
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, table, CSVOptions{Delimiter: ';'}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Код;Кол-во\nA;1\nB;2\nC;3\nD;4\nИтого;10\n"; buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+
+	table.More = func() ([][]any, error) { return nil, errors.New("connection lost") }
+	if err := WriteCSV(&bytes.Buffer{}, table, CSVOptions{Delimiter: ';'}); err == nil {
+		t.Fatal("expected error from More")
+	}
+}
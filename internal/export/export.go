@@ -0,0 +1,142 @@
+// Package export выгружает табличные отчеты в XLSX и CSV.
+package export
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatXLSX Format = "xlsx"
+	FormatCSV  Format = "csv"
+)
+
+const (
+	XLSXContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	csvContentType  = "text/csv"
+)
+
+// Negotiate выбирает формат выгрузки: параметр ?format= важнее заголовка Accept.
+// Без явного запроса выгрузки ответ остается в JSON.
+func Negotiate(query, accept string) (Format, error) {
+	switch f := Format(strings.ToLower(query)); f {
+	case FormatJSON, FormatXLSX, FormatCSV:
+		return f, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unknown export format %q", query)
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case XLSXContentType:
+			return FormatXLSX, nil
+		case csvContentType:
+			return FormatCSV, nil
+		}
+	}
+	return FormatJSON, nil
+}
+
+type ColumnType int
+
+const (
+	Text ColumnType = iota
+	Integer
+	Number
+	Date
+)
+
+// Column - колонка отчета. Для колонок с Total в итоговой строке выводится сумма.
+type Column struct {
+	Header string
+	Type   ColumnType
+	Total  bool
+}
+
+// Table - отчет для выгрузки. Значения строки соответствуют колонкам по порядку.
+type Table struct {
+	Name    string
+	Columns []Column
+	Rows    [][]any
+	// More возвращает следующую порцию строк после Rows, пустая порция - конец
+	// отчета. Так большой отчет выгружается по мере чтения из базы.
+	More func() ([][]any, error)
+}
+
+func (t Table) hasTotals() bool {
+	for _, c := range t.Columns {
+		if c.Total {
+			return true
+		}
+	}
+	return false
+}
+
+// each вызывает fn для строк Rows, затем для порций из More.
+func (t Table) each(fn func(row []any) error) error {
+	rows := t.Rows
+	for {
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		if t.More == nil {
+			return nil
+		}
+		var err error
+		if rows, err = t.More(); err != nil || len(rows) == 0 {
+			return err
+		}
+	}
+}
+
+// addTotals прибавляет значения строки к суммам колонок с Total.
+func (t Table) addTotals(sums []float64, row []any) {
+	for i, c := range t.Columns {
+		if c.Total {
+			if v, ok := toFloat(row[i]); ok {
+				sums[i] += v
+			}
+		}
+	}
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func toTime(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, !t.IsZero()
+	case *time.Time:
+		if t == nil {
+			return time.Time{}, false
+		}
+		return *t, !t.IsZero()
+	}
+	return time.Time{}, false
+}
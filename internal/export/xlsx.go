@@ -0,0 +1,253 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XLSX собирается вручную: книга из одного листа со строками inlineStr,
+// поэтому строки отчета пишутся в архив по мере формирования.
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`
+
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`
+
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<numFmts count="1"><numFmt numFmtId="164" formatCode="dd.mm.yyyy hh:mm"/></numFmts>` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="7">` +
+		`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+		`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="4" fontId="1" fillId="0" borderId="0" xfId="0" applyNumberFormat="1" applyFont="1"/>` +
+		`<xf numFmtId="1" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+		`<xf numFmtId="1" fontId="1" fillId="0" borderId="0" xfId="0" applyNumberFormat="1" applyFont="1"/>` +
+		`</cellXfs>` +
+		`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+		`</styleSheet>`
+)
+
+// Номера стилей из xlsxStyles
+const (
+	styleDefault = iota
+	styleBold
+	styleDate
+	styleNumber
+	styleNumberBold
+	styleInteger
+	styleIntegerBold
+)
+
+// WriteXLSX выводит отчет книгой Excel с заголовками, типизированными ячейками
+// и строкой итогов с формулами SUM.
+func WriteXLSX(w io.Writer, t Table) error {
+	zw := zip.NewWriter(w)
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, escape(sheetName(t.Name)))},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if err := writeSheet(f, t); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeSheet(w io.Writer, t Table) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+
+	bw.WriteString("<cols>")
+	for i, c := range t.Columns {
+		fmt.Fprintf(bw, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, columnWidth(c))
+	}
+	bw.WriteString("</cols><sheetData>")
+
+	bw.WriteString(`<row r="1">`)
+	for i, c := range t.Columns {
+		writeString(bw, cellRef(i, 1), c.Header, styleBold)
+	}
+	bw.WriteString("</row>")
+
+	r := 1
+	sums := make([]float64, len(t.Columns))
+	err := t.each(func(row []any) error {
+		r++
+		t.addTotals(sums, row)
+		fmt.Fprintf(bw, `<row r="%d">`, r)
+		for i, c := range t.Columns {
+			writeCell(bw, cellRef(i, r), c, row[i])
+		}
+		_, err := bw.WriteString("</row>")
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if t.hasTotals() {
+		r++
+		fmt.Fprintf(bw, `<row r="%d">`, r)
+		for i, c := range t.Columns {
+			ref := cellRef(i, r)
+			switch {
+			case c.Total:
+				style := styleNumberBold
+				if c.Type == Integer {
+					style = styleIntegerBold
+				}
+				formula := ""
+				if r > 2 {
+					formula = fmt.Sprintf("<f>SUM(%s:%s)</f>", cellRef(i, 2), cellRef(i, r-1))
+				}
+				fmt.Fprintf(bw, `<c r="%s" s="%d">%s<v>%s</v></c>`, ref, style, formula, formatFloat(sums[i]))
+			case i == 0:
+				writeString(bw, ref, "Итого", styleBold)
+			}
+		}
+		bw.WriteString("</row>")
+	}
+
+	bw.WriteString("</sheetData></worksheet>")
+	return bw.Flush()
+}
+
+func writeCell(w *bufio.Writer, ref string, c Column, v any) {
+	switch c.Type {
+	case Integer:
+		if f, ok := toFloat(v); ok {
+			fmt.Fprintf(w, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleInteger, formatFloat(f))
+			return
+		}
+	case Number:
+		if f, ok := toFloat(v); ok {
+			fmt.Fprintf(w, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleNumber, formatFloat(f))
+			return
+		}
+	case Date:
+		if t, ok := toTime(v); ok {
+			fmt.Fprintf(w, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleDate, formatFloat(excelDate(t)))
+			return
+		}
+		// Пустая дата (в том числе nil *time.Time) оставляет ячейку пустой
+		if s, ok := v.(string); ok && s != "" {
+			writeString(w, ref, s, styleDefault)
+		}
+		return
+	}
+
+	if v == nil {
+		return
+	}
+	if s := fmt.Sprint(v); s != "" {
+		writeString(w, ref, s, styleDefault)
+	}
+}
+
+func writeString(w *bufio.Writer, ref, s string, style int) {
+	fmt.Fprintf(w, `<c r="%s" t="inlineStr" s="%d"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escape(s))
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// cellRef возвращает адрес ячейки в нотации A1 для колонки col (с нуля) и строки row (с единицы).
+func cellRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
+
+// excelDate переводит время в серийный номер даты Excel без учета часового пояса:
+// в ячейку попадает то же время, что и в отчете.
+func excelDate(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	return wall.Sub(epoch).Hours() / 24
+}
+
+func columnWidth(c Column) int {
+	width := len([]rune(c.Header)) + 2
+	if c.Type == Date && width < 18 {
+		width = 18
+	}
+	return max(width, 10)
+}
+
+// sheetName приводит название листа к ограничениям Excel.
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "Отчет"
+	}
+	if r := []rune(name); len(r) > 31 {
+		name = string(r[:31])
+	}
+	return name
+}
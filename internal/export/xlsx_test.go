@@ -0,0 +1,157 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readXLSX возвращает содержимое частей книги по именам.
+func readXLSX(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(body)
+	}
+	return parts
+}
+
+func TestWriteXLSX(t *testing.T) {
+	table := testTable()
+	table.Name = "Заказы: март/апрель [склад]"
+	table.Rows = append(table.Rows, []any{"<b>A&B</b>", 1, 1.0, nil})
+
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	parts := readXLSX(t, buf.Bytes())
+
+	for name, body := range parts {
+		if err := xml.Unmarshal([]byte(body), new(struct{})); err != nil {
+			t.Fatalf("%s is not well-formed: %v", name, err)
+		}
+	}
+
+	if want := `<sheet name="Заказы_ март_апрель _склад_"`; !strings.Contains(parts["xl/workbook.xml"], want) {
+		t.Fatalf("sheet name is not cleaned up: %s", parts["xl/workbook.xml"])
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A2" t="inlineStr" s="0"><is><t xml:space="preserve">ООО «Восток» ✓</t></is></c>`,
+		`<c r="A3" t="inlineStr" s="0"><is><t xml:space="preserve">ИП &#34;Петров&#34;; склад</t></is></c>`,
+		`<c r="A4" t="inlineStr" s="0"><is><t xml:space="preserve">&lt;b&gt;A&amp;B&lt;/b&gt;</t></is></c>`,
+		`<c r="B2" s="5"><v>3</v></c>`,
+		`<c r="C2" s="3"><v>1234.5</v></c>`,
+		// 01.03.2024 14:05 - день 45352 с 30.12.1899
+		`<c r="D2" s="2"><v>45352.586805555555</v></c>`,
+		`<c r="A5" t="inlineStr" s="1"><is><t xml:space="preserve">Итого</t></is></c>`,
+		`<c r="B5" s="6"><f>SUM(B2:B4)</f><v>6</v></c>`,
+		`<c r="C5" s="4"><f>SUM(C2:C4)</f><v>1235.75</v></c>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet does not contain %s", want)
+		}
+	}
+	// Пустая дата не выводится
+	if strings.Contains(sheet, `r="D3"`) || strings.Contains(sheet, `r="D5"`) {
+		t.Error("unexpected cell for empty date")
+	}
+}
+
+func TestWriteXLSX_EmptyTotals(t *testing.T) {
+	table := testTable()
+	table.Rows = nil
+
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	sheet := readXLSX(t, buf.Bytes())["xl/worksheets/sheet1.xml"]
+	if want := `<c r="B2" s="6"><v>0</v></c>`; !strings.Contains(sheet, want) {
+		t.Fatalf("sheet does not contain %s", want)
+	}
+	if strings.Contains(sheet, "SUM(") {
+		t.Fatal("totals of an empty report must not have formulas")
+	}
+}
+
+func TestSheetName(t *testing.T) {
+	tests := map[string]string{
+		"":                      "Отчет",
+		`a[b]c:d*e?f/g\h`:       "a_b_c_d_e_f_g_h",
+		strings.Repeat("Я", 40): strings.Repeat("Я", 31),
+	}
+	for name, want := range tests {
+		if got := sheetName(name); got != want {
+			t.Errorf("sheetName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCellRef(t *testing.T) {
+	tests := map[int]string{0: "A1", 25: "Z1", 26: "AA1", 51: "AZ1", 52: "BA1", 701: "ZZ1", 702: "AAA1"}
+	for col, want := range tests {
+		if got := cellRef(col, 1); got != want {
+			t.Errorf("cellRef(%d, 1) = %q, want %q", col, got, want)
+		}
+	}
+}
+
+func TestExcelDate(t *testing.T) {
+	// Время ячейки совпадает с временем отчета независимо от часового пояса
+	msk := time.FixedZone("MSK", 3*60*60)
+	tests := []struct {
+		t    time.Time
+		want float64
+	}{
+		{time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC), 61},
+		{time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), 45352.5},
+		{time.Date(2024, 3, 1, 12, 0, 0, 0, msk), 45352.5},
+	}
+	for _, tt := range tests {
+		if got := excelDate(tt.t); got != tt.want {
+			t.Errorf("excelDate(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestWriteXLSX_More(t *testing.T) {
+	more := true
+	table := Table{
+		Columns: []Column{{Header: "Код"}, {Header: "Кол-во", Type: Integer, Total: true}},
+		More: func() ([][]any, error) {
+			if !more {
+				return nil, nil
+			}
+			more = false
+			return [][]any{{"A", 1}, {"B", 2}}, nil
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteXLSX(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	sheet := readXLSX(t, buf.Bytes())["xl/worksheets/sheet1.xml"]
+	if want := `<c r="B4" s="6"><f>SUM(B2:B3)</f><v>3</v></c>`; !strings.Contains(sheet, want) {
+		t.Fatalf("sheet does not contain %s", want)
+	}
+}
@@ -0,0 +1,92 @@
+package label
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// decodeCode128 переводит ширины обратно в значения символов Code128.
+func decodeCode128(t *testing.T, widths []int) []int {
+	t.Helper()
+	index := make(map[string]int, len(code128Patterns))
+	for v, p := range code128Patterns {
+		index[p] = v
+	}
+
+	var values []int
+	for len(widths) > 0 {
+		n := min(6, len(widths))
+		if len(widths) == 7 {
+			n = 7
+		}
+		var p strings.Builder
+		for _, w := range widths[:n] {
+			p.WriteString(strconv.Itoa(w))
+		}
+		v, ok := index[p.String()]
+		if !ok {
+			t.Fatalf("unknown pattern %s", p.String())
+		}
+		values = append(values, v)
+		widths = widths[n:]
+	}
+	return values
+}
+
+func TestCode128(t *testing.T) {
+	tests := []struct {
+		data string
+		want []int
+	}{
+		// Start B, P J J 1 2 3 C, контрольный символ (104+48+84+126+68+90+114+245) mod 103 = 55
+		{"PJJ123C", []int{104, 48, 42, 42, 17, 18, 19, 35, 55, 106}},
+		// Start C, пары 12 34 56: (105+12+68+168) mod 103 = 44
+		{"123456", []int{105, 12, 34, 56, 44, 106}},
+		// Нечетная последняя цифра переходит в набор B: (105+12+68+300+84) mod 103 = 54
+		{"12345", []int{105, 12, 34, 100, 21, 54, 106}},
+		// Одна цифра кодируется набором B: (104+17) mod 103 = 18
+		{"1", []int{104, 17, 18, 106}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			widths, err := Code128(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := decodeCode128(t, widths); !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+
+			// Каждый символ занимает 11 модулей, стоп-символ - 13
+			modules := 0
+			for _, w := range widths {
+				modules += w
+			}
+			if want := 11*(len(tt.want)-1) + 13; modules != want {
+				t.Fatalf("got %d modules, want %d", modules, want)
+			}
+		})
+	}
+}
+
+func TestCode128_Patterns(t *testing.T) {
+	for v, p := range code128Patterns[:code128Stop] {
+		sum := 0
+		for _, w := range p {
+			sum += int(w - '0')
+		}
+		if len(p) != 6 || sum != 11 {
+			t.Fatalf("pattern %d %q must have 6 elements of 11 modules", v, p)
+		}
+	}
+}
+
+func TestCode128_Invalid(t *testing.T) {
+	for _, data := range []string{"", "Заказ", "a\tb"} {
+		if _, err := Code128(data); err == nil {
+			t.Fatalf("expected error for %q", data)
+		}
+	}
+}
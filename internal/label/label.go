@@ -0,0 +1,79 @@
+// Package label формирует этикетки собранных заказов для печати
+// на принтерах Zebra (ZPL) и в PDF.
+package label
+
+import (
+	"eastwh/internal/model"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type Format string
+
+const (
+	FormatZPL Format = "zpl"
+	FormatPDF Format = "pdf"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatZPL:
+		return FormatZPL, nil
+	case FormatPDF:
+		return FormatPDF, nil
+	}
+	return "", fmt.Errorf("unknown label format %q", s)
+}
+
+func (f Format) ContentType() string {
+	if f == FormatPDF {
+		return "application/pdf"
+	}
+	return "application/zpl; charset=utf-8"
+}
+
+// Label - данные одной этикетки. Code кодируется в Code128 и QR; по нему
+// заказ находится сканированием (номер накладной).
+type Label struct {
+	OrderUID      int
+	FolioNum      int
+	FolioDate     string
+	ClientName    string
+	ClientAddress string
+	Driver        string
+	Code          string
+}
+
+func New(o model.Order) Label {
+	return Label{
+		OrderUID:      o.OrderUid,
+		FolioNum:      o.FolioNum,
+		FolioDate:     model.Date(o.FolioDate),
+		ClientName:    o.ClientName,
+		ClientAddress: o.ClientAddress,
+		Driver:        o.Driver,
+		Code:          strconv.Itoa(o.FolioNum),
+	}
+}
+
+// Размер этикетки 100x150 мм
+const (
+	widthMM  = 100
+	heightMM = 150
+)
+
+// Render выводит этикетки в заданном формате: по одной этикетке на страницу.
+func Render(w io.Writer, format Format, labels []Label) error {
+	if len(labels) == 0 {
+		return fmt.Errorf("no labels to render")
+	}
+
+	switch format {
+	case FormatZPL:
+		return renderZPL(w, labels)
+	case FormatPDF:
+		return renderPDF(w, labels)
+	}
+	return fmt.Errorf("unknown label format %q", format)
+}
@@ -0,0 +1,351 @@
+package label
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// PDF формируется без сторонних библиотек. Для кириллицы встраивается шрифт
+// Go Regular (CIDFontType2 с кодировкой Identity-H: коды символов - номера глифов).
+
+const ptPerMM = 72 / 25.4
+
+var (
+	pdfFontOnce sync.Once
+	pdfFontData *sfnt.Font
+	pdfFontErr  error
+)
+
+func loadPDFFont() (*sfnt.Font, error) {
+	pdfFontOnce.Do(func() {
+		pdfFontData, pdfFontErr = sfnt.Parse(goregular.TTF)
+	})
+	return pdfFontData, pdfFontErr
+}
+
+// pdfFont отслеживает использованные глифы для таблиц ширин и ToUnicode.
+type pdfFont struct {
+	font  *sfnt.Font
+	buf   sfnt.Buffer
+	upm   fixed.Int26_6
+	width map[sfnt.GlyphIndex]int
+	runes map[sfnt.GlyphIndex]rune
+}
+
+func newPDFFont() (*pdfFont, error) {
+	f, err := loadPDFFont()
+	if err != nil {
+		return nil, err
+	}
+	return &pdfFont{
+		font:  f,
+		upm:   fixed.Int26_6(f.UnitsPerEm()) << 6,
+		width: make(map[sfnt.GlyphIndex]int),
+		runes: make(map[sfnt.GlyphIndex]rune),
+	}, nil
+}
+
+// glyph возвращает номер глифа и его ширину в тысячных долях кегля.
+func (f *pdfFont) glyph(r rune) (sfnt.GlyphIndex, int) {
+	gi, err := f.font.GlyphIndex(&f.buf, r)
+	if err != nil || gi == 0 {
+		gi, _ = f.font.GlyphIndex(&f.buf, '?')
+		r = '?'
+	}
+	if w, ok := f.width[gi]; ok {
+		return gi, w
+	}
+
+	adv, err := f.font.GlyphAdvance(&f.buf, gi, f.upm, font.HintingNone)
+	w := 0
+	if err == nil {
+		w = int(adv) * 1000 / int(f.upm)
+	}
+	f.width[gi] = w
+	f.runes[gi] = r
+	return gi, w
+}
+
+func (f *pdfFont) textWidth(s string, size float64) float64 {
+	total := 0
+	for _, r := range s {
+		_, w := f.glyph(r)
+		total += w
+	}
+	return float64(total) * size / 1000
+}
+
+func (f *pdfFont) encode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		gi, _ := f.glyph(r)
+		fmt.Fprintf(&b, "%04X", uint16(gi))
+	}
+	return b.String()
+}
+
+// wrap разбивает текст на строки по ширине; не поместившееся в maxLines отбрасывается.
+func (f *pdfFont) wrap(s string, size, maxWidth float64, maxLines int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line == "" || f.textWidth(candidate, size) <= maxWidth {
+			line = candidate
+			continue
+		}
+		lines = append(lines, line)
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+	}
+	return lines
+}
+
+// pdfPage - содержимое страницы в координатах от левого верхнего угла.
+type pdfPage struct {
+	font   *pdfFont
+	height float64
+	b      strings.Builder
+}
+
+func (p *pdfPage) text(x, top, size float64, s string) {
+	fmt.Fprintf(&p.b, "BT /F1 %.2f Tf %.2f %.2f Td <%s> Tj ET\n", size, x, p.height-top-size, p.font.encode(s))
+}
+
+func (p *pdfPage) rect(x, top, w, h float64) {
+	fmt.Fprintf(&p.b, "%.3f %.3f %.3f %.3f re f\n", x, p.height-top-h, w, h)
+}
+
+func (p *pdfPage) code128(x, top, maxWidth, height float64, data string) error {
+	widths, err := Code128(data)
+	if err != nil {
+		return err
+	}
+	modules := 0
+	for _, w := range widths {
+		modules += w
+	}
+
+	module := min(1.2, maxWidth/float64(modules))
+	dark := true
+	for _, w := range widths {
+		if dark {
+			p.rect(x, top, float64(w)*module, height)
+		}
+		x += float64(w) * module
+		dark = !dark
+	}
+	return nil
+}
+
+func (p *pdfPage) qr(x, top, size float64, data string) error {
+	code, err := EncodeQR(data)
+	if err != nil {
+		return err
+	}
+
+	module := size / float64(code.Size)
+	for y := 0; y < code.Size; y++ {
+		for cx := 0; cx < code.Size; cx++ {
+			if code.Dark(cx, y) {
+				p.rect(x+float64(cx)*module, top+float64(y)*module, module, module)
+			}
+		}
+	}
+	return nil
+}
+
+func drawLabel(p *pdfPage, l Label, width float64) error {
+	margin := 5 * ptPerMM
+	inner := width - 2*margin
+	top := margin
+
+	p.text(margin, top, 18, fmt.Sprintf("Накладная № %d", l.FolioNum))
+	top += 24
+	p.text(margin, top, 10, "от "+l.FolioDate)
+	top += 22
+
+	for _, line := range p.font.wrap(l.ClientName, 13, inner, 3) {
+		p.text(margin, top, 13, line)
+		top += 16
+	}
+	top += 6
+	for _, line := range p.font.wrap(l.ClientAddress, 10, inner, 4) {
+		p.text(margin, top, 10, line)
+		top += 13
+	}
+	top += 6
+	p.text(margin, top, 10, "Водитель: "+l.Driver)
+	top += 24
+
+	if err := p.code128(margin, top, inner, 60, l.Code); err != nil {
+		return err
+	}
+	top += 64
+	p.text(margin, top, 10, l.Code)
+	top += 24
+
+	return p.qr(margin, top, 30*ptPerMM, l.Code)
+}
+
+func renderPDF(w io.Writer, labels []Label) error {
+	f, err := newPDFFont()
+	if err != nil {
+		return err
+	}
+
+	width, height := widthMM*ptPerMM, heightMM*ptPerMM
+	contents := make([]string, 0, len(labels))
+	for _, l := range labels {
+		p := &pdfPage{font: f, height: height}
+		if err := drawLabel(p, l, width); err != nil {
+			return err
+		}
+		contents = append(contents, p.b.String())
+	}
+
+	var doc pdfDocument
+	catalog := doc.reserve()
+	pages := doc.reserve()
+	fontRef, err := doc.addFont(f)
+	if err != nil {
+		return err
+	}
+
+	kids := make([]string, 0, len(contents))
+	for _, c := range contents {
+		content := doc.addStream("", []byte(c))
+		page := doc.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>", pages, width, height, fontRef, content))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+
+	doc.set(pages, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	doc.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages))
+
+	return doc.write(w, catalog)
+}
+
+type pdfDocument struct {
+	objects [][]byte
+}
+
+func (d *pdfDocument) reserve() int {
+	d.objects = append(d.objects, nil)
+	return len(d.objects)
+}
+
+func (d *pdfDocument) set(ref int, body string) {
+	d.objects[ref-1] = []byte(body)
+}
+
+func (d *pdfDocument) add(body string) int {
+	ref := d.reserve()
+	d.set(ref, body)
+	return ref
+}
+
+func (d *pdfDocument) addStream(dict string, data []byte) int {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+
+	ref := d.reserve()
+	d.objects[ref-1] = []byte(fmt.Sprintf("<< /Length %d /Filter /FlateDecode %s>>\nstream\n%s\nendstream",
+		buf.Len(), dict, buf.Bytes()))
+	return ref
+}
+
+func (d *pdfDocument) addFont(f *pdfFont) (int, error) {
+	ppem := f.upm
+	metrics, err := f.font.Metrics(&f.buf, ppem, font.HintingNone)
+	if err != nil {
+		return 0, err
+	}
+	bounds, err := f.font.Bounds(&f.buf, ppem, font.HintingNone)
+	if err != nil {
+		return 0, err
+	}
+	scale := func(v fixed.Int26_6) int { return int(v) * 1000 / int(ppem) }
+
+	fontFile := d.addStream(fmt.Sprintf("/Length1 %d ", len(goregular.TTF)), goregular.TTF)
+	descriptor := d.add(fmt.Sprintf("<< /Type /FontDescriptor /FontName /GoRegular /Flags 32 "+
+		"/FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		scale(bounds.Min.X), -scale(bounds.Max.Y), scale(bounds.Max.X), -scale(bounds.Min.Y),
+		scale(metrics.Ascent), -scale(metrics.Descent), scale(metrics.CapHeight), fontFile))
+
+	glyphs := make([]sfnt.GlyphIndex, 0, len(f.width))
+	for gi := range f.width {
+		glyphs = append(glyphs, gi)
+	}
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i] < glyphs[j] })
+
+	var widths, toUnicode strings.Builder
+	for _, gi := range glyphs {
+		fmt.Fprintf(&widths, "%d [%d] ", gi, f.width[gi])
+	}
+
+	toUnicode.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(glyphs); start += 100 {
+		chunk := glyphs[start:min(start+100, len(glyphs))]
+		fmt.Fprintf(&toUnicode, "%d beginbfchar\n", len(chunk))
+		for _, gi := range chunk {
+			fmt.Fprintf(&toUnicode, "<%04X> <%04X>\n", uint16(gi), f.runes[gi])
+		}
+		toUnicode.WriteString("endbfchar\n")
+	}
+	toUnicode.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend")
+
+	cmap := d.addStream("", []byte(toUnicode.String()))
+	cidFont := d.add(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /GoRegular "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+		"/FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s] >>", descriptor, widths.String()))
+
+	return d.add(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /GoRegular /Encoding /Identity-H "+
+		"/DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", cidFont, cmap)), nil
+}
+
+func (d *pdfDocument) write(w io.Writer, root int) error {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	offsets := make([]int, len(d.objects))
+	for i, obj := range d.objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n", i+1)
+		buf.Write(obj)
+		buf.WriteString("\nendobj\n")
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(d.objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.objects)+1, root, xref)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
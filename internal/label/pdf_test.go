@@ -0,0 +1,54 @@
+package label
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestRenderPDF(t *testing.T) {
+	labels := []Label{
+		{OrderUID: 1, FolioNum: 5501, FolioDate: "01.03.2024", ClientName: "ООО «Восток»", Code: "5501"},
+		{OrderUID: 2, FolioNum: 5502, FolioDate: "01.03.2024", ClientName: "ИП (Петров)", Code: "5502"},
+	}
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatPDF, labels); err != nil {
+		t.Fatal(err)
+	}
+	pdf := buf.Bytes()
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+	if n := bytes.Count(pdf, []byte("/Type /Page ")); n != len(labels) {
+		t.Fatalf("got %d pages, want %d", n, len(labels))
+	}
+
+	// startxref указывает на таблицу xref, а ее записи - на начала объектов
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("startxref is missing")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point to xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	if len(entries) == 0 {
+		t.Fatal("xref table is empty")
+	}
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(string(e[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Fatalf("xref entry %d points to %q", i+1, pdf[offset:offset+10])
+		}
+	}
+}
+
+func TestRender_NoLabels(t *testing.T) {
+	if err := Render(&bytes.Buffer{}, FormatPDF, nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,496 @@
+package label
+
+import "fmt"
+
+// QR-код с уровнем коррекции M в байтовом режиме. Версий 1-10 достаточно
+// для содержимого этикетки (до 213 байт).
+
+type qrVersion struct {
+	ecPerBlock int
+	// blocks - число блоков данных и их длина в каждой из групп
+	blocks    [][2]int
+	alignment []int
+}
+
+var qrVersions = [...]qrVersion{
+	1:  {10, [][2]int{{1, 16}}, nil},
+	2:  {16, [][2]int{{1, 28}}, []int{6, 18}},
+	3:  {26, [][2]int{{1, 44}}, []int{6, 22}},
+	4:  {18, [][2]int{{2, 32}}, []int{6, 26}},
+	5:  {24, [][2]int{{2, 43}}, []int{6, 30}},
+	6:  {16, [][2]int{{4, 27}}, []int{6, 34}},
+	7:  {18, [][2]int{{4, 31}}, []int{6, 22, 38}},
+	8:  {22, [][2]int{{2, 38}, {2, 39}}, []int{6, 24, 42}},
+	9:  {22, [][2]int{{3, 36}, {2, 37}}, []int{6, 26, 46}},
+	10: {26, [][2]int{{4, 43}, {1, 44}}, []int{6, 28, 50}},
+}
+
+func (v qrVersion) dataCodewords() int {
+	n := 0
+	for _, g := range v.blocks {
+		n += g[0] * g[1]
+	}
+	return n
+}
+
+// QR - матрица модулей QR-кода; true - темный модуль.
+type QR struct {
+	Size    int
+	modules []bool
+}
+
+func (q *QR) Dark(x, y int) bool {
+	return q.modules[y*q.Size+x]
+}
+
+// EncodeQR строит QR-код для данных, выбирая минимальную подходящую версию.
+func EncodeQR(data string) (*QR, error) {
+	version := 0
+	for v := 1; v < len(qrVersions); v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*qrVersions[v].dataCodewords() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("qr: data too long (%d bytes)", len(data))
+	}
+
+	codewords := qrCodewords(version, []byte(data))
+
+	best := (*qrMatrix)(nil)
+	bestPenalty := 0
+	for mask := 0; mask < 8; mask++ {
+		m := newQRMatrix(version)
+		m.placeData(codewords)
+		m.applyMask(mask)
+		m.placeFormat(mask)
+		if p := m.penalty(); best == nil || p < bestPenalty {
+			best, bestPenalty = m, p
+		}
+	}
+
+	return &QR{Size: best.size, modules: best.dark}, nil
+}
+
+// qrCodewords кодирует данные, разбивает их на блоки, добавляет коды
+// Рида-Соломона и перемежает блоки.
+func qrCodewords(version int, data []byte) []byte {
+	v := qrVersions[version]
+	capacity := v.dataCodewords()
+
+	var bits qrBits
+	bits.append(0b0100, 4)
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	for i := 0; i < 4 && len(bits) < capacity*8; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+
+	stream := bits.bytes()
+	for pad := byte(0xEC); len(stream) < capacity; pad ^= 0xEC ^ 0x11 {
+		stream = append(stream, pad)
+	}
+
+	var blocks, ecBlocks [][]byte
+	for _, g := range v.blocks {
+		for i := 0; i < g[0]; i++ {
+			block := stream[:g[1]]
+			stream = stream[g[1]:]
+			blocks = append(blocks, block)
+			ecBlocks = append(ecBlocks, reedSolomon(block, v.ecPerBlock))
+		}
+	}
+
+	var out []byte
+	for i := 0; ; i++ {
+		added := false
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	for i := 0; i < v.ecPerBlock; i++ {
+		for _, b := range ecBlocks {
+			out = append(out, b[i])
+		}
+	}
+	return out
+}
+
+type qrBits []bool
+
+func (b *qrBits) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+func (b qrBits) bytes() []byte {
+	out := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+// Арифметика поля GF(256) с образующим многочленом x^8+x^4+x^3+x^2+1.
+var gfExp, gfLog = func() (exp [512]byte, log [256]byte) {
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// reedSolomon вычисляет n кодовых слов коррекции ошибок для блока данных.
+func reedSolomon(data []byte, n int) []byte {
+	generator := []byte{1}
+	for i := 0; i < n; i++ {
+		next := make([]byte, len(generator)+1)
+		for j, c := range generator {
+			next[j] ^= c
+			next[j+1] ^= gfMul(c, gfExp[i])
+		}
+		generator = next
+	}
+
+	rem := make([]byte, n)
+	for _, d := range data {
+		factor := d ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for j := 0; j < n; j++ {
+			rem[j] ^= gfMul(generator[j+1], factor)
+		}
+	}
+	return rem
+}
+
+type qrMatrix struct {
+	version  int
+	size     int
+	dark     []bool
+	reserved []bool
+}
+
+func newQRMatrix(version int) *qrMatrix {
+	size := 17 + 4*version
+	m := &qrMatrix{
+		version:  version,
+		size:     size,
+		dark:     make([]bool, size*size),
+		reserved: make([]bool, size*size),
+	}
+
+	m.finder(0, 0)
+	m.finder(size-7, 0)
+	m.finder(0, size-7)
+
+	for i := 8; i < size-8; i++ {
+		m.set(i, 6, i%2 == 0)
+		m.set(6, i, i%2 == 0)
+	}
+
+	align := qrVersions[version].alignment
+	last := len(align) - 1
+	for i, y := range align {
+		for j, x := range align {
+			// Позиции, совпадающие с искателями, пропускаются
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					d := max(abs(dx), abs(dy))
+					m.set(x+dx, y+dy, d != 1)
+				}
+			}
+		}
+	}
+
+	// Темный модуль и области формата резервируются до размещения данных
+	m.set(8, size-8, true)
+	for i := 0; i < 9; i++ {
+		m.reserve(8, i)
+		m.reserve(i, 8)
+	}
+	for i := 0; i < 8; i++ {
+		m.reserve(size-1-i, 8)
+		m.reserve(8, size-1-i)
+	}
+
+	if version >= 7 {
+		info := qrVersionInfo(version)
+		for i := 0; i < 18; i++ {
+			bit := info>>i&1 == 1
+			a, b := size-11+i%3, i/3
+			m.set(a, b, bit)
+			m.set(b, a, bit)
+		}
+	}
+	return m
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func (m *qrMatrix) set(x, y int, dark bool) {
+	m.dark[y*m.size+x] = dark
+	m.reserved[y*m.size+x] = true
+}
+
+func (m *qrMatrix) reserve(x, y int) {
+	m.reserved[y*m.size+x] = true
+}
+
+func (m *qrMatrix) finder(x0, y0 int) {
+	for dy := -1; dy <= 7; dy++ {
+		for dx := -1; dx <= 7; dx++ {
+			x, y := x0+dx, y0+dy
+			if x < 0 || y < 0 || x >= m.size || y >= m.size {
+				continue
+			}
+			d := max(abs(dx-3), abs(dy-3))
+			m.set(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+// placeData размещает кодовые слова зигзагом снизу вверх парами столбцов справа налево.
+func (m *qrMatrix) placeData(codewords []byte) {
+	bit := 0
+	total := len(codewords) * 8
+	upward := true
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for i := 0; i < m.size; i++ {
+			y := i
+			if upward {
+				y = m.size - 1 - i
+			}
+			for _, x := range []int{right, right - 1} {
+				if m.reserved[y*m.size+x] {
+					continue
+				}
+				if bit < total {
+					m.dark[y*m.size+x] = codewords[bit/8]>>(7-bit%8)&1 == 1
+				}
+				bit++
+			}
+		}
+		upward = !upward
+	}
+}
+
+func qrMaskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (y/2+x/3)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+func (m *qrMatrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if !m.reserved[y*m.size+x] && qrMaskBit(mask, x, y) {
+				m.dark[y*m.size+x] = !m.dark[y*m.size+x]
+			}
+		}
+	}
+}
+
+// bchRemainder возвращает остаток от деления value, сдвинутого на степень
+// многочлена, на многочлен poly.
+func bchRemainder(value, poly int) int {
+	deg := 0
+	for p := poly; p > 1; p >>= 1 {
+		deg++
+	}
+	rem := value << deg
+	for i := bitLen(rem) - 1; i >= deg; i-- {
+		if rem>>i&1 == 1 {
+			rem ^= poly << (i - deg)
+		}
+	}
+	return rem
+}
+
+func bitLen(x int) int {
+	n := 0
+	for ; x > 0; x >>= 1 {
+		n++
+	}
+	return n
+}
+
+func qrVersionInfo(version int) int {
+	return version<<12 | bchRemainder(version, 0x1F25)
+}
+
+// qrFormatInfo возвращает 15 бит формата: уровень коррекции M (00), номер маски
+// и код BCH, наложенные маской 0x5412.
+func qrFormatInfo(mask int) int {
+	data := 0b00<<3 | mask
+	return (data<<10 | bchRemainder(data, 0x537)) ^ 0x5412
+}
+
+// placeFormat записывает формат в обе копии области формата.
+func (m *qrMatrix) placeFormat(mask int) {
+	info := qrFormatInfo(mask)
+
+	for i := 0; i < 15; i++ {
+		bit := info>>i&1 == 1
+
+		// Копия у левого верхнего искателя
+		switch {
+		case i < 6:
+			m.dark[i*m.size+8] = bit
+		case i < 8:
+			m.dark[(i+1)*m.size+8] = bit
+		case i == 8:
+			m.dark[8*m.size+7] = bit
+		default:
+			m.dark[8*m.size+14-i] = bit
+		}
+
+		// Копия у правого верхнего и левого нижнего искателей
+		if i < 8 {
+			m.dark[8*m.size+m.size-1-i] = bit
+		} else {
+			m.dark[(m.size-15+i)*m.size+8] = bit
+		}
+	}
+}
+
+// penalty оценивает маску по четырем правилам стандарта; меньше - лучше.
+func (m *qrMatrix) penalty() int {
+	size := m.size
+	at := func(x, y int) bool { return m.dark[y*size+x] }
+	score := 0
+
+	// Правило 1: серии из пяти и более одинаковых модулей
+	for y := 0; y < size; y++ {
+		for _, horizontal := range []bool{true, false} {
+			run := 1
+			for i := 1; i < size; i++ {
+				var prev, cur bool
+				if horizontal {
+					prev, cur = at(i-1, y), at(i, y)
+				} else {
+					prev, cur = at(y, i-1), at(y, i)
+				}
+				if cur == prev {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += run - 2
+				}
+				run = 1
+			}
+			if run >= 5 {
+				score += run - 2
+			}
+		}
+	}
+
+	// Правило 2: блоки 2x2 одного цвета
+	for y := 0; y < size-1; y++ {
+		for x := 0; x < size-1; x++ {
+			c := at(x, y)
+			if at(x+1, y) == c && at(x, y+1) == c && at(x+1, y+1) == c {
+				score += 3
+			}
+		}
+	}
+
+	// Правило 3: шаблоны, похожие на искатель
+	patterns := [][]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	for y := 0; y < size; y++ {
+		for x := 0; x+11 <= size; x++ {
+			for _, p := range patterns {
+				h, v := true, true
+				for k, want := range p {
+					h = h && at(x+k, y) == want
+					v = v && at(y, x+k) == want
+				}
+				if h {
+					score += 40
+				}
+				if v {
+					score += 40
+				}
+			}
+		}
+	}
+
+	// Правило 4: отклонение доли темных модулей от 50%
+	dark := 0
+	for _, d := range m.dark {
+		if d {
+			dark++
+		}
+	}
+	percent := dark * 100 / (size * size)
+	score += abs(percent-50) / 5 * 10
+	return score
+}
@@ -0,0 +1,116 @@
+package label
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Примеры кодов Рида-Соломона для версии 1-M: "01234567" из приложения
+// ISO/IEC 18004 и "HELLO WORLD" в буквенно-цифровом режиме.
+func TestReedSolomon(t *testing.T) {
+	tests := []struct {
+		name     string
+		data, ec []byte
+	}{
+		{
+			"01234567",
+			[]byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11},
+			[]byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55},
+		},
+		{
+			"HELLO WORLD",
+			[]byte{0x20, 0x5B, 0x0B, 0x78, 0xD1, 0x72, 0xDC, 0x4D, 0x43, 0x40, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11},
+			[]byte{0xC4, 0x23, 0x27, 0x77, 0xEB, 0xD7, 0xE7, 0xE2, 0x5D, 0x17},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reedSolomon(tt.data, len(tt.ec)); !bytes.Equal(got, tt.ec) {
+				t.Fatalf("got % X, want % X", got, tt.ec)
+			}
+		})
+	}
+}
+
+func TestQRCodewords(t *testing.T) {
+	// Байтовый режим 0100, длина 00000001, 'A' 01000001, терминатор 0000,
+	// затем чередование 0xEC и 0x11 до 16 кодовых слов версии 1-M.
+	data := []byte{0x40, 0x14, 0x10, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC}
+	got := qrCodewords(1, []byte("A"))
+	if want := append(data, reedSolomon(data, 10)...); !bytes.Equal(got, want) {
+		t.Fatalf("got % X, want % X", got, want)
+	}
+
+	// Версия 8: 2 блока по 38 и 2 блока по 39 слов данных, по 22 слова коррекции.
+	got = qrCodewords(8, bytes.Repeat([]byte("x"), 100))
+	if len(got) != 2*38+2*39+4*22 {
+		t.Fatalf("got %d codewords", len(got))
+	}
+	// Сначала идут первые слова блоков, начинающихся с 0, 38, 76 и 115 слова
+	// потока: заголовок 0x46, данные 0x87, данные 0x87 и дополнение 0x11.
+	if !bytes.Equal(got[:4], []byte{0x46, 0x87, 0x87, 0x11}) {
+		t.Fatalf("blocks are not interleaved: % X", got[:4])
+	}
+}
+
+// Значения из таблицы C.1 ISO/IEC 18004 для уровня коррекции M.
+func TestQRFormatInfo(t *testing.T) {
+	want := []int{0x5412, 0x5125, 0x5E7C, 0x5B4B, 0x45F9, 0x40CE, 0x4F97, 0x4AA0}
+	for mask, w := range want {
+		if got := qrFormatInfo(mask); got != w {
+			t.Errorf("mask %d: got %015b, want %015b", mask, got, w)
+		}
+	}
+}
+
+// Значения из таблицы D.1 ISO/IEC 18004.
+func TestQRVersionInfo(t *testing.T) {
+	want := map[int]int{7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3}
+	for version, w := range want {
+		if got := qrVersionInfo(version); got != w {
+			t.Errorf("version %d: got %018b, want %018b", version, got, w)
+		}
+	}
+}
+
+func TestEncodeQR(t *testing.T) {
+	tests := []struct {
+		data string
+		size int
+	}{
+		{strings.Repeat("x", 14), 21},
+		{strings.Repeat("x", 15), 25},
+		{strings.Repeat("x", 213), 57},
+	}
+	for _, tt := range tests {
+		q, err := EncodeQR(tt.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Size != tt.size {
+			t.Fatalf("%d bytes: got size %d, want %d", len(tt.data), q.Size, tt.size)
+		}
+
+		// Искатели в трех углах: темная рамка, светлое кольцо, темный центр
+		for _, corner := range [][2]int{{0, 0}, {q.Size - 7, 0}, {0, q.Size - 7}} {
+			x, y := corner[0], corner[1]
+			if !q.Dark(x, y) || q.Dark(x+1, y+1) || !q.Dark(x+3, y+3) {
+				t.Fatalf("%d bytes: no finder pattern at %v", len(tt.data), corner)
+			}
+		}
+		// Синхронизирующая линия и темный модуль у левого нижнего искателя
+		for i := 8; i < q.Size-8; i++ {
+			if q.Dark(i, 6) != (i%2 == 0) {
+				t.Fatalf("%d bytes: broken timing pattern at %d", len(tt.data), i)
+			}
+		}
+		if !q.Dark(8, q.Size-8) {
+			t.Fatalf("%d bytes: dark module is missing", len(tt.data))
+		}
+	}
+
+	if _, err := EncodeQR(strings.Repeat("x", 214)); err == nil {
+		t.Fatal("expected error for too long data")
+	}
+}
@@ -0,0 +1,46 @@
+package label
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Разрешение принтера Zebra, точек на мм (203 dpi)
+const zplDotsPerMM = 8
+
+// zplEscape экранирует служебные символы ZPL; поле должно предваряться ^FH.
+var zplEscape = strings.NewReplacer(`\`, `\5C`, `^`, `\5E`, `~`, `\7E`)
+
+func zplText(x, y, height, width, lines int, text string) string {
+	return fmt.Sprintf("^FO%d,%d^A0N,%d,%d^FB%d,%d,0,L^FH\\^FD%s^FS\n",
+		x, y, height, height, width, lines, zplEscape.Replace(text))
+}
+
+// renderZPL использует встроенные в принтер Code128 (^BC) и QR (^BQ):
+// принтер печатает их точнее, чем переданный растр.
+func renderZPL(w io.Writer, labels []Label) error {
+	const margin = 5 * zplDotsPerMM
+	width := widthMM*zplDotsPerMM - 2*margin
+
+	for _, l := range labels {
+		var b strings.Builder
+		b.WriteString("^XA\n^CI28\n")
+		fmt.Fprintf(&b, "^PW%d\n^LL%d\n", widthMM*zplDotsPerMM, heightMM*zplDotsPerMM)
+
+		b.WriteString(zplText(margin, margin, 60, width, 1, fmt.Sprintf("Накладная № %d", l.FolioNum)))
+		b.WriteString(zplText(margin, margin+70, 30, width, 1, "от "+l.FolioDate))
+		b.WriteString(zplText(margin, margin+130, 40, width, 3, l.ClientName))
+		b.WriteString(zplText(margin, margin+270, 30, width, 4, l.ClientAddress))
+		b.WriteString(zplText(margin, margin+420, 30, width, 1, "Водитель: "+l.Driver))
+
+		fmt.Fprintf(&b, "^FO%d,%d^BY3^BCN,180,Y,N,N^FH\\^FD%s^FS\n", margin, margin+500, zplEscape.Replace(l.Code))
+		fmt.Fprintf(&b, "^FO%d,%d^BQN,2,8^FH\\^FDMA,%s^FS\n", margin, margin+760, zplEscape.Replace(l.Code))
+		b.WriteString("^XZ\n")
+
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
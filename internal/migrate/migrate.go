@@ -0,0 +1,240 @@
+// Package migrate - версионные миграции схемы базы. Миграции хранятся
+// SQL-файлами в каталоге migrations и встраиваются в программу, примененные
+// версии записываются в таблицу schema_migrations вместе с контрольной суммой.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Table - таблица примененных миграций.
+const Table = "schema_migrations"
+
+const (
+	// lockName - имя блокировки GET_LOCK, под которой выполняются миграции,
+	// чтобы два экземпляра сервера не мигрировали базу одновременно
+	lockName           = "eastwh.schema_migrations"
+	DefaultLockTimeout = time.Minute
+)
+
+var (
+	ErrLocked           = errors.New("migrations are locked by another process")
+	ErrChecksumMismatch = errors.New("applied migration was changed")
+	ErrUnknownVersion   = errors.New("applied migration is unknown")
+	ErrNoDown           = errors.New("migration can not be rolled back")
+)
+
+const createTable = "CREATE TABLE IF NOT EXISTS " + Table + ` (
+	version BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	checksum CHAR(64) NOT NULL,
+	applied_at DATETIME(3) NOT NULL
+)`
+
+// record - запись о примененной миграции.
+type record struct {
+	Version   uint      `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"column:name"`
+	Checksum  string    `gorm:"column:checksum"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (record) TableName() string {
+	return Table
+}
+
+// State - состояние миграции в базе.
+type State string
+
+const (
+	StatePending State = "pending"
+	StateApplied State = "applied"
+	// StateChanged - миграция применена, но ее скрипт с тех пор изменился
+	StateChanged State = "changed"
+	// StateUnknown - версия применена в базе, но ее нет среди миграций программы
+	StateUnknown State = "unknown"
+)
+
+// Status - состояние одной версии схемы.
+type Status struct {
+	Version   uint
+	Name      string
+	State     State
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	// LockTimeout - сколько ждать, пока миграции выполняет другой процесс
+	LockTimeout time.Duration
+	// Baseline приводит к состоянию первой миграции базу, созданную до появления
+	// миграций. Up вызывает его под блокировкой, пока в базе не записано
+	// ни одной примененной миграции.
+	Baseline func(db *gorm.DB) error
+}
+
+func New(db *gorm.DB, migrations []Migration) *Migrator {
+	return &Migrator{
+		db:          db,
+		migrations:  migrations,
+		LockTimeout: DefaultLockTimeout,
+	}
+}
+
+// Status возвращает состояние всех миграций программы и версий, примененных в базе.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	db := m.db.WithContext(ctx)
+	var records []record
+	if db.Migrator().HasTable(Table) {
+		if err := db.Order("version").Find(&records).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		statuses = append(statuses, Status{Version: mig.Version, Name: mig.Name, State: StatePending})
+	}
+	for _, r := range records {
+		i := slices.IndexFunc(statuses, func(s Status) bool { return s.Version == r.Version })
+		if i < 0 {
+			statuses = append(statuses, Status{Version: r.Version, Name: r.Name, State: StateUnknown})
+			i = len(statuses) - 1
+		} else if m.migrations[i].Checksum != r.Checksum {
+			statuses[i].State = StateChanged
+		} else {
+			statuses[i].State = StateApplied
+		}
+		appliedAt := r.AppliedAt
+		statuses[i].AppliedAt = &appliedAt
+	}
+	slices.SortStableFunc(statuses, func(a, b Status) int { return cmp.Compare(a.Version, b.Version) })
+	return statuses, nil
+}
+
+// Up применяет все непримененные миграции по возрастанию версии и возвращает
+// примененные. Если уже примененная миграция изменена или неизвестна, ни одна
+// миграция не применяется.
+func (m *Migrator) Up(ctx context.Context) (applied []Migration, err error) {
+	err = m.locked(ctx, func(db *gorm.DB, records []record) error {
+		if len(records) == 0 && m.Baseline != nil {
+			if err := m.Baseline(db); err != nil {
+				return fmt.Errorf("baseline: %w", err)
+			}
+		}
+		for _, mig := range m.migrations {
+			if slices.ContainsFunc(records, func(r record) bool { return r.Version == mig.Version }) {
+				continue
+			}
+			if err := exec(db, mig.Up); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			r := record{Version: mig.Version, Name: mig.Name, Checksum: mig.Checksum, AppliedAt: time.Now()}
+			if err := db.Create(&r).Error; err != nil {
+				return err
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down откатывает steps последних примененных миграций и возвращает откаченные.
+func (m *Migrator) Down(ctx context.Context, steps int) (rolledBack []Migration, err error) {
+	err = m.locked(ctx, func(db *gorm.DB, records []record) error {
+		for i := len(records) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+			mig := m.migration(records[i].Version)
+			if mig.Down == "" {
+				return fmt.Errorf("%w: %d_%s", ErrNoDown, mig.Version, mig.Name)
+			}
+			if err := exec(db, mig.Down); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+			}
+			if err := db.Delete(&records[i]).Error; err != nil {
+				return err
+			}
+			rolledBack = append(rolledBack, mig)
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// locked выполняет fn под блокировкой миграций на одном соединении с базой.
+// fn получает примененные миграции по возрастанию версии, уже сверенные
+// с миграциями программы.
+func (m *Migrator) locked(ctx context.Context, fn func(db *gorm.DB, records []record) error) error {
+	return m.db.WithContext(ctx).Connection(func(db *gorm.DB) error {
+		var acquired sql.NullInt64
+		err := db.Raw("SELECT GET_LOCK(?, ?)", lockName, int(m.LockTimeout/time.Second)).Scan(&acquired).Error
+		if err != nil {
+			return err
+		}
+		if !acquired.Valid || acquired.Int64 != 1 {
+			return ErrLocked
+		}
+		// Блокировка снимается и при отмене запроса: иначе соединение
+		// вернулось бы в пул, удерживая ее
+		defer db.WithContext(context.WithoutCancel(ctx)).Exec("SELECT RELEASE_LOCK(?)", lockName)
+
+		if err := db.Exec(createTable).Error; err != nil {
+			return err
+		}
+		var records []record
+		if err := db.Order("version").Find(&records).Error; err != nil {
+			return err
+		}
+		if err := m.verify(records); err != nil {
+			return err
+		}
+		return fn(db, records)
+	})
+}
+
+// verify сверяет примененные миграции с миграциями программы.
+func (m *Migrator) verify(records []record) error {
+	for _, r := range records {
+		mig := m.migration(r.Version)
+		switch {
+		case mig.Version == 0:
+			return fmt.Errorf("%w: %d_%s", ErrUnknownVersion, r.Version, r.Name)
+		case mig.Checksum != r.Checksum:
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, r.Version, r.Name)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) migration(version uint) Migration {
+	i := slices.IndexFunc(m.migrations, func(mig Migration) bool { return mig.Version == version })
+	if i < 0 {
+		return Migration{}
+	}
+	return m.migrations[i]
+}
+
+// exec выполняет запросы скрипта по очереди. DDL в MySQL не откатывается
+// транзакцией, поэтому при ошибке сообщается номер запроса, на котором
+// остановилась миграция.
+func exec(db *gorm.DB, script string) error {
+	statements, err := Statements(script)
+	if err != nil {
+		return err
+	}
+	for i, s := range statements {
+		if err := db.Exec(s).Error; err != nil {
+			return fmt.Errorf("statement %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDSNEnv - DSN пустой базы MySQL. Тест применения миграций удаляет
+// из нее все таблицы.
+const testDSNEnv = "EASTWH_TEST_MYSQL_DSN"
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_add_index.up.sql":     {Data: []byte("CREATE INDEX i ON t (a);")},
+		"m/0001_create_t.up.sql":      {Data: []byte("CREATE TABLE t (a INT);")},
+		"m/0001_create_t.down.sql":    {Data: []byte("DROP TABLE t;")},
+		"m/0010_seed.up.sql":          {Data: []byte("INSERT INTO t VALUES (1);")},
+		"m/0010_seed.down.sql":        {Data: []byte("DELETE FROM t;")},
+		"m/notes/0003_skipped.up.sql": {Data: []byte("-")},
+	}
+	migrations, err := Load(fsys, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var versions []uint
+	for _, m := range migrations {
+		versions = append(versions, m.Version)
+	}
+	if !slices.Equal(versions, []uint{1, 2, 10}) {
+		t.Fatalf("unexpected versions %v", versions)
+	}
+	if m := migrations[0]; m.Name != "create_t" || m.Down != "DROP TABLE t;" || len(m.Checksum) != 64 {
+		t.Fatalf("unexpected migration %+v", m)
+	}
+	if migrations[1].Down != "" {
+		t.Fatalf("migration without down script got %q", migrations[1].Down)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"file name":      {"m/create_t.up.sql": {Data: []byte("SELECT 1;")}},
+		"zero version":   {"m/0000_create_t.up.sql": {Data: []byte("SELECT 1;")}},
+		"different name": {"m/0001_a.up.sql": {Data: []byte("SELECT 1;")}, "m/0001_b.down.sql": {Data: []byte("SELECT 1;")}},
+		"no up script":   {"m/0001_a.down.sql": {Data: []byte("SELECT 1;")}},
+	}
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(fsys, "m"); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestStatements(t *testing.T) {
+	script := `-- комментарий
+CREATE TABLE t (
+	a INT
+);
+INSERT INTO t VALUES (1);
+
+DELIMITER //
+CREATE PROCEDURE p()
+BEGIN
+	SELECT a FROM t;
+END //
+DELIMITER ;
+DROP TABLE t`
+
+	statements, err := Statements(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"CREATE TABLE t (\n\ta INT\n)",
+		"INSERT INTO t VALUES (1)",
+		"CREATE PROCEDURE p()\nBEGIN\n\tSELECT a FROM t;\nEND",
+		"DROP TABLE t",
+	}
+	if !slices.Equal(statements, want) {
+		t.Fatalf("got %q, want %q", statements, want)
+	}
+
+	if _, err := Statements("DELIMITER\nSELECT 1;"); err == nil {
+		t.Fatal("expected error for delimiter without value")
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	migrations, err := Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+	for i, m := range migrations {
+		if m.Version != uint(i+1) {
+			t.Fatalf("migration %d_%s: versions must go without gaps", m.Version, m.Name)
+		}
+		if m.Down == "" {
+			t.Fatalf("migration %d_%s: down script is missing", m.Version, m.Name)
+		}
+		for _, script := range []string{m.Up, m.Down} {
+			if _, err := Statements(script); err != nil {
+				t.Fatalf("migration %d_%s: %v", m.Version, m.Name, err)
+			}
+		}
+	}
+}
+
+func TestMigrator_MySQL(t *testing.T) {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	m := New(db, migrations)
+
+	if _, err := m.Down(ctx, len(migrations)); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrator().DropTable(Table); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != len(migrations) || statuses[0].State != StatePending {
+		t.Fatalf("unexpected status %+v", statuses)
+	}
+
+	errBaseline := errors.New("baseline failed")
+	m.Baseline = func(*gorm.DB) error { return errBaseline }
+	if applied, err := m.Up(ctx); !errors.Is(err, errBaseline) || len(applied) != 0 {
+		t.Fatalf("expected baseline error before migrations, applied %d: %v", len(applied), err)
+	}
+
+	baselines := 0
+	m.Baseline = func(db *gorm.DB) error {
+		baselines++
+		return nil
+	}
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("applied %d of %d migrations", len(applied), len(migrations))
+	}
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("second up applied %d migrations: %v", len(applied), err)
+	}
+	if baselines != 1 {
+		t.Fatalf("expected baseline before the first migration only, got %d calls", baselines)
+	}
+	if !db.Migrator().HasTable("orders") {
+		t.Fatal("orders table was not created")
+	}
+
+	last := migrations[len(migrations)-1]
+	rolledBack, err := m.Down(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rolledBack) != 1 || rolledBack[0].Version != last.Version {
+		t.Fatalf("unexpected rollback %+v", rolledBack)
+	}
+	statuses, err = m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := statuses[len(statuses)-1]; s.State != StatePending || s.AppliedAt != nil {
+		t.Fatalf("rolled back migration has status %+v", s)
+	}
+
+	changed := slices.Clone(migrations)
+	changed[0].Up += "\n-- changed"
+	changed[0].Checksum = "changed"
+	if _, err := New(db, changed).Up(ctx); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	if _, err := New(db, migrations[:0]).Up(ctx); !errors.Is(err, ErrUnknownVersion) {
+		t.Fatalf("expected unknown version, got %v", err)
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS sla_rules;
+DROP TABLE IF EXISTS payroll_entries;
+DROP TABLE IF EXISTS payroll_periods;
+DROP TABLE IF EXISTS tariffs;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS order_lines;
+DROP TABLE IF EXISTS order_events;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS employee_teams;
+DROP TABLE IF EXISTS user_teams;
+DROP TABLE IF EXISTS user_projects;
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS employees;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS teams;
+DROP TABLE IF EXISTS roles;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,294 @@
+-- Исходная схема: таблицы в том виде, в каком их создавал AutoMigrate.
+-- IF NOT EXISTS позволяет применить миграцию к базе, созданной AutoMigrate.
+
+CREATE TABLE IF NOT EXISTS users (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	first_name LONGTEXT,
+	name LONGTEXT,
+	last_name LONGTEXT,
+	email VARCHAR(191) NOT NULL,
+	password LONGTEXT,
+	loggedin BOOLEAN,
+	token LONGTEXT,
+	`restore` BOOLEAN,
+	blocked BOOLEAN,
+	phone LONGTEXT,
+	PRIMARY KEY (id),
+	INDEX idx_users_deleted_at (deleted_at),
+	CONSTRAINT uni_users_email UNIQUE (email)
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	name VARCHAR(191) NOT NULL,
+	description LONGTEXT,
+	priority BIGINT,
+	PRIMARY KEY (id),
+	INDEX idx_roles_deleted_at (deleted_at),
+	CONSTRAINT uni_roles_name UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS teams (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	name VARCHAR(191) NOT NULL,
+	PRIMARY KEY (id),
+	INDEX idx_teams_deleted_at (deleted_at),
+	CONSTRAINT uni_teams_name UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	name VARCHAR(191) NOT NULL,
+	vid_doc LONGTEXT,
+	PRIMARY KEY (id),
+	INDEX idx_projects_deleted_at (deleted_at),
+	CONSTRAINT uni_projects_name UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS employees (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	code VARCHAR(191) NOT NULL,
+	first_name LONGTEXT,
+	name LONGTEXT,
+	last_name LONGTEXT,
+	inn LONGTEXT,
+	phone LONGTEXT,
+	PRIMARY KEY (id),
+	INDEX idx_employees_deleted_at (deleted_at),
+	CONSTRAINT uni_employees_code UNIQUE (code)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	role_id BIGINT UNSIGNED,
+	user_id BIGINT UNSIGNED,
+	PRIMARY KEY (id),
+	INDEX idx_user_roles_deleted_at (deleted_at)
+);
+
+CREATE TABLE IF NOT EXISTS user_projects (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	project_id BIGINT UNSIGNED,
+	user_id BIGINT UNSIGNED,
+	PRIMARY KEY (id),
+	INDEX idx_user_projects_deleted_at (deleted_at)
+);
+
+CREATE TABLE IF NOT EXISTS user_teams (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	team_id BIGINT UNSIGNED,
+	user_id BIGINT UNSIGNED,
+	PRIMARY KEY (id),
+	INDEX idx_user_teams_deleted_at (deleted_at),
+	CONSTRAINT fk_teams_team_users FOREIGN KEY (team_id) REFERENCES teams (id),
+	CONSTRAINT fk_users_team_users FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE TABLE IF NOT EXISTS employee_teams (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	team_id BIGINT UNSIGNED NOT NULL,
+	employee_id BIGINT UNSIGNED NOT NULL,
+	share DOUBLE NOT NULL DEFAULT 0,
+	PRIMARY KEY (id, team_id, employee_id),
+	INDEX idx_employee_teams_deleted_at (deleted_at)
+);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	user_id BIGINT UNSIGNED,
+	family_id VARCHAR(64),
+	token_hash VARCHAR(64),
+	expires_at DATETIME(3) NULL,
+	used_at DATETIME(3) NULL,
+	revoked_at DATETIME(3) NULL,
+	PRIMARY KEY (id),
+	INDEX idx_refresh_tokens_deleted_at (deleted_at),
+	INDEX idx_refresh_tokens_user_id (user_id),
+	INDEX idx_refresh_tokens_family_id (family_id),
+	UNIQUE INDEX idx_refresh_tokens_token_hash (token_hash)
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	order_uid BIGINT NOT NULL,
+	unicum_num BIGINT,
+	folio_num BIGINT,
+	folio_date DATETIME(3) NULL,
+	order_date DATETIME(3) NULL,
+	order_sum DOUBLE,
+	folio_sum DOUBLE,
+	driver VARCHAR(100),
+	agent VARCHAR(100),
+	brieforg VARCHAR(20),
+	client_id BIGINT,
+	client_name VARCHAR(120),
+	client_address VARCHAR(150),
+	vid_doc VARCHAR(100),
+	start_at DATETIME(3) NULL,
+	finish_at DATETIME(3) NULL,
+	done BOOLEAN,
+	status BIGINT NOT NULL DEFAULT 0,
+	user_id BIGINT UNSIGNED,
+	employee_id BIGINT UNSIGNED,
+	team_id BIGINT UNSIGNED,
+	`check` BOOLEAN,
+	check_user_id BIGINT UNSIGNED,
+	shortage BOOLEAN,
+	paused_at DATETIME(3) NULL,
+	paused_seconds BIGINT NOT NULL DEFAULT 0,
+	work_seconds BIGINT NOT NULL DEFAULT 0,
+	priority BIGINT NOT NULL DEFAULT 0,
+	ship_by DATETIME(3) NULL,
+	PRIMARY KEY (id),
+	INDEX idx_orders_deleted_at (deleted_at),
+	INDEX idx_orders_folio_date (folio_date),
+	INDEX idx_orders_order_date (order_date),
+	INDEX idx_orders_status (status),
+	INDEX idx_orders_ship_by (ship_by),
+	CONSTRAINT uni_orders_order_uid UNIQUE (order_uid),
+	FULLTEXT INDEX ft_orders_search (client_name, client_address, driver, agent)
+);
+
+CREATE TABLE IF NOT EXISTS order_events (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	order_uid BIGINT UNSIGNED NOT NULL,
+	type VARCHAR(20) NOT NULL,
+	from_status BIGINT,
+	to_status BIGINT,
+	actor_user_id BIGINT UNSIGNED,
+	employee_id BIGINT UNSIGNED,
+	`before` TEXT,
+	`after` TEXT,
+	created_at DATETIME(3) NULL,
+	PRIMARY KEY (id),
+	INDEX idx_order_events_order_uid (order_uid),
+	INDEX idx_order_events_actor_user_id (actor_user_id),
+	INDEX idx_order_events_created_at (created_at)
+);
+
+CREATE TABLE IF NOT EXISTS order_lines (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	order_uid BIGINT UNSIGNED NOT NULL,
+	line_no BIGINT NOT NULL,
+	product_code VARCHAR(50),
+	product_name VARCHAR(200),
+	barcode VARCHAR(20),
+	unit VARCHAR(20),
+	quantity DOUBLE,
+	picked_quantity DOUBLE,
+	location VARCHAR(50),
+	picked BOOLEAN,
+	shortage_reason VARCHAR(20),
+	PRIMARY KEY (id),
+	INDEX idx_order_lines_deleted_at (deleted_at),
+	UNIQUE INDEX idx_order_line (order_uid, line_no),
+	INDEX idx_order_lines_product_code (product_code),
+	INDEX idx_order_lines_barcode (barcode),
+	INDEX idx_order_lines_location (location)
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	role_id BIGINT UNSIGNED,
+	permission VARCHAR(64),
+	PRIMARY KEY (id),
+	INDEX idx_role_permissions_deleted_at (deleted_at),
+	UNIQUE INDEX idx_role_permission (role_id, permission)
+);
+
+CREATE TABLE IF NOT EXISTS tariffs (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	vid_doc VARCHAR(100),
+	per_order DOUBLE,
+	per_line DOUBLE,
+	per_1000_sum DOUBLE,
+	PRIMARY KEY (id),
+	INDEX idx_tariffs_deleted_at (deleted_at),
+	UNIQUE INDEX idx_tariffs_vid_doc (vid_doc)
+);
+
+CREATE TABLE IF NOT EXISTS payroll_periods (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	start_dt VARCHAR(10),
+	finish_dt VARCHAR(10),
+	closed_at DATETIME(3) NULL,
+	closed_by BIGINT UNSIGNED,
+	total DOUBLE,
+	PRIMARY KEY (id),
+	INDEX idx_payroll_periods_deleted_at (deleted_at),
+	INDEX idx_payroll_periods_start_dt (start_dt),
+	INDEX idx_payroll_periods_finish_dt (finish_dt)
+);
+
+CREATE TABLE IF NOT EXISTS payroll_entries (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	period_id BIGINT UNSIGNED,
+	employee_id BIGINT UNSIGNED,
+	employee_name VARCHAR(150),
+	orders DOUBLE,
+	lines_count DOUBLE,
+	order_sum DOUBLE,
+	amount DOUBLE,
+	PRIMARY KEY (id),
+	INDEX idx_payroll_entries_period_id (period_id),
+	CONSTRAINT fk_payroll_periods_entries FOREIGN KEY (period_id) REFERENCES payroll_periods (id)
+);
+
+CREATE TABLE IF NOT EXISTS sla_rules (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	created_at DATETIME(3) NULL,
+	updated_at DATETIME(3) NULL,
+	deleted_at DATETIME(3) NULL,
+	vid_doc VARCHAR(100),
+	assemble_minutes BIGINT,
+	ship_lead_minutes BIGINT,
+	at_risk_minutes BIGINT,
+	PRIMARY KEY (id),
+	INDEX idx_sla_rules_deleted_at (deleted_at),
+	UNIQUE INDEX idx_sla_rules_vid_doc (vid_doc)
+);
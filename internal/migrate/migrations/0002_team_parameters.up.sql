@@ -0,0 +1,20 @@
+-- getTeamParametrs возвращает бригады пользователя с их сотрудниками.
+DROP PROCEDURE IF EXISTS getTeamParametrs;
+
+DELIMITER //
+CREATE PROCEDURE getTeamParametrs(IN p_user_id BIGINT UNSIGNED)
+BEGIN
+	SELECT ut.id,
+		ut.team_id,
+		t.name,
+		u.id AS user_id,
+		CONCAT(u.first_name, ' ', u.name, ' ', u.last_name) AS user_name,
+		CONCAT(e.first_name, ' ', e.name, ' ', e.last_name) AS employee_name
+	FROM user_teams ut
+		LEFT JOIN teams t ON t.id = ut.team_id
+		LEFT JOIN users u ON u.id = ut.user_id
+		LEFT JOIN employee_teams et ON et.team_id = t.id
+		LEFT JOIN employees e ON e.id = et.employee_id
+	WHERE ut.user_id = p_user_id;
+END //
+DELIMITER ;
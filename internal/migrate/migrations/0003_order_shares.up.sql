@@ -0,0 +1,39 @@
+-- order_shares - участники сборки заказа и их доли в оплате на момент сборки.
+CREATE TABLE IF NOT EXISTS order_shares (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	order_uid BIGINT,
+	employee_id BIGINT UNSIGNED,
+	employee_name VARCHAR(150),
+	share DOUBLE,
+	PRIMARY KEY (id),
+	INDEX idx_order_shares_order_uid (order_uid)
+);
+
+-- Уже собранные заказы (собран, проверен, отгружен) получают доли по текущему
+-- составу команд: дальше он на них не влияет. Доли, как и в TeamShares,
+-- отрицательными не бывают, а при нулевой сумме делятся поровну.
+INSERT INTO order_shares (order_uid, employee_id, employee_name, share)
+SELECT o.order_uid,
+	et.employee_id,
+	CONCAT_WS(' ', NULLIF(e.first_name, ''), NULLIF(e.name, ''), NULLIF(e.last_name, '')),
+	CASE WHEN t.total > 0 THEN GREATEST(et.share, 0) ELSE 1 END
+FROM orders o
+	JOIN employee_teams et ON et.team_id = o.team_id AND et.deleted_at IS NULL
+	JOIN (
+		SELECT team_id, SUM(GREATEST(share, 0)) AS total
+		FROM employee_teams
+		WHERE deleted_at IS NULL
+		GROUP BY team_id
+	) t ON t.team_id = o.team_id
+	LEFT JOIN employees e ON e.id = et.employee_id
+WHERE o.status IN (3, 4, 5) AND o.team_id <> 0 AND o.deleted_at IS NULL;
+
+INSERT INTO order_shares (order_uid, employee_id, employee_name, share)
+SELECT o.order_uid,
+	o.employee_id,
+	CONCAT_WS(' ', NULLIF(e.first_name, ''), NULLIF(e.name, ''), NULLIF(e.last_name, '')),
+	1
+FROM orders o
+	LEFT JOIN employees e ON e.id = o.employee_id
+WHERE o.status IN (3, 4, 5) AND o.employee_id <> 0 AND o.deleted_at IS NULL
+	AND NOT EXISTS (SELECT 1 FROM order_shares s WHERE s.order_uid = o.order_uid);
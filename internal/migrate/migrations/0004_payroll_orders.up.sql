@@ -0,0 +1,26 @@
+-- payroll_orders - заказы, оплаченные в закрытых периодах. Заказ оплачивается
+-- один раз, даже если после закрытия периода его собрали повторно.
+CREATE TABLE IF NOT EXISTS payroll_orders (
+	id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+	period_id BIGINT UNSIGNED,
+	order_uid BIGINT,
+	PRIMARY KEY (id),
+	INDEX idx_payroll_orders_period_id (period_id),
+	UNIQUE INDEX idx_payroll_orders_order_uid (order_uid),
+	CONSTRAINT fk_payroll_periods_orders FOREIGN KEY (period_id) REFERENCES payroll_periods (id)
+);
+
+-- Уже закрытые периоды получают заказы, первая сборка которых пришлась на период.
+INSERT INTO payroll_orders (period_id, order_uid)
+SELECT p.id, o.order_uid
+FROM orders o
+	LEFT JOIN (
+		SELECT order_uid, MIN(created_at) AS assembled_at
+		FROM order_events
+		WHERE to_status = 3 AND from_status <> 3
+		GROUP BY order_uid
+	) ev ON ev.order_uid = o.order_uid
+	JOIN payroll_periods p ON p.deleted_at IS NULL
+		AND LEAST(COALESCE(ev.assembled_at, o.finish_at), COALESCE(o.finish_at, ev.assembled_at)) >= p.start_dt
+		AND LEAST(COALESCE(ev.assembled_at, o.finish_at), COALESCE(o.finish_at, ev.assembled_at)) < DATE_ADD(p.finish_dt, INTERVAL 1 DAY)
+WHERE o.status IN (3, 4, 5) AND o.deleted_at IS NULL;
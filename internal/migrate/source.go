@@ -0,0 +1,134 @@
+package migrate
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var embedded embed.FS
+
+// Migration - версия схемы базы: скрипты применения и отката.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+	// Checksum - sha256 скрипта применения. Изменение уже примененной
+	// миграции обнаруживается по несовпадению с записью в schema_migrations.
+	Checksum string
+}
+
+// fileName - имя файла миграции: 0001_create_tables.up.sql или .down.sql.
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Embedded возвращает миграции, встроенные в программу.
+func Embedded() ([]Migration, error) {
+	return Load(embedded, "migrations")
+}
+
+// Load читает миграции из каталога dir. Файлы одной версии объединяются
+// в одну миграцию, скрипт применения обязателен, отката - нет.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		match := fileName.FindStringSubmatch(e.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: unexpected file name", e.Name())
+		}
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migration %s: invalid version", e.Name())
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[m.Version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d: different names %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s: up script is missing", m.Version, m.Name)
+		}
+		sum := sha256.Sum256([]byte(m.Up))
+		m.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return migrations, nil
+}
+
+// Statements разбивает скрипт на отдельные запросы: драйвер MySQL без
+// multiStatements выполняет только один запрос за раз. Запросы разделяются
+// точкой с запятой в конце строки; для тел процедур разделитель меняется
+// директивой DELIMITER, как в клиенте mysql. Строки комментариев "--" пропускаются.
+func Statements(script string) ([]string, error) {
+	var statements []string
+	var buf strings.Builder
+	delimiter := ";"
+
+	flush := func() {
+		if s := strings.TrimSpace(buf.String()); s != "" {
+			statements = append(statements, s)
+		}
+		buf.Reset()
+	}
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		if fields := strings.Fields(trimmed); len(fields) > 0 && strings.EqualFold(fields[0], "DELIMITER") {
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("invalid delimiter directive %q", trimmed)
+			}
+			flush()
+			delimiter = fields[1]
+			continue
+		}
+
+		if strings.HasSuffix(trimmed, delimiter) {
+			buf.WriteString(strings.TrimSuffix(strings.TrimRight(line, " \t\r"), delimiter))
+			flush()
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	flush()
+	return statements, nil
+}
@@ -0,0 +1,51 @@
+package model
+
+type BatchStatus string
+
+const (
+	BatchOK     BatchStatus = "ok"
+	BatchFailed BatchStatus = "failed"
+	// BatchRolledBack - элемент обработан без ошибок, но отменен вместе
+	// со всем атомарным пакетом из-за ошибки в другом элементе.
+	BatchRolledBack BatchStatus = "rolled_back"
+)
+
+// BatchResult - итог обработки одного элемента пакета. Item - сохраненная
+// запись или, если записи нет, элемент запроса.
+type BatchResult struct {
+	Index  int         `json:"index"`
+	Status BatchStatus `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Item   any         `json:"item,omitempty"`
+}
+
+// BatchReport - отчет о пакетной операции. В атомарном пакете элементы
+// фиксируются все вместе или не фиксируется ни один.
+type BatchReport struct {
+	Atomic    bool          `json:"atomic"`
+	Committed int           `json:"committed"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// Add добавляет итог элемента в отчет.
+func (r *BatchReport) Add(index int, item any, err error) {
+	result := BatchResult{Index: index, Status: BatchOK, Item: item}
+	if err != nil {
+		result.Status, result.Error = BatchFailed, err.Error()
+		r.Failed++
+	} else {
+		r.Committed++
+	}
+	r.Results = append(r.Results, result)
+}
+
+// RollBack отмечает успешные элементы отмененными после отката атомарного пакета.
+func (r *BatchReport) RollBack() {
+	for i := range r.Results {
+		if r.Results[i].Status == BatchOK {
+			r.Results[i].Status = BatchRolledBack
+		}
+	}
+	r.Committed = 0
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// DispatchStrategy - порядок выдачи заказов из очереди сборки.
+type DispatchStrategy string
+
+const (
+	// DispatchFIFO - первым выдается самый ранний заказ по дате заказа.
+	DispatchFIFO DispatchStrategy = "fifo"
+	// DispatchPriority - сначала срочные заказы, при равной срочности - с более
+	// ранней обещанной отгрузкой, затем ранние.
+	DispatchPriority DispatchStrategy = "priority"
+	// DispatchClient - сначала заказы клиента, которого сборщик собирал последним,
+	// чтобы заказы одного клиента собирались подряд.
+	DispatchClient DispatchStrategy = "client"
+	// DispatchRoute - сначала заказы водителя последнего собранного заказа,
+	// чтобы машина комплектовалась одним сборщиком.
+	DispatchRoute DispatchStrategy = "route"
+)
+
+// ParseDispatchStrategy разбирает имя стратегии; пустое имя - FIFO.
+func ParseDispatchStrategy(name string) (DispatchStrategy, error) {
+	switch s := DispatchStrategy(name); s {
+	case "":
+		return DispatchFIFO, nil
+	case DispatchFIFO, DispatchPriority, DispatchClient, DispatchRoute:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown dispatch strategy %q", name)
+}
+
+// DispatchRequest - запрос следующего заказа из очереди сборки. Очередь - новые
+// заказы без сборщика по проектам, доступным пользователю.
+type DispatchRequest struct {
+	Strategy   DispatchStrategy `json:"strategy" form:"strategy"`
+	EmployeeID uint             `json:"employee_id" form:"employee_id"`
+	TeamID     uint             `json:"team_id" form:"team_id"`
+	// VidDoc сужает очередь до одного проекта из доступных пользователю
+	VidDoc string `json:"vid_doc" form:"vid_doc"`
+	// Limit - размер просматриваемой очереди, для выдачи заказа не используется
+	Limit int `json:"-" form:"limit"`
+	// UserID - сборщик, получающий заказ; заполняется сервером.
+	UserID uint `json:"-" form:"-"`
+}
+
+// Normalize подставляет стратегию по умолчанию и размер очереди.
+func (r *DispatchRequest) Normalize(strategy DispatchStrategy) (err error) {
+	if r.Strategy == "" {
+		r.Strategy = strategy
+	}
+	if r.Strategy, err = ParseDispatchStrategy(string(r.Strategy)); err != nil {
+		return err
+	}
+	if r.Limit == 0 {
+		r.Limit = DefaultListLimit
+	}
+	if r.Limit < 0 || r.Limit > MaxListLimit {
+		return errors.New("limit must be between 1 and " + strconv.Itoa(MaxListLimit))
+	}
+	return nil
+}
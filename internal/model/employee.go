@@ -1,6 +1,10 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
 
 type Employee struct {
 	gorm.Model
@@ -17,3 +21,8 @@ type Employee struct {
 func (Employee) TableName() string {
 	return "employees"
 }
+
+// FullName - фамилия, имя и отчество через пробел, как в отчетах по сборке.
+func (e Employee) FullName() string {
+	return strings.Join(strings.Fields(e.FirstName+" "+e.Name+" "+e.LastName), " ")
+}
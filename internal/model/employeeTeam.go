@@ -6,6 +6,9 @@ type EmployeeTeam struct {
 	gorm.Model
 	TeamID     uint `gorm:"primaryKey;autoIncrement:false" json:"team_id"`
 	EmployeeID uint `gorm:"primaryKey;autoIncrement:false" json:"employee_id"`
+	// Share - доля сотрудника в оплате совместно собранных командой заказов.
+	// Если доли всех участников нулевые, оплата делится поровну.
+	Share float64 `gorm:"column:share;not null;default:0" json:"share"`
 }
 
 func (EmployeeTeam) TableName() string {
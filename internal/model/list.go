@@ -0,0 +1,204 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultListLimit = 100
+	MaxListLimit     = 1000
+)
+
+// ListQuery - параметры постраничного списка. Страницы задаются либо курсором
+// (limit и cursor из next_cursor предыдущего ответа), либо номером (page и size).
+// Сортировка по одному полю, "-" перед именем - по убыванию.
+type ListQuery struct {
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+	Page   int    `form:"page"`
+	Size   int    `form:"size"`
+	Sort   string `form:"sort"`
+	// Filters - значения фильтров из строки запроса, несколько значений через запятую
+	Filters map[string]string `form:"-"`
+}
+
+// ListField - поле, по которому разрешены сортировка или фильтр.
+type ListField struct {
+	Column string
+	// Parse приводит значение фильтра к типу колонки; nil - строка как есть
+	Parse func(string) (any, error)
+}
+
+// ListSpec - белый список полей сортировки и фильтров для списка.
+type ListSpec struct {
+	Sort        map[string]ListField
+	Filters     map[string]ListField
+	DefaultSort string
+}
+
+// ListCondition - фильтр по колонке, несколько значений объединяются через IN.
+type ListCondition struct {
+	Column string
+	Values []any
+}
+
+// Page - единый конверт ответа для списков.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Page       int    `json:"page,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Normalize проверяет параметры по спецификации списка и подставляет значения по умолчанию.
+func (q *ListQuery) Normalize(spec ListSpec) error {
+	if q.Limit == 0 {
+		q.Limit = q.Size
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultListLimit
+	}
+	if q.Limit < 0 || q.Limit > MaxListLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxListLimit)
+	}
+	if q.Page < 0 {
+		return fmt.Errorf("page must be positive")
+	}
+	if q.Page > 0 && q.Cursor != "" {
+		return fmt.Errorf("page and cursor can not be used together")
+	}
+	if q.Sort == "" {
+		q.Sort = spec.DefaultSort
+	}
+	if _, _, err := q.Order(spec); err != nil {
+		return err
+	}
+	_, err := q.Conditions(spec)
+	return err
+}
+
+// Order возвращает колонку и направление сортировки.
+func (q ListQuery) Order(spec ListSpec) (column string, desc bool, err error) {
+	name := strings.TrimPrefix(q.Sort, "-")
+	field, ok := spec.Sort[name]
+	if !ok {
+		return "", false, fmt.Errorf("unknown sort field %q", name)
+	}
+	return field.Column, name != q.Sort, nil
+}
+
+// Conditions возвращает фильтры списка с приведенными к типам колонок значениями.
+func (q ListQuery) Conditions(spec ListSpec) ([]ListCondition, error) {
+	var conds []ListCondition
+	for name, raw := range q.Filters {
+		field, ok := spec.Filters[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter %q", name)
+		}
+
+		cond := ListCondition{Column: field.Column}
+		for _, s := range strings.Split(raw, ",") {
+			var v any = strings.TrimSpace(s)
+			if field.Parse != nil {
+				var err error
+				if v, err = field.Parse(v.(string)); err != nil {
+					return nil, fmt.Errorf("filter %s: %w", name, err)
+				}
+			}
+			cond.Values = append(cond.Values, v)
+		}
+		conds = append(conds, cond)
+	}
+	return conds, nil
+}
+
+func ParseBoolFilter(s string) (any, error) {
+	return strconv.ParseBool(s)
+}
+
+func ParseIntFilter(s string) (any, error) {
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func ParseOrderStatusFilter(s string) (any, error) {
+	status, err := ParseOrderStatus(s)
+	return int(status), err
+}
+
+func listFields(parse func(string) (any, error), columns ...string) map[string]ListField {
+	fields := make(map[string]ListField, len(columns))
+	for _, c := range columns {
+		fields[c] = ListField{Column: c, Parse: parse}
+	}
+	return fields
+}
+
+func mergeListFields(sets ...map[string]ListField) map[string]ListField {
+	fields := make(map[string]ListField)
+	for _, set := range sets {
+		for name, f := range set {
+			fields[name] = f
+		}
+	}
+	return fields
+}
+
+var (
+	OrderListSpec = ListSpec{
+		Sort: listFields(nil, "id", "order_uid", "folio_num", "folio_date", "order_date", "order_sum",
+			"client_name", "vid_doc", "status", "priority", "created_at", "updated_at"),
+		Filters: mergeListFields(
+			listFields(nil, "vid_doc"),
+			listFields(ParseIntFilter, "client_id", "user_id", "employee_id", "team_id"),
+			listFields(ParseBoolFilter, "done", "check", "shortage"),
+			map[string]ListField{"status": {Column: "status", Parse: ParseOrderStatusFilter}},
+		),
+		DefaultSort: "id",
+	}
+	UserListSpec = ListSpec{
+		Sort:        listFields(nil, "id", "first_name", "name", "last_name", "email", "created_at"),
+		Filters:     mergeListFields(listFields(nil, "email"), listFields(ParseBoolFilter, "blocked")),
+		DefaultSort: "id",
+	}
+	EmployeeListSpec = ListSpec{
+		Sort:        listFields(nil, "id", "code", "first_name", "name", "last_name", "created_at"),
+		Filters:     listFields(nil, "code", "inn"),
+		DefaultSort: "first_name",
+	}
+	TeamListSpec = ListSpec{
+		Sort:        listFields(nil, "id", "name", "created_at"),
+		DefaultSort: "name",
+	}
+	ProjectListSpec = ListSpec{
+		Sort:        listFields(nil, "id", "name", "vid_doc", "created_at"),
+		Filters:     listFields(nil, "vid_doc"),
+		DefaultSort: "name",
+	}
+	RoleListSpec = ListSpec{
+		Sort:        listFields(nil, "id", "name", "priority", "created_at"),
+		DefaultSort: "id",
+	}
+	UserProjectListSpec = ListSpec{
+		Sort:        listFields(nil, "id", "created_at"),
+		Filters:     listFields(ParseIntFilter, "user_id", "project_id"),
+		DefaultSort: "id",
+	}
+	UserRoleListSpec = ListSpec{
+		Sort:        listFields(nil, "id", "created_at"),
+		Filters:     listFields(ParseIntFilter, "user_id", "role_id"),
+		DefaultSort: "id",
+	}
+	UserTeamListSpec = ListSpec{
+		Sort:        listFields(nil, "id", "created_at"),
+		Filters:     listFields(ParseIntFilter, "user_id", "team_id"),
+		DefaultSort: "id",
+	}
+	EmployeeTeamListSpec = ListSpec{
+		Sort:        listFields(nil, "id", "created_at"),
+		Filters:     listFields(ParseIntFilter, "employee_id", "team_id"),
+		DefaultSort: "id",
+	}
+)
@@ -1,6 +1,9 @@
 package model
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
 	"time"
 
 	"gorm.io/gorm"
@@ -8,49 +11,181 @@ import (
 
 type Order struct {
 	gorm.Model
-	OrderUid      int     `gorm:"column:order_uid;not null;unique" json:"order_uid"`
-	UnicumNum     int     `gorm:"column:unicum_num" json:"unicum_num"`
-	FolioNum      int     `gorm:"column:folio_num" json:"folio_num"`
-	FolioDate     string  `gorm:"column:folio_date" json:"folio_date"`
-	OrderDate     string  `gorm:"column:order_date;size:19" json:"order_date"`
-	OrderSum      float64 `gorm:"column:order_sum" json:"order_sum"`
-	FolioSum      float64 `gorm:"column:folio_sum" json:"folio_sum"`
-	Driver        string  `gorm:"column:driver;size:100" json:"driver"`
-	Agent         string  `gorm:"column:agent;size:100" json:"agent"`
-	Brieforg      string  `gorm:"column:brieforg;size:20" json:"brieforg"`
-	ClientId      int     `gorm:"column:client_id" json:"client_id"`
-	ClientName    string  `gorm:"column:client_name;size:120" json:"client_name"`
-	ClientAddress string  `gorm:"column:client_address;size:150" json:"client_address"`
-	VidDoc        string  `gorm:"column:vid_doc;size:100" json:"vid_doc"`
-	StartAt       string  `gorm:"column:start_at" json:"start_at"`
-	FinishAt      string  `gorm:"column:finish_at" json:"finish_at"`
-	Done          bool    `gorm:"column:done" json:"done"`
-	Status        int     `gorm:"column:status" json:"status"`
-	UserID        uint    `gorm:"column:user_id" json:"user_id"`
-	EmployeeID    uint    `gorm:"column:employee_id" json:"employee_id"`
-	Check         bool    `gorm:"column:check" json:"check"`
+	OrderUid      int         `gorm:"column:order_uid;not null;unique" json:"order_uid"`
+	UnicumNum     int         `gorm:"column:unicum_num" json:"unicum_num"`
+	FolioNum      int         `gorm:"column:folio_num" json:"folio_num"`
+	FolioDate     time.Time   `gorm:"column:folio_date;index" json:"folio_date"`
+	OrderDate     time.Time   `gorm:"column:order_date;index" json:"order_date"`
+	OrderSum      float64     `gorm:"column:order_sum" json:"order_sum"`
+	FolioSum      float64     `gorm:"column:folio_sum" json:"folio_sum"`
+	Driver        string      `gorm:"column:driver;size:100" json:"driver"`
+	Agent         string      `gorm:"column:agent;size:100" json:"agent"`
+	Brieforg      string      `gorm:"column:brieforg;size:20" json:"brieforg"`
+	ClientId      int         `gorm:"column:client_id" json:"client_id"`
+	ClientName    string      `gorm:"column:client_name;size:120" json:"client_name"`
+	ClientAddress string      `gorm:"column:client_address;size:150" json:"client_address"`
+	VidDoc        string      `gorm:"column:vid_doc;size:100" json:"vid_doc"`
+	StartAt       *time.Time  `gorm:"column:start_at" json:"start_at"`
+	FinishAt      *time.Time  `gorm:"column:finish_at" json:"finish_at"`
+	Done          bool        `gorm:"column:done" json:"done"`
+	Status        OrderStatus `gorm:"column:status;not null;default:0;index" json:"status"`
+	UserID        uint        `gorm:"column:user_id" json:"user_id"`
+	EmployeeID    uint        `gorm:"column:employee_id" json:"employee_id"`
+	TeamID        uint        `gorm:"column:team_id" json:"team_id"`
+	Check         bool        `gorm:"column:check" json:"check"`
+	CheckUserID   uint        `gorm:"column:check_user_id" json:"check_user_id"`
+	// Shortage - заказ собран с недостачей по строкам
+	Shortage bool `gorm:"column:shortage" json:"shortage"`
+	// PausedAt - начало текущей паузы, PausedSeconds - сумма завершенных пауз,
+	// WorkSeconds - чистое время сборки, фиксируется при ее окончании
+	PausedAt      *time.Time `gorm:"column:paused_at" json:"paused_at,omitempty"`
+	PausedSeconds int64      `gorm:"column:paused_seconds;not null;default:0" json:"paused_seconds"`
+	WorkSeconds   int64      `gorm:"column:work_seconds;not null;default:0" json:"work_seconds"`
+	// Priority - срочность заказа из ERP, больший приоритет собирается раньше
+	Priority int `gorm:"column:priority;not null;default:0" json:"priority"`
+	// ShipBy - обещанное клиенту время отгрузки из ERP
+	ShipBy *time.Time `gorm:"column:ship_by;index" json:"ship_by"`
+	// SLA - состояние норматива сборки, вычисляется при чтении заказа
+	SLA *OrderSLA `gorm:"-" json:"sla,omitempty"`
+	// Lines заполняется только при загрузке заказа из ERP
+	Lines []OrderLine `gorm:"-" json:"lines,omitempty"`
 }
 
+// AssemblyOrder - строка отчета о сборке. Длительность сборки - чистое время
+// между отметками начала и окончания, для заказов без отметок - время от
+// загрузки заказа до перевода в статус "собран".
 type AssemblyOrder struct {
-	OrderUid        int     `gorm:"column:order_uid;not null;unique" json:"order_uid"`
-	OrderDate       string  `gorm:"column:order_date;size:19" json:"order_date"`
-	OrderSum        float32 `gorm:"column:order_sum" json:"order_sum"`
-	FolioNum        int     `gorm:"column:folio_num" json:"folio_num"`
-	FolioDate       string  `gorm:"column:folio_date" json:"folio_date"`
-	UnicumNum       int     `gorm:"column:unicum_num" json:"unicum_num"`
-	FolioSum        float64 `gorm:"column:folio_sum" json:"folio_sum"`
-	UserID          uint    `gorm:"column:user_id" json:"user_id"`
-	EmployeeID      uint    `gorm:"column:employee_id" json:"employee_id"`
-	CreatedAt       time.Time
-	AssemblyDate    string `gorm:"column:assembly_date;size:19" json:"assembly_date"`
-	DateDiffMinutes string `gorm:"column:date_diff_minutes;size:19" json:"date_diff_minutes"`
-	DateDiffHours   string `gorm:"column:date_diff_hours;size:19" json:"date_diff_hours"`
-	UserName        string `gorm:"column:user_name" json:"user_name"`
-	EmployeeName    string `gorm:"column:employee_name" json:"employee_name"`
-	ClientName      string `gorm:"column:client_name;size:120" json:"client_name"`
-	VidDoc          string `gorm:"column:vid_doc;size:100" json:"vid_doc"`
+	OrderUid        int         `gorm:"column:order_uid" json:"order_uid"`
+	OrderDate       time.Time   `gorm:"column:order_date" json:"order_date"`
+	OrderSum        float64     `gorm:"column:order_sum" json:"order_sum"`
+	FolioNum        int         `gorm:"column:folio_num" json:"folio_num"`
+	FolioDate       time.Time   `gorm:"column:folio_date" json:"folio_date"`
+	UnicumNum       int         `gorm:"column:unicum_num" json:"unicum_num"`
+	FolioSum        float64     `gorm:"column:folio_sum" json:"folio_sum"`
+	Status          OrderStatus `gorm:"column:status" json:"status"`
+	UserID          uint        `gorm:"column:user_id" json:"user_id"`
+	EmployeeID      uint        `gorm:"column:employee_id" json:"employee_id"`
+	TeamID          uint        `gorm:"column:team_id" json:"team_id"`
+	LineCount       int         `gorm:"column:line_count" json:"line_count"`
+	CreatedAt       time.Time   `gorm:"column:created_at" json:"created_at"`
+	AssemblyDate    time.Time   `gorm:"column:assembly_date" json:"assembly_date"`
+	DurationMinutes float64     `gorm:"-" json:"duration_minutes"`
+	DurationHours   float64     `gorm:"-" json:"duration_hours"`
+	UserName        string      `gorm:"column:user_name" json:"user_name"`
+	EmployeeName    string      `gorm:"column:employee_name" json:"employee_name"`
+	ClientName      string      `gorm:"column:client_name" json:"client_name"`
+	VidDoc          string      `gorm:"column:vid_doc" json:"vid_doc"`
+	// Недостачи по строкам заказа заполняются отдельно от основного запроса
+	Shortage      bool        `gorm:"-" json:"shortage"`
+	ShortageLines []OrderLine `gorm:"-" json:"shortage_lines,omitempty"`
+	// Отметки начала и окончания сборки; Timed - длительность посчитана
+	// по чистому времени работы, а не от загрузки заказа
+	StartAt     *time.Time `gorm:"column:start_at" json:"start_at,omitempty"`
+	FinishAt    *time.Time `gorm:"column:finish_at" json:"finish_at,omitempty"`
+	WorkSeconds int64      `gorm:"column:work_seconds" json:"work_seconds"`
+	Timed       bool       `gorm:"-" json:"timed"`
+}
+
+// SetDuration вычисляет длительность сборки в минутах и часах. Если сборка
+// отмечена началом и окончанием, берется чистое время без пауз, иначе -
+// время от загрузки заказа до сборки.
+func (a *AssemblyOrder) SetDuration() {
+	d := a.AssemblyDate.Sub(a.CreatedAt)
+	a.Timed = a.WorkSeconds > 0
+	if a.Timed {
+		d = time.Duration(a.WorkSeconds) * time.Second
+	}
+	if d < 0 {
+		d = 0
+	}
+	a.DurationMinutes = math.Round(d.Minutes())
+	a.DurationHours = math.Round(d.Hours()*100) / 100
+}
+
+// AssemblyFilter - параметры отчета о сборке. Период задается по дате накладной,
+// нулевые значения остальных полей фильтр не ограничивают.
+type AssemblyFilter struct {
+	StartDT    string `form:"start_dt" json:"start_dt"`
+	FinishDT   string `form:"finish_dt" json:"finish_dt"`
+	VidDoc     string `form:"vid_doc" json:"vid_doc"`
+	TeamID     uint   `form:"team_id" json:"team_id"`
+	EmployeeID uint   `form:"employee_id" json:"employee_id"`
+	UserID     uint   `form:"user_id" json:"user_id"`
+	// ByAssemblyDate - период задается по времени окончания сборки: отметке
+	// окончания, без нее - переводу в "собран" по журналу. Используется расчетом
+	// оплаты, чтобы заказ оплачивался в периоде, когда его собрали.
+	ByAssemblyDate bool `form:"-" json:"-"`
+}
+
+func (f AssemblyFilter) DateRange() (DateRange, error) {
+	return ParseDateRange(f.StartDT, f.FinishDT)
 }
 
 func (Order) TableName() string {
 	return "orders"
 }
+
+// UnmarshalJSON принимает даты заказа в форматах ParseTime.
+func (o *Order) UnmarshalJSON(data []byte) error {
+	type order Order
+	aux := struct {
+		*order
+		FolioDate string `json:"folio_date"`
+		OrderDate string `json:"order_date"`
+		StartAt   string `json:"start_at"`
+		FinishAt  string `json:"finish_at"`
+		ShipBy    string `json:"ship_by"`
+	}{order: (*order)(o)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	for _, d := range []struct {
+		name  string
+		value string
+		dst   *time.Time
+	}{
+		{"folio_date", aux.FolioDate, &o.FolioDate},
+		{"order_date", aux.OrderDate, &o.OrderDate},
+	} {
+		if d.value == "" {
+			continue
+		}
+		if *d.dst, err = ParseTime(d.value); err != nil {
+			return fmt.Errorf("%s: %w", d.name, err)
+		}
+	}
+	for _, d := range []struct {
+		name  string
+		value string
+		dst   **time.Time
+	}{
+		{"start_at", aux.StartAt, &o.StartAt},
+		{"finish_at", aux.FinishAt, &o.FinishAt},
+		{"ship_by", aux.ShipBy, &o.ShipBy},
+	} {
+		*d.dst = nil
+		if d.value == "" {
+			continue
+		}
+		t, err := ParseTime(d.value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", d.name, err)
+		}
+		*d.dst = &t
+	}
+	return nil
+}
+
+// AfterFind переводит даты заказа в часовой пояс склада.
+func (o *Order) AfterFind(*gorm.DB) error {
+	o.FolioDate = o.FolioDate.In(Location)
+	o.OrderDate = o.OrderDate.In(Location)
+	for _, t := range []*time.Time{o.StartAt, o.FinishAt, o.PausedAt, o.ShipBy} {
+		if t != nil {
+			*t = t.In(Location)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+type OrderEventType string
+
+const (
+	OrderEventImport OrderEventType = "import"
+	OrderEventAssign OrderEventType = "assign"
+	OrderEventCheck  OrderEventType = "check"
+	OrderEventPick   OrderEventType = "pick"
+	OrderEventStatus OrderEventType = "status"
+
+	OrderEventWorkStart  OrderEventType = "work_start"
+	OrderEventWorkPause  OrderEventType = "work_pause"
+	OrderEventWorkResume OrderEventType = "work_resume"
+	OrderEventWorkFinish OrderEventType = "work_finish"
+)
+
+// OrderEvent - запись журнала изменений заказа. Журнал только пополняется:
+// записи не изменяются и не удаляются, поэтому у модели нет UpdatedAt и DeletedAt.
+type OrderEvent struct {
+	ID          uint           `gorm:"primarykey" json:"id"`
+	OrderUID    uint           `gorm:"column:order_uid;not null;index" json:"order_uid"`
+	Type        OrderEventType `gorm:"column:type;size:20;not null" json:"type"`
+	FromStatus  OrderStatus    `gorm:"column:from_status" json:"from_status"`
+	ToStatus    OrderStatus    `gorm:"column:to_status" json:"to_status"`
+	ActorUserID uint           `gorm:"column:actor_user_id;index" json:"actor_user_id"`
+	EmployeeID  uint           `gorm:"column:employee_id" json:"employee_id"`
+	Before      string         `gorm:"column:before;type:text" json:"before"`
+	After       string         `gorm:"column:after;type:text" json:"after"`
+	CreatedAt   time.Time      `gorm:"column:created_at;index" json:"created_at"`
+}
+
+func (OrderEvent) TableName() string {
+	return "order_events"
+}
+
+// orderEventFields - индексы полей заказа, отслеживаемых в журнале: колонок
+// ERP, чтобы повторная загрузка показывала изменения из учетной системы,
+// и колонок состояния сборки.
+var orderEventFields = func() map[string]int {
+	byColumn := make(map[string]int)
+	typ := reflect.TypeOf(Order{})
+	for i := 0; i < typ.NumField(); i++ {
+		for _, setting := range strings.Split(typ.Field(i).Tag.Get("gorm"), ";") {
+			if column, ok := strings.CutPrefix(setting, "column:"); ok {
+				byColumn[column] = i
+			}
+		}
+	}
+
+	fields := make(map[string]int)
+	for _, column := range append(slices.Clone(OrderERPColumns), OrderStateColumns...) {
+		i, ok := byColumn[column]
+		if !ok {
+			panic(fmt.Sprintf("order has no column %q", column))
+		}
+		fields[column] = i
+	}
+	return fields
+}()
+
+func eventValues(o *Order) string {
+	if o == nil {
+		return ""
+	}
+	v := reflect.ValueOf(*o)
+	values := make(map[string]any, len(orderEventFields))
+	for column, i := range orderEventFields {
+		values[column] = v.Field(i).Interface()
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+// NewOrderEvent фиксирует изменение заказа от before к after.
+// before равен nil, если заказ только что создан.
+func NewOrderEvent(typ OrderEventType, actorUserID uint, before *Order, after Order) OrderEvent {
+	event := OrderEvent{
+		OrderUID:    uint(after.OrderUid),
+		Type:        typ,
+		FromStatus:  after.Status,
+		ToStatus:    after.Status,
+		ActorUserID: actorUserID,
+		EmployeeID:  after.EmployeeID,
+		Before:      eventValues(before),
+		After:       eventValues(&after),
+	}
+	if before != nil {
+		event.FromStatus = before.Status
+	}
+	return event
+}
+
+// EventType возвращает тип события журнала для перехода.
+func (t OrderTransition) EventType(from OrderStatus) OrderEventType {
+	switch {
+	case t.Status == OrderStatusAssigned:
+		return OrderEventAssign
+	case t.Status == OrderStatusChecked, from == OrderStatusChecked && t.Status == OrderStatusAssembled:
+		return OrderEventCheck
+	default:
+		return OrderEventStatus
+	}
+}
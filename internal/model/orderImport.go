@@ -0,0 +1,106 @@
+package model
+
+import "time"
+
+type OrderImportStatus string
+
+const (
+	OrderImportCreated   OrderImportStatus = "created"
+	OrderImportUpdated   OrderImportStatus = "updated"
+	OrderImportUnchanged OrderImportStatus = "unchanged"
+	OrderImportFailed    OrderImportStatus = "failed"
+	// OrderImportRolledBack - заказ отменен вместе с атомарной загрузкой
+	OrderImportRolledBack OrderImportStatus = "rolled_back"
+)
+
+// OrderImportResult - итог загрузки одного заказа из ERP.
+type OrderImportResult struct {
+	OrderUID int               `json:"order_uid"`
+	Status   OrderImportStatus `json:"status"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// ImportBatches загружает заказы pending пачками по size заказов функцией run.
+// Если пачка не загрузилась, ее заказы загружаются по одному, чтобы ошибку
+// получили только заказы, которые не удается сохранить, а не вся пачка.
+func ImportBatches(results []OrderImportResult, pending []int, size int, run func(batch []int) error) {
+	fail := func(i int, err error) {
+		results[i].Status = OrderImportFailed
+		results[i].Error = err.Error()
+	}
+
+	for start := 0; start < len(pending); start += size {
+		batch := pending[start:min(start+size, len(pending))]
+		err := run(batch)
+		if err == nil {
+			continue
+		}
+		if len(batch) == 1 {
+			fail(batch[0], err)
+			continue
+		}
+		for _, i := range batch {
+			if err := run([]int{i}); err != nil {
+				fail(i, err)
+			}
+		}
+	}
+}
+
+// OrderERPColumns - колонки заказа, которыми владеет ERP. Повторная загрузка
+// обновляет только их, не затрагивая сборщика, проверку и статус.
+var OrderERPColumns = []string{
+	"unicum_num", "folio_num", "folio_date", "order_date", "order_sum", "folio_sum",
+	"driver", "agent", "brieforg", "client_id", "client_name", "client_address", "vid_doc",
+	"priority", "ship_by",
+}
+
+// OrderStateColumns - колонки состояния сборки, которые меняют переходы статуса,
+// подтверждение строк и отметки времени.
+var OrderStateColumns = []string{"status", "done", "check", "user_id", "employee_id", "team_id", "check_user_id",
+	"shortage", "start_at", "finish_at", "paused_at", "paused_seconds", "work_seconds"}
+
+// ERPEqual сравнивает поля заказа, которыми владеет ERP.
+func (o Order) ERPEqual(other Order) bool {
+	return o.UnicumNum == other.UnicumNum &&
+		o.FolioNum == other.FolioNum &&
+		o.FolioDate.Equal(other.FolioDate) &&
+		o.OrderDate.Equal(other.OrderDate) &&
+		o.OrderSum == other.OrderSum &&
+		o.FolioSum == other.FolioSum &&
+		o.Driver == other.Driver &&
+		o.Agent == other.Agent &&
+		o.Brieforg == other.Brieforg &&
+		o.ClientId == other.ClientId &&
+		o.ClientName == other.ClientName &&
+		o.ClientAddress == other.ClientAddress &&
+		o.VidDoc == other.VidDoc &&
+		o.Priority == other.Priority &&
+		timeEqual(o.ShipBy, other.ShipBy)
+}
+
+func timeEqual(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// MergeERP переносит в заказ поля ERP из загруженной версии.
+func (o *Order) MergeERP(imported Order) {
+	o.UnicumNum = imported.UnicumNum
+	o.FolioNum = imported.FolioNum
+	o.FolioDate = imported.FolioDate
+	o.OrderDate = imported.OrderDate
+	o.OrderSum = imported.OrderSum
+	o.FolioSum = imported.FolioSum
+	o.Driver = imported.Driver
+	o.Agent = imported.Agent
+	o.Brieforg = imported.Brieforg
+	o.ClientId = imported.ClientId
+	o.ClientName = imported.ClientName
+	o.ClientAddress = imported.ClientAddress
+	o.VidDoc = imported.VidDoc
+	o.Priority = imported.Priority
+	o.ShipBy = imported.ShipBy
+}
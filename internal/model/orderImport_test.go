@@ -0,0 +1,39 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestImportBatches_RetriesFailedBatchOneByOne(t *testing.T) {
+	results := make([]OrderImportResult, 5)
+	var calls [][]int
+	ImportBatches(results, []int{0, 1, 2, 3, 4}, 3, func(batch []int) error {
+		calls = append(calls, slices.Clone(batch))
+		if slices.Contains(batch, 1) {
+			return errors.New("data too long")
+		}
+		for _, i := range batch {
+			results[i].Status = OrderImportCreated
+		}
+		return nil
+	})
+
+	want := [][]int{{0, 1, 2}, {0}, {1}, {2}, {3, 4}}
+	if !slices.EqualFunc(calls, want, slices.Equal[[]int]) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for i, r := range results {
+		status := OrderImportCreated
+		if i == 1 {
+			status = OrderImportFailed
+		}
+		if r.Status != status {
+			t.Fatalf("order %d: status %s, want %s", i, r.Status, status)
+		}
+	}
+	if results[1].Error != "data too long" {
+		t.Fatalf("unexpected error %q", results[1].Error)
+	}
+}
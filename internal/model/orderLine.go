@@ -0,0 +1,238 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// OrderLine - строка заказа: что и откуда собрать.
+type OrderLine struct {
+	gorm.Model
+	OrderUID       uint    `gorm:"column:order_uid;not null;uniqueIndex:idx_order_line" json:"order_uid"`
+	LineNo         int     `gorm:"column:line_no;not null;uniqueIndex:idx_order_line" json:"line_no"`
+	ProductCode    string  `gorm:"column:product_code;size:50;index" json:"product_code"`
+	ProductName    string  `gorm:"column:product_name;size:200" json:"product_name"`
+	Barcode        string  `gorm:"column:barcode;size:20;index" json:"barcode"`
+	Unit           string  `gorm:"column:unit;size:20" json:"unit"`
+	Quantity       float64 `gorm:"column:quantity" json:"quantity"`
+	PickedQuantity float64 `gorm:"column:picked_quantity" json:"picked_quantity"`
+	Location       string  `gorm:"column:location;size:50;index" json:"location"`
+	// Picked - строка подтверждена сборщиком, в том числе с недостачей
+	Picked         bool           `gorm:"column:picked" json:"picked"`
+	ShortageReason ShortageReason `gorm:"column:shortage_reason;size:20" json:"shortage_reason,omitempty"`
+}
+
+// Shortage - количество, которое не удалось собрать.
+func (l OrderLine) Shortage() float64 {
+	if !l.Picked || l.PickedQuantity >= l.Quantity {
+		return 0
+	}
+	return l.Quantity - l.PickedQuantity
+}
+
+type ShortageReason string
+
+const (
+	ShortageOutOfStock    ShortageReason = "out_of_stock"
+	ShortageDamaged       ShortageReason = "damaged"
+	ShortageWrongLocation ShortageReason = "wrong_location"
+)
+
+func (r ShortageReason) Valid() bool {
+	switch r {
+	case ShortageOutOfStock, ShortageDamaged, ShortageWrongLocation:
+		return true
+	}
+	return false
+}
+
+// PickConfirmation - подтверждение сборщиком собранного количества по строкам заказа.
+type PickConfirmation struct {
+	OrderUID   uint       `json:"order_uid" binding:"required"`
+	UserID     uint       `json:"user_id"`
+	EmployeeID uint       `json:"employee_id"`
+	Lines      []LinePick `json:"lines" binding:"required,min=1"`
+	ActorID    uint       `json:"-"`
+}
+
+type LinePick struct {
+	LineNo         int            `json:"line_no"`
+	PickedQuantity float64        `json:"picked_quantity"`
+	ShortageReason ShortageReason `json:"shortage_reason"`
+}
+
+// Apply отмечает строки собранными. Недостача требует указания причины,
+// собрать больше заказанного нельзя.
+func (p PickConfirmation) Apply(lines []OrderLine) ([]OrderLine, error) {
+	byNo := make(map[int]int, len(lines))
+	for i, l := range lines {
+		byNo[l.LineNo] = i
+	}
+
+	var changed []OrderLine
+	for _, pick := range p.Lines {
+		i, ok := byNo[pick.LineNo]
+		if !ok {
+			return nil, fmt.Errorf("line %d not found", pick.LineNo)
+		}
+		line := &lines[i]
+
+		switch {
+		case pick.PickedQuantity < 0:
+			return nil, fmt.Errorf("line %d: picked quantity is negative", pick.LineNo)
+		case pick.PickedQuantity > line.Quantity:
+			return nil, fmt.Errorf("line %d: picked quantity exceeds ordered %g", pick.LineNo, line.Quantity)
+		case pick.PickedQuantity < line.Quantity && !pick.ShortageReason.Valid():
+			return nil, fmt.Errorf("line %d: shortage reason %q is invalid", pick.LineNo, pick.ShortageReason)
+		}
+
+		line.PickedQuantity = pick.PickedQuantity
+		line.Picked = true
+		line.ShortageReason = ""
+		if pick.PickedQuantity < line.Quantity {
+			line.ShortageReason = pick.ShortageReason
+		}
+		changed = append(changed, *line)
+	}
+	return changed, nil
+}
+
+var errNoLines = errors.New("order has no lines")
+
+// PickRollup возвращает, все ли строки подтверждены и есть ли среди них недостачи.
+func PickRollup(lines []OrderLine) (complete, shortage bool, err error) {
+	if len(lines) == 0 {
+		return false, false, errNoLines
+	}
+
+	complete = true
+	for _, l := range lines {
+		if !l.Picked {
+			complete = false
+		}
+		if l.Shortage() > 0 {
+			shortage = true
+		}
+	}
+	return complete, shortage, nil
+}
+
+func (OrderLine) TableName() string {
+	return "order_lines"
+}
+
+// OrderLineERPColumns - колонки строки, которыми владеет ERP.
+// Собранное количество при повторной загрузке сохраняется.
+var OrderLineERPColumns = []string{"product_code", "product_name", "barcode", "unit", "quantity", "location"}
+
+func (l OrderLine) erpEqual(other OrderLine) bool {
+	return l.ProductCode == other.ProductCode &&
+		l.ProductName == other.ProductName &&
+		l.Barcode == other.Barcode &&
+		l.Unit == other.Unit &&
+		l.Quantity == other.Quantity &&
+		l.Location == other.Location
+}
+
+// number возвращает номер строки; строке без номера присваивается ее
+// порядковый номер i+1 в загрузке.
+func (l OrderLine) number(i int) int {
+	if l.LineNo == 0 {
+		return i + 1
+	}
+	return l.LineNo
+}
+
+// ValidateOrderLines проверяет, что номера строк загружаемого заказа не
+// повторяются, в том числе порядковые номера строк без номера.
+func ValidateOrderLines(lines []OrderLine) error {
+	seen := make(map[int]bool, len(lines))
+	for i, l := range lines {
+		no := l.number(i)
+		if seen[no] {
+			return fmt.Errorf("duplicate line_no %d", no)
+		}
+		seen[no] = true
+	}
+	return nil
+}
+
+// MergeOrderLines сопоставляет строки заказа по номеру строки. Возвращает строки
+// для записи (новые и измененные), ID удаленных из ERP строк и признак изменений.
+// Строкам без номера присваивается порядковый номер в пакете. Номера строк
+// должны быть проверены ValidateOrderLines.
+func MergeOrderLines(orderUID uint, current, imported []OrderLine) (rows []OrderLine, removed []uint, changed bool) {
+	byNo := make(map[int]OrderLine, len(current))
+	for _, l := range current {
+		byNo[l.LineNo] = l
+	}
+
+	seen := make(map[int]bool, len(imported))
+	for i, l := range imported {
+		l.LineNo = l.number(i)
+		seen[l.LineNo] = true
+
+		existing, found := byNo[l.LineNo]
+		if found && existing.erpEqual(l) {
+			continue
+		}
+
+		row := OrderLine{OrderUID: orderUID, LineNo: l.LineNo}
+		if found {
+			row = existing
+		}
+		row.ProductCode = l.ProductCode
+		row.ProductName = l.ProductName
+		row.Barcode = l.Barcode
+		row.Unit = l.Unit
+		row.Quantity = l.Quantity
+		row.Location = l.Location
+		rows = append(rows, row)
+	}
+
+	for _, l := range current {
+		if !seen[l.LineNo] {
+			removed = append(removed, l.ID)
+		}
+	}
+
+	return rows, removed, len(rows) > 0 || len(removed) > 0
+}
+
+// PickList - лист подбора заказа, сгруппированный по адресам хранения.
+type PickList struct {
+	OrderUID  uint               `json:"order_uid"`
+	Locations []PickListLocation `json:"locations"`
+}
+
+type PickListLocation struct {
+	Location string      `json:"location"`
+	Lines    []OrderLine `json:"lines"`
+}
+
+// NewPickList группирует строки по адресу хранения в порядке обхода склада:
+// адреса и товары внутри адреса сортируются по возрастанию.
+func NewPickList(orderUID uint, lines []OrderLine) PickList {
+	sorted := make([]OrderLine, len(lines))
+	copy(sorted, lines)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Location != sorted[j].Location {
+			return sorted[i].Location < sorted[j].Location
+		}
+		return sorted[i].ProductName < sorted[j].ProductName
+	})
+
+	list := PickList{OrderUID: orderUID, Locations: []PickListLocation{}}
+	for _, l := range sorted {
+		n := len(list.Locations)
+		if n == 0 || list.Locations[n-1].Location != l.Location {
+			list.Locations = append(list.Locations, PickListLocation{Location: l.Location})
+			n++
+		}
+		list.Locations[n-1].Lines = append(list.Locations[n-1].Lines, l)
+	}
+	return list
+}
@@ -0,0 +1,37 @@
+package model
+
+import "testing"
+
+func TestValidateOrderLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []OrderLine
+		ok    bool
+	}{
+		{"numbered", []OrderLine{{LineNo: 1}, {LineNo: 5}}, true},
+		{"implicit", []OrderLine{{}, {}, {}}, true},
+		{"duplicate", []OrderLine{{LineNo: 3}, {LineNo: 3}}, false},
+		{"implicit clashes with explicit", []OrderLine{{LineNo: 2}, {}}, false},
+		{"explicit clashes with implicit", []OrderLine{{}, {LineNo: 1}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateOrderLines(tt.lines); (err == nil) != tt.ok {
+				t.Fatalf("ValidateOrderLines() = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMergeOrderLines_NumbersImplicitLines(t *testing.T) {
+	current := []OrderLine{{LineNo: 1, ProductCode: "P1"}, {LineNo: 2, ProductCode: "P2"}}
+	current[0].ID, current[1].ID = 10, 20
+
+	rows, removed, changed := MergeOrderLines(7, current, []OrderLine{{ProductCode: "P1"}, {LineNo: 3, ProductCode: "P3"}})
+	if !changed || len(rows) != 1 || rows[0].LineNo != 3 || rows[0].OrderUID != 7 {
+		t.Fatalf("unexpected rows %+v", rows)
+	}
+	if len(removed) != 1 || removed[0] != 20 {
+		t.Fatalf("unexpected removed %v", removed)
+	}
+}
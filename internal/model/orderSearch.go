@@ -0,0 +1,195 @@
+package model
+
+import (
+	"errors"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultSearchLimit = 50
+	MaxSearchLimit     = 200
+)
+
+// OrderSearch - поиск заказа по клиенту, адресу, водителю, агенту и номерам документа.
+type OrderSearch struct {
+	Q        string `form:"q" binding:"required"`
+	StartDT  string `form:"start_dt"`
+	FinishDT string `form:"finish_dt"`
+	Limit    int    `form:"limit"`
+}
+
+func (s *OrderSearch) Normalize() error {
+	if len(s.Terms()) == 0 {
+		return errors.New("empty search query")
+	}
+	if (s.StartDT == "") != (s.FinishDT == "") {
+		return errors.New("start_dt and finish_dt must be set together")
+	}
+	if _, ok, err := s.DateRange(); ok && err != nil {
+		return err
+	}
+	if s.Limit == 0 {
+		s.Limit = DefaultSearchLimit
+	}
+	if s.Limit < 0 || s.Limit > MaxSearchLimit {
+		return errors.New("limit must be between 1 and " + strconv.Itoa(MaxSearchLimit))
+	}
+	return nil
+}
+
+// DateRange - период по дате накладной; ok == false, если период не задан.
+func (s OrderSearch) DateRange() (r DateRange, ok bool, err error) {
+	if s.StartDT == "" {
+		return r, false, nil
+	}
+	r, err = ParseDateRange(s.StartDT, s.FinishDT)
+	return r, true, err
+}
+
+// Terms - слова запроса в нижнем регистре без знаков препинания.
+func (s OrderSearch) Terms() []string {
+	words := strings.FieldsFunc(strings.ToLower(s.Q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool, len(words))
+	terms := words[:0]
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// Numbers - числовые слова запроса, которые могут быть номером документа.
+func (s OrderSearch) Numbers() []int {
+	var numbers []int
+	for _, t := range s.Terms() {
+		if n, err := strconv.Atoi(t); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
+
+// OrderSearchHit - найденный заказ с релевантностью и подсвеченными совпадениями.
+// В Highlights значения экранированы для HTML, совпадения обернуты в <mark>.
+type OrderSearchHit struct {
+	Order      Order             `json:"order"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// orderSearchFields - текстовые поля поиска и их вес при ранжировании без FULLTEXT.
+var orderSearchFields = []struct {
+	name   string
+	weight float64
+	value  func(Order) string
+}{
+	{"client_name", 3, func(o Order) string { return o.ClientName }},
+	{"client_address", 2, func(o Order) string { return o.ClientAddress }},
+	{"driver", 1, func(o Order) string { return o.Driver }},
+	{"agent", 1, func(o Order) string { return o.Agent }},
+}
+
+// OrderNumberScore - надбавка к релевантности за точное совпадение номера документа.
+const OrderNumberScore = 10
+
+// ScoreOrder оценивает релевантность заказа там, где нет полнотекстового индекса:
+// каждое слово, найденное в поле, добавляет вес поля.
+func ScoreOrder(o Order, terms []string) float64 {
+	var score float64
+	for _, f := range orderSearchFields {
+		value := strings.ToLower(f.value(o))
+		for _, t := range terms {
+			if strings.Contains(value, t) {
+				score += f.weight
+			}
+		}
+	}
+	return score + NumberScore(o, OrderSearch{Q: strings.Join(terms, " ")}.Numbers())
+}
+
+// NumberScore - надбавка за совпадение номера накладной и уникального номера.
+func NumberScore(o Order, numbers []int) float64 {
+	var score float64
+	for _, n := range []int{o.FolioNum, o.UnicumNum} {
+		for _, number := range numbers {
+			if n == number {
+				score += OrderNumberScore
+				break
+			}
+		}
+	}
+	return score
+}
+
+// Highlight возвращает поля заказа, в которых найдены слова запроса.
+func (h *OrderSearchHit) Highlight(terms []string) {
+	h.Highlights = make(map[string]string)
+	for _, f := range orderSearchFields {
+		if marked, ok := highlight(f.value(h.Order), terms); ok {
+			h.Highlights[f.name] = marked
+		}
+	}
+	// Номера документа подсвечиваются только при точном совпадении
+	for name, n := range map[string]int{"folio_num": h.Order.FolioNum, "unicum_num": h.Order.UnicumNum} {
+		for _, t := range terms {
+			if n != 0 && t == strconv.Itoa(n) {
+				h.Highlights[name] = "<mark>" + t + "</mark>"
+			}
+		}
+	}
+	if len(h.Highlights) == 0 {
+		h.Highlights = nil
+	}
+}
+
+// highlight оборачивает вхождения слов в <mark> без учета регистра.
+// Пересекающиеся вхождения объединяются.
+func highlight(text string, terms []string) (string, bool) {
+	// Регистр меняется посимвольно: strings.ToLower может изменить длину
+	// строки (İ -> i̇), и позиции совпадений разошлись бы с текстом
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	type span struct{ from, to int }
+	var spans []span
+	for _, t := range terms {
+		term := []rune(t)
+		for i := 0; i+len(term) <= len(lower); i++ {
+			if string(lower[i:i+len(term)]) == t {
+				spans = append(spans, span{i, i + len(term)})
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return "", false
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].from < spans[j].from })
+	var b strings.Builder
+	pos := 0
+	for i := 0; i < len(spans); {
+		from, to := spans[i].from, spans[i].to
+		for i++; i < len(spans) && spans[i].from <= to; i++ {
+			to = max(to, spans[i].to)
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:from])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[from:to])))
+		b.WriteString("</mark>")
+		pos = to
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:])))
+	return b.String(), true
+}
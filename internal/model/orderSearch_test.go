@@ -0,0 +1,78 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+		ok    bool
+	}{
+		{"case insensitive", "ООО Ромашка", []string{"ромаш"}, "ООО <mark>Ромаш</mark>ка", true},
+		{"every occurrence", "ана и ана", []string{"ана"}, "<mark>ана</mark> и <mark>ана</mark>", true},
+		{"overlapping terms are merged", "Ромашкино", []string{"ромаш", "машкин"}, "<mark>Ромашкин</mark>о", true},
+		{"adjacent terms are merged", "абвг", []string{"аб", "вг"}, "<mark>абвг</mark>", true},
+		{"nested term", "Сидоров", []string{"сидоров", "дор"}, "<mark>Сидоров</mark>", true},
+		{"html is escaped", `<b>"A&B"</b> склад`, []string{"a", "склад"},
+			"&lt;b&gt;&#34;<mark>A</mark>&amp;B&#34;&lt;/b&gt; <mark>склад</mark>", true},
+		{"term inside entity is not broken", "a&b", []string{"amp"}, "", false},
+		// strings.ToLower("İ") - две руны, позиции после нее не должны сдвигаться
+		{"lowercase changes length", "İSTANBUL, ул. Мира", []string{"istanbul", "мира"},
+			"<mark>İSTANBUL</mark>, ул. <mark>Мира</mark>", true},
+		{"no match", "Петров", []string{"иванов"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := highlight(tt.text, tt.terms)
+			if got != tt.want || ok != tt.ok {
+				t.Fatalf("highlight(%q, %q) = %q, %v, want %q, %v", tt.text, tt.terms, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestScoreOrder(t *testing.T) {
+	o := Order{ClientName: "ООО Ромашка", ClientAddress: "ул. Ромашковая", Driver: "Сидоров", FolioNum: 5501, UnicumNum: 7001}
+	tests := []struct {
+		q    string
+		want float64
+	}{
+		{"ромашк", 3 + 2},
+		{"РОМАШК сидоров", 3 + 2 + 1},
+		{"5501", OrderNumberScore},
+		{"5501 7001", 2 * OrderNumberScore},
+		{"550", 0},
+		{"иванов", 0},
+	}
+	for _, tt := range tests {
+		if got := ScoreOrder(o, OrderSearch{Q: tt.q}.Terms()); got != tt.want {
+			t.Errorf("ScoreOrder(%q) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestOrderSearchHit_Highlight(t *testing.T) {
+	h := OrderSearchHit{Order: Order{ClientName: "ООО Ромашка", Driver: "Сидоров", FolioNum: 5501}}
+	h.Highlight(OrderSearch{Q: "ромашк 5501 550"}.Terms())
+	want := map[string]string{"client_name": "ООО <mark>Ромашк</mark>а", "folio_num": "<mark>5501</mark>"}
+	if !reflect.DeepEqual(h.Highlights, want) {
+		t.Fatalf("got %v, want %v", h.Highlights, want)
+	}
+
+	h.Highlight([]string{"иванов"})
+	if h.Highlights != nil {
+		t.Fatalf("expected no highlights, got %v", h.Highlights)
+	}
+}
+
+func TestOrderSearch_Terms(t *testing.T) {
+	got := OrderSearch{Q: "  Ромашка, ромашка; ул.Мира-5 "}.Terms()
+	if want := []string{"ромашка", "ул", "мира", "5"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,186 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+type OrderStatus int
+
+const (
+	OrderStatusNew OrderStatus = iota
+	OrderStatusAssigned
+	OrderStatusPicking
+	OrderStatusAssembled
+	OrderStatusChecked
+	OrderStatusShipped
+	OrderStatusCancelled
+	OrderStatusReturned
+)
+
+var orderStatusNames = map[OrderStatus]string{
+	OrderStatusNew:       "new",
+	OrderStatusAssigned:  "assigned",
+	OrderStatusPicking:   "picking",
+	OrderStatusAssembled: "assembled",
+	OrderStatusChecked:   "checked",
+	OrderStatusShipped:   "shipped",
+	OrderStatusCancelled: "cancelled",
+	OrderStatusReturned:  "returned",
+}
+
+var orderStatusTitles = map[OrderStatus]string{
+	OrderStatusNew:       "Новый",
+	OrderStatusAssigned:  "Назначен",
+	OrderStatusPicking:   "Собирается",
+	OrderStatusAssembled: "Собран",
+	OrderStatusChecked:   "Проверен",
+	OrderStatusShipped:   "Отгружен",
+	OrderStatusCancelled: "Отменен",
+	OrderStatusReturned:  "Возвращен",
+}
+
+// orderTransitions - допустимые переходы между статусами заказа.
+// Обратные переходы позволяют снять сборщика, вернуть заказ в сборку или снять проверку.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusNew:       {OrderStatusAssigned, OrderStatusCancelled},
+	OrderStatusAssigned:  {OrderStatusPicking, OrderStatusAssembled, OrderStatusNew, OrderStatusCancelled},
+	OrderStatusPicking:   {OrderStatusAssembled, OrderStatusAssigned, OrderStatusCancelled},
+	OrderStatusAssembled: {OrderStatusChecked, OrderStatusPicking, OrderStatusCancelled},
+	OrderStatusChecked:   {OrderStatusShipped, OrderStatusAssembled},
+	OrderStatusShipped:   {OrderStatusReturned},
+	OrderStatusCancelled: {OrderStatusNew},
+	OrderStatusReturned:  {},
+}
+
+func (s OrderStatus) String() string {
+	if name, ok := orderStatusNames[s]; ok {
+		return name
+	}
+	return strconv.Itoa(int(s))
+}
+
+// Title - название статуса для отчетов.
+func (s OrderStatus) Title() string {
+	if title, ok := orderStatusTitles[s]; ok {
+		return title
+	}
+	return s.String()
+}
+
+func (s OrderStatus) Valid() bool {
+	_, ok := orderStatusNames[s]
+	return ok
+}
+
+func (s OrderStatus) CanTransitionTo(to OrderStatus) bool {
+	for _, next := range orderTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Next возвращает статусы, в которые заказ может перейти из текущего.
+func (s OrderStatus) Next() []OrderStatus {
+	return orderTransitions[s]
+}
+
+// Assembled - заказ собран (в том числе проверен или отгружен).
+func (s OrderStatus) Assembled() bool {
+	return s == OrderStatusAssembled || s == OrderStatusChecked || s == OrderStatusShipped
+}
+
+// Checked - заказ проверен (в том числе отгружен).
+func (s OrderStatus) Checked() bool {
+	return s == OrderStatusChecked || s == OrderStatusShipped
+}
+
+func (s OrderStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON принимает как название статуса, так и его числовой код.
+func (s *OrderStatus) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var code int
+		if err := json.Unmarshal(data, &code); err != nil {
+			return fmt.Errorf("invalid order status %s", data)
+		}
+		name = strconv.Itoa(code)
+	}
+
+	status, err := ParseOrderStatus(name)
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
+
+func ParseOrderStatus(name string) (OrderStatus, error) {
+	for status, n := range orderStatusNames {
+		if n == name {
+			return status, nil
+		}
+	}
+	if code, err := strconv.Atoi(name); err == nil && OrderStatus(code).Valid() {
+		return OrderStatus(code), nil
+	}
+	return 0, fmt.Errorf("unknown order status %q", name)
+}
+
+// OrderTransition - запрос на перевод заказа в другой статус.
+type OrderTransition struct {
+	OrderUID   uint        `json:"order_uid" binding:"required"`
+	Status     OrderStatus `json:"status"`
+	UserID     uint        `json:"user_id"`
+	EmployeeID uint        `json:"employee_id"`
+	// TeamID - команда, собирающая заказ совместно; оплата делится между ее участниками
+	TeamID uint `json:"team_id"`
+	// ActorID - пользователь, выполняющий переход; заполняется сервером для журнала.
+	ActorID uint `json:"-"`
+}
+
+// Apply переводит заказ в новый статус и синхронизирует с ним поля Done и Check.
+// Проверка допустимости перехода выполняется вызывающей стороной.
+func (o *Order) Apply(t OrderTransition) {
+	from := o.Status
+
+	switch {
+	case t.Status == OrderStatusNew:
+		o.UserID = 0
+		o.EmployeeID = 0
+		o.TeamID = 0
+		o.Shortage = false
+		o.StartAt, o.FinishAt, o.PausedAt = nil, nil, nil
+		o.PausedSeconds, o.WorkSeconds = 0, 0
+	case t.Status == OrderStatusChecked:
+		o.CheckUserID = t.UserID
+	case from == OrderStatusChecked && t.Status == OrderStatusAssembled:
+		o.CheckUserID = 0
+	case from == OrderStatusAssembled && t.Status == OrderStatusPicking:
+		o.Shortage = false
+		// Время после окончания до возврата в сборку считается паузой
+		if o.FinishAt != nil {
+			o.PausedAt, o.FinishAt, o.WorkSeconds = o.FinishAt, nil, 0
+		}
+	case !from.Assembled() && (t.Status == OrderStatusAssigned || t.Status == OrderStatusPicking || t.Status == OrderStatusAssembled):
+		if t.UserID != 0 {
+			o.UserID = t.UserID
+		}
+		if t.EmployeeID != 0 {
+			o.EmployeeID = t.EmployeeID
+		}
+		if t.TeamID != 0 {
+			o.TeamID = t.TeamID
+		}
+	}
+
+	o.Status = t.Status
+	o.Done = t.Status.Assembled()
+	o.Check = t.Status.Checked()
+}
@@ -0,0 +1,132 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// WorkAction - отметка времени сборки: начало, пауза, продолжение, окончание.
+type WorkAction string
+
+const (
+	WorkStart  WorkAction = "start"
+	WorkPause  WorkAction = "pause"
+	WorkResume WorkAction = "resume"
+	WorkFinish WorkAction = "finish"
+)
+
+// OrderWork - отметка начала, паузы, продолжения или окончания сборки.
+type OrderWork struct {
+	OrderUID   uint `json:"order_uid" binding:"required"`
+	EmployeeID uint `json:"employee_id"`
+	TeamID     uint `json:"team_id"`
+	// Action, UserID и At заполняются сервером: действие по маршруту,
+	// сборщик - текущий пользователь, время - время запроса.
+	Action WorkAction `json:"-"`
+	UserID uint       `json:"-"`
+	At     time.Time  `json:"-"`
+}
+
+var workEventTypes = map[WorkAction]OrderEventType{
+	WorkStart:  OrderEventWorkStart,
+	WorkPause:  OrderEventWorkPause,
+	WorkResume: OrderEventWorkResume,
+	WorkFinish: OrderEventWorkFinish,
+}
+
+// EventType - тип события журнала для отметки.
+func (w OrderWork) EventType() OrderEventType {
+	return workEventTypes[w.Action]
+}
+
+// ApplyWork отмечает время сборки. Начало переводит назначенный заказ в сборку,
+// окончание - в "собран". Новый заказ сначала назначается сборщику.
+func (o *Order) ApplyWork(w OrderWork) error {
+	switch w.Action {
+	case WorkStart:
+		switch {
+		case o.Status == OrderStatusAssigned:
+			o.Apply(OrderTransition{Status: OrderStatusPicking, UserID: w.UserID, EmployeeID: w.EmployeeID, TeamID: w.TeamID})
+		case o.Status == OrderStatusPicking && o.StartAt == nil:
+			// Сборка начата подтверждением строк без отметки начала
+		default:
+			return fmt.Errorf("assembly can not be started in status %s", o.Status)
+		}
+		o.StartAt, o.PausedAt, o.PausedSeconds = &w.At, nil, 0
+
+	case WorkPause:
+		if err := o.working(); err != nil {
+			return err
+		}
+		if o.PausedAt != nil {
+			return fmt.Errorf("assembly is already paused")
+		}
+		o.PausedAt = &w.At
+
+	case WorkResume:
+		if err := o.working(); err != nil {
+			return err
+		}
+		if o.PausedAt == nil {
+			return fmt.Errorf("assembly is not paused")
+		}
+		o.resume(w.At)
+
+	case WorkFinish:
+		if err := o.working(); err != nil {
+			return err
+		}
+		o.StopWork(w.At)
+		o.Apply(OrderTransition{Status: OrderStatusAssembled})
+
+	default:
+		return fmt.Errorf("unknown work action %q", w.Action)
+	}
+	return nil
+}
+
+func (o *Order) working() error {
+	if o.Status != OrderStatusPicking || o.StartAt == nil {
+		return fmt.Errorf("assembly is not started")
+	}
+	return nil
+}
+
+func (o *Order) resume(at time.Time) {
+	if pause := at.Sub(*o.PausedAt); pause > 0 {
+		o.PausedSeconds += int64(pause / time.Second)
+	}
+	o.PausedAt = nil
+}
+
+// StopWork фиксирует окончание начатой сборки и чистое время работы.
+// Незавершенная пауза закрывается временем окончания.
+func (o *Order) StopWork(at time.Time) {
+	if o.StartAt == nil || o.FinishAt != nil {
+		return
+	}
+	if o.PausedAt != nil {
+		o.resume(at)
+	}
+	o.FinishAt = &at
+	o.WorkSeconds = int64(o.WorkDuration(at) / time.Second)
+}
+
+// WorkDuration - чистое время сборки без пауз на момент now.
+func (o Order) WorkDuration(now time.Time) time.Duration {
+	if o.StartAt == nil {
+		return 0
+	}
+	end := now
+	switch {
+	case o.FinishAt != nil:
+		end = *o.FinishAt
+	case o.PausedAt != nil:
+		end = *o.PausedAt
+	}
+	d := end.Sub(*o.StartAt) - time.Duration(o.PausedSeconds)*time.Second
+	if d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyWork(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return t0.Add(time.Duration(minutes) * time.Minute) }
+	work := func(o *Order, action WorkAction, minutes int) error {
+		return o.ApplyWork(OrderWork{Action: action, UserID: 5, EmployeeID: 6, At: at(minutes)})
+	}
+	mustWork := func(o *Order, action WorkAction, minutes int) {
+		t.Helper()
+		if err := work(o, action, minutes); err != nil {
+			t.Fatalf("%s at %d: %v", action, minutes, err)
+		}
+	}
+
+	var o Order
+	if err := work(&o, WorkStart, 0); err == nil {
+		t.Fatal("expected error for start of unassigned order")
+	}
+	o.Apply(OrderTransition{Status: OrderStatusAssigned, UserID: 5})
+	if err := work(&o, WorkPause, 0); err == nil {
+		t.Fatal("expected error for pause before start")
+	}
+	mustWork(&o, WorkStart, 0)
+	if o.Status != OrderStatusPicking || o.UserID != 5 || o.EmployeeID != 6 || !o.StartAt.Equal(t0) {
+		t.Fatalf("unexpected started order %+v", o)
+	}
+	if err := work(&o, WorkStart, 1); err == nil {
+		t.Fatal("expected error for second start")
+	}
+
+	// Повторная пауза не сдвигает начало паузы
+	mustWork(&o, WorkPause, 10)
+	if err := work(&o, WorkPause, 12); err == nil {
+		t.Fatal("expected error for double pause")
+	}
+	if !o.PausedAt.Equal(at(10)) {
+		t.Fatalf("paused at %v", o.PausedAt)
+	}
+	if d := o.WorkDuration(at(14)); d != 10*time.Minute {
+		t.Fatalf("paused duration %v", d)
+	}
+
+	mustWork(&o, WorkResume, 15)
+	if err := work(&o, WorkResume, 16); err == nil {
+		t.Fatal("expected error for resume without pause")
+	}
+	if o.PausedAt != nil || o.PausedSeconds != 5*60 {
+		t.Fatalf("unexpected resumed order %+v", o)
+	}
+	if d := o.WorkDuration(at(20)); d != 15*time.Minute {
+		t.Fatalf("duration after resume %v", d)
+	}
+
+	// Окончание во время паузы закрывает паузу временем окончания
+	mustWork(&o, WorkPause, 25)
+	mustWork(&o, WorkFinish, 40)
+	if o.Status != OrderStatusAssembled || !o.FinishAt.Equal(at(40)) || o.PausedAt != nil ||
+		o.PausedSeconds != 20*60 || o.WorkSeconds != 20*60 {
+		t.Fatalf("unexpected finished order %+v", o)
+	}
+	if d := o.WorkDuration(at(90)); d != 20*time.Minute {
+		t.Fatalf("finished duration %v", d)
+	}
+	if err := work(&o, WorkFinish, 41); err == nil {
+		t.Fatal("expected error for second finish")
+	}
+	if err := work(&o, WorkStart, 42); err == nil {
+		t.Fatal("expected error for start of assembled order")
+	}
+
+	// Возврат собранного заказа в сборку: время до возврата считается паузой
+	o.Apply(OrderTransition{Status: OrderStatusPicking})
+	if o.FinishAt != nil || !o.PausedAt.Equal(at(40)) || o.WorkSeconds != 0 {
+		t.Fatalf("unexpected reopened order %+v", o)
+	}
+	if d := o.WorkDuration(at(55)); d != 20*time.Minute {
+		t.Fatalf("reopened duration %v", d)
+	}
+	mustWork(&o, WorkResume, 60)
+	mustWork(&o, WorkFinish, 70)
+	if o.Status != OrderStatusAssembled || o.PausedSeconds != 40*60 || o.WorkSeconds != 30*60 {
+		t.Fatalf("unexpected order finished after reopening %+v", o)
+	}
+}
+
+func TestApplyWork_StartAfterPick(t *testing.T) {
+	// Сборка начата подтверждением строк без отметки начала
+	o := Order{Status: OrderStatusPicking, UserID: 5}
+	at := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err := o.ApplyWork(OrderWork{Action: WorkStart, UserID: 5, At: at}); err != nil {
+		t.Fatal(err)
+	}
+	if !o.StartAt.Equal(at) || o.Status != OrderStatusPicking {
+		t.Fatalf("unexpected order %+v", o)
+	}
+
+	if err := (&Order{Status: OrderStatusNew}).ApplyWork(OrderWork{Action: "stop"}); err == nil {
+		t.Fatal("expected error for unknown action")
+	}
+}
+
+func TestStopWork(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	// Заказ без отметки начала не получает времени сборки
+	var o Order
+	o.StopWork(t0)
+	if o.FinishAt != nil || o.WorkSeconds != 0 {
+		t.Fatalf("unexpected order %+v", o)
+	}
+
+	// Повторная остановка не меняет окончание
+	o = Order{StartAt: &t0}
+	o.StopWork(t0.Add(time.Hour))
+	o.StopWork(t0.Add(2 * time.Hour))
+	if !o.FinishAt.Equal(t0.Add(time.Hour)) || o.WorkSeconds != 3600 {
+		t.Fatalf("unexpected stopped order %+v", o)
+	}
+}
+
+func TestWorkDuration_NotNegative(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	o := Order{StartAt: &t0, PausedSeconds: 3600}
+	if d := o.WorkDuration(t0.Add(time.Minute)); d != 0 {
+		t.Fatalf("got %v", d)
+	}
+	if d := (Order{}).WorkDuration(t0); d != 0 {
+		t.Fatalf("not started: got %v", d)
+	}
+}
@@ -0,0 +1,243 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tariff - сдельные расценки сборки. Тариф с пустым VidDoc применяется
+// к видам документов, для которых отдельный тариф не задан.
+type Tariff struct {
+	gorm.Model
+	VidDoc     string  `gorm:"column:vid_doc;size:100;uniqueIndex" json:"vid_doc"`
+	PerOrder   float64 `gorm:"column:per_order" json:"per_order"`
+	PerLine    float64 `gorm:"column:per_line" json:"per_line"`
+	Per1000Sum float64 `gorm:"column:per_1000_sum" json:"per_1000_sum"`
+}
+
+func (Tariff) TableName() string {
+	return "tariffs"
+}
+
+func (t Tariff) Scope() string {
+	return t.VidDoc
+}
+
+// Negative - в тарифе есть отрицательные расценки.
+func (t Tariff) Negative() bool {
+	return t.PerOrder < 0 || t.PerLine < 0 || t.Per1000Sum < 0
+}
+
+// Amount - оплата за сборку заказа по тарифу.
+func (t Tariff) Amount(o AssemblyOrder) float64 {
+	return t.PerOrder + t.PerLine*float64(o.LineCount) + t.Per1000Sum*o.OrderSum/1000
+}
+
+// PayrollPeriod - закрытый (выплаченный) период. Границы периода - даты окончания
+// сборки, как и при расчете оплаты. Начисления закрытого периода хранятся
+// в PayrollEntry и не пересчитываются, оплаченные заказы - в PayrollOrder.
+type PayrollPeriod struct {
+	gorm.Model
+	StartDT  string         `gorm:"column:start_dt;size:10;index" json:"start_dt"`
+	FinishDT string         `gorm:"column:finish_dt;size:10;index" json:"finish_dt"`
+	ClosedAt time.Time      `gorm:"column:closed_at" json:"closed_at"`
+	ClosedBy uint           `gorm:"column:closed_by" json:"closed_by"`
+	Total    float64        `gorm:"column:total" json:"total"`
+	Entries  []PayrollEntry `gorm:"foreignKey:PeriodID" json:"entries,omitempty"`
+	Orders   []PayrollOrder `gorm:"foreignKey:PeriodID" json:"-"`
+}
+
+func (PayrollPeriod) TableName() string {
+	return "payroll_periods"
+}
+
+type PayrollEntry struct {
+	ID           uint    `gorm:"primarykey" json:"-"`
+	PeriodID     uint    `gorm:"column:period_id;index" json:"-"`
+	EmployeeID   uint    `gorm:"column:employee_id" json:"employee_id"`
+	EmployeeName string  `gorm:"column:employee_name;size:150" json:"employee_name"`
+	Orders       float64 `gorm:"column:orders" json:"orders"`
+	Lines        float64 `gorm:"column:lines_count" json:"lines"`
+	OrderSum     float64 `gorm:"column:order_sum" json:"order_sum"`
+	Amount       float64 `gorm:"column:amount" json:"amount"`
+}
+
+func (PayrollEntry) TableName() string {
+	return "payroll_entries"
+}
+
+// PayrollOrder - заказ, оплаченный в закрытом периоде. Заказ оплачивается
+// один раз: повторная сборка после закрытия периода в расчет не попадает.
+type PayrollOrder struct {
+	ID       uint `gorm:"primarykey" json:"-"`
+	PeriodID uint `gorm:"column:period_id;index" json:"-"`
+	OrderUID int  `gorm:"column:order_uid;uniqueIndex" json:"order_uid"`
+}
+
+func (PayrollOrder) TableName() string {
+	return "payroll_orders"
+}
+
+// OrderShare - доля участника в оплате собранного заказа. Доли записываются
+// при сборке, чтобы смена состава команды не меняла оплату уже собранных заказов.
+type OrderShare struct {
+	ID           uint    `gorm:"primarykey" json:"-"`
+	OrderUID     int     `gorm:"column:order_uid;index" json:"order_uid"`
+	EmployeeID   uint    `gorm:"column:employee_id" json:"employee_id"`
+	EmployeeName string  `gorm:"column:employee_name;size:150" json:"employee_name"`
+	Share        float64 `gorm:"column:share" json:"share"`
+}
+
+func (OrderShare) TableName() string {
+	return "order_shares"
+}
+
+// AssemblyShares распределяет оплату заказа на момент сборки. Заказ, собранный
+// командой, делится между ее участниками members, иначе оплата целиком
+// достается сборщику. names - ФИО сотрудников по ID.
+func AssemblyShares(o Order, members []EmployeeTeam, names map[uint]string) []OrderShare {
+	var parts []PayrollShare
+	switch {
+	case o.TeamID != 0 && len(members) > 0:
+		parts = TeamShares(members, names)
+	case o.EmployeeID != 0:
+		parts = []PayrollShare{{EmployeeID: o.EmployeeID, Name: names[o.EmployeeID], Share: 1}}
+	}
+
+	shares := make([]OrderShare, 0, len(parts))
+	for _, p := range parts {
+		shares = append(shares, OrderShare{OrderUID: o.OrderUid, EmployeeID: p.EmployeeID, EmployeeName: p.Name, Share: p.Share})
+	}
+	return shares
+}
+
+// PayrollRange - период расчета оплаты по дням включительно.
+type PayrollRange struct {
+	StartDT  string `form:"start_dt" json:"start_dt" binding:"required"`
+	FinishDT string `form:"finish_dt" json:"finish_dt" binding:"required"`
+}
+
+// Normalize проверяет даты периода и приводит их к виду 2006-01-02,
+// в котором хранятся границы закрытых периодов.
+func (r *PayrollRange) Normalize() error {
+	if !dateOnly(r.StartDT) || !dateOnly(r.FinishDT) {
+		return errors.New("payroll period must be set in whole days")
+	}
+	period, err := ParseDateRange(r.StartDT, r.FinishDT)
+	if err != nil {
+		return err
+	}
+	r.StartDT = period.From.Format(time.DateOnly)
+	r.FinishDT = period.To.AddDate(0, 0, -1).Format(time.DateOnly)
+	return nil
+}
+
+// AssemblyFilter - заказы, собранные в периоде. Оплата начисляется по времени
+// сборки, а не по дате накладной: заказ, выставленный в одном периоде и собранный
+// в следующем, оплачивается в следующем.
+func (r PayrollRange) AssemblyFilter() AssemblyFilter {
+	return AssemblyFilter{StartDT: r.StartDT, FinishDT: r.FinishDT, ByAssemblyDate: true}
+}
+
+// Payroll - расчет сдельной оплаты за период.
+type Payroll struct {
+	StartDT  string         `json:"start_dt"`
+	FinishDT string         `json:"finish_dt"`
+	Closed   bool           `json:"closed"`
+	Total    float64        `json:"total"`
+	Entries  []PayrollEntry `json:"entries"`
+	// Unassigned - оплата собранных заказов без сборщика, не вошедшая в начисления
+	Unassigned float64 `json:"unassigned"`
+	// Orders - заказы, вошедшие в расчет; при закрытии периода отмечаются оплаченными
+	Orders []int `json:"-"`
+}
+
+// PayrollShare - доля сотрудника в оплате заказа.
+type PayrollShare struct {
+	EmployeeID uint
+	Name       string
+	Share      float64
+}
+
+// CalculatePayroll начисляет оплату за собранные заказы. shares возвращает
+// участников сборки заказа; количество заказов и строк делится между ними
+// в тех же долях, что и оплата.
+func CalculatePayroll(orders []AssemblyOrder, tariffs []Tariff, shares func(AssemblyOrder) []PayrollShare) Payroll {
+	byVidDoc := make(map[string]Tariff, len(tariffs))
+	for _, t := range tariffs {
+		byVidDoc[t.VidDoc] = t
+	}
+
+	var p Payroll
+	entries := make(map[uint]*PayrollEntry)
+	p.Orders = make([]int, 0, len(orders))
+	for _, o := range orders {
+		p.Orders = append(p.Orders, o.OrderUid)
+		tariff, ok := byVidDoc[o.VidDoc]
+		if !ok {
+			tariff = byVidDoc[""]
+		}
+		amount := tariff.Amount(o)
+
+		parts := shares(o)
+		total := 0.0
+		for _, s := range parts {
+			total += s.Share
+		}
+		if total <= 0 {
+			p.Unassigned += amount
+			continue
+		}
+
+		for _, s := range parts {
+			k := s.Share / total
+			e, ok := entries[s.EmployeeID]
+			if !ok {
+				e = &PayrollEntry{EmployeeID: s.EmployeeID, EmployeeName: s.Name}
+				entries[s.EmployeeID] = e
+			}
+			e.Orders += k
+			e.Lines += k * float64(o.LineCount)
+			e.OrderSum += k * o.OrderSum
+			e.Amount += k * amount
+		}
+	}
+
+	p.Entries = make([]PayrollEntry, 0, len(entries))
+	for _, e := range entries {
+		e.Orders = round2(e.Orders)
+		e.Lines = round2(e.Lines)
+		e.OrderSum = round2(e.OrderSum)
+		e.Amount = round2(e.Amount)
+		p.Total += e.Amount
+		p.Entries = append(p.Entries, *e)
+	}
+	sort.Slice(p.Entries, func(i, j int) bool { return p.Entries[i].EmployeeID < p.Entries[j].EmployeeID })
+
+	p.Total = round2(p.Total)
+	p.Unassigned = round2(p.Unassigned)
+	return p
+}
+
+// TeamShares распределяет заказ между участниками команды по их долям,
+// а при нулевых долях - поровну.
+func TeamShares(members []EmployeeTeam, names map[uint]string) []PayrollShare {
+	total := 0.0
+	for _, m := range members {
+		total += math.Max(m.Share, 0)
+	}
+
+	shares := make([]PayrollShare, 0, len(members))
+	for _, m := range members {
+		share := 1.0
+		if total > 0 {
+			share = math.Max(m.Share, 0)
+		}
+		shares = append(shares, PayrollShare{EmployeeID: m.EmployeeID, Name: names[m.EmployeeID], Share: share})
+	}
+	return shares
+}
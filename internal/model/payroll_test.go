@@ -0,0 +1,103 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCalculatePayroll(t *testing.T) {
+	tariffs := []Tariff{
+		{VidDoc: "", PerOrder: 10},
+		{VidDoc: "R", PerOrder: 5, PerLine: 1, Per1000Sum: 2},
+	}
+	orders := []AssemblyOrder{
+		// R: 5 + 1*3 + 2*1500/1000 = 11, делится 2:1
+		{OrderUid: 1, VidDoc: "R", LineCount: 3, OrderSum: 1500, TeamID: 7},
+		// Вид без тарифа - тариф по умолчанию 10, целиком сборщику 2
+		{OrderUid: 2, VidDoc: "X", LineCount: 1, OrderSum: 100, EmployeeID: 2},
+		// Без участников оплата не начисляется
+		{OrderUid: 3, VidDoc: "X", LineCount: 2},
+	}
+	shares := func(o AssemblyOrder) []PayrollShare {
+		switch o.OrderUid {
+		case 1:
+			return []PayrollShare{{EmployeeID: 1, Name: "Иванов", Share: 2}, {EmployeeID: 2, Name: "Петров", Share: 1}}
+		case 2:
+			return []PayrollShare{{EmployeeID: 2, Name: "Петров", Share: 1}}
+		}
+		return nil
+	}
+
+	got := CalculatePayroll(orders, tariffs, shares)
+	want := Payroll{
+		Total: 21,
+		Entries: []PayrollEntry{
+			{EmployeeID: 1, EmployeeName: "Иванов", Orders: 0.67, Lines: 2, OrderSum: 1000, Amount: 7.33},
+			{EmployeeID: 2, EmployeeName: "Петров", Orders: 1.33, Lines: 2, OrderSum: 600, Amount: 13.67},
+		},
+		Unassigned: 10,
+		Orders:     []int{1, 2, 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestCalculatePayroll_Empty(t *testing.T) {
+	got := CalculatePayroll(nil, nil, func(AssemblyOrder) []PayrollShare { return nil })
+	if got.Total != 0 || got.Entries == nil || len(got.Entries) != 0 {
+		t.Fatalf("unexpected payroll %+v", got)
+	}
+}
+
+func TestTeamShares(t *testing.T) {
+	names := map[uint]string{1: "Иванов", 2: "Петров"}
+	tests := []struct {
+		name    string
+		members []EmployeeTeam
+		want    []float64
+	}{
+		{"shares", []EmployeeTeam{{EmployeeID: 1, Share: 3}, {EmployeeID: 2, Share: 1}}, []float64{3, 1}},
+		{"zero shares are split equally", []EmployeeTeam{{EmployeeID: 1}, {EmployeeID: 2}}, []float64{1, 1}},
+		{"negative share counts as zero", []EmployeeTeam{{EmployeeID: 1, Share: -2}, {EmployeeID: 2, Share: 1}}, []float64{0, 1}},
+		{"only negative shares are split equally", []EmployeeTeam{{EmployeeID: 1, Share: -1}, {EmployeeID: 2, Share: -1}}, []float64{1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := TeamShares(tt.members, names)
+			if len(shares) != len(tt.want) {
+				t.Fatalf("got %+v", shares)
+			}
+			for i, s := range shares {
+				if s.EmployeeID != tt.members[i].EmployeeID || s.Name != names[s.EmployeeID] || s.Share != tt.want[i] {
+					t.Fatalf("share %d: got %+v, want %v", i, s, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAssemblyShares(t *testing.T) {
+	names := map[uint]string{1: "Иванов", 2: "Петров", 3: "Сидоров"}
+	members := []EmployeeTeam{{TeamID: 7, EmployeeID: 1, Share: 2}, {TeamID: 7, EmployeeID: 2, Share: 1}}
+
+	got := AssemblyShares(Order{OrderUid: 10, TeamID: 7, EmployeeID: 3}, members, names)
+	want := []OrderShare{
+		{OrderUID: 10, EmployeeID: 1, EmployeeName: "Иванов", Share: 2},
+		{OrderUID: 10, EmployeeID: 2, EmployeeName: "Петров", Share: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("team order: got %+v, want %+v", got, want)
+	}
+
+	// Команда без участников - оплата сборщику
+	got = AssemblyShares(Order{OrderUid: 11, TeamID: 7, EmployeeID: 3}, nil, names)
+	want = []OrderShare{{OrderUID: 11, EmployeeID: 3, EmployeeName: "Сидоров", Share: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("employee order: got %+v, want %+v", got, want)
+	}
+
+	if got := AssemblyShares(Order{OrderUid: 12}, nil, names); len(got) != 0 {
+		t.Fatalf("order without collector: got %+v", got)
+	}
+}
@@ -0,0 +1,71 @@
+package model
+
+import "gorm.io/gorm"
+
+type Permission string
+
+const (
+	PermUsersView       Permission = "users.view"
+	PermUsersManage     Permission = "users.manage"
+	PermUsersBlock      Permission = "users.block"
+	PermRolesManage     Permission = "roles.manage"
+	PermEmployeesManage Permission = "employees.manage"
+	PermTeamsManage     Permission = "teams.manage"
+	PermProjectsManage  Permission = "projects.manage"
+	PermOrdersView      Permission = "orders.view"
+	PermOrdersImport    Permission = "orders.import"
+	PermOrdersAssign    Permission = "orders.assign"
+	PermOrdersPick      Permission = "orders.pick"
+	PermOrdersCheck     Permission = "orders.check"
+	PermOrdersManage    Permission = "orders.manage"
+	PermReportsView     Permission = "reports.view"
+	PermPayrollManage   Permission = "payroll.manage"
+)
+
+// AdminRoleName - роль, которой при старте сервера выдаются все права каталога.
+const (
+	AdminRoleName     = "admin"
+	AdminRolePriority = 1000
+)
+
+type PermissionInfo struct {
+	Code        Permission `json:"code"`
+	Description string     `json:"description"`
+}
+
+var Permissions = []PermissionInfo{
+	{PermUsersView, "Просмотр пользователей и их привязок"},
+	{PermUsersManage, "Создание и изменение пользователей"},
+	{PermUsersBlock, "Блокировка пользователей"},
+	{PermRolesManage, "Управление ролями и правами"},
+	{PermEmployeesManage, "Управление сотрудниками"},
+	{PermTeamsManage, "Управление командами"},
+	{PermProjectsManage, "Управление проектами"},
+	{PermOrdersView, "Просмотр заказов других пользователей и проверки"},
+	{PermOrdersImport, "Загрузка заказов"},
+	{PermOrdersAssign, "Назначение сборщиков заказов"},
+	{PermOrdersPick, "Сборка заказов"},
+	{PermOrdersCheck, "Проверка собранных заказов"},
+	{PermOrdersManage, "Отгрузка, отмена и возврат заказов"},
+	{PermReportsView, "Просмотр отчетов"},
+	{PermPayrollManage, "Тарифы сдельной оплаты и закрытие периодов"},
+}
+
+func (p Permission) Valid() bool {
+	for _, info := range Permissions {
+		if info.Code == p {
+			return true
+		}
+	}
+	return false
+}
+
+type RolePermission struct {
+	gorm.Model
+	RoleID     uint       `gorm:"column:role_id;uniqueIndex:idx_role_permission" json:"role_id"`
+	Permission Permission `gorm:"column:permission;size:64;uniqueIndex:idx_role_permission" json:"permission"`
+}
+
+func (RolePermission) TableName() string {
+	return "role_permissions"
+}
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+type ProductivityGroup string
+
+const (
+	ProductivityByEmployee ProductivityGroup = "employee"
+	ProductivityByUser     ProductivityGroup = "user"
+	ProductivityByTeam     ProductivityGroup = "team"
+)
+
+type ProductivityBucket string
+
+const (
+	BucketNone  ProductivityBucket = ""
+	BucketDay   ProductivityBucket = "day"
+	BucketWeek  ProductivityBucket = "week"
+	BucketMonth ProductivityBucket = "month"
+)
+
+// ProductivityFilter - параметры отчета о производительности поверх фильтра отчета о сборке.
+type ProductivityFilter struct {
+	AssemblyFilter
+	GroupBy ProductivityGroup  `form:"group_by" json:"group_by"`
+	Bucket  ProductivityBucket `form:"bucket" json:"bucket"`
+}
+
+func (f ProductivityFilter) Validate() error {
+	switch f.GroupBy {
+	case ProductivityByEmployee, ProductivityByUser, ProductivityByTeam:
+	default:
+		return fmt.Errorf("unknown group_by %q", f.GroupBy)
+	}
+	switch f.Bucket {
+	case BucketNone, BucketDay, BucketWeek, BucketMonth:
+	default:
+		return fmt.Errorf("unknown bucket %q", f.Bucket)
+	}
+	return nil
+}
+
+// ProductivityKey - сотрудник, пользователь или команда, к которым относится заказ.
+type ProductivityKey struct {
+	ID   uint
+	Name string
+}
+
+type ProductivityRow struct {
+	Period         string            `json:"period,omitempty"`
+	GroupBy        ProductivityGroup `json:"group_by"`
+	ID             uint              `json:"id"`
+	Name           string            `json:"name"`
+	Orders         int               `json:"orders"`
+	OrderSum       float64           `json:"order_sum"`
+	AvgMinutes     float64           `json:"avg_minutes"`
+	MedianMinutes  float64           `json:"median_minutes"`
+	P90Minutes     float64           `json:"p90_minutes"`
+	Checked        int               `json:"checked"`
+	CheckedRatio   float64           `json:"checked_ratio"`
+	ShortageOrders int               `json:"shortage_orders"`
+	ShortageLines  int               `json:"shortage_lines"`
+
+	minutes []float64
+}
+
+// Start возвращает начало периода, в который попадает момент t.
+// Неделя начинается с понедельника.
+func (b ProductivityBucket) Start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch b {
+	case BucketDay:
+		return day
+	case BucketWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case BucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// Productivity сводит собранные заказы по ключам группировки и периодам. Заказ,
+// относящийся к нескольким ключам (например, командам), учитывается в каждом.
+func Productivity(orders []AssemblyOrder, f ProductivityFilter, keys func(AssemblyOrder) []ProductivityKey) []ProductivityRow {
+	type rowKey struct {
+		period string
+		id     uint
+	}
+	rows := make(map[rowKey]*ProductivityRow)
+
+	for _, o := range orders {
+		period := ""
+		if f.Bucket != BucketNone {
+			period = f.Bucket.Start(o.AssemblyDate).Format("2006-01-02")
+		}
+
+		for _, k := range keys(o) {
+			row, ok := rows[rowKey{period, k.ID}]
+			if !ok {
+				row = &ProductivityRow{Period: period, GroupBy: f.GroupBy, ID: k.ID, Name: k.Name}
+				rows[rowKey{period, k.ID}] = row
+			}
+
+			row.Orders++
+			row.OrderSum += o.OrderSum
+			row.minutes = append(row.minutes, o.DurationMinutes)
+			if o.Status.Checked() {
+				row.Checked++
+			}
+			if o.Shortage {
+				row.ShortageOrders++
+				row.ShortageLines += len(o.ShortageLines)
+			}
+		}
+	}
+
+	result := make([]ProductivityRow, 0, len(rows))
+	for _, row := range rows {
+		sort.Float64s(row.minutes)
+		total := 0.0
+		for _, m := range row.minutes {
+			total += m
+		}
+		row.AvgMinutes = round2(total / float64(len(row.minutes)))
+		row.MedianMinutes = median(row.minutes)
+		row.P90Minutes = percentile(row.minutes, 90)
+		row.CheckedRatio = round2(float64(row.Checked) / float64(row.Orders))
+		row.OrderSum = round2(row.OrderSum)
+		result = append(result, *row)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Period != result[j].Period {
+			return result[i].Period < result[j].Period
+		}
+		if result[i].Orders != result[j].Orders {
+			return result[i].Orders > result[j].Orders
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// median ожидает отсортированный непустой срез.
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return round2((sorted[n/2-1] + sorted[n/2]) / 2)
+}
+
+// percentile - перцентиль по методу ближайшего ранга; срез отсортирован и не пуст.
+func percentile(sorted []float64, p int) float64 {
+	rank := int(math.Ceil(float64(p) / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
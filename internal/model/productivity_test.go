@@ -0,0 +1,119 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMedianAndPercentile(t *testing.T) {
+	tests := []struct {
+		name   string
+		sorted []float64
+		median float64
+		p90    float64
+	}{
+		{"single", []float64{5}, 5, 5},
+		{"odd", []float64{1, 2, 3}, 2, 3},
+		{"even", []float64{1, 2, 3, 4}, 2.5, 4},
+		{"even rounded", []float64{1, 1.005, 2, 3}, 1.5, 3},
+		{"ten", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 5.5, 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := median(tt.sorted); got != tt.median {
+				t.Errorf("median = %v, want %v", got, tt.median)
+			}
+			if got := percentile(tt.sorted, 90); got != tt.p90 {
+				t.Errorf("p90 = %v, want %v", got, tt.p90)
+			}
+		})
+	}
+}
+
+func TestProductivityBucket_Start(t *testing.T) {
+	at := func(day, hour int) time.Time { return time.Date(2024, time.March, day, hour, 30, 0, 0, Location) }
+	tests := []struct {
+		bucket ProductivityBucket
+		t      time.Time
+		want   time.Time
+	}{
+		{BucketDay, at(6, 23), time.Date(2024, time.March, 6, 0, 0, 0, 0, Location)},
+		// 4 марта 2024 - понедельник
+		{BucketWeek, at(4, 0), time.Date(2024, time.March, 4, 0, 0, 0, 0, Location)},
+		{BucketWeek, at(6, 12), time.Date(2024, time.March, 4, 0, 0, 0, 0, Location)},
+		{BucketWeek, at(10, 23), time.Date(2024, time.March, 4, 0, 0, 0, 0, Location)},
+		{BucketWeek, at(2, 9), time.Date(2024, time.February, 26, 0, 0, 0, 0, Location)},
+		{BucketMonth, at(31, 23), time.Date(2024, time.March, 1, 0, 0, 0, 0, Location)},
+		{BucketMonth, at(1, 0), time.Date(2024, time.March, 1, 0, 0, 0, 0, Location)},
+		{BucketNone, at(6, 12), time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := tt.bucket.Start(tt.t); !got.Equal(tt.want) {
+			t.Errorf("%q start of %v = %v, want %v", tt.bucket, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestProductivity(t *testing.T) {
+	at := func(day int) time.Time { return time.Date(2024, time.March, day, 12, 0, 0, 0, Location) }
+	orders := []AssemblyOrder{
+		{OrderUid: 1, AssemblyDate: at(4), DurationMinutes: 10, OrderSum: 100, Status: OrderStatusChecked, TeamID: 1},
+		{OrderUid: 2, AssemblyDate: at(6), DurationMinutes: 30, OrderSum: 200, Status: OrderStatusAssembled, TeamID: 1,
+			Shortage: true, ShortageLines: []OrderLine{{LineNo: 1}, {LineNo: 2}}},
+		// Сотрудник в двух командах: заказ учитывается в каждой
+		{OrderUid: 3, AssemblyDate: at(12), DurationMinutes: 20, OrderSum: 300.333, Status: OrderStatusShipped, EmployeeID: 9},
+		// Заказ без участников не относится ни к одному ключу
+		{OrderUid: 4, AssemblyDate: at(12), DurationMinutes: 99},
+	}
+	teams := map[uint]string{1: "Смена 1", 2: "Смена 2"}
+	keys := func(o AssemblyOrder) []ProductivityKey {
+		switch {
+		case o.TeamID != 0:
+			return []ProductivityKey{{ID: o.TeamID, Name: teams[o.TeamID]}}
+		case o.EmployeeID == 9:
+			return []ProductivityKey{{ID: 1, Name: teams[1]}, {ID: 2, Name: teams[2]}}
+		}
+		return nil
+	}
+
+	got := Productivity(orders, ProductivityFilter{GroupBy: ProductivityByTeam}, keys)
+	want := []ProductivityRow{
+		{GroupBy: ProductivityByTeam, ID: 1, Name: "Смена 1", Orders: 3, OrderSum: 600.33, AvgMinutes: 20,
+			MedianMinutes: 20, P90Minutes: 30, Checked: 2, CheckedRatio: 0.67, ShortageOrders: 1, ShortageLines: 2,
+			minutes: []float64{10, 20, 30}},
+		{GroupBy: ProductivityByTeam, ID: 2, Name: "Смена 2", Orders: 1, OrderSum: 300.33, AvgMinutes: 20,
+			MedianMinutes: 20, P90Minutes: 20, Checked: 1, CheckedRatio: 1, minutes: []float64{20}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v\nwant %+v", got, want)
+	}
+
+	got = Productivity(orders, ProductivityFilter{GroupBy: ProductivityByTeam, Bucket: BucketWeek}, keys)
+	var periods []string
+	for _, row := range got {
+		periods = append(periods, row.Period)
+		if row.Period == "2024-03-04" && (row.ID != 1 || row.Orders != 2 || row.MedianMinutes != 20) {
+			t.Fatalf("unexpected first week row %+v", row)
+		}
+	}
+	if !reflect.DeepEqual(periods, []string{"2024-03-04", "2024-03-11", "2024-03-11"}) {
+		t.Fatalf("unexpected weeks %v", periods)
+	}
+}
+
+func TestProductivity_NoOrders(t *testing.T) {
+	// Сотрудник без собранных заказов не получает строки с нулевым делителем
+	keys := func(o AssemblyOrder) []ProductivityKey {
+		if o.EmployeeID == 0 {
+			return nil
+		}
+		return []ProductivityKey{{ID: o.EmployeeID}}
+	}
+	for _, orders := range [][]AssemblyOrder{nil, {{OrderUid: 1, DurationMinutes: 15}}} {
+		got := Productivity(orders, ProductivityFilter{GroupBy: ProductivityByEmployee, Bucket: BucketMonth}, keys)
+		if got == nil || len(got) != 0 {
+			t.Fatalf("expected no rows, got %+v", got)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken хранит только хеш токена. Все токены одной сессии (цепочки ротаций)
+// имеют общий FamilyID, по которому сессия отзывается целиком.
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint       `gorm:"column:user_id;index" json:"user_id"`
+	FamilyID  string     `gorm:"column:family_id;size:64;index" json:"family_id"`
+	TokenHash string     `gorm:"column:token_hash;size:64;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"column:expires_at" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"used_at"`
+	RevokedAt *time.Time `gorm:"column:revoked_at" json:"revoked_at"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
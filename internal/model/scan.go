@@ -0,0 +1,55 @@
+package model
+
+// ScanEntity - тип сущности, найденной по штрихкоду.
+type ScanEntity string
+
+const (
+	ScanEntityOrder    ScanEntity = "order"
+	ScanEntityEmployee ScanEntity = "employee"
+	ScanEntityProduct  ScanEntity = "product"
+)
+
+// ScanAction - следующее действие, которое терминал предлагает после сканирования.
+type ScanAction string
+
+const (
+	ScanActionNone        ScanAction = "none"
+	ScanActionSelectOrder ScanAction = "select_order"
+	ScanActionAssign      ScanAction = "assign"
+	ScanActionPick        ScanAction = "pick"
+	ScanActionConfirmLine ScanAction = "confirm_line"
+	ScanActionCheck       ScanAction = "check"
+	ScanActionShip        ScanAction = "ship"
+)
+
+// ScanRequest - штрихкод, считанный терминалом. OrderUID передается, когда
+// сканирование выполняется в контексте открытого на терминале заказа.
+type ScanRequest struct {
+	Barcode  string `json:"barcode" binding:"required"`
+	OrderUID uint   `json:"order_uid"`
+}
+
+type ScanResult struct {
+	Barcode  string     `json:"barcode"`
+	Type     ScanEntity `json:"type"`
+	Action   ScanAction `json:"action"`
+	Order    *Order     `json:"order,omitempty"`
+	Employee *Employee  `json:"employee,omitempty"`
+	Line     *OrderLine `json:"line,omitempty"`
+}
+
+// ScanAction возвращает действие с заказом, доступное в текущем статусе.
+func (s OrderStatus) ScanAction() ScanAction {
+	switch s {
+	case OrderStatusNew:
+		return ScanActionAssign
+	case OrderStatusAssigned, OrderStatusPicking:
+		return ScanActionPick
+	case OrderStatusAssembled:
+		return ScanActionCheck
+	case OrderStatusChecked:
+		return ScanActionShip
+	default:
+		return ScanActionNone
+	}
+}
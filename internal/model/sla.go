@@ -0,0 +1,175 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SLAState string
+
+const (
+	SLAOk       SLAState = "ok"
+	SLAAtRisk   SLAState = "at_risk"
+	SLABreached SLAState = "breached"
+)
+
+// DefaultSLAAtRisk - за сколько до срока заказ считается под угрозой,
+// если в правиле не задано иное.
+const DefaultSLAAtRisk = 30 * time.Minute
+
+func ParseSLAState(s string) (SLAState, error) {
+	switch state := SLAState(s); state {
+	case SLAOk, SLAAtRisk, SLABreached:
+		return state, nil
+	}
+	return "", fmt.Errorf("unknown sla state %q", s)
+}
+
+// SLARule - норматив сборки проекта. Правило с пустым VidDoc применяется
+// к видам документов, для которых отдельное правило не задано.
+type SLARule struct {
+	gorm.Model
+	VidDoc string `gorm:"column:vid_doc;size:100;uniqueIndex" json:"vid_doc"`
+	// AssembleMinutes - собрать в течение стольких минут после загрузки, 0 - без ограничения
+	AssembleMinutes int `gorm:"column:assemble_minutes" json:"assemble_minutes"`
+	// ShipLeadMinutes - закончить сборку за столько минут до обещанной отгрузки
+	ShipLeadMinutes int `gorm:"column:ship_lead_minutes" json:"ship_lead_minutes"`
+	// AtRiskMinutes - за сколько минут до срока заказ под угрозой, 0 - DefaultSLAAtRisk
+	AtRiskMinutes int `gorm:"column:at_risk_minutes" json:"at_risk_minutes"`
+}
+
+func (SLARule) TableName() string {
+	return "sla_rules"
+}
+
+func (r SLARule) Scope() string {
+	return r.VidDoc
+}
+
+// Negative - в правиле есть отрицательные нормативы.
+func (r SLARule) Negative() bool {
+	return r.AssembleMinutes < 0 || r.ShipLeadMinutes < 0 || r.AtRiskMinutes < 0
+}
+
+// Due - срок окончания сборки: загрузка плюс норматив или обещанная отгрузка
+// минус запас, что наступит раньше. ok == false, если срок не определен.
+func (r SLARule) Due(o Order) (due time.Time, ok bool) {
+	if r.AssembleMinutes > 0 {
+		due = o.CreatedAt.Add(time.Duration(r.AssembleMinutes) * time.Minute)
+	}
+	if o.ShipBy != nil {
+		ship := o.ShipBy.Add(-time.Duration(r.ShipLeadMinutes) * time.Minute)
+		if due.IsZero() || ship.Before(due) {
+			due = ship
+		}
+	}
+	return due, !due.IsZero()
+}
+
+// OrderSLA - состояние норматива сборки заказа.
+type OrderSLA struct {
+	State SLAState  `json:"state"`
+	DueAt time.Time `json:"due_at"`
+}
+
+// SLARules - правила по видам документов.
+type SLARules map[string]SLARule
+
+func NewSLARules(rules []SLARule) SLARules {
+	byVidDoc := make(SLARules, len(rules))
+	for _, r := range rules {
+		byVidDoc[r.VidDoc] = r
+	}
+	return byVidDoc
+}
+
+// Rule - правило вида документа или правило по умолчанию.
+func (rules SLARules) Rule(vidDoc string) SLARule {
+	if r, ok := rules[vidDoc]; ok {
+		return r
+	}
+	return rules[""]
+}
+
+// Evaluate вычисляет состояние норматива на момент now. Собранный заказ
+// оценивается по отметке окончания сборки, без нее - по assembledAt, времени
+// перевода в "собран" из журнала; для заказов, собранных до появления журнала, -
+// по updated_at. Отмененный и возвращенный заказы не оцениваются.
+func (rules SLARules) Evaluate(o Order, assembledAt, now time.Time) *OrderSLA {
+	if o.Status == OrderStatusCancelled || o.Status == OrderStatusReturned {
+		return nil
+	}
+	rule := rules.Rule(o.VidDoc)
+	due, ok := rule.Due(o)
+	if !ok {
+		return nil
+	}
+	sla := &OrderSLA{State: SLAOk, DueAt: due.In(Location)}
+
+	if o.Status.Assembled() {
+		finished := o.UpdatedAt
+		switch {
+		case o.FinishAt != nil:
+			finished = *o.FinishAt
+		case !assembledAt.IsZero():
+			finished = assembledAt
+		}
+		if finished.After(due) {
+			sla.State = SLABreached
+		}
+		return sla
+	}
+
+	atRisk := DefaultSLAAtRisk
+	if rule.AtRiskMinutes > 0 {
+		atRisk = time.Duration(rule.AtRiskMinutes) * time.Minute
+	}
+	switch {
+	case now.After(due):
+		sla.State = SLABreached
+	case now.After(due.Add(-atRisk)):
+		sla.State = SLAAtRisk
+	}
+	return sla
+}
+
+// Apply заполняет состояние норматива у заказов. assembled - время перевода
+// в "собран" по журналу, см. OrderEventRepository.AssembledAt.
+func (rules SLARules) Apply(orders []Order, assembled map[uint]time.Time, now time.Time) {
+	for i := range orders {
+		orders[i].SLA = rules.Evaluate(orders[i], assembled[uint(orders[i].OrderUid)], now)
+	}
+}
+
+// AssembledUIDs - собранные заказы без отметки окончания, для которых
+// время сборки берется из журнала.
+func AssembledUIDs(orders []Order) []uint {
+	uids := make([]uint, 0)
+	for _, o := range orders {
+		if o.Status.Assembled() && o.FinishAt == nil {
+			uids = append(uids, uint(o.OrderUid))
+		}
+	}
+	return uids
+}
+
+// SLAOrders отбирает заказы в указанных состояниях, ближайший срок - первым.
+func SLAOrders(orders []Order, states ...SLAState) []Order {
+	selected := make([]Order, 0)
+	for _, o := range orders {
+		if o.SLA == nil {
+			continue
+		}
+		for _, state := range states {
+			if o.SLA.State == state {
+				selected = append(selected, o)
+				break
+			}
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool { return selected[i].SLA.DueAt.Before(selected[j].SLA.DueAt) })
+	return selected
+}
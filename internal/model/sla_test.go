@@ -0,0 +1,66 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func updated(o Order, at time.Time) Order {
+	o.UpdatedAt = at
+	return o
+}
+
+func TestSLARules_Evaluate(t *testing.T) {
+	loaded := time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+	due := loaded.Add(time.Hour)
+	rules := NewSLARules([]SLARule{{AssembleMinutes: 60}, {VidDoc: "B", AssembleMinutes: 60, AtRiskMinutes: 10}})
+	finish := due.Add(time.Minute)
+
+	tests := []struct {
+		name        string
+		order       Order
+		assembledAt time.Time
+		now         time.Time
+		want        SLAState
+	}{
+		{"open", Order{}, time.Time{}, loaded, SLAOk},
+		{"open at risk", Order{}, time.Time{}, due.Add(-20 * time.Minute), SLAAtRisk},
+		{"open at risk by rule", Order{VidDoc: "B"}, time.Time{}, due.Add(-20 * time.Minute), SLAOk},
+		{"open breached", Order{}, time.Time{}, due.Add(time.Second), SLABreached},
+		{"assembled on time, updated later", updated(Order{Status: OrderStatusShipped}, due.Add(time.Hour)),
+			due.Add(-time.Minute), due.Add(time.Hour), SLAOk},
+		{"assembled late", Order{Status: OrderStatusAssembled}, due.Add(time.Minute), due.Add(time.Hour), SLABreached},
+		{"finish mark wins", Order{Status: OrderStatusChecked, FinishAt: &finish}, due.Add(-time.Minute), due, SLABreached},
+		{"before journal", updated(Order{Status: OrderStatusAssembled}, due.Add(time.Minute)), time.Time{}, due, SLABreached},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.order.CreatedAt = loaded
+			sla := rules.Evaluate(tt.order, tt.assembledAt, tt.now)
+			if sla == nil || sla.State != tt.want || !sla.DueAt.Equal(due) {
+				t.Fatalf("expected %s due %v, got %+v", tt.want, due, sla)
+			}
+		})
+	}
+
+	for _, status := range []OrderStatus{OrderStatusCancelled, OrderStatusReturned} {
+		o := Order{Status: status}
+		o.CreatedAt = loaded
+		if sla := rules.Evaluate(o, time.Time{}, due); sla != nil {
+			t.Fatalf("expected %s order not to be evaluated, got %+v", status, sla)
+		}
+	}
+}
+
+func TestAssembledUIDs(t *testing.T) {
+	finish := time.Now()
+	orders := []Order{
+		{OrderUid: 1, Status: OrderStatusAssembled},
+		{OrderUid: 2, Status: OrderStatusShipped, FinishAt: &finish},
+		{OrderUid: 3, Status: OrderStatusPicking},
+		{OrderUid: 4, Status: OrderStatusChecked},
+	}
+	if got := AssembledUIDs(orders); len(got) != 2 || got[0] != 1 || got[1] != 4 {
+		t.Fatalf("unexpected uids %v", got)
+	}
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Location - часовой пояс склада. В нем разбираются даты без смещения
+// и выводятся даты заказов; задается в apiserver.Config.TimeZone.
+var Location = time.Local
+
+// timeLayouts - форматы дат без часового пояса. Кроме дд.мм.гггг принимаются
+// форматы, в которых даты заказов хранились строками до перехода на DATETIME.
+var timeLayouts = []string{
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+	"02.01.2006",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// ParseTime разбирает дату в формате RFC3339 или дд.мм.гггг [чч:мм[:сс]].
+// Даты без смещения относятся к часовому поясу склада.
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, Location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, expected RFC3339 or dd.mm.yyyy", s)
+}
+
+// dateOnly - в строке нет времени, только дата.
+func dateOnly(s string) bool {
+	return !strings.ContainsAny(strings.TrimSpace(s), ":T ")
+}
+
+// DateRange - полуинтервал [From, To) для фильтров по датам.
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// ParseDateRange разбирает границы периода. Конечная дата без времени
+// включает весь день, конечная дата со временем - всю указанную секунду.
+func ParseDateRange(start, finish string) (DateRange, error) {
+	from, err := ParseTime(start)
+	if err != nil {
+		return DateRange{}, err
+	}
+	to, err := ParseTime(finish)
+	if err != nil {
+		return DateRange{}, err
+	}
+
+	if dateOnly(finish) {
+		to = to.AddDate(0, 0, 1)
+	} else {
+		to = to.Truncate(time.Second).Add(time.Second)
+	}
+	if !to.After(from) {
+		return DateRange{}, fmt.Errorf("finish date %q is before start date %q", finish, start)
+	}
+	return DateRange{From: from, To: to}, nil
+}
+
+// Date - дата в часовом поясе склада для отображения, пустая для нулевого времени.
+func Date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(Location).Format("02.01.2006")
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// withLocation устанавливает часовой пояс склада на время теста.
+func withLocation(t *testing.T, loc *time.Location) {
+	t.Helper()
+	prev := Location
+	Location = loc
+	t.Cleanup(func() { Location = prev })
+}
+
+func TestParseTime(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	withLocation(t, msk)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2024-03-01T10:20:30Z", time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC)},
+		{"2024-03-01T10:20:30.5+05:00", time.Date(2024, 3, 1, 10, 20, 30, 5e8, time.FixedZone("", 5*60*60))},
+		{"01.03.2024", time.Date(2024, 3, 1, 0, 0, 0, 0, msk)},
+		{"01.03.2024 10:20", time.Date(2024, 3, 1, 10, 20, 0, 0, msk)},
+		{" 01.03.2024 10:20:30 ", time.Date(2024, 3, 1, 10, 20, 30, 0, msk)},
+		{"2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, msk)},
+		{"2024-03-01 10:20:30", time.Date(2024, 3, 1, 10, 20, 30, 0, msk)},
+		{"2024-03-01T10:20:30", time.Date(2024, 3, 1, 10, 20, 30, 0, msk)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.in)
+		if err != nil {
+			t.Errorf("ParseTime(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseTime_Invalid(t *testing.T) {
+	for _, in := range []string{"", "garbage", "31.02.2024", "2024-13-01", "01.03.24", "1.3.2024", "01/03/2024", "2024-03-01T25:00:00Z"} {
+		if got, err := ParseTime(in); err == nil {
+			t.Errorf("ParseTime(%q) = %v, expected error", in, got)
+		}
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	withLocation(t, msk)
+
+	tests := []struct {
+		name          string
+		start, finish string
+		from, to      time.Time
+	}{
+		{"end date includes the whole day", "01.03.2024", "31.03.2024",
+			time.Date(2024, 3, 1, 0, 0, 0, 0, msk), time.Date(2024, 4, 1, 0, 0, 0, 0, msk)},
+		{"one day", "2024-03-01", "2024-03-01",
+			time.Date(2024, 3, 1, 0, 0, 0, 0, msk), time.Date(2024, 3, 2, 0, 0, 0, 0, msk)},
+		{"end time includes its second", "01.03.2024 08:00", "01.03.2024 17:30:15",
+			time.Date(2024, 3, 1, 8, 0, 0, 0, msk), time.Date(2024, 3, 1, 17, 30, 16, 0, msk)},
+		{"fractional end second", "2024-03-01T00:00:00Z", "2024-03-01T10:00:00.250Z",
+			time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 10, 0, 1, 0, time.UTC)},
+		// Дата без смещения - полночь по времени склада, то есть 21:00 UTC накануне
+		{"mixed zones", "2024-03-01T00:00:00Z", "01.03.2024",
+			time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 21, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseDateRange(tt.start, tt.finish)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !r.From.Equal(tt.from) || !r.To.Equal(tt.to) {
+				t.Fatalf("got [%v, %v), want [%v, %v)", r.From, r.To, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestParseDateRange_Invalid(t *testing.T) {
+	tests := [][2]string{
+		{"garbage", "31.03.2024"},
+		{"01.03.2024", "garbage"},
+		{"", ""},
+		{"02.03.2024", "01.03.2024"},
+		{"01.03.2024 10:00", "01.03.2024 09:59:59"},
+	}
+	for _, tt := range tests {
+		if r, err := ParseDateRange(tt[0], tt[1]); err == nil {
+			t.Errorf("ParseDateRange(%q, %q) = %+v, expected error", tt[0], tt[1], r)
+		}
+	}
+}
+
+func TestDate(t *testing.T) {
+	withLocation(t, time.FixedZone("MSK", 3*60*60))
+	if got := Date(time.Date(2024, 2, 29, 22, 0, 0, 0, time.UTC)); got != "01.03.2024" {
+		t.Fatalf("got %q", got)
+	}
+	if got := Date(time.Time{}); got != "" {
+		t.Fatalf("zero time: got %q", got)
+	}
+}
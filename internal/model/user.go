@@ -7,7 +7,7 @@ import (
 type User struct {
 	gorm.Model
 	FirstName string     `gorm:"column:first_name" json:"first_name"`
-	Name      string     `gorm:"column:"name" json:"name" validate:"required"`
+	Name      string     `gorm:"column:name" json:"name" validate:"required"`
 	LastName  string     `gorm:"column:last_name" json:"last_name" validate:"required"`
 	Email     string     `gorm:"column:email;not null;unique" json:"email"`
 	Password  string     `gorm:"column:password" json:"password,omitempty" validate:"required"`
@@ -23,8 +23,8 @@ type User struct {
 }
 
 type UserEmployee struct {
-	ID   uint   `gorm:"column:"id" json:"id" `
-	Name string `gorm:"column:"name" json:"name" `
+	ID   uint   `gorm:"column:id" json:"id"`
+	Name string `gorm:"column:name" json:"name"`
 }
 
 func (User) TableName() string {
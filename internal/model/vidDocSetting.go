@@ -0,0 +1,9 @@
+package model
+
+// VidDocSetting - настройка, задаваемая по видам документов и сохраняемая
+// таблицей целиком: тариф, правило SLA.
+type VidDocSetting interface {
+	// Scope - вид документа, пустой - значение по умолчанию
+	Scope() string
+	Negative() bool
+}
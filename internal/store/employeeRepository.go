@@ -1,12 +1,16 @@
 package store
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type EmployeeRepository interface {
-	Add(model.Employee) (model.Employee, error)
-	All() ([]model.Employee, error)
-	ByID(uint) (model.Employee, error)
-	ByCode(string) (model.Employee, error)
-	Update(model.Employee) (model.Employee, error)
-	Delete(uint) error
+	Add(context.Context, model.Employee) (model.Employee, error)
+	All(context.Context) ([]model.Employee, error)
+	List(context.Context, model.ListQuery) (model.Page[model.Employee], error)
+	ByID(context.Context, uint) (model.Employee, error)
+	ByCode(context.Context, string) (model.Employee, error)
+	Update(context.Context, model.Employee) (model.Employee, error)
+	Delete(context.Context, uint) error
 }
\ No newline at end of file
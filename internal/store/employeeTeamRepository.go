@@ -1,14 +1,18 @@
 package store
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type EmployeeTeamRepository interface {
-	Add(model.EmployeeTeam) (model.EmployeeTeam, error)
-	All() ([]model.EmployeeTeam, error)
-	ByID(uint) (model.EmployeeTeam, error)
-	ByEmployeeID(uint) ([]model.EmployeeTeam, error)
-	ByTeamID(uint) ([]model.EmployeeTeam, error)
-	Update(model.EmployeeTeam) (model.EmployeeTeam, error)
-	Delete(uint) error
-	DeleteEmployeeTeam(uint, uint) error
+	Add(context.Context, model.EmployeeTeam) (model.EmployeeTeam, error)
+	All(context.Context) ([]model.EmployeeTeam, error)
+	List(context.Context, model.ListQuery) (model.Page[model.EmployeeTeam], error)
+	ByID(context.Context, uint) (model.EmployeeTeam, error)
+	ByEmployeeID(context.Context, uint) ([]model.EmployeeTeam, error)
+	ByTeamID(context.Context, uint) ([]model.EmployeeTeam, error)
+	Update(context.Context, model.EmployeeTeam) (model.EmployeeTeam, error)
+	Delete(context.Context, uint) error
+	DeleteEmployeeTeam(context.Context, uint, uint) error
 }
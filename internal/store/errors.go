@@ -0,0 +1,12 @@
+package store
+
+import "errors"
+
+var (
+	ErrIllegalTransition = errors.New("illegal order status transition")
+	ErrInvalidPick       = errors.New("invalid pick confirmation")
+	ErrPeriodClosed      = errors.New("payroll period is closed")
+	ErrInvalidCursor     = errors.New("invalid cursor")
+	ErrOtherCollector    = errors.New("order is assigned to another collector")
+	ErrNotAssigned       = errors.New("order is not assigned to a collector")
+)
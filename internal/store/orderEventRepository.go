@@ -0,0 +1,16 @@
+package store
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"time"
+)
+
+// OrderEventRepository - чтение журнала заказов. События пишутся
+// OrderRepository в одной транзакции с изменением заказа.
+type OrderEventRepository interface {
+	ByOrderUID(context.Context, uint) ([]model.OrderEvent, error)
+	// AssembledAt возвращает время последнего перевода заказов в статус "собран"
+	// по журналу. Заказы без такого события в результат не попадают.
+	AssembledAt(ctx context.Context, orderUIDs []uint) (map[uint]time.Time, error)
+}
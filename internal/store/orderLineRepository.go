@@ -0,0 +1,13 @@
+package store
+
+import (
+	"context"
+	"eastwh/internal/model"
+)
+
+type OrderLineRepository interface {
+	ByOrderUID(context.Context, uint) ([]model.OrderLine, error)
+	// ByBarcode возвращает строку с товаром по EAN. При orderUID = 0 ищется
+	// строка последнего заказа с этим товаром.
+	ByBarcode(ctx context.Context, barcode string, orderUID uint) (model.OrderLine, error)
+}
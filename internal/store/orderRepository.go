@@ -1,17 +1,28 @@
 package store
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type OrderRepository interface {
-	Add(model.Order) (model.Order, error)
-	SetCollector(orderuid uint, user_id uint, employee_id uint) error
-	ByUserID(uint) ([]model.Order, error)
-	ByAccessUser(uint, string, string) ([]model.Order, error)
-	ByID(uint) ([]model.Order, error)
-	ByOrderUID(uint) ([]model.Order, error)
-	ByDateRange(string, string) ([]model.Order, error)
-	All() ([]model.Order, error)
-	AssemblyOrder(string, string) ([]model.AssemblyOrder, error)
-	SetCheck(uint, uint, bool) error
-	CheckedList(string, string, bool) ([]model.Order, error)
+	Add(context.Context, model.Order, uint) (model.Order, error)
+	Upsert(context.Context, []model.Order, uint) ([]model.OrderImportResult, error)
+	Transition(context.Context, model.OrderTransition) (model.Order, error)
+	ConfirmPick(context.Context, model.PickConfirmation) (model.Order, error)
+	Work(context.Context, model.OrderWork) (model.Order, error)
+	Queue(context.Context, model.DispatchRequest) ([]model.Order, error)
+	Next(context.Context, model.DispatchRequest) (model.Order, error)
+	ByUserID(context.Context, uint) ([]model.Order, error)
+	ByAccessUser(context.Context, uint, model.DateRange) ([]model.Order, error)
+	ByID(context.Context, uint) ([]model.Order, error)
+	ByOrderUID(context.Context, uint) ([]model.Order, error)
+	ByBarcode(context.Context, int) (model.Order, error)
+	ByDateRange(context.Context, model.DateRange) ([]model.Order, error)
+	All(context.Context) ([]model.Order, error)
+	List(context.Context, model.ListQuery) (model.Page[model.Order], error)
+	// Search возвращает лучшие s.Limit совпадений и общее число найденных заказов.
+	Search(context.Context, model.OrderSearch) (model.Page[model.OrderSearchHit], error)
+	AssemblyOrder(context.Context, model.AssemblyFilter) ([]model.AssemblyOrder, error)
+	CheckedList(context.Context, model.DateRange, bool) ([]model.Order, error)
 }
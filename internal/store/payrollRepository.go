@@ -0,0 +1,23 @@
+package store
+
+import (
+	"context"
+	"eastwh/internal/model"
+)
+
+// PayrollRepository - тарифы сдельной оплаты и закрытые периоды.
+type PayrollRepository interface {
+	Tariffs(context.Context) ([]model.Tariff, error)
+	// SetTariffs заменяет таблицу тарифов целиком.
+	SetTariffs(context.Context, []model.Tariff) ([]model.Tariff, error)
+	// Shares возвращает доли участников, записанные при сборке заказов.
+	Shares(ctx context.Context, orderUIDs []int) ([]model.OrderShare, error)
+	Periods(context.Context) ([]model.PayrollPeriod, error)
+	// Overlapping возвращает закрытые периоды, пересекающиеся с интервалом дат, вместе с начислениями.
+	Overlapping(ctx context.Context, startDT, finishDT string) ([]model.PayrollPeriod, error)
+	// Paid возвращает заказы из orderUIDs, уже оплаченные в закрытых периодах.
+	Paid(ctx context.Context, orderUIDs []int) ([]int, error)
+	// Close сохраняет период с начислениями и оплаченными заказами. Если интервал дат сборки пересекается
+	// с уже закрытым периодом, возвращает ErrPeriodClosed.
+	Close(context.Context, model.PayrollPeriod) (model.PayrollPeriod, error)
+}
@@ -1,11 +1,15 @@
 package store
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type ProjectRepository interface {
-	Add(model.Project) (model.Project, error)
-	ByID(uint) (model.Project, error)
-	All() ([]model.Project, error)
-	Update(model.Project) (model.Project, error)
-	Delete(uint) error
+	Add(context.Context, model.Project) (model.Project, error)
+	ByID(context.Context, uint) (model.Project, error)
+	All(context.Context) ([]model.Project, error)
+	List(context.Context, model.ListQuery) (model.Page[model.Project], error)
+	Update(context.Context, model.Project) (model.Project, error)
+	Delete(context.Context, uint) error
 }
@@ -0,0 +1,15 @@
+package store
+
+import (
+	"context"
+	"eastwh/internal/model"
+)
+
+type RefreshTokenRepository interface {
+	Add(context.Context, model.RefreshToken) (model.RefreshToken, error)
+	ByHash(context.Context, string) (model.RefreshToken, error)
+	Use(context.Context, uint) (bool, error)
+	FamilyActive(context.Context, string) (bool, error)
+	RevokeFamily(context.Context, string) error
+	RevokeUser(context.Context, uint) error
+}
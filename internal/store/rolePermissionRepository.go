@@ -0,0 +1,12 @@
+package store
+
+import (
+	"context"
+	"eastwh/internal/model"
+)
+
+type RolePermissionRepository interface {
+	ByRoleID(context.Context, uint) ([]model.RolePermission, error)
+	ByRoleIDs(context.Context, []uint) ([]model.RolePermission, error)
+	SetForRole(context.Context, uint, []model.Permission) ([]model.RolePermission, error)
+}
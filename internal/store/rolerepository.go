@@ -1,11 +1,16 @@
 package store
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type RoleRepository interface {
-	Add(model.Role) (model.Role, error)
-	All() ([]model.Role, error)
-	ByID(uint) (model.Role, error)
-	Update(model.Role) (model.Role, error)
-	Delete(uint) error
+	Add(context.Context, model.Role) (model.Role, error)
+	All(context.Context) ([]model.Role, error)
+	List(context.Context, model.ListQuery) (model.Page[model.Role], error)
+	ByID(context.Context, uint) (model.Role, error)
+	ByName(context.Context, string) (model.Role, error)
+	Update(context.Context, model.Role) (model.Role, error)
+	Delete(context.Context, uint) error
 }
@@ -0,0 +1,13 @@
+package store
+
+import (
+	"context"
+	"eastwh/internal/model"
+)
+
+// SLARepository - нормативы сборки по проектам.
+type SLARepository interface {
+	Rules(context.Context) ([]model.SLARule, error)
+	// SetRules заменяет таблицу правил целиком.
+	SetRules(context.Context, []model.SLARule) ([]model.SLARule, error)
+}
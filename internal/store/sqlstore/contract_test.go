@@ -0,0 +1,66 @@
+package sqlstore
+
+import (
+	"context"
+	"eastwh/internal/migrate"
+	"eastwh/internal/store"
+	"eastwh/internal/store/storetest"
+	"os"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDSNEnv - DSN пустой базы MySQL для общего набора тестов хранилища.
+// Таблицы базы очищаются перед каждым тестом. Без переменной набор
+// пропускается; make test-mysql запускает его на MySQL из docker-compose.
+const testDSNEnv = "EASTWH_TEST_MYSQL_DSN"
+
+func TestStore_Contract(t *testing.T) {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := migrate.Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrate.New(db, migrations).Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	storetest.Run(t, func(t *testing.T) store.Store {
+		tables, err := db.Migrator().GetTables()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// TRUNCATE не допускает внешних ключей на таблицу, поэтому их
+		// проверка отключается на время очистки на одном соединении
+		err = db.Connection(func(conn *gorm.DB) error {
+			if err := conn.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+				return err
+			}
+			defer conn.Exec("SET FOREIGN_KEY_CHECKS = 1")
+			for _, table := range tables {
+				if table == migrate.Table {
+					continue
+				}
+				if err := conn.Exec("TRUNCATE TABLE " + table).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return New(db)
+	})
+}
@@ -1,31 +1,37 @@
 package sqlstore
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type EmployeeRepository struct {
 	store *Store
 }
 
-func (r *EmployeeRepository) Add(u model.Employee) (model.Employee, error) {
-	return u, r.store.db.Create(&u).Error
+func (r *EmployeeRepository) Add(ctx context.Context, u model.Employee) (model.Employee, error) {
+	return u, r.store.db.WithContext(ctx).Create(&u).Error
 }
 
-func (r *EmployeeRepository) All() (employee []model.Employee, err error) {
-	return employee, r.store.db.Table("employees").Select("*").Order("first_name").Order("name").Scan(&employee).Error
+func (r *EmployeeRepository) All(ctx context.Context) (employee []model.Employee, err error) {
+	return employee, r.store.db.WithContext(ctx).Table("employees").Select("*").Order("first_name").Order("name").Scan(&employee).Error
 }
 
-func (r *EmployeeRepository) ByID(id uint) (employee model.Employee, err error) {
+func (r *EmployeeRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.Employee], error) {
+	return list[model.Employee](r.store.db.WithContext(ctx), q, model.EmployeeListSpec)
+}
+
+func (r *EmployeeRepository) ByID(ctx context.Context, id uint) (employee model.Employee, err error) {
 	employee.ID = id
-	return employee, r.store.db.First(&employee).Error
+	return employee, r.store.db.WithContext(ctx).First(&employee).Error
 }
 
-func (r *EmployeeRepository) ByCode(code string) (employee model.Employee, err error) {
-	employee.Code = code
-	return employee, r.store.db.First(&employee).Error
+func (r *EmployeeRepository) ByCode(ctx context.Context, code string) (employee model.Employee, err error) {
+	return employee, r.store.db.WithContext(ctx).Where("code = ?", code).First(&employee).Error
 }
 
-func (r *EmployeeRepository) Update(u model.Employee) (model.Employee, error) {
-	return u, r.store.db.Model(&model.Employee{}).Where("id=?", u.ID).Updates(map[string]interface{}{
+func (r *EmployeeRepository) Update(ctx context.Context, u model.Employee) (model.Employee, error) {
+	return u, r.store.db.WithContext(ctx).Model(&model.Employee{}).Where("id=?", u.ID).Updates(map[string]interface{}{
 		"code":       u.Code,
 		"first_name": u.FirstName,
 		"name":       u.Name,
@@ -35,12 +41,12 @@ func (r *EmployeeRepository) Update(u model.Employee) (model.Employee, error) {
 	}).Error
 }
 
-func (r *EmployeeRepository) Delete(id uint) error {
+func (r *EmployeeRepository) Delete(ctx context.Context, id uint) error {
 	var employee model.Employee
-	result := r.store.db.Table("employees").Where("id=?", id)
+	result := r.store.db.WithContext(ctx).Table("employees").Where("id=?", id)
 	err := result.First(&employee).Error
 	if err != nil {
 		return err
 	}
-	return r.store.db.Delete(&employee).Error
+	return r.store.db.WithContext(ctx).Delete(&employee).Error
 }
@@ -0,0 +1,167 @@
+package sqlstore
+
+import (
+	"bytes"
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// listCursor - позиция после последней записи страницы: значение поля
+// сортировки и id, разрешающий совпадения значений.
+type listCursor struct {
+	Value any  `json:"v"`
+	ID    uint `json:"id"`
+}
+
+// list возвращает страницу записей T по параметрам q. Курсорная выборка идет
+// по индексу (колонка сортировки, id) и не зависит от глубины страницы, номер
+// страницы переводится в OFFSET. Связи из preloads загружаются только для
+// записей страницы.
+func list[T any](db *gorm.DB, q model.ListQuery, spec model.ListSpec, preloads ...string) (page model.Page[T], err error) {
+	if err = q.Normalize(spec); err != nil {
+		return page, err
+	}
+	column, desc, _ := q.Order(spec)
+	conds, _ := q.Conditions(spec)
+
+	stmt := &gorm.Statement{DB: db}
+	if err = stmt.Parse(new(T)); err != nil {
+		return page, err
+	}
+	field := stmt.Schema.LookUpField(column)
+	if field == nil {
+		return page, errors.New("unknown column " + column)
+	}
+
+	query := db.Model(new(T))
+	for _, c := range conds {
+		col := clause.Column{Name: c.Column}
+		if len(c.Values) == 1 {
+			query = query.Where(clause.Eq{Column: col, Value: c.Values[0]})
+		} else {
+			query = query.Where(clause.IN{Column: col, Values: c.Values})
+		}
+	}
+
+	if err = query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+
+	find := query.Session(&gorm.Session{}).
+		Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: desc})
+	if column != "id" {
+		find = find.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: desc})
+	}
+	for _, p := range preloads {
+		find = find.Preload(p)
+	}
+
+	page.Limit = q.Limit
+	switch {
+	case q.Page > 0:
+		page.Page = q.Page
+		err = find.Offset((q.Page - 1) * q.Limit).Limit(q.Limit).Find(&page.Items).Error
+		return page, err
+	case q.Cursor != "":
+		cursor, err := decodeCursor(q.Cursor, field)
+		if err != nil {
+			return page, err
+		}
+		find = find.Where(afterCursor(column, desc, cursor))
+	}
+
+	if err = find.Limit(q.Limit + 1).Find(&page.Items).Error; err != nil {
+		return page, err
+	}
+	if len(page.Items) > q.Limit {
+		page.Items = page.Items[:q.Limit]
+		page.NextCursor, err = encodeCursor(stmt.Schema, field, page.Items[q.Limit-1])
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	return page, err
+}
+
+func afterCursor(column string, desc bool, c listCursor) clause.Expression {
+	col, id := clause.Column{Name: column}, clause.Column{Name: "id"}
+	if column == "id" {
+		if desc {
+			return clause.Lt{Column: id, Value: c.ID}
+		}
+		return clause.Gt{Column: id, Value: c.ID}
+	}
+	if desc {
+		return clause.Or(clause.Lt{Column: col, Value: c.Value},
+			clause.And(clause.Eq{Column: col, Value: c.Value}, clause.Lt{Column: id, Value: c.ID}))
+	}
+	return clause.Or(clause.Gt{Column: col, Value: c.Value},
+		clause.And(clause.Eq{Column: col, Value: c.Value}, clause.Gt{Column: id, Value: c.ID}))
+}
+
+func encodeCursor[T any](s *schema.Schema, field *schema.Field, item T) (string, error) {
+	rv := reflect.ValueOf(&item).Elem()
+	value, _ := field.ValueOf(context.Background(), rv)
+	id, _ := s.LookUpField("id").ValueOf(context.Background(), rv)
+
+	// Значение приводится к базовому типу, чтобы статусы и другие типы
+	// со своим JSON попадали в запрос так же, как хранятся в базе
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = v.Uint()
+	case reflect.Float32, reflect.Float64:
+		value = v.Float()
+	case reflect.String:
+		value = v.String()
+	case reflect.Bool:
+		value = v.Bool()
+	}
+
+	c := listCursor{Value: value}
+	if idValue, ok := id.(uint); ok {
+		c.ID = idValue
+	}
+	data, err := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data), err
+}
+
+func decodeCursor(s string, field *schema.Field) (c listCursor, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, store.ErrInvalidCursor
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		return c, store.ErrInvalidCursor
+	}
+
+	switch v := c.Value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			c.Value = i
+		} else if c.Value, err = v.Float64(); err != nil {
+			return c, store.ErrInvalidCursor
+		}
+	case string:
+		if field.FieldType == reflect.TypeOf(time.Time{}) {
+			if c.Value, err = time.Parse(time.RFC3339Nano, v); err != nil {
+				return c, store.ErrInvalidCursor
+			}
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,40 @@
+package sqlstore
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"time"
+)
+
+type OrderEventRepository struct {
+	store *Store
+}
+
+func (r *OrderEventRepository) ByOrderUID(ctx context.Context, orderUID uint) (events []model.OrderEvent, err error) {
+	return events, r.store.db.WithContext(ctx).Where("order_uid = ?", orderUID).Order("created_at, id").Find(&events).Error
+}
+
+func (r *OrderEventRepository) AssembledAt(ctx context.Context, orderUIDs []uint) (map[uint]time.Time, error) {
+	assembled := make(map[uint]time.Time, len(orderUIDs))
+	if len(orderUIDs) == 0 {
+		return assembled, nil
+	}
+
+	var rows []struct {
+		OrderUID    uint      `gorm:"column:order_uid"`
+		AssembledAt time.Time `gorm:"column:assembled_at"`
+	}
+	err := r.store.db.WithContext(ctx).Model(&model.OrderEvent{}).
+		Select("order_uid, MAX(created_at) AS assembled_at").
+		Where("order_uid IN ? AND to_status = ? AND from_status <> ?", orderUIDs,
+			model.OrderStatusAssembled, model.OrderStatusAssembled).
+		Group("order_uid").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		assembled[row.OrderUID] = row.AssembledAt
+	}
+	return assembled, nil
+}
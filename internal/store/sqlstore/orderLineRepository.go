@@ -0,0 +1,24 @@
+package sqlstore
+
+import (
+	"context"
+	"eastwh/internal/model"
+)
+
+type OrderLineRepository struct {
+	store *Store
+}
+
+func (r *OrderLineRepository) ByOrderUID(ctx context.Context, orderUID uint) (lines []model.OrderLine, err error) {
+	return lines, r.store.db.WithContext(ctx).Where("order_uid = ?", orderUID).Order("location, line_no").Find(&lines).Error
+}
+
+// ByBarcode ищет строку с товаром по EAN. При orderUID = 0 поиск ведется по всем
+// заказам и возвращается строка последнего из них.
+func (r *OrderLineRepository) ByBarcode(ctx context.Context, barcode string, orderUID uint) (line model.OrderLine, err error) {
+	query := r.store.db.WithContext(ctx).Where("barcode = ?", barcode)
+	if orderUID != 0 {
+		query = query.Where("order_uid = ?", orderUID)
+	}
+	return line, query.Order("order_uid DESC, line_no").Take(&line).Error
+}
@@ -1,92 +1,707 @@
 package sqlstore
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
 
 type OrderRepository struct {
 	store *Store
 }
 
-func (r *OrderRepository) Add(u model.Order) (model.Order, error) {
-	return u, r.store.db.Create(&u).Error
+// saveOrderState записывает состояние заказа. При переходе в "собран"
+// фиксируются участники сборки и их доли в оплате.
+func saveOrderState(tx *gorm.DB, before model.Order, order *model.Order) error {
+	if err := tx.Model(order).Select(model.OrderStateColumns).Updates(order).Error; err != nil {
+		return err
+	}
+	if order.Status != model.OrderStatusAssembled || before.Status == model.OrderStatusAssembled {
+		return nil
+	}
+
+	var members []model.EmployeeTeam
+	if order.TeamID != 0 {
+		if err := tx.Where("team_id = ?", order.TeamID).Find(&members).Error; err != nil {
+			return err
+		}
+	}
+	ids := []uint{order.EmployeeID}
+	for _, m := range members {
+		ids = append(ids, m.EmployeeID)
+	}
+	var employees []model.Employee
+	if err := tx.Where("id IN ?", ids).Find(&employees).Error; err != nil {
+		return err
+	}
+	names := make(map[uint]string, len(employees))
+	for _, e := range employees {
+		names[e.ID] = e.FullName()
+	}
+
+	// Повторная сборка после возврата заменяет доли прошлой сборки
+	if err := tx.Where("order_uid = ?", order.OrderUid).Delete(&model.OrderShare{}).Error; err != nil {
+		return err
+	}
+	shares := model.AssemblyShares(*order, members, names)
+	if len(shares) == 0 {
+		return nil
+	}
+	return tx.Create(&shares).Error
 }
 
-func (r *OrderRepository) SetCollector(orderuid uint, user_id uint, employee_id uint) error {
-	err := r.store.db.Model(&model.Order{}).Where("order_uid=?", orderuid).Updates(map[string]interface{}{
-		"user_id":     user_id,
-		"employee_id": employee_id,
-		"done":        1,
-	}).Error
+// Add создает заказ с его строками и запись о загрузке в журнале.
+func (r *OrderRepository) Add(ctx context.Context, u model.Order, actorUserID uint) (model.Order, error) {
+	if err := model.ValidateOrderLines(u.Lines); err != nil {
+		return u, err
+	}
+	return u, r.store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&u).Error; err != nil {
+			return err
+		}
+
+		if len(u.Lines) > 0 {
+			u.Lines, _, _ = model.MergeOrderLines(uint(u.OrderUid), nil, u.Lines)
+			if err := tx.Create(&u.Lines).Error; err != nil {
+				return err
+			}
+		}
+
+		event := model.NewOrderEvent(model.OrderEventImport, actorUserID, nil, u)
+		return tx.Create(&event).Error
+	})
+}
 
+// orderImportBatchSize - число заказов, обрабатываемых одним запросом при загрузке.
+const orderImportBatchSize = 500
+
+// Upsert загружает заказы из ERP: новые создаются, у существующих обновляются
+// только поля ERP. Каждая пачка обрабатывается в своей транзакции, после ошибки
+// пачки ее заказы повторяются по одному: failed получают только те, которые
+// не удалось сохранить, загрузка остальных не прерывается.
+func (r *OrderRepository) Upsert(ctx context.Context, orders []model.Order, actorUserID uint) ([]model.OrderImportResult, error) {
+	results := make([]model.OrderImportResult, len(orders))
+	index := make(map[int]int, len(orders))
+	var pending []int
+
+	for i, o := range orders {
+		results[i] = model.OrderImportResult{OrderUID: o.OrderUid}
+		linesErr := model.ValidateOrderLines(o.Lines)
+		switch _, dup := index[o.OrderUid]; {
+		case o.OrderUid == 0:
+			results[i].Status = model.OrderImportFailed
+			results[i].Error = "order_uid is required"
+		case dup:
+			results[i].Status = model.OrderImportFailed
+			results[i].Error = "duplicate order_uid in batch"
+		case o.FolioDate.IsZero() || o.OrderDate.IsZero():
+			results[i].Status = model.OrderImportFailed
+			results[i].Error = "folio_date and order_date are required"
+		case linesErr != nil:
+			results[i].Status = model.OrderImportFailed
+			results[i].Error = linesErr.Error()
+		default:
+			index[o.OrderUid] = i
+			pending = append(pending, i)
+		}
+	}
+
+	model.ImportBatches(results, pending, orderImportBatchSize, func(batch []int) error {
+		return r.store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return r.upsertBatch(tx, orders, batch, results, actorUserID)
+		})
+	})
+
+	return results, nil
+}
+
+func (r *OrderRepository) upsertBatch(tx *gorm.DB, orders []model.Order, batch []int, results []model.OrderImportResult, actorUserID uint) error {
+	uids := make([]int, 0, len(batch))
+	for _, i := range batch {
+		uids = append(uids, orders[i].OrderUid)
+	}
+
+	var existing []model.Order
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_uid IN ?", uids).Find(&existing).Error
 	if err != nil {
 		return err
 	}
+	byUID := make(map[int]model.Order, len(existing))
+	for _, o := range existing {
+		byUID[o.OrderUid] = o
+	}
 
-	return nil
+	var existingLines []model.OrderLine
+	if len(existing) > 0 {
+		err = tx.Where("order_uid IN ?", uids).Find(&existingLines).Error
+		if err != nil {
+			return err
+		}
+	}
+	linesByUID := make(map[uint][]model.OrderLine)
+	for _, l := range existingLines {
+		linesByUID[l.OrderUID] = append(linesByUID[l.OrderUID], l)
+	}
+
+	now := time.Now()
+	var rows []model.Order
+	var lines []model.OrderLine
+	var removedLines []uint
+	var events []model.OrderEvent
+	for _, i := range batch {
+		imported := orders[i]
+		current, found := byUID[imported.OrderUid]
+
+		// Строки заказа обновляются, только если ERP их передала
+		linesChanged := false
+		if imported.Lines != nil {
+			uid := uint(imported.OrderUid)
+			changedLines, removed, changed := model.MergeOrderLines(uid, linesByUID[uid], imported.Lines)
+			for j := range changedLines {
+				changedLines[j].UpdatedAt = now
+			}
+			lines = append(lines, changedLines...)
+			removedLines = append(removedLines, removed...)
+			linesChanged = changed
+		}
+
+		switch {
+		case !found:
+			// Поля склада у нового заказа не принимаются от ERP
+			row := model.Order{OrderUid: imported.OrderUid}
+			row.MergeERP(imported)
+			rows = append(rows, row)
+			events = append(events, model.NewOrderEvent(model.OrderEventImport, actorUserID, nil, row))
+			results[i].Status = model.OrderImportCreated
+		case current.ERPEqual(imported) && !linesChanged:
+			results[i].Status = model.OrderImportUnchanged
+		default:
+			row := current
+			row.MergeERP(imported)
+			row.UpdatedAt = now
+			rows = append(rows, row)
+			events = append(events, model.NewOrderEvent(model.OrderEventImport, actorUserID, &current, row))
+			results[i].Status = model.OrderImportUpdated
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "order_uid"}},
+		DoUpdates: clause.AssignmentColumns(append([]string{"updated_at"}, model.OrderERPColumns...)),
+	}).Create(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	if len(removedLines) > 0 {
+		if err := tx.Unscoped().Delete(&model.OrderLine{}, removedLines).Error; err != nil {
+			return err
+		}
+	}
+	if len(lines) > 0 {
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "order_uid"}, {Name: "line_no"}},
+			DoUpdates: clause.AssignmentColumns(append([]string{"updated_at"}, model.OrderLineERPColumns...)),
+		}).Create(&lines).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Create(&events).Error
 }
 
-func (r *OrderRepository) ByUserID(userID uint) (order []model.Order, err error) {
-	return order, r.store.db.Where("user_id=?", userID).Find(&order).Error
+// Transition переводит заказ в новый статус. Строка заказа блокируется до конца
+// транзакции, чтобы параллельные переходы не обошли проверку допустимости.
+func (r *OrderRepository) Transition(ctx context.Context, t model.OrderTransition) (order model.Order, err error) {
+	err = r.store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_uid=?", t.OrderUID).First(&order).Error
+		if err != nil {
+			return err
+		}
+
+		if !order.Status.CanTransitionTo(t.Status) {
+			return fmt.Errorf("%w: %s -> %s", store.ErrIllegalTransition, order.Status, t.Status)
+		}
+
+		before := order
+		order.Apply(t)
+		if t.Status == model.OrderStatusAssembled {
+			order.StopWork(time.Now())
+		}
+
+		err = saveOrderState(tx, before, &order)
+		if err != nil {
+			return err
+		}
+
+		event := model.NewOrderEvent(t.EventType(before.Status), t.ActorID, &before, order)
+		return tx.Create(&event).Error
+	})
+	return order, err
 }
 
-func (r *OrderRepository) ByID(ID uint) (order []model.Order, err error) {
-	return order, r.store.db.Where("id=?", ID).Find(&order).Error
+// ConfirmPick записывает собранное количество по строкам. Назначенный заказ
+// переходит в сборку, после подтверждения всех строк - в "собран" с признаком недостачи.
+func (r *OrderRepository) ConfirmPick(ctx context.Context, p model.PickConfirmation) (order model.Order, err error) {
+	err = r.store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_uid=?", p.OrderUID).First(&order).Error
+		if err != nil {
+			return err
+		}
+
+		if order.Status != model.OrderStatusAssigned && order.Status != model.OrderStatusPicking {
+			return fmt.Errorf("%w: pick is not allowed in status %s", store.ErrIllegalTransition, order.Status)
+		}
+
+		var lines []model.OrderLine
+		if err := tx.Where("order_uid = ?", p.OrderUID).Find(&lines).Error; err != nil {
+			return err
+		}
+
+		changed, err := p.Apply(lines)
+		if err != nil {
+			return fmt.Errorf("%w: %v", store.ErrInvalidPick, err)
+		}
+		for _, l := range changed {
+			err := tx.Model(&l).Select("picked_quantity", "picked", "shortage_reason").Updates(&l).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		before := order
+		if order.Status == model.OrderStatusAssigned {
+			order.Apply(model.OrderTransition{Status: model.OrderStatusPicking, UserID: p.UserID, EmployeeID: p.EmployeeID})
+		}
+
+		complete, shortage, err := model.PickRollup(lines)
+		if err != nil {
+			return fmt.Errorf("%w: %v", store.ErrInvalidPick, err)
+		}
+		if complete {
+			order.Apply(model.OrderTransition{Status: model.OrderStatusAssembled, UserID: p.UserID, EmployeeID: p.EmployeeID})
+			order.Shortage = shortage
+			order.StopWork(time.Now())
+		}
+
+		if order.Status != before.Status {
+			err = saveOrderState(tx, before, &order)
+			if err != nil {
+				return err
+			}
+		}
+
+		event := model.NewOrderEvent(model.OrderEventPick, p.ActorID, &before, order)
+		return tx.Create(&event).Error
+	})
+	return order, err
 }
 
-func (r *OrderRepository) ByOrderUID(orderUID uint) (order []model.Order, err error) {
-	return order, r.store.db.Where("order_uid=?", orderUID).Find(&order).Error
+// Work отмечает начало, паузу, продолжение или окончание сборки. Отмечать время
+// может только сборщик, на которого назначен заказ: новый заказ сборщик
+// получает через очередь выдачи.
+func (r *OrderRepository) Work(ctx context.Context, w model.OrderWork) (order model.Order, err error) {
+	err = r.store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_uid=?", w.OrderUID).First(&order).Error
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case order.UserID == 0:
+			return store.ErrNotAssigned
+		case order.UserID != w.UserID:
+			return store.ErrOtherCollector
+		}
+
+		before := order
+		if err := order.ApplyWork(w); err != nil {
+			return fmt.Errorf("%w: %v", store.ErrIllegalTransition, err)
+		}
+
+		err = saveOrderState(tx, before, &order)
+		if err != nil {
+			return err
+		}
+
+		event := model.NewOrderEvent(w.EventType(), w.UserID, &before, order)
+		return tx.Create(&event).Error
+	})
+	return order, err
 }
 
-func (r *OrderRepository) ByDateRange(dtStart string, dtFinish string) (orders []model.Order, err error) {
-	return orders, r.store.db.Where("orders.order_date BETWEEN ? AND ?", dtStart, dtFinish).Find(&orders).Error
+// ByBarcode ищет заказ по штрихкоду накладной: номеру фолио или уникальному номеру.
+// При совпадении у нескольких заказов возвращается последний загруженный.
+func (r *OrderRepository) ByBarcode(ctx context.Context, code int) (order model.Order, err error) {
+	return order, r.store.db.WithContext(ctx).Where("folio_num = ? OR unicum_num = ?", code, code).Order("id DESC").First(&order).Error
 }
 
-func (r *OrderRepository) All() (orders []model.Order, err error) {
-	return orders, r.store.db.Find(&orders).Error
+func (r *OrderRepository) ByUserID(ctx context.Context, userID uint) (order []model.Order, err error) {
+	return order, r.store.db.WithContext(ctx).Where("user_id=?", userID).Find(&order).Error
 }
 
-func (r *OrderRepository) ByAccessUser(userID uint, startDT, finishDT string) (orders []model.Order, err error) {
-	return orders, r.store.db.Raw(`
-								SELECT o.*
-								FROM user_projects usp
-									LEFT JOIN projects p on usp.project_id = p.id
-									LEFT JOIN users u on u.id = usp.user_id
-									LEFT JOIN orders o on o.vid_doc	= p.vid_doc
-								WHERE o.deleted_at IS NULL
-									  AND usp.deleted_at IS NULL
-									  AND p.deleted_at IS NULL
-									  AND	usp.user_id = ?
-									  AND o.folio_date between ? and ?
-									  AND o.Done = 0								
-									  AND o.Check = 0
-`, userID, startDT, finishDT).Scan(&orders).Error
+func (r *OrderRepository) ByID(ctx context.Context, ID uint) (order []model.Order, err error) {
+	return order, r.store.db.WithContext(ctx).Where("id=?", ID).Find(&order).Error
 }
 
-func (r *OrderRepository) AssemblyOrder(startDT, finishDT string) (assemblyOrders []model.AssemblyOrder, err error) {
-	/*	return assemblyOrders, r.store.db.Raw(`
-												SELECT *
-												FROM eastwh.assembly_orders_vw ao
-												where ao.folio_date between ? and ?
-		`, startDT, finishDT).Scan(&assemblyOrders).Error
-	*/
-	return assemblyOrders, r.store.db.Raw("CALL `eastwh`.`get_assembly_orders`(?, ?)", startDT, finishDT).Scan(&assemblyOrders).Error
+func (r *OrderRepository) ByOrderUID(ctx context.Context, orderUID uint) (order []model.Order, err error) {
+	return order, r.store.db.WithContext(ctx).Where("order_uid=?", orderUID).Find(&order).Error
 }
 
-func (r *OrderRepository) SetCheck(orderuid uint, user_id uint, check bool) error {
-	err := r.store.db.Model(&model.Order{}).Where("order_uid=?", orderuid).Updates(map[string]interface{}{
-		"user_id": user_id,
-		"check":   check,
-	}).Error
+func (r *OrderRepository) ByDateRange(ctx context.Context, period model.DateRange) (orders []model.Order, err error) {
+	return orders, r.store.db.WithContext(ctx).Where("order_date >= ? AND order_date < ?", period.From, period.To).Find(&orders).Error
+}
 
+func (r *OrderRepository) All(ctx context.Context) (orders []model.Order, err error) {
+	return orders, r.store.db.WithContext(ctx).Find(&orders).Error
+}
+
+func (r *OrderRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.Order], error) {
+	return list[model.Order](r.store.db.WithContext(ctx), q, model.OrderListSpec)
+}
+
+// accessVidDocs - подзапрос видов документов проектов пользователя.
+func accessVidDocs(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Table("user_projects usp").
+		Select("p.vid_doc").
+		Joins("JOIN projects p ON p.id = usp.project_id AND p.deleted_at IS NULL").
+		Where("usp.deleted_at IS NULL AND usp.user_id = ?", userID)
+}
+
+// ByAccessUser возвращает несобранные заказы проектов пользователя за период.
+func (r *OrderRepository) ByAccessUser(ctx context.Context, userID uint, period model.DateRange) (orders []model.Order, err error) {
+	db := r.store.db.WithContext(ctx)
+	return orders, db.
+		Where("vid_doc IN (?)", accessVidDocs(db, userID)).
+		Where("folio_date >= ? AND folio_date < ?", period.From, period.To).
+		Where("status IN ?", []model.OrderStatus{model.OrderStatusNew, model.OrderStatusAssigned, model.OrderStatusPicking}).
+		Find(&orders).Error
+}
+
+// Queue возвращает очередь сборки пользователя в порядке выдачи по стратегии.
+func (r *OrderRepository) Queue(ctx context.Context, d model.DispatchRequest) (orders []model.Order, err error) {
+	query, err := r.dispatchQuery(r.store.db.WithContext(ctx), d)
+	if err != nil {
+		return nil, err
+	}
+	return orders, query.Limit(d.Limit).Find(&orders).Error
+}
+
+// Next выдает сборщику следующий заказ из очереди. Если у сборщика уже есть
+// назначенный и не начатый заказ, возвращается он, чтобы повторный запрос
+// с терминала не набирал заказы впрок. Строка кандидата блокируется с
+// SKIP LOCKED: параллельные запросы получают разные заказы, не дожидаясь
+// друг друга (MySQL 8.0+).
+func (r *OrderRepository) Next(ctx context.Context, d model.DispatchRequest) (order model.Order, err error) {
+	err = r.store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND status = ?", d.UserID, model.OrderStatusAssigned).
+			Order("updated_at").First(&order).Error
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		query, err := r.dispatchQuery(tx, d)
+		if err != nil {
+			return err
+		}
+		// Take, а не First: First заменил бы порядок стратегии сортировкой по id
+		err = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).Take(&order).Error
+		if err != nil {
+			return err
+		}
+
+		t := model.OrderTransition{Status: model.OrderStatusAssigned, UserID: d.UserID,
+			EmployeeID: d.EmployeeID, TeamID: d.TeamID, ActorID: d.UserID}
+		before := order
+		order.Apply(t)
+
+		err = saveOrderState(tx, before, &order)
+		if err != nil {
+			return err
+		}
+
+		event := model.NewOrderEvent(t.EventType(before.Status), t.ActorID, &before, order)
+		return tx.Create(&event).Error
+	})
+	return order, err
+}
+
+// dispatchQuery строит выборку очереди: новые заказы без сборщика по проектам
+// пользователя, упорядоченные по стратегии. Стратегии по клиенту и маршруту
+// поднимают в начало заказы клиента или водителя последнего заказа сборщика.
+func (r *OrderRepository) dispatchQuery(db *gorm.DB, d model.DispatchRequest) (*gorm.DB, error) {
+	query := db.Model(&model.Order{}).
+		Where("status = ? AND user_id = 0", model.OrderStatusNew).
+		Where("vid_doc IN (?)", accessVidDocs(db, d.UserID))
+	if d.VidDoc != "" {
+		query = query.Where("vid_doc = ?", d.VidDoc)
+	}
+
+	var last model.Order
+	if d.Strategy == model.DispatchClient || d.Strategy == model.DispatchRoute {
+		err := db.Where("user_id = ?", d.UserID).Order("updated_at DESC").Limit(1).Find(&last).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Сортировка собирается одним выражением: gorm не объединяет
+	// выражения ORDER BY с параметрами и обычные колонки
+	order := clause.Expr{SQL: "order_date, id"}
+	switch d.Strategy {
+	case model.DispatchPriority:
+		order.SQL = "priority DESC, ship_by IS NULL, ship_by, " + order.SQL
+	case model.DispatchClient:
+		if last.ID != 0 {
+			order = clause.Expr{SQL: "client_id = ? DESC, " + order.SQL, Vars: []any{last.ClientId}}
+		}
+	case model.DispatchRoute:
+		if last.ID != 0 && last.Driver != "" {
+			order = clause.Expr{SQL: "driver = ? DESC, " + order.SQL, Vars: []any{last.Driver}}
+		}
+	}
+	return query.Order(clause.OrderBy{Expression: order}), nil
+}
+
+// AssemblyOrder строит отчет о собранных заказах. Время сборки берется из отметки
+// окончания, без нее - из журнала заказа; для заказов, собранных до появления
+// журнала, - из updated_at.
+// assembledAt - время сборки заказа: более раннее из первого перевода
+// в "собран" по журналу и отметки окончания сборки.
+const assembledAt = "LEAST(COALESCE(ev.assembled_at, o.finish_at), COALESCE(o.finish_at, ev.assembled_at))"
+
+func (r *OrderRepository) AssemblyOrder(ctx context.Context, f model.AssemblyFilter) (assemblyOrders []model.AssemblyOrder, err error) {
+	db := r.store.db.WithContext(ctx)
+	period, err := f.DateRange()
+	if err != nil {
+		return nil, err
+	}
+
+	// Время сборки - первый перевод в "собран": повторная сборка после
+	// возврата в работу не переносит заказ в следующий период
+	assembled := db.Model(&model.OrderEvent{}).
+		Select("order_uid, MIN(created_at) AS assembled_at").
+		Where("to_status = ? AND from_status <> ?", model.OrderStatusAssembled, model.OrderStatusAssembled).
+		Group("order_uid")
+
+	query := db.Table("orders o").
+		Select(`o.order_uid, o.order_date, o.order_sum, o.folio_num, o.unicum_num, o.folio_date, o.folio_sum,
+			o.status, o.user_id, o.employee_id, o.team_id, o.created_at, o.client_name, o.vid_doc,
+			o.start_at, o.finish_at, o.work_seconds,
+			(SELECT COUNT(*) FROM order_lines ol WHERE ol.order_uid = o.order_uid AND ol.deleted_at IS NULL) AS line_count,
+			COALESCE(`+assembledAt+`, o.updated_at) AS assembly_date,
+			CONCAT_WS(' ', u.first_name, u.name, u.last_name) AS user_name,
+			CONCAT_WS(' ', e.first_name, e.name, e.last_name) AS employee_name`).
+		Joins("LEFT JOIN users u ON u.id = o.user_id").
+		Joins("LEFT JOIN employees e ON e.id = o.employee_id").
+		Joins("LEFT JOIN (?) ev ON ev.order_uid = o.order_uid", assembled).
+		Where("o.deleted_at IS NULL").
+		Where("o.status IN ?", []model.OrderStatus{model.OrderStatusAssembled, model.OrderStatusChecked, model.OrderStatusShipped})
+
+	if f.ByAssemblyDate {
+		query = query.Where(assembledAt+" >= ? AND "+assembledAt+" < ?", period.From, period.To)
+	} else {
+		query = query.Where("o.folio_date >= ? AND o.folio_date < ?", period.From, period.To)
+	}
+
+	if f.VidDoc != "" {
+		query = query.Where("o.vid_doc = ?", f.VidDoc)
+	}
+	if f.UserID != 0 {
+		query = query.Where("o.user_id = ?", f.UserID)
+	}
+	if f.EmployeeID != 0 {
+		query = query.Where("o.employee_id = ?", f.EmployeeID)
+	}
+	if f.TeamID != 0 {
+		query = query.Where("(o.team_id = ? OR o.employee_id IN (?))", f.TeamID, db.Model(&model.EmployeeTeam{}).
+			Select("employee_id").Where("team_id = ?", f.TeamID))
+	}
+
+	err = query.Order("assembly_date").Scan(&assemblyOrders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range assemblyOrders {
+		a := &assemblyOrders[i]
+		a.FolioDate, a.OrderDate = a.FolioDate.In(model.Location), a.OrderDate.In(model.Location)
+		a.CreatedAt, a.AssemblyDate = a.CreatedAt.In(model.Location), a.AssemblyDate.In(model.Location)
+		for _, t := range []*time.Time{a.StartAt, a.FinishAt} {
+			if t != nil {
+				*t = t.In(model.Location)
+			}
+		}
+		a.SetDuration()
+	}
+
+	return assemblyOrders, r.fillShortages(ctx, assemblyOrders)
+}
+
+// fillShortages дополняет собранные заказы строками с недостачей.
+func (r *OrderRepository) fillShortages(ctx context.Context, assemblyOrders []model.AssemblyOrder) error {
+	if len(assemblyOrders) == 0 {
+		return nil
+	}
+
+	uids := make([]int, 0, len(assemblyOrders))
+	for _, o := range assemblyOrders {
+		uids = append(uids, o.OrderUid)
+	}
+
+	var lines []model.OrderLine
+	err := r.store.db.WithContext(ctx).Where("order_uid IN ? AND picked = ? AND picked_quantity < quantity", uids, true).
+		Order("order_uid, line_no").Find(&lines).Error
 	if err != nil {
 		return err
 	}
+
+	byUID := make(map[int][]model.OrderLine)
+	for _, l := range lines {
+		byUID[int(l.OrderUID)] = append(byUID[int(l.OrderUID)], l)
+	}
+	for i := range assemblyOrders {
+		assemblyOrders[i].ShortageLines = byUID[assemblyOrders[i].OrderUid]
+		assemblyOrders[i].Shortage = len(assemblyOrders[i].ShortageLines) > 0
+	}
 	return nil
 }
 
-func (r *OrderRepository) CheckedList(startDT, finishDT string, checkStatus bool) (orders []model.Order, err error) {
-	return orders, r.store.db.Raw(`SELECT * 
-											FROM eastwh.orders o
-											where o.folio_date between ? and ?
-											AND IFNULL(o.check, 0) = ?
-	`, startDT, finishDT, checkStatus).Scan(&orders).Error
+func (r *OrderRepository) CheckedList(ctx context.Context, period model.DateRange, checkStatus bool) (orders []model.Order, err error) {
+	return orders, r.store.db.WithContext(ctx).
+		Where("folio_date >= ? AND folio_date < ?", period.From, period.To).
+		Where("IFNULL(`check`, 0) = ?", checkStatus).
+		Find(&orders).Error
+}
+
+// orderSearchIndex - полнотекстовый индекс по текстовым полям заказа.
+const (
+	orderSearchIndex   = "ft_orders_search"
+	orderSearchColumns = "client_name, client_address, driver, agent"
+	// minFullTextToken - innodb_ft_min_token_size по умолчанию; более короткие
+	// слова не попадают в индекс и ищутся через LIKE
+	minFullTextToken = 3
+)
+
+// CreateOrderSearchIndex создает полнотекстовый индекс для поиска заказов.
+// Для баз, отличных от MySQL, поиск работает через LIKE и индекс не нужен.
+func CreateOrderSearchIndex(db *gorm.DB) error {
+	if db.Dialector.Name() != "mysql" || db.Migrator().HasIndex(&model.Order{}, orderSearchIndex) {
+		return nil
+	}
+	return db.Exec("CREATE FULLTEXT INDEX " + orderSearchIndex + " ON orders (" + orderSearchColumns + ")").Error
+}
+
+// Search ищет заказы по словам запроса: каждое слово должно найтись в тексте
+// заказа, числа также сравниваются с номером накладной и уникальным номером.
+// В MySQL слова ищутся по FULLTEXT-индексу, ранжирование - по его релевантности.
+func (r *OrderRepository) Search(ctx context.Context, s model.OrderSearch) (page model.Page[model.OrderSearchHit], err error) {
+	db := r.store.db.WithContext(ctx)
+	terms, numbers := s.Terms(), s.Numbers()
+	fulltext := db.Dialector.Name() == "mysql"
+
+	query := db.Model(&model.Order{})
+	period, ok, err := s.DateRange()
+	if err != nil {
+		return page, err
+	}
+	if ok {
+		query = query.Where("folio_date >= ? AND folio_date < ?", period.From, period.To)
+	}
+
+	// Слова без чисел объединяются в одно условие MATCH, чтобы индекс
+	// использовался один раз; числа могут совпасть и с номером документа
+	var against, required []string
+	for _, t := range terms {
+		indexed := fulltext && utf8.RuneCountInString(t) >= minFullTextToken
+		if indexed {
+			against = append(against, t+"*")
+		}
+		n, err := strconv.Atoi(t)
+		if indexed && err != nil {
+			required = append(required, "+"+t+"*")
+			continue
+		}
+
+		var cond *gorm.DB
+		if indexed {
+			cond = db.Where("MATCH("+orderSearchColumns+") AGAINST(? IN BOOLEAN MODE)", t+"*")
+		} else {
+			like := "%" + escapeLike(t) + "%"
+			cond = db.Where("client_name LIKE ? ESCAPE '!' OR client_address LIKE ? ESCAPE '!' OR "+
+				"driver LIKE ? ESCAPE '!' OR agent LIKE ? ESCAPE '!'", like, like, like, like)
+		}
+		if err == nil {
+			cond = cond.Or("folio_num = ? OR unicum_num = ?", n, n)
+		}
+		query = query.Where(cond)
+	}
+	if len(required) > 0 {
+		query = query.Where("MATCH("+orderSearchColumns+") AGAINST(? IN BOOLEAN MODE)", strings.Join(required, " "))
+	}
+
+	page.Limit = s.Limit
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return page, err
+	}
+
+	var rows []struct {
+		model.Order `gorm:"embedded"`
+		Score       float64 `gorm:"column:score"`
+	}
+	if len(against) > 0 {
+		// Надбавка за номер документа входит в ранжирование до LIMIT
+		score := clause.Expr{
+			SQL:  "orders.*, MATCH(" + orderSearchColumns + ") AGAINST(? IN BOOLEAN MODE) AS score",
+			Vars: []interface{}{strings.Join(against, " ")},
+		}
+		if len(numbers) > 0 {
+			score.SQL = "orders.*, MATCH(" + orderSearchColumns + ") AGAINST(? IN BOOLEAN MODE)" +
+				" + (folio_num IN ?) * ? + (unicum_num IN ?) * ? AS score"
+			score.Vars = append(score.Vars, numbers, model.OrderNumberScore, numbers, model.OrderNumberScore)
+		}
+		query = query.Select("?", score).Order("score DESC")
+	}
+	err = query.Order("id DESC").Limit(s.Limit).Scan(&rows).Error
+	if err != nil {
+		return page, err
+	}
+
+	hits := make([]model.OrderSearchHit, len(rows))
+	for i, row := range rows {
+		row.Order.AfterFind(nil)
+		hits[i] = model.OrderSearchHit{Order: row.Order, Score: row.Score}
+		if len(against) == 0 {
+			hits[i].Score = model.ScoreOrder(row.Order, terms)
+		}
+		hits[i].Highlight(terms)
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	page.Items = hits
+	return page, nil
+}
+
+// likeEscaper экранирует в слове поиска символы шаблона LIKE. Экранирующий
+// символ '!' задается явно: обратная косая черта экранирует по умолчанию
+// только в MySQL, а с ESCAPE '!' в любой базе остается обычным символом.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// escapeLike возвращает слово, которое LIKE ... ESCAPE '!' сравнивает буквально.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
 }
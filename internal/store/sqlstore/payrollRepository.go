@@ -0,0 +1,67 @@
+package sqlstore
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PayrollRepository struct {
+	store *Store
+}
+
+func (r *PayrollRepository) Tariffs(ctx context.Context) (tariffs []model.Tariff, err error) {
+	return tariffs, r.store.db.WithContext(ctx).Order("vid_doc").Find(&tariffs).Error
+}
+
+func (r *PayrollRepository) SetTariffs(ctx context.Context, tariffs []model.Tariff) ([]model.Tariff, error) {
+	return replaceAll(r.store.db.WithContext(ctx), tariffs)
+}
+
+func (r *PayrollRepository) Shares(ctx context.Context, orderUIDs []int) (shares []model.OrderShare, err error) {
+	if len(orderUIDs) == 0 {
+		return nil, nil
+	}
+	return shares, r.store.db.WithContext(ctx).Where("order_uid IN ?", orderUIDs).Order("id").Find(&shares).Error
+}
+
+func (r *PayrollRepository) Periods(ctx context.Context) (periods []model.PayrollPeriod, err error) {
+	return periods, r.store.db.WithContext(ctx).Order("start_dt DESC").Find(&periods).Error
+}
+
+func (r *PayrollRepository) Overlapping(ctx context.Context, startDT, finishDT string) (periods []model.PayrollPeriod, err error) {
+	return periods, overlapping(r.store.db.WithContext(ctx), startDT, finishDT).
+		Preload("Entries").Order("start_dt").Find(&periods).Error
+}
+
+func (r *PayrollRepository) Paid(ctx context.Context, orderUIDs []int) (paid []int, err error) {
+	if len(orderUIDs) == 0 {
+		return nil, nil
+	}
+	return paid, r.store.db.WithContext(ctx).Model(&model.PayrollOrder{}).
+		Where("order_uid IN ?", orderUIDs).Pluck("order_uid", &paid).Error
+}
+
+func (r *PayrollRepository) Close(ctx context.Context, p model.PayrollPeriod) (model.PayrollPeriod, error) {
+	err := r.store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Блокировка пересекающихся периодов не дает закрыть один интервал дважды
+		var count int64
+		err := overlapping(tx, p.StartDT, p.FinishDT).Model(&model.PayrollPeriod{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return store.ErrPeriodClosed
+		}
+		return tx.Create(&p).Error
+	})
+	return p, err
+}
+
+func overlapping(db *gorm.DB, startDT, finishDT string) *gorm.DB {
+	return db.Where("start_dt <= ? AND finish_dt >= ?", finishDT, startDT)
+}
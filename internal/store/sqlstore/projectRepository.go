@@ -1,34 +1,41 @@
 package sqlstore
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type ProjectRepository struct {
 	store *Store
 }
 
-func (r *ProjectRepository) Add(u model.Project) (model.Project, error) {
-	return u, r.store.db.Create(&u).Error
+func (r *ProjectRepository) Add(ctx context.Context, u model.Project) (model.Project, error) {
+	return u, r.store.db.WithContext(ctx).Create(&u).Error
 }
 
-func (r *ProjectRepository) All() (project []model.Project, err error) {
-	return project, r.store.db.Find(&project).Error
+func (r *ProjectRepository) All(ctx context.Context) (project []model.Project, err error) {
+	return project, r.store.db.WithContext(ctx).Find(&project).Error
 }
 
-func (r *ProjectRepository) ByID(id uint) (project model.Project, err error) {
+func (r *ProjectRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.Project], error) {
+	return list[model.Project](r.store.db.WithContext(ctx), q, model.ProjectListSpec)
+}
+
+func (r *ProjectRepository) ByID(ctx context.Context, id uint) (project model.Project, err error) {
 	project.ID = id
-	return project, r.store.db.First(&project, id).Error
+	return project, r.store.db.WithContext(ctx).First(&project, id).Error
 }
 
-func (r *ProjectRepository) Update(u model.Project) (project model.Project, err error) {
-	return u, r.store.db.Model(&u).Update("name", u.Name).Error
+func (r *ProjectRepository) Update(ctx context.Context, u model.Project) (project model.Project, err error) {
+	return u, r.store.db.WithContext(ctx).Model(&u).Update("name", u.Name).Error
 }
 
-func (r *ProjectRepository) Delete(id uint) error {
+func (r *ProjectRepository) Delete(ctx context.Context, id uint) error {
 	var project model.Project
-	result := r.store.db.Table("projects").Where("id=?", id)
+	result := r.store.db.WithContext(ctx).Table("projects").Where("id=?", id)
 	err := result.First(&project).Error
 	if err != nil {
 		return err
 	}
-	return r.store.db.Delete(&project).Error
+	return r.store.db.WithContext(ctx).Delete(&project).Error
 }
@@ -0,0 +1,48 @@
+package sqlstore
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"time"
+)
+
+type RefreshTokenRepository struct {
+	store *Store
+}
+
+func (r *RefreshTokenRepository) Add(ctx context.Context, t model.RefreshToken) (model.RefreshToken, error) {
+	return t, r.store.db.WithContext(ctx).Create(&t).Error
+}
+
+func (r *RefreshTokenRepository) ByHash(ctx context.Context, hash string) (t model.RefreshToken, err error) {
+	return t, r.store.db.WithContext(ctx).Where("token_hash = ?", hash).First(&t).Error
+}
+
+// Use помечает токен использованным. Условное обновление гарантирует, что из
+// двух параллельных запросов с одним токеном успешным будет только один.
+func (r *RefreshTokenRepository) Use(ctx context.Context, id uint) (bool, error) {
+	result := r.store.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *RefreshTokenRepository) FamilyActive(ctx context.Context, familyID string) (bool, error) {
+	var count int64
+	err := r.store.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL AND expires_at > ?", familyID, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.store.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *RefreshTokenRepository) RevokeUser(ctx context.Context, userID uint) error {
+	return r.store.db.WithContext(ctx).Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,33 @@
+package sqlstore
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// replaceAll заменяет таблицу записей T целиком: старые записи удаляются,
+// новые получают id заново. Удаляем безвозвратно, иначе удаленные записи
+// заняли бы уникальные ключи, например vid_doc тарифов и правил SLA.
+func replaceAll[T any](db *gorm.DB, rows []T) ([]T, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return rows, err
+	}
+	for i := range rows {
+		if err := stmt.Schema.PrioritizedPrimaryField.Set(db.Statement.Context, reflect.ValueOf(&rows[i]).Elem(), 0); err != nil {
+			return rows, err
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("1 = 1").Delete(new(T)).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+	return rows, err
+}
@@ -0,0 +1,41 @@
+package sqlstore
+
+import (
+	"context"
+	"eastwh/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type RolePermissionRepository struct {
+	store *Store
+}
+
+func (r *RolePermissionRepository) ByRoleID(ctx context.Context, roleID uint) (rp []model.RolePermission, err error) {
+	return rp, r.store.db.WithContext(ctx).Where("role_id = ?", roleID).Order("permission").Find(&rp).Error
+}
+
+func (r *RolePermissionRepository) ByRoleIDs(ctx context.Context, roleIDs []uint) (rp []model.RolePermission, err error) {
+	if len(roleIDs) == 0 {
+		return rp, nil
+	}
+	return rp, r.store.db.WithContext(ctx).Where("role_id IN ?", roleIDs).Find(&rp).Error
+}
+
+// SetForRole полностью заменяет набор прав роли.
+func (r *RolePermissionRepository) SetForRole(ctx context.Context, roleID uint, permissions []model.Permission) (rp []model.RolePermission, err error) {
+	err = r.store.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("role_id = ?", roleID).Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+
+		for _, p := range permissions {
+			rp = append(rp, model.RolePermission{RoleID: roleID, Permission: p})
+		}
+		if len(rp) == 0 {
+			return nil
+		}
+		return tx.Create(&rp).Error
+	})
+	return rp, err
+}
@@ -1,36 +1,47 @@
 package sqlstore
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type RoleRepository struct {
 	store *Store
 }
 
-func (r *RoleRepository) Add(u model.Role) (model.Role, error) {
-	return u, r.store.db.Create(&u).Error
+func (r *RoleRepository) Add(ctx context.Context, u model.Role) (model.Role, error) {
+	return u, r.store.db.WithContext(ctx).Create(&u).Error
 }
 
-func (r *RoleRepository) All() (roles []model.Role, err error) {
-	return roles, r.store.db.Find(&roles).Error
+func (r *RoleRepository) All(ctx context.Context) (roles []model.Role, err error) {
+	return roles, r.store.db.WithContext(ctx).Find(&roles).Error
 }
 
-func (r *RoleRepository) ByID(id uint) (role model.Role, err error) {
+func (r *RoleRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.Role], error) {
+	return list[model.Role](r.store.db.WithContext(ctx), q, model.RoleListSpec)
+}
+
+func (r *RoleRepository) ByID(ctx context.Context, id uint) (role model.Role, err error) {
 	role.ID = id
-	return role, r.store.db.First(&role, id).Error
+	return role, r.store.db.WithContext(ctx).First(&role, id).Error
+}
+
+func (r *RoleRepository) ByName(ctx context.Context, name string) (role model.Role, err error) {
+	return role, r.store.db.WithContext(ctx).Where("name = ?", name).First(&role).Error
 }
 
-func (r *RoleRepository) Update(u model.Role) (model.Role, error) {
-	return u, r.store.db.Model(&u).Updates(map[string]interface{}{"name": u.Name,
+func (r *RoleRepository) Update(ctx context.Context, u model.Role) (model.Role, error) {
+	return u, r.store.db.WithContext(ctx).Model(&u).Updates(map[string]interface{}{"name": u.Name,
 		"description": u.Description,
 		"priority":    u.Priority}).Error
 }
 
-func (r *RoleRepository) Delete(id uint) error {
+func (r *RoleRepository) Delete(ctx context.Context, id uint) error {
 	var role model.Role
-	result := r.store.db.Table("roles").Where("id=?", id)
+	result := r.store.db.WithContext(ctx).Table("roles").Where("id=?", id)
 	err := result.First(&role).Error
 	if err != nil {
 		return err
 	}
-	return r.store.db.Delete(&role).Error
+	return r.store.db.WithContext(ctx).Delete(&role).Error
 }
@@ -0,0 +1,18 @@
+package sqlstore
+
+import (
+	"context"
+	"eastwh/internal/model"
+)
+
+type SLARepository struct {
+	store *Store
+}
+
+func (r *SLARepository) Rules(ctx context.Context) (rules []model.SLARule, err error) {
+	return rules, r.store.db.WithContext(ctx).Order("vid_doc").Find(&rules).Error
+}
+
+func (r *SLARepository) SetRules(ctx context.Context, rules []model.SLARule) ([]model.SLARule, error) {
+	return replaceAll(r.store.db.WithContext(ctx), rules)
+}
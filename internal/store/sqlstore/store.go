@@ -1,23 +1,30 @@
 package sqlstore
 
 import (
+	"context"
 	"eastwh/internal/store"
 
 	"gorm.io/gorm"
 )
 
 type Store struct {
-	db                     *gorm.DB
-	userRepository         *UserRepository
-	userTeamRepository     *UserTeamRepository
-	userRoleRepository     *UserRoleRepository
-	userProjectRepository  *UserProjectRepository
-	teamRepository         *TeamRepository
-	orderRepository        *OrderRepository
-	projectRepository      *ProjectRepository
-	employeeRepository     *EmployeeRepository
-	roleRepository         *RoleRepository
-	employeeTeamRepository *EmployeeTeamRepository
+	db                       *gorm.DB
+	userRepository           *UserRepository
+	userTeamRepository       *UserTeamRepository
+	userRoleRepository       *UserRoleRepository
+	userProjectRepository    *UserProjectRepository
+	teamRepository           *TeamRepository
+	orderRepository          *OrderRepository
+	orderEventRepository     *OrderEventRepository
+	orderLineRepository      *OrderLineRepository
+	payrollRepository        *PayrollRepository
+	projectRepository        *ProjectRepository
+	refreshTokenRepository   *RefreshTokenRepository
+	employeeRepository       *EmployeeRepository
+	roleRepository           *RoleRepository
+	rolePermissionRepository *RolePermissionRepository
+	slaRepository            *SLARepository
+	employeeTeamRepository   *EmployeeTeamRepository
 }
 
 func New(db *gorm.DB) *Store {
@@ -26,6 +33,12 @@ func New(db *gorm.DB) *Store {
 	}
 }
 
+func (s *Store) WithTx(ctx context.Context, fn func(store.Store) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(New(tx))
+	})
+}
+
 func (s *Store) User() store.UserRepository {
 	if s.userRepository != nil {
 		return s.userRepository
@@ -62,6 +75,30 @@ func (s *Store) Order() store.OrderRepository {
 	return s.orderRepository
 }
 
+func (s *Store) OrderEvent() store.OrderEventRepository {
+	if s.orderEventRepository != nil {
+		return s.orderEventRepository
+	}
+
+	s.orderEventRepository = &OrderEventRepository{
+		store: s,
+	}
+
+	return s.orderEventRepository
+}
+
+func (s *Store) OrderLine() store.OrderLineRepository {
+	if s.orderLineRepository != nil {
+		return s.orderLineRepository
+	}
+
+	s.orderLineRepository = &OrderLineRepository{
+		store: s,
+	}
+
+	return s.orderLineRepository
+}
+
 func (s *Store) Project() store.ProjectRepository {
 	if s.projectRepository != nil {
 		return s.projectRepository
@@ -74,6 +111,18 @@ func (s *Store) Project() store.ProjectRepository {
 	return s.projectRepository
 }
 
+func (s *Store) RefreshToken() store.RefreshTokenRepository {
+	if s.refreshTokenRepository != nil {
+		return s.refreshTokenRepository
+	}
+
+	s.refreshTokenRepository = &RefreshTokenRepository{
+		store: s,
+	}
+
+	return s.refreshTokenRepository
+}
+
 func (s *Store) Role() store.RoleRepository {
 	if s.roleRepository != nil {
 		return s.roleRepository
@@ -86,6 +135,18 @@ func (s *Store) Role() store.RoleRepository {
 	return s.roleRepository
 }
 
+func (s *Store) RolePermission() store.RolePermissionRepository {
+	if s.rolePermissionRepository != nil {
+		return s.rolePermissionRepository
+	}
+
+	s.rolePermissionRepository = &RolePermissionRepository{
+		store: s,
+	}
+
+	return s.rolePermissionRepository
+}
+
 func (s *Store) Employee() store.EmployeeRepository {
 	if s.employeeRepository != nil {
 		return s.employeeRepository
@@ -145,3 +206,27 @@ func (s *Store) EmployeeTeam() store.EmployeeTeamRepository {
 
 	return s.employeeTeamRepository
 }
+
+func (s *Store) Payroll() store.PayrollRepository {
+	if s.payrollRepository != nil {
+		return s.payrollRepository
+	}
+
+	s.payrollRepository = &PayrollRepository{
+		store: s,
+	}
+
+	return s.payrollRepository
+}
+
+func (s *Store) SLA() store.SLARepository {
+	if s.slaRepository != nil {
+		return s.slaRepository
+	}
+
+	s.slaRepository = &SLARepository{
+		store: s,
+	}
+
+	return s.slaRepository
+}
@@ -0,0 +1,217 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"eastwh/internal/model"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// blockingConnector выдает соединения, запросы которых выполняются, пока
+// не будет отменен их контекст, - как долгий запрос к MySQL.
+type blockingConnector struct {
+	aborted chan error
+}
+
+func (c *blockingConnector) Connect(context.Context) (driver.Conn, error) {
+	return &blockingConn{aborted: c.aborted}, nil
+}
+
+func (c *blockingConnector) Driver() driver.Driver {
+	return nil
+}
+
+type blockingConn struct {
+	aborted chan error
+}
+
+func (c *blockingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	select {
+	case <-ctx.Done():
+		c.aborted <- ctx.Err()
+		return nil, ctx.Err()
+	case <-time.After(5 * time.Second):
+		c.aborted <- nil
+		return nil, errors.New("query was not cancelled")
+	}
+}
+
+func (c *blockingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (c *blockingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (c *blockingConn) Close() error {
+	return nil
+}
+
+func newBlockingStore(t *testing.T) (*Store, chan error) {
+	t.Helper()
+	aborted := make(chan error, 1)
+	sqlDB := sql.OpenDB(&blockingConnector{aborted: aborted})
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(db), aborted
+}
+
+func TestRepository_DeadlineAbortsQuery(t *testing.T) {
+	st, aborted := newBlockingStore(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := st.Order().All(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("query took %v, expected to stop at the deadline", elapsed)
+	}
+	if err := <-aborted; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("driver query was not aborted: %v", err)
+	}
+}
+
+func TestRepository_CancelAbortsQuery(t *testing.T) {
+	st, aborted := newBlockingStore(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := st.Employee().ByID(ctx, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	if err := <-aborted; !errors.Is(err, context.Canceled) {
+		t.Fatalf("driver query was not aborted: %v", err)
+	}
+}
+
+var errRecorded = errors.New("recorded")
+
+// recordingConnector выдает соединения, которые запоминают запросы и их
+// аргументы и возвращают errRecorded вместо результата.
+type recordingConnector struct {
+	queries []recordedQuery
+}
+
+type recordedQuery struct {
+	sql  string
+	args []any
+}
+
+func (c *recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	return &recordingConn{connector: c}, nil
+}
+
+func (c *recordingConnector) Driver() driver.Driver {
+	return nil
+}
+
+type recordingConn struct {
+	connector *recordingConnector
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q := recordedQuery{sql: query}
+	for _, a := range args {
+		q.args = append(q.args, a.Value)
+	}
+	c.connector.queries = append(c.connector.queries, q)
+	return nil, errRecorded
+}
+
+func (c *recordingConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+func newRecordingStore(t *testing.T) (*Store, *recordingConnector) {
+	t.Helper()
+	connector := &recordingConnector{}
+	sqlDB := sql.OpenDB(connector)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(db), connector
+}
+
+// Поиск по коду бейджа должен фильтровать по коду, иначе любой бейдж
+// находит первого сотрудника справочника.
+func TestEmployeeRepository_ByCodeFiltersByCode(t *testing.T) {
+	st, connector := newRecordingStore(t)
+
+	if _, err := st.Employee().ByCode(context.Background(), "E200"); !errors.Is(err, errRecorded) {
+		t.Fatalf("expected recorded query, got %v", err)
+	}
+	if len(connector.queries) != 1 {
+		t.Fatalf("expected one query, got %+v", connector.queries)
+	}
+	q := connector.queries[0]
+	if !strings.Contains(q.sql, "code = ?") || len(q.args) == 0 || q.args[0] != "E200" {
+		t.Fatalf("query does not filter by code: %s %v", q.sql, q.args)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"ромашка": "ромашка",
+		"100%":    "100!%",
+		"a_b":     "a!_b",
+		`c:\d`:    `c:\d`,
+		"!":       "!!",
+	}
+	for in, want := range tests {
+		if got := escapeLike(in); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// Короткие слова ищутся через LIKE, и символы шаблона в них не должны
+// расширять поиск.
+func TestOrderRepository_SearchEscapesLike(t *testing.T) {
+	st, connector := newRecordingStore(t)
+
+	if _, err := st.Order().Search(context.Background(), model.OrderSearch{Q: "ив", Limit: 10}); !errors.Is(err, errRecorded) {
+		t.Fatalf("expected recorded query, got %v", err)
+	}
+	if len(connector.queries) == 0 {
+		t.Fatal("expected a query")
+	}
+	q := connector.queries[0]
+	if !strings.Contains(q.sql, "LIKE ? ESCAPE '!'") || len(q.args) == 0 || q.args[0] != "%ив%" {
+		t.Fatalf("query does not escape LIKE: %s %v", q.sql, q.args)
+	}
+}
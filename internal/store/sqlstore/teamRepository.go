@@ -1,33 +1,40 @@
 package sqlstore
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type TeamRepository struct {
 	store *Store
 }
 
-func (r *TeamRepository) Add(u model.Team) (model.Team, error) {
-	return u, r.store.db.Create(&u).Error
+func (r *TeamRepository) Add(ctx context.Context, u model.Team) (model.Team, error) {
+	return u, r.store.db.WithContext(ctx).Create(&u).Error
 }
 
-func (r *TeamRepository) ByID(id uint) (team model.Team, err error) {
-	return team, r.store.db.Where("id=?", id).First(&team).Error
+func (r *TeamRepository) ByID(ctx context.Context, id uint) (team model.Team, err error) {
+	return team, r.store.db.WithContext(ctx).Where("id=?", id).First(&team).Error
 }
 
-func (r *TeamRepository) All() (teams []model.Team, err error) {
-	return teams, r.store.db.Model(&model.Team{}).Preload("Employees").Find(&teams).Error
+func (r *TeamRepository) All(ctx context.Context) (teams []model.Team, err error) {
+	return teams, r.store.db.WithContext(ctx).Model(&model.Team{}).Preload("Employees").Find(&teams).Error
 }
 
-func (r *TeamRepository) Update(u model.Team) (model.Team, error) {
-	return u, r.store.db.Model(&u).Update("name", u.Name).Error
+func (r *TeamRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.Team], error) {
+	return list[model.Team](r.store.db.WithContext(ctx), q, model.TeamListSpec, "Employees")
 }
 
-func (r *TeamRepository) Delete(id uint) error {
+func (r *TeamRepository) Update(ctx context.Context, u model.Team) (model.Team, error) {
+	return u, r.store.db.WithContext(ctx).Model(&u).Update("name", u.Name).Error
+}
+
+func (r *TeamRepository) Delete(ctx context.Context, id uint) error {
 	var team model.Team
-	result := r.store.db.Table("teams").Where("id=?", id)
+	result := r.store.db.WithContext(ctx).Table("teams").Where("id=?", id)
 	err := result.First(&team).Error
 	if err != nil {
 		return err
 	}
-	return r.store.db.Delete(&team).Error
+	return r.store.db.WithContext(ctx).Delete(&team).Error
 }
@@ -1,6 +1,7 @@
 package sqlstore
 
 import (
+	"context"
 	"eastwh/internal/model"
 )
 
@@ -8,41 +9,44 @@ type UserProjectRepository struct {
 	store *Store
 }
 
-func (r *UserProjectRepository) Add(u model.UserProject) (model.UserProject, error) {
-	return u, r.store.db.Create(&u).Error
+func (r *UserProjectRepository) Add(ctx context.Context, u model.UserProject) (model.UserProject, error) {
+	return u, r.store.db.WithContext(ctx).Create(&u).Error
 }
 
-func (r *UserProjectRepository) Update(userproject model.UserProject) (model.UserProject, error) {
-	return userproject, r.store.db.Model(&userproject).Update("user_id", userproject.UserID).Update("project_id", userproject.ProjectID).Error
+func (r *UserProjectRepository) Update(ctx context.Context, userproject model.UserProject) (model.UserProject, error) {
+	return userproject, r.store.db.WithContext(ctx).Model(&userproject).Update("user_id", userproject.UserID).Update("project_id", userproject.ProjectID).Error
 }
 
-func (r *UserProjectRepository) Delete(id uint) error {
+func (r *UserProjectRepository) Delete(ctx context.Context, id uint) error {
 	var up model.UserProject
-	result := r.store.db.Table("user_projects").Where("id=?", id)
-	err := result.First(&up)
+	err := r.store.db.WithContext(ctx).Where("id=?", id).First(&up).Error
 	if err != nil {
-		return nil
+		return err
 	}
-	return r.store.db.Delete(&up).Error
+	return r.store.db.WithContext(ctx).Delete(&up).Error
 }
 
-func (r *UserProjectRepository) DeleteUserProject(user_id, project_id uint) error {
-	return r.store.db.Exec("DELETE FROM user_projects WHERE user_id = ? and project_id = ?", user_id, project_id).Error
+func (r *UserProjectRepository) DeleteUserProject(ctx context.Context, user_id, project_id uint) error {
+	return r.store.db.WithContext(ctx).Exec("DELETE FROM user_projects WHERE user_id = ? and project_id = ?", user_id, project_id).Error
 }
 
-func (r *UserProjectRepository) ByID(Id uint) (up model.UserProject, err error) {
+func (r *UserProjectRepository) ByID(ctx context.Context, Id uint) (up model.UserProject, err error) {
 	up.ID = Id
-	return up, r.store.db.First(&up, Id).Error
+	return up, r.store.db.WithContext(ctx).First(&up, Id).Error
 }
 
-func (r *UserProjectRepository) ByUserID(userID uint) (userproject []model.UserProject, err error) {
-	return userproject, r.store.db.Where("user_id=?", userID).Find(&userproject).Error
+func (r *UserProjectRepository) ByUserID(ctx context.Context, userID uint) (userproject []model.UserProject, err error) {
+	return userproject, r.store.db.WithContext(ctx).Where("user_id=?", userID).Find(&userproject).Error
 }
 
-func (r *UserProjectRepository) ByProjectID(projectID uint) (userproject []model.UserProject, err error) {
-	return userproject, r.store.db.Where("project_id=?", projectID).Find(&userproject).Error
+func (r *UserProjectRepository) ByProjectID(ctx context.Context, projectID uint) (userproject []model.UserProject, err error) {
+	return userproject, r.store.db.WithContext(ctx).Where("project_id=?", projectID).Find(&userproject).Error
 }
 
-func (r *UserProjectRepository) All() (userproject []model.UserProject, err error) {
-	return userproject, r.store.db.Find(&userproject).Error
+func (r *UserProjectRepository) All(ctx context.Context) (userproject []model.UserProject, err error) {
+	return userproject, r.store.db.WithContext(ctx).Find(&userproject).Error
+}
+
+func (r *UserProjectRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.UserProject], error) {
+	return list[model.UserProject](r.store.db.WithContext(ctx), q, model.UserProjectListSpec)
 }
@@ -1,6 +1,7 @@
 package sqlstore
 
 import (
+	"context"
 	"crypto/rand"
 	"eastwh/internal/model"
 	"encoding/base64"
@@ -15,15 +16,15 @@ type UserRepository struct {
 	store *Store
 }
 
-func (r *UserRepository) Add(u model.User) (model.User, error) {
+func (r *UserRepository) Add(ctx context.Context, u model.User) (model.User, error) {
 	hashPassword(&u.Password)
-	err := r.store.db.Create(&u).Error
+	err := r.store.db.WithContext(ctx).Create(&u).Error
 	u.Password = ""
 	return u, err
 }
 
-func (r *UserRepository) Login(email, password string) (user model.User, err error) {
-	result := r.store.db.Table("users").Where(&model.User{Email: email})
+func (r *UserRepository) Login(ctx context.Context, email, password string) (user model.User, err error) {
+	result := r.store.db.WithContext(ctx).Table("users").Where(&model.User{Email: email})
 	err = result.First(&user).Error
 	if err != nil {
 		return user, err
@@ -51,29 +52,29 @@ func checkPassword(existingHash, incomingPass string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(existingHash), []byte(incomingPass)) == nil
 }
 
-func (r *UserRepository) Logout(id uint) error {
+func (r *UserRepository) Logout(ctx context.Context, id uint) error {
 	user := model.User{
 		Model: gorm.Model{
 			ID: id,
 		},
 	}
 
-	return r.store.db.Model(&user).Where("id = ?", id).Updates(map[string]interface{}{"loggedin": 0,
+	return r.store.db.WithContext(ctx).Model(&user).Where("id = ?", id).Updates(map[string]interface{}{"loggedin": 0,
 		"token": ""}).Error
 }
 
-func (r *UserRepository) UpdateToken(id uint, token string) error {
-	return r.store.db.Model(&model.User{}).Where("id=?", id).Update("token", token).Error
+func (r *UserRepository) UpdateToken(ctx context.Context, id uint, token string) error {
+	return r.store.db.WithContext(ctx).Model(&model.User{}).Where("id=?", id).Update("token", token).Error
 }
 
-func (r *UserRepository) Restore(email string) (password string, err error) {
+func (r *UserRepository) Restore(ctx context.Context, email string) (password string, err error) {
 	pass, err := generateTemporaryPassword()
 	if err != nil {
 		return "", err
 	}
 
 	var user model.User
-	result := r.store.db.Table("users").Where("email=?", email)
+	result := r.store.db.WithContext(ctx).Table("users").Where("email=?", email)
 	err = result.First(&user).Error
 	if err != nil {
 		return "", err
@@ -81,18 +82,18 @@ func (r *UserRepository) Restore(email string) (password string, err error) {
 
 	hPass := pass
 	hashPassword(&hPass)
-	return pass, r.store.db.Model(&model.User{}).Where("id=?", user.ID).Updates(map[string]interface{}{"password": hPass,
+	return pass, r.store.db.WithContext(ctx).Model(&model.User{}).Where("id=?", user.ID).Updates(map[string]interface{}{"password": hPass,
 		"restore": true}).Error
 }
 
-func (r *UserRepository) ChangePassword(id uint, password string) error {
+func (r *UserRepository) ChangePassword(ctx context.Context, id uint, password string) error {
 	err := hashPassword(&password)
 	if err != nil {
 		return err
 	}
 
 	fmt.Println("id - ", id)
-	return r.store.db.Model(&model.User{}).Where("id=?", id).Updates(map[string]interface{}{"password": password,
+	return r.store.db.WithContext(ctx).Model(&model.User{}).Where("id=?", id).Updates(map[string]interface{}{"password": password,
 		"restore": false}).Error
 }
 
@@ -112,22 +113,30 @@ func hashPassword(s *string) error {
 	return nil
 }
 
-func (r *UserRepository) All() (users []model.User, err error) {
-	err = r.store.db.
+func (r *UserRepository) All(ctx context.Context) (users []model.User, err error) {
+	err = r.store.db.WithContext(ctx).
 		Preload("Projects").
 		//Preload("").
 		//Preload("").
 		Find(&users).Error
 
 	for i := 0; i < len(users); i++ {
-		users[i].Password = ""
+		users[i].Password, users[i].Token = "", ""
 	}
 
 	return users, err
 }
 
-func (r *UserRepository) Profile(id uint) (u model.User, err error) {
-	err = r.store.db.
+func (r *UserRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.User], error) {
+	page, err := list[model.User](r.store.db.WithContext(ctx), q, model.UserListSpec, "Projects")
+	for i := range page.Items {
+		page.Items[i].Password, page.Items[i].Token = "", ""
+	}
+	return page, err
+}
+
+func (r *UserRepository) Profile(ctx context.Context, id uint) (u model.User, err error) {
+	err = r.store.db.WithContext(ctx).
 		//	Preload("TeamUsers.Team").
 		//Preload("TeamUsers.Employee").
 		Preload("Teams").           // если нужны и сами команды
@@ -141,8 +150,8 @@ func (r *UserRepository) Profile(id uint) (u model.User, err error) {
 	return u, nil
 }
 
-func (r *UserRepository) EmployeeByUserID(id uint) (u []model.UserEmployee, err error) {
-	return u, r.store.db.Raw(`SELECT e.id,
+func (r *UserRepository) EmployeeByUserID(ctx context.Context, id uint) (u []model.UserEmployee, err error) {
+	return u, r.store.db.WithContext(ctx).Raw(`SELECT e.id,
 	    CONCAT(e.first_name,
                 ' ',
                 e.name,
@@ -154,8 +163,8 @@ FROM user_teams utm
 WHERE ifnull(e.id, 0) != 0 and utm.user_id =?`, id).Scan(&u).Error
 }
 
-func (r *UserRepository) Update(u model.User) (model.User, error) {
-	err := r.store.db.Model(&u).Updates(map[string]interface{}{"first_name": u.FirstName,
+func (r *UserRepository) Update(ctx context.Context, u model.User) (model.User, error) {
+	err := r.store.db.WithContext(ctx).Model(&u).Updates(map[string]interface{}{"first_name": u.FirstName,
 		"last_name": u.LastName,
 		"name":      u.Name,
 		"phone":     u.Phone}).Error
@@ -167,16 +176,16 @@ func (r *UserRepository) Update(u model.User) (model.User, error) {
 	return u, nil
 }
 
-func (r *UserRepository) ByID(id uint) (u model.User, err error) {
-	return u, r.store.db.First(&u, id).Error
+func (r *UserRepository) ByID(ctx context.Context, id uint) (u model.User, err error) {
+	return u, r.store.db.WithContext(ctx).First(&u, id).Error
 }
 
-func (r *UserRepository) ByEmail(email string) (u model.User, err error) {
-	return u, r.store.db.Where("email=?", email).First(&u).Error
+func (r *UserRepository) ByEmail(ctx context.Context, email string) (u model.User, err error) {
+	return u, r.store.db.WithContext(ctx).Where("email=?", email).First(&u).Error
 }
 
-func (r *UserRepository) BlockedUser(id uint, blocked bool) error {
-	return r.store.db.Model(&model.User{}).Where("id=?", id).Update("blocked", blocked).Error
+func (r *UserRepository) BlockedUser(ctx context.Context, id uint, blocked bool) error {
+	return r.store.db.WithContext(ctx).Model(&model.User{}).Where("id=?", id).Update("blocked", blocked).Error
 }
 
 // Функция для генерации временного пароля
@@ -1,41 +1,47 @@
 package sqlstore
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type UserRoleRepository struct {
 	store *Store
 }
 
-func (r *UserRoleRepository) Add(u model.UserRole) (model.UserRole, error) {
-	return u, r.store.db.Create(&u).Error
+func (r *UserRoleRepository) Add(ctx context.Context, u model.UserRole) (model.UserRole, error) {
+	return u, r.store.db.WithContext(ctx).Create(&u).Error
 }
 
-func (r *UserRoleRepository) Update(userrole model.UserRole) (model.UserRole, error) {
-	return userrole, r.store.db.Model(&userrole).Update("user_id", userrole.UserID).Update("role_id", userrole.RoleID).Error
+func (r *UserRoleRepository) Update(ctx context.Context, userrole model.UserRole) (model.UserRole, error) {
+	return userrole, r.store.db.WithContext(ctx).Model(&userrole).Update("user_id", userrole.UserID).Update("role_id", userrole.RoleID).Error
 }
 
-func (r *UserRoleRepository) Delete(id uint) error {
+func (r *UserRoleRepository) Delete(ctx context.Context, id uint) error {
 	var ur model.UserRole
-	result := r.store.db.Table("userroles").Where("id=,", id)
-	err := result.First(&ur)
+	err := r.store.db.WithContext(ctx).Where("id=?", id).First(&ur).Error
 	if err != nil {
-		return nil
+		return err
 	}
-	return r.store.db.Delete(&ur).Error
+	return r.store.db.WithContext(ctx).Delete(&ur).Error
 }
 
-func (r *UserRoleRepository) ByID(ID uint) (ur model.UserRole, err error) {
-	return ur, r.store.db.First(&ur, ID).Error
+func (r *UserRoleRepository) ByID(ctx context.Context, ID uint) (ur model.UserRole, err error) {
+	return ur, r.store.db.WithContext(ctx).First(&ur, ID).Error
 }
 
-func (r *UserRoleRepository) ByUserID(userID uint) (userroles []model.UserRole, err error) {
-	return userroles, r.store.db.Where("user_id").Find(&userroles).Error
+func (r *UserRoleRepository) ByUserID(ctx context.Context, userID uint) (userroles []model.UserRole, err error) {
+	return userroles, r.store.db.WithContext(ctx).Where("user_id=?", userID).Find(&userroles).Error
 }
 
-func (r *UserRoleRepository) ByRoleID(roleID uint) (userroles []model.UserRole, err error) {
-	return userroles, r.store.db.Where("role_id", roleID).Find(&userroles).Error
+func (r *UserRoleRepository) ByRoleID(ctx context.Context, roleID uint) (userroles []model.UserRole, err error) {
+	return userroles, r.store.db.WithContext(ctx).Where("role_id", roleID).Find(&userroles).Error
 }
 
-func (r *UserRoleRepository) All() (userroles []model.UserRole, err error) {
-	return userroles, r.store.db.Find(&userroles).Error
+func (r *UserRoleRepository) All(ctx context.Context) (userroles []model.UserRole, err error) {
+	return userroles, r.store.db.WithContext(ctx).Find(&userroles).Error
+}
+
+func (r *UserRoleRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.UserRole], error) {
+	return list[model.UserRole](r.store.db.WithContext(ctx), q, model.UserRoleListSpec)
 }
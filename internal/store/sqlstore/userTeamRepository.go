@@ -1,45 +1,51 @@
 package sqlstore
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type UserTeamRepository struct {
 	store *Store
 }
 
-func (r *UserTeamRepository) Add(u model.UserTeam) (model.UserTeam, error) {
-	return u, r.store.db.Create(&u).Error
+func (r *UserTeamRepository) Add(ctx context.Context, u model.UserTeam) (model.UserTeam, error) {
+	return u, r.store.db.WithContext(ctx).Create(&u).Error
 }
 
-func (r *UserTeamRepository) Update(userteam model.UserTeam) (model.UserTeam, error) {
-	return userteam, r.store.db.Save(&userteam).Error
+func (r *UserTeamRepository) Update(ctx context.Context, userteam model.UserTeam) (model.UserTeam, error) {
+	return userteam, r.store.db.WithContext(ctx).Save(&userteam).Error
 }
 
-func (r *UserTeamRepository) Delete(id uint) error {
+func (r *UserTeamRepository) Delete(ctx context.Context, id uint) error {
 	var ut model.UserTeam
-	result := r.store.db.Table("userteams").Where("id=,", id)
-	err := result.First(&ut)
+	err := r.store.db.WithContext(ctx).Where("id=?", id).First(&ut).Error
 	if err != nil {
-		return nil
+		return err
 	}
-	return r.store.db.Delete(&ut).Error
+	return r.store.db.WithContext(ctx).Delete(&ut).Error
 }
 
-func (r *UserTeamRepository) DeleteUserTeam(team_id, user_id uint) error {
-	return r.store.db.Exec("DELETE FROM user_teams WHERE team_id = ? AND user_id = ?", team_id, user_id).Error
+func (r *UserTeamRepository) DeleteUserTeam(ctx context.Context, team_id, user_id uint) error {
+	return r.store.db.WithContext(ctx).Exec("DELETE FROM user_teams WHERE team_id = ? AND user_id = ?", team_id, user_id).Error
 }
 
-func (r *UserTeamRepository) ByID(ID uint) (ur model.UserTeam, err error) {
-	return ur, r.store.db.First(&ur, ID).Error
+func (r *UserTeamRepository) ByID(ctx context.Context, ID uint) (ur model.UserTeam, err error) {
+	return ur, r.store.db.WithContext(ctx).First(&ur, ID).Error
 }
 
-func (r *UserTeamRepository) ByUserID(userID uint) (userteam []model.UserTeam, err error) {
-	return userteam, r.store.db.Where("user_id").Find(&userteam).Error
+func (r *UserTeamRepository) ByUserID(ctx context.Context, userID uint) (userteam []model.UserTeam, err error) {
+	return userteam, r.store.db.WithContext(ctx).Where("user_id = ?", userID).Find(&userteam).Error
 }
 
-func (r *UserTeamRepository) ByTeamID(teamID uint) (userteam []model.UserTeam, err error) {
-	return userteam, r.store.db.Where("team_id", teamID).Find(&userteam).Error
+func (r *UserTeamRepository) ByTeamID(ctx context.Context, teamID uint) (userteam []model.UserTeam, err error) {
+	return userteam, r.store.db.WithContext(ctx).Where("team_id", teamID).Find(&userteam).Error
 }
 
-func (r *UserTeamRepository) All() (userteam []model.UserTeam, err error) {
-	return userteam, r.store.db.Find(&userteam).Error
+func (r *UserTeamRepository) All(ctx context.Context) (userteam []model.UserTeam, err error) {
+	return userteam, r.store.db.WithContext(ctx).Find(&userteam).Error
+}
+
+func (r *UserTeamRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.UserTeam], error) {
+	return list[model.UserTeam](r.store.db.WithContext(ctx), q, model.UserTeamListSpec)
 }
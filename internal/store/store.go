@@ -1,10 +1,21 @@
 package store
 
+import "context"
+
 type Store interface {
+	// WithTx выполняет fn в одной транзакции: репозитории переданного fn
+	// хранилища работают внутри нее. Ошибка fn откатывает все изменения.
+	WithTx(ctx context.Context, fn func(Store) error) error
 	Employee() EmployeeRepository
 	Order() OrderRepository
+	OrderEvent() OrderEventRepository
+	OrderLine() OrderLineRepository
+	Payroll() PayrollRepository
 	Project() ProjectRepository
+	RefreshToken() RefreshTokenRepository
 	Role() RoleRepository
+	RolePermission() RolePermissionRepository
+	SLA() SLARepository
 	Team() TeamRepository
 	User() UserRepository
 	UserRole() UserRoleRepository
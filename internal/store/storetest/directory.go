@@ -0,0 +1,451 @@
+package storetest
+
+import (
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func testEmployee(t *testing.T, st store.Store) {
+	e, err := st.Employee().Add(ctx, model.Employee{Code: "E1", FirstName: "Иванов", Name: "Иван"})
+	check(t, err)
+	if e.ID == 0 || e.CreatedAt.IsZero() {
+		t.Fatalf("expected id and created_at to be set, got %+v", e)
+	}
+	_, err = st.Employee().Add(ctx, model.Employee{Code: "E2", FirstName: "Петров", Name: "Петр"})
+	check(t, err)
+
+	if _, err := st.Employee().Add(ctx, model.Employee{Code: "E1", FirstName: "Сидоров"}); err == nil {
+		t.Fatal("expected duplicate code to be rejected")
+	}
+
+	got, err := st.Employee().ByCode(ctx, "E2")
+	check(t, err)
+	if got.FirstName != "Петров" {
+		t.Fatalf("ByCode returned %q", got.FirstName)
+	}
+	_, err = st.Employee().ByCode(ctx, "missing")
+	expectNotFound(t, err)
+
+	e.Phone = "100"
+	_, err = st.Employee().Update(ctx, e)
+	check(t, err)
+	got, err = st.Employee().ByID(ctx, e.ID)
+	check(t, err)
+	if got.Phone != "100" {
+		t.Fatalf("update was not saved: %+v", got)
+	}
+
+	check(t, st.Employee().Delete(ctx, e.ID))
+	_, err = st.Employee().ByID(ctx, e.ID)
+	expectNotFound(t, err)
+	expectNotFound(t, st.Employee().Delete(ctx, e.ID))
+
+	all, err := st.Employee().All(ctx)
+	check(t, err)
+	if len(all) != 1 || all[0].Code != "E2" {
+		t.Fatalf("deleted employee is listed: %+v", all)
+	}
+}
+
+func testEmployeeList(t *testing.T, st store.Store) {
+	for _, code := range []string{"C", "A", "D", "B", "E"} {
+		_, err := st.Employee().Add(ctx, model.Employee{Code: code, FirstName: code, Name: code, INN: "1"})
+		check(t, err)
+	}
+	_, err := st.Employee().Add(ctx, model.Employee{Code: "F", FirstName: "F", Name: "F", INN: "2"})
+	check(t, err)
+
+	codes := func(items []model.Employee) (codes []string) {
+		for _, e := range items {
+			codes = append(codes, e.Code)
+		}
+		return codes
+	}
+	q := model.ListQuery{Limit: 2, Sort: "-code", Filters: map[string]string{"inn": "1"}}
+
+	var pages [][]string
+	for {
+		page, err := st.Employee().List(ctx, q)
+		check(t, err)
+		if page.Total != 5 {
+			t.Fatalf("expected total 5, got %d", page.Total)
+		}
+		pages = append(pages, codes(page.Items))
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	want := [][]string{{"E", "D"}, {"C", "B"}, {"A"}}
+	if !slices.EqualFunc(pages, want, slices.Equal[[]string]) {
+		t.Fatalf("expected cursor pages %v, got %v", want, pages)
+	}
+
+	page, err := st.Employee().List(ctx, model.ListQuery{Page: 2, Size: 4, Sort: "code"})
+	check(t, err)
+	if got := codes(page.Items); !slices.Equal(got, []string{"E", "F"}) || page.Total != 6 {
+		t.Fatalf("unexpected second page %v of %d", got, page.Total)
+	}
+
+	page, err = st.Employee().List(ctx, model.ListQuery{Page: 3, Size: 4})
+	check(t, err)
+	if page.Items == nil || len(page.Items) != 0 {
+		t.Fatalf("expected empty page, got %v", page.Items)
+	}
+
+	_, err = st.Employee().List(ctx, model.ListQuery{Cursor: "not a cursor"})
+	expectError(t, err, store.ErrInvalidCursor)
+}
+
+func testTeamMembers(t *testing.T, st store.Store) {
+	team, err := st.Team().Add(ctx, model.Team{Name: "Смена 1"})
+	check(t, err)
+	if _, err := st.Team().Add(ctx, model.Team{Name: "Смена 1"}); err == nil {
+		t.Fatal("expected duplicate team name to be rejected")
+	}
+	e, err := st.Employee().Add(ctx, model.Employee{Code: "E1", FirstName: "Иванов", Name: "Иван", LastName: "Иванович"})
+	check(t, err)
+
+	et, err := st.EmployeeTeam().Add(ctx, model.EmployeeTeam{TeamID: team.ID, EmployeeID: e.ID, Share: 1})
+	check(t, err)
+	byTeam, err := st.EmployeeTeam().ByTeamID(ctx, team.ID)
+	check(t, err)
+	if len(byTeam) != 1 || byTeam[0].ID != et.ID {
+		t.Fatalf("unexpected team members %+v", byTeam)
+	}
+
+	teams, err := st.Team().All(ctx)
+	check(t, err)
+	if len(teams) != 1 || len(teams[0].Employees) != 1 || teams[0].Employees[0].ID != e.ID {
+		t.Fatalf("team employees are not loaded: %+v", teams)
+	}
+
+	u, err := st.User().Add(ctx, model.User{Email: "a@example.com", Password: "secret", Name: "A", LastName: "A"})
+	check(t, err)
+	_, err = st.UserTeam().Add(ctx, model.UserTeam{TeamID: team.ID, UserID: u.ID})
+	check(t, err)
+	employees, err := st.User().EmployeeByUserID(ctx, u.ID)
+	check(t, err)
+	if len(employees) != 1 || employees[0].ID != e.ID || employees[0].Name != "Иванов Иван Иванович" {
+		t.Fatalf("unexpected user employees %+v", employees)
+	}
+
+	check(t, st.EmployeeTeam().DeleteEmployeeTeam(ctx, e.ID, team.ID))
+	byEmployee, err := st.EmployeeTeam().ByEmployeeID(ctx, e.ID)
+	check(t, err)
+	if len(byEmployee) != 0 {
+		t.Fatalf("employee team was not deleted: %+v", byEmployee)
+	}
+}
+
+func testProject(t *testing.T, st store.Store) {
+	p, err := st.Project().Add(ctx, model.Project{Name: "Розница", VidDoc: "R"})
+	check(t, err)
+	p.Name = "Опт"
+	_, err = st.Project().Update(ctx, p)
+	check(t, err)
+
+	got, err := st.Project().ByID(ctx, p.ID)
+	check(t, err)
+	if got.Name != "Опт" {
+		t.Fatalf("project was not renamed: %+v", got)
+	}
+
+	check(t, st.Project().Delete(ctx, p.ID))
+	// Удаленный проект по-прежнему занимает имя, как в базе с мягким удалением
+	if _, err := st.Project().Add(ctx, model.Project{Name: "Опт"}); err == nil {
+		t.Fatal("expected name of deleted project to stay reserved")
+	}
+	_, err = st.Project().ByID(ctx, p.ID)
+	expectNotFound(t, err)
+}
+
+func testRole(t *testing.T, st store.Store) {
+	r, err := st.Role().Add(ctx, model.Role{Name: "admin", Priority: 100})
+	check(t, err)
+	got, err := st.Role().ByName(ctx, "admin")
+	check(t, err)
+	if got.ID != r.ID || got.Priority != 100 {
+		t.Fatalf("unexpected role %+v", got)
+	}
+	_, err = st.Role().ByName(ctx, "missing")
+	expectNotFound(t, err)
+
+	r.Description = "Администратор"
+	_, err = st.Role().Update(ctx, r)
+	check(t, err)
+	got, err = st.Role().ByID(ctx, r.ID)
+	check(t, err)
+	if got.Description != "Администратор" {
+		t.Fatalf("role was not updated: %+v", got)
+	}
+}
+
+func testRolePermission(t *testing.T, st store.Store) {
+	r1, err := st.Role().Add(ctx, model.Role{Name: "r1"})
+	check(t, err)
+	r2, err := st.Role().Add(ctx, model.Role{Name: "r2"})
+	check(t, err)
+
+	_, err = st.RolePermission().SetForRole(ctx, r1.ID, []model.Permission{model.PermUsersView, model.PermUsersManage})
+	check(t, err)
+	_, err = st.RolePermission().SetForRole(ctx, r2.ID, []model.Permission{model.PermRolesManage})
+	check(t, err)
+	_, err = st.RolePermission().SetForRole(ctx, r1.ID, []model.Permission{model.PermUsersBlock})
+	check(t, err)
+
+	perms, err := st.RolePermission().ByRoleID(ctx, r1.ID)
+	check(t, err)
+	if len(perms) != 1 || perms[0].Permission != model.PermUsersBlock {
+		t.Fatalf("permissions were not replaced: %+v", perms)
+	}
+	perms, err = st.RolePermission().ByRoleIDs(ctx, []uint{r1.ID, r2.ID})
+	check(t, err)
+	if len(perms) != 2 {
+		t.Fatalf("expected permissions of both roles, got %+v", perms)
+	}
+}
+
+func testUser(t *testing.T, st store.Store) {
+	u, err := st.User().Add(ctx, model.User{Email: "a@example.com", Password: "secret", Name: "A", LastName: "A"})
+	check(t, err)
+	if u.Password != "" {
+		t.Fatal("password is returned after Add")
+	}
+	if _, err := st.User().Add(ctx, model.User{Email: "a@example.com", Password: "other"}); err == nil {
+		t.Fatal("expected duplicate email to be rejected")
+	}
+
+	_, err = st.User().Login(ctx, "a@example.com", "wrong")
+	if err == nil {
+		t.Fatal("expected login with wrong password to fail")
+	}
+	_, err = st.User().Login(ctx, "b@example.com", "secret")
+	expectNotFound(t, err)
+	logged, err := st.User().Login(ctx, "a@example.com", "secret")
+	check(t, err)
+	if !logged.LoggedIn || logged.Password != "" {
+		t.Fatalf("unexpected logged in user %+v", logged)
+	}
+
+	temp, err := st.User().Restore(ctx, "a@example.com")
+	check(t, err)
+	restored, err := st.User().Login(ctx, "a@example.com", temp)
+	check(t, err)
+	if !restored.Restore {
+		t.Fatal("expected temporary password to be marked")
+	}
+	check(t, st.User().ChangePassword(ctx, u.ID, "changed"))
+	_, err = st.User().Login(ctx, "a@example.com", "changed")
+	check(t, err)
+
+	check(t, st.User().BlockedUser(ctx, u.ID, true))
+	got, err := st.User().ByEmail(ctx, "a@example.com")
+	check(t, err)
+	if !got.Blocked || got.Restore {
+		t.Fatalf("expected blocked user with changed password, got %+v", got)
+	}
+
+	u.Phone = "200"
+	_, err = st.User().Update(ctx, u)
+	check(t, err)
+	got, err = st.User().ByID(ctx, u.ID)
+	check(t, err)
+	if got.Phone != "200" {
+		t.Fatalf("user was not updated: %+v", got)
+	}
+	_, err = st.User().ByID(ctx, u.ID+1)
+	expectNotFound(t, err)
+}
+
+func testUserLinks(t *testing.T, st store.Store) {
+	u, err := st.User().Add(ctx, model.User{Email: "a@example.com", Password: "secret", Name: "A", LastName: "A"})
+	check(t, err)
+	p, err := st.Project().Add(ctx, model.Project{Name: "Розница", VidDoc: "R"})
+	check(t, err)
+	team, err := st.Team().Add(ctx, model.Team{Name: "Смена 1"})
+	check(t, err)
+	role, err := st.Role().Add(ctx, model.Role{Name: "picker"})
+	check(t, err)
+
+	up, err := st.UserProject().Add(ctx, model.UserProject{UserID: u.ID, ProjectID: p.ID})
+	check(t, err)
+	ut, err := st.UserTeam().Add(ctx, model.UserTeam{UserID: u.ID, TeamID: team.ID})
+	check(t, err)
+	ur, err := st.UserRole().Add(ctx, model.UserRole{UserID: u.ID, RoleID: role.ID})
+	check(t, err)
+
+	profile, err := st.User().Profile(ctx, u.ID)
+	check(t, err)
+	if len(profile.Projects) != 1 || profile.Projects[0].VidDoc != "R" || len(profile.Teams) != 1 || profile.Password != "" {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+
+	byUser, err := st.UserTeam().ByUserID(ctx, u.ID)
+	check(t, err)
+	if len(byUser) != 1 || byUser[0].ID != ut.ID {
+		t.Fatalf("unexpected user teams %+v", byUser)
+	}
+	byUser, err = st.UserTeam().ByUserID(ctx, u.ID+1)
+	check(t, err)
+	if len(byUser) != 0 {
+		t.Fatalf("user teams of another user are returned: %+v", byUser)
+	}
+	roles, err := st.UserRole().ByRoleID(ctx, role.ID)
+	check(t, err)
+	if len(roles) != 1 || roles[0].ID != ur.ID {
+		t.Fatalf("unexpected user roles %+v", roles)
+	}
+
+	check(t, st.UserTeam().Delete(ctx, ut.ID))
+	expectNotFound(t, st.UserTeam().Delete(ctx, ut.ID))
+	check(t, st.UserProject().Delete(ctx, up.ID))
+	expectNotFound(t, st.UserProject().Delete(ctx, up.ID))
+	check(t, st.UserRole().Delete(ctx, ur.ID))
+	expectNotFound(t, st.UserRole().Delete(ctx, ur.ID))
+
+	byUser, err = st.UserTeam().ByUserID(ctx, u.ID)
+	check(t, err)
+	if len(byUser) != 0 {
+		t.Fatalf("deleted user team is returned: %+v", byUser)
+	}
+}
+
+func testRefreshToken(t *testing.T, st store.Store) {
+	expires := time.Now().Add(time.Hour)
+	t1, err := st.RefreshToken().Add(ctx, model.RefreshToken{UserID: 1, FamilyID: "f1", TokenHash: "h1", ExpiresAt: expires})
+	check(t, err)
+	_, err = st.RefreshToken().Add(ctx, model.RefreshToken{UserID: 1, FamilyID: "f2", TokenHash: "h2", ExpiresAt: expires})
+	check(t, err)
+	if _, err := st.RefreshToken().Add(ctx, model.RefreshToken{UserID: 2, TokenHash: "h1"}); err == nil {
+		t.Fatal("expected duplicate token hash to be rejected")
+	}
+
+	got, err := st.RefreshToken().ByHash(ctx, "h1")
+	check(t, err)
+	if got.ID != t1.ID {
+		t.Fatalf("unexpected token %+v", got)
+	}
+
+	used, err := st.RefreshToken().Use(ctx, t1.ID)
+	check(t, err)
+	if !used {
+		t.Fatal("expected first use to succeed")
+	}
+	used, err = st.RefreshToken().Use(ctx, t1.ID)
+	check(t, err)
+	if used {
+		t.Fatal("expected token to be used only once")
+	}
+
+	check(t, st.RefreshToken().RevokeFamily(ctx, "f1"))
+	active, err := st.RefreshToken().FamilyActive(ctx, "f1")
+	check(t, err)
+	if active {
+		t.Fatal("revoked family is active")
+	}
+	active, err = st.RefreshToken().FamilyActive(ctx, "f2")
+	check(t, err)
+	if !active {
+		t.Fatal("expected other family to stay active")
+	}
+
+	check(t, st.RefreshToken().RevokeUser(ctx, 1))
+	active, err = st.RefreshToken().FamilyActive(ctx, "f2")
+	check(t, err)
+	if active {
+		t.Fatal("user tokens were not revoked")
+	}
+}
+
+func testPayroll(t *testing.T, st store.Store) {
+	_, err := st.Payroll().SetTariffs(ctx, []model.Tariff{{VidDoc: "B", PerOrder: 2}, {VidDoc: "A", PerLine: 1}})
+	check(t, err)
+	_, err = st.Payroll().SetTariffs(ctx, []model.Tariff{{VidDoc: "C", PerOrder: 3}, {VidDoc: "A", PerLine: 1}})
+	check(t, err)
+	tariffs, err := st.Payroll().Tariffs(ctx)
+	check(t, err)
+	if len(tariffs) != 2 || tariffs[0].VidDoc != "A" || tariffs[1].VidDoc != "C" {
+		t.Fatalf("tariffs were not replaced: %+v", tariffs)
+	}
+
+	p, err := st.Payroll().Close(ctx, model.PayrollPeriod{StartDT: "2024-03-01", FinishDT: "2024-03-15", ClosedAt: time.Now(),
+		Total: 10, Entries: []model.PayrollEntry{{EmployeeID: 1, Amount: 10}},
+		Orders: []model.PayrollOrder{{OrderUID: 1}, {OrderUID: 2}}})
+	check(t, err)
+	if p.ID == 0 {
+		t.Fatal("period id is not set")
+	}
+	paid, err := st.Payroll().Paid(ctx, []int{2, 3})
+	check(t, err)
+	if !slices.Equal(paid, []int{2}) {
+		t.Fatalf("expected order 2 to be paid, got %v", paid)
+	}
+	_, err = st.Payroll().Close(ctx, model.PayrollPeriod{StartDT: "2024-03-10", FinishDT: "2024-03-31", ClosedAt: time.Now()})
+	expectError(t, err, store.ErrPeriodClosed)
+	_, err = st.Payroll().Close(ctx, model.PayrollPeriod{StartDT: "2024-03-16", FinishDT: "2024-03-31", ClosedAt: time.Now()})
+	check(t, err)
+
+	periods, err := st.Payroll().Overlapping(ctx, "2024-03-15", "2024-03-20")
+	check(t, err)
+	if len(periods) != 2 || periods[0].StartDT != "2024-03-01" || len(periods[0].Entries) != 1 {
+		t.Fatalf("unexpected overlapping periods %+v", periods)
+	}
+	periods, err = st.Payroll().Periods(ctx)
+	check(t, err)
+	if len(periods) != 2 || periods[0].StartDT != "2024-03-16" {
+		t.Fatalf("expected latest period first, got %+v", periods)
+	}
+}
+
+func testSLA(t *testing.T, st store.Store) {
+	_, err := st.SLA().SetRules(ctx, []model.SLARule{{VidDoc: "B", AssembleMinutes: 30}, {VidDoc: "A", AssembleMinutes: 60}})
+	check(t, err)
+	if _, err := st.SLA().SetRules(ctx, []model.SLARule{{VidDoc: "A"}, {VidDoc: "A"}}); err == nil {
+		t.Fatal("expected duplicate rules to be rejected")
+	}
+
+	rules, err := st.SLA().Rules(ctx)
+	check(t, err)
+	if len(rules) != 2 || rules[0].VidDoc != "A" || rules[0].AssembleMinutes != 60 {
+		t.Fatalf("rules were changed by failed update: %+v", rules)
+	}
+
+	// id переданных правил не сохраняются, правила получают новые
+	rules, err = st.SLA().SetRules(ctx, []model.SLARule{{Model: gorm.Model{ID: rules[0].ID}, VidDoc: "A"},
+		{Model: gorm.Model{ID: rules[0].ID}, VidDoc: "B"}})
+	check(t, err)
+	if rules[0].ID == 0 || rules[0].ID == rules[1].ID {
+		t.Fatalf("expected new ids, got %+v", rules)
+	}
+}
+
+func testWithTx(t *testing.T, st store.Store) {
+	errRollback := errors.New("rollback")
+	err := st.WithTx(ctx, func(tx store.Store) error {
+		if _, err := tx.Employee().Add(ctx, model.Employee{Code: "E1", FirstName: "A", Name: "A"}); err != nil {
+			return err
+		}
+		if _, err := tx.Employee().ByCode(ctx, "E1"); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	expectError(t, err, errRollback)
+	_, err = st.Employee().ByCode(ctx, "E1")
+	expectNotFound(t, err)
+
+	err = st.WithTx(ctx, func(tx store.Store) error {
+		_, err := tx.Employee().Add(ctx, model.Employee{Code: "E2", FirstName: "B", Name: "B"})
+		return err
+	})
+	check(t, err)
+	_, err = st.Employee().ByCode(ctx, "E2")
+	check(t, err)
+}
@@ -0,0 +1,638 @@
+package storetest
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newOrder(uid int, vidDoc string, d int) model.Order {
+	return model.Order{
+		OrderUid:   uid,
+		FolioNum:   5000 + uid,
+		UnicumNum:  7000 + uid,
+		FolioDate:  day(d),
+		OrderDate:  day(d),
+		VidDoc:     vidDoc,
+		ClientName: fmt.Sprintf("Клиент %d", uid),
+	}
+}
+
+func newLines(quantities ...float64) []model.OrderLine {
+	lines := make([]model.OrderLine, len(quantities))
+	for i, q := range quantities {
+		lines[i] = model.OrderLine{ProductCode: fmt.Sprintf("P%d", i+1), Barcode: fmt.Sprintf("460%d", i+1),
+			Quantity: q, Location: fmt.Sprintf("A-%d", len(quantities)-i)}
+	}
+	return lines
+}
+
+func addOrder(t *testing.T, st store.Store, o model.Order) model.Order {
+	t.Helper()
+	o, err := st.Order().Add(ctx, o, 1)
+	check(t, err)
+	return o
+}
+
+func orderByUID(t *testing.T, st store.Store, uid int) model.Order {
+	t.Helper()
+	orders, err := st.Order().ByOrderUID(ctx, uint(uid))
+	check(t, err)
+	if len(orders) != 1 {
+		t.Fatalf("expected order %d, got %+v", uid, orders)
+	}
+	return orders[0]
+}
+
+func orderUIDs(orders []model.Order) (uids []int) {
+	for _, o := range orders {
+		uids = append(uids, o.OrderUid)
+	}
+	return uids
+}
+
+// addCollector создает пользователя с доступом к проектам vidDocs.
+func addCollector(t *testing.T, st store.Store, email string, vidDocs ...string) model.User {
+	t.Helper()
+	u, err := st.User().Add(ctx, model.User{Email: email, Password: "secret", FirstName: "Иванов", Name: "Иван", LastName: "Иванович"})
+	check(t, err)
+	for _, vidDoc := range vidDocs {
+		p, err := st.Project().Add(ctx, model.Project{Name: email + " " + vidDoc, VidDoc: vidDoc})
+		check(t, err)
+		_, err = st.UserProject().Add(ctx, model.UserProject{UserID: u.ID, ProjectID: p.ID})
+		check(t, err)
+	}
+	return u
+}
+
+func testCanceledContext(t *testing.T, st store.Store) {
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err := st.Employee().All(canceled)
+	expectError(t, err, context.Canceled)
+	_, err = st.Order().Add(canceled, newOrder(1, "A", 1), 1)
+	expectError(t, err, context.Canceled)
+	err = st.WithTx(canceled, func(store.Store) error { return nil })
+	expectError(t, err, context.Canceled)
+
+	orders, err := st.Order().All(ctx)
+	check(t, err)
+	if len(orders) != 0 {
+		t.Fatalf("order was added with canceled context: %+v", orders)
+	}
+}
+
+func testOrderAdd(t *testing.T, st store.Store) {
+	o := newOrder(1, "A", 1)
+	o.Lines = newLines(2, 3)
+	o = addOrder(t, st, o)
+	if o.ID == 0 || len(o.Lines) != 2 || o.Lines[0].LineNo != 1 || o.Lines[1].LineNo != 2 {
+		t.Fatalf("unexpected added order %+v", o)
+	}
+	if _, err := st.Order().Add(ctx, newOrder(1, "A", 1), 1); err == nil {
+		t.Fatal("expected duplicate order_uid to be rejected")
+	}
+
+	lines, err := st.OrderLine().ByOrderUID(ctx, 1)
+	check(t, err)
+	if len(lines) != 2 || lines[0].Location != "A-1" {
+		t.Fatalf("expected lines ordered by location, got %+v", lines)
+	}
+	line, err := st.OrderLine().ByBarcode(ctx, "4602", 0)
+	check(t, err)
+	if line.OrderUID != 1 || line.LineNo != 2 {
+		t.Fatalf("unexpected line by barcode %+v", line)
+	}
+	_, err = st.OrderLine().ByBarcode(ctx, "4602", 2)
+	expectNotFound(t, err)
+
+	got, err := st.Order().ByBarcode(ctx, 7001)
+	check(t, err)
+	if got.OrderUid != 1 || got.FolioDate.Location() != model.Location {
+		t.Fatalf("unexpected order by barcode %+v", got)
+	}
+	_, err = st.Order().ByBarcode(ctx, 42)
+	expectNotFound(t, err)
+
+	events, err := st.OrderEvent().ByOrderUID(ctx, 1)
+	check(t, err)
+	if len(events) != 1 || events[0].Type != model.OrderEventImport {
+		t.Fatalf("expected import event, got %+v", events)
+	}
+}
+
+func testOrderList(t *testing.T, st store.Store) {
+	u := addCollector(t, st, "a@example.com", "A")
+	for uid := 1; uid <= 4; uid++ {
+		vidDoc := "A"
+		if uid == 4 {
+			vidDoc = "B"
+		}
+		addOrder(t, st, newOrder(uid, vidDoc, uid))
+	}
+	_, err := st.Order().Transition(ctx, model.OrderTransition{OrderUID: 2, Status: model.OrderStatusAssigned, UserID: u.ID})
+	check(t, err)
+	_, err = st.Order().Transition(ctx, model.OrderTransition{OrderUID: 3, Status: model.OrderStatusCancelled})
+	check(t, err)
+
+	page, err := st.Order().List(ctx, model.ListQuery{Sort: "-order_uid", Filters: map[string]string{"status": "new,assigned"}})
+	check(t, err)
+	if got := orderUIDs(page.Items); !slices.Equal(got, []int{4, 2, 1}) || page.Total != 3 {
+		t.Fatalf("unexpected order list %v of %d", got, page.Total)
+	}
+
+	page, err = st.Order().List(ctx, model.ListQuery{Sort: "id", Limit: 1, Filters: map[string]string{"vid_doc": "A", "status": "new,assigned,picking"}})
+	check(t, err)
+	if got := orderUIDs(page.Items); !slices.Equal(got, []int{1}) || page.Total != 2 || page.NextCursor == "" {
+		t.Fatalf("unexpected open orders %v of %d", got, page.Total)
+	}
+	access, err := st.Order().ByAccessUser(ctx, u.ID, model.DateRange{From: day(2), To: day(5)})
+	check(t, err)
+	if got := orderUIDs(access); !slices.Equal(got, []int{2}) {
+		t.Fatalf("unexpected orders of user projects %v", got)
+	}
+	byDate, err := st.Order().ByDateRange(ctx, model.DateRange{From: day(2), To: day(4)})
+	check(t, err)
+	if got := orderUIDs(byDate); !slices.Equal(got, []int{2, 3}) {
+		t.Fatalf("unexpected orders by date %v", got)
+	}
+	byUser, err := st.Order().ByUserID(ctx, u.ID)
+	check(t, err)
+	if got := orderUIDs(byUser); !slices.Equal(got, []int{2}) {
+		t.Fatalf("unexpected orders of user %v", got)
+	}
+}
+
+func testOrderTransition(t *testing.T, st store.Store) {
+	addOrder(t, st, newOrder(1, "A", 1))
+
+	o, err := st.Order().Transition(ctx, model.OrderTransition{OrderUID: 1, Status: model.OrderStatusAssigned,
+		UserID: 5, EmployeeID: 6, ActorID: 9})
+	check(t, err)
+	if o.Status != model.OrderStatusAssigned || o.UserID != 5 || o.EmployeeID != 6 {
+		t.Fatalf("unexpected assigned order %+v", o)
+	}
+
+	_, err = st.Order().Transition(ctx, model.OrderTransition{OrderUID: 1, Status: model.OrderStatusShipped})
+	expectError(t, err, store.ErrIllegalTransition)
+	_, err = st.Order().Transition(ctx, model.OrderTransition{OrderUID: 2, Status: model.OrderStatusAssigned})
+	expectNotFound(t, err)
+
+	if got := orderByUID(t, st, 1); got.Status != model.OrderStatusAssigned || got.UserID != 5 {
+		t.Fatalf("transition was not saved: %+v", got)
+	}
+	events, err := st.OrderEvent().ByOrderUID(ctx, 1)
+	check(t, err)
+	if len(events) != 2 || events[1].Type != model.OrderEventAssign || events[1].ActorUserID != 9 ||
+		events[1].FromStatus != model.OrderStatusNew || events[1].ToStatus != model.OrderStatusAssigned {
+		t.Fatalf("unexpected events %+v", events)
+	}
+}
+
+func testOrderAssembledAt(t *testing.T, st store.Store) {
+	addOrder(t, st, newOrder(1, "A", 1))
+	addOrder(t, st, newOrder(2, "A", 1))
+	for _, status := range []model.OrderStatus{model.OrderStatusAssigned, model.OrderStatusAssembled, model.OrderStatusChecked} {
+		_, err := st.Order().Transition(ctx, model.OrderTransition{OrderUID: 1, Status: status, UserID: 5, EmployeeID: 6})
+		check(t, err)
+	}
+
+	events, err := st.OrderEvent().ByOrderUID(ctx, 1)
+	check(t, err)
+	var want time.Time
+	for _, e := range events {
+		if e.ToStatus == model.OrderStatusAssembled {
+			want = e.CreatedAt
+		}
+	}
+
+	assembled, err := st.OrderEvent().AssembledAt(ctx, []uint{1, 2})
+	check(t, err)
+	if len(assembled) != 1 || !assembled[1].Equal(want) {
+		t.Fatalf("expected assembled time %v of order 1, got %v", want, assembled)
+	}
+	assembled, err = st.OrderEvent().AssembledAt(ctx, nil)
+	check(t, err)
+	if len(assembled) != 0 {
+		t.Fatalf("expected no assembled times, got %v", assembled)
+	}
+}
+
+func testOrderConfirmPick(t *testing.T, st store.Store) {
+	o := newOrder(1, "A", 1)
+	o.Lines = newLines(2, 3)
+	addOrder(t, st, o)
+	pick := func(lines ...model.LinePick) (model.Order, error) {
+		return st.Order().ConfirmPick(ctx, model.PickConfirmation{OrderUID: 1, UserID: 5, Lines: lines, ActorID: 5})
+	}
+
+	_, err := pick(model.LinePick{LineNo: 1, PickedQuantity: 2})
+	expectError(t, err, store.ErrIllegalTransition)
+
+	_, err = st.Order().Transition(ctx, model.OrderTransition{OrderUID: 1, Status: model.OrderStatusAssigned, UserID: 5})
+	check(t, err)
+	_, err = pick(model.LinePick{LineNo: 9, PickedQuantity: 1})
+	expectError(t, err, store.ErrInvalidPick)
+
+	got, err := pick(model.LinePick{LineNo: 1, PickedQuantity: 2})
+	check(t, err)
+	if got.Status != model.OrderStatusPicking {
+		t.Fatalf("expected picking, got %s", got.Status)
+	}
+
+	got, err = pick(model.LinePick{LineNo: 2, PickedQuantity: 1, ShortageReason: model.ShortageOutOfStock})
+	check(t, err)
+	if got.Status != model.OrderStatusAssembled || !got.Shortage {
+		t.Fatalf("expected assembled with shortage, got %+v", got)
+	}
+
+	lines, err := st.OrderLine().ByOrderUID(ctx, 1)
+	check(t, err)
+	for _, l := range lines {
+		if !l.Picked {
+			t.Fatalf("line %d is not picked", l.LineNo)
+		}
+		if l.LineNo == 2 && (l.PickedQuantity != 1 || l.ShortageReason != model.ShortageOutOfStock) {
+			t.Fatalf("shortage was not saved: %+v", l)
+		}
+	}
+	events, err := st.OrderEvent().ByOrderUID(ctx, 1)
+	check(t, err)
+	if len(events) != 4 || events[3].Type != model.OrderEventPick {
+		t.Fatalf("unexpected events %+v", events)
+	}
+}
+
+func testOrderWork(t *testing.T, st store.Store) {
+	addOrder(t, st, newOrder(1, "A", 1))
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	work := func(userID uint, action model.WorkAction, at time.Time) (model.Order, error) {
+		return st.Order().Work(ctx, model.OrderWork{OrderUID: 1, UserID: userID, Action: action, At: at})
+	}
+
+	// новый заказ нельзя взять в обход очереди выдачи
+	_, err := work(5, model.WorkStart, start)
+	expectError(t, err, store.ErrNotAssigned)
+	if o := orderByUID(t, st, 1); o.Status != model.OrderStatusNew || o.UserID != 0 {
+		t.Fatalf("unassigned order was taken over: %+v", o)
+	}
+
+	_, err = st.Order().Transition(ctx, model.OrderTransition{OrderUID: 1, Status: model.OrderStatusAssigned, UserID: 5})
+	check(t, err)
+
+	_, err = work(6, model.WorkStart, start)
+	expectError(t, err, store.ErrOtherCollector)
+	_, err = work(5, model.WorkPause, start)
+	expectError(t, err, store.ErrIllegalTransition)
+
+	o, err := work(5, model.WorkStart, start)
+	check(t, err)
+	if o.Status != model.OrderStatusPicking || o.StartAt == nil {
+		t.Fatalf("assembly was not started: %+v", o)
+	}
+	_, err = work(5, model.WorkPause, start.Add(time.Minute))
+	check(t, err)
+	_, err = work(5, model.WorkFinish, start.Add(3*time.Minute))
+	check(t, err)
+
+	o = orderByUID(t, st, 1)
+	if o.Status != model.OrderStatusAssembled || o.FinishAt == nil || o.WorkSeconds != 60 || o.PausedSeconds != 120 {
+		t.Fatalf("unexpected finished order %+v", o)
+	}
+	if !o.StartAt.Equal(start) || o.StartAt.Location() != model.Location {
+		t.Fatalf("unexpected start time %v", o.StartAt)
+	}
+}
+
+func testOrderDispatch(t *testing.T, st store.Store) {
+	u := addCollector(t, st, "a@example.com", "A")
+	other := addCollector(t, st, "b@example.com")
+
+	shipBy := day(5)
+	orders := []model.Order{newOrder(1, "A", 3), newOrder(2, "A", 2), newOrder(3, "B", 1), newOrder(4, "A", 4)}
+	orders[1].Priority = 5
+	orders[3].Priority, orders[3].ShipBy = 5, &shipBy
+	for _, o := range orders {
+		addOrder(t, st, o)
+	}
+
+	queue, err := st.Order().Queue(ctx, model.DispatchRequest{UserID: u.ID, Strategy: model.DispatchFIFO, Limit: 10})
+	check(t, err)
+	if got := orderUIDs(queue); !slices.Equal(got, []int{2, 1, 4}) {
+		t.Fatalf("unexpected fifo queue %v", got)
+	}
+	queue, err = st.Order().Queue(ctx, model.DispatchRequest{UserID: u.ID, Strategy: model.DispatchPriority, Limit: 2})
+	check(t, err)
+	if got := orderUIDs(queue); !slices.Equal(got, []int{4, 2}) {
+		t.Fatalf("unexpected priority queue %v", got)
+	}
+
+	next := model.DispatchRequest{UserID: u.ID, EmployeeID: 7, Strategy: model.DispatchFIFO, Limit: 10}
+	o, err := st.Order().Next(ctx, next)
+	check(t, err)
+	if o.OrderUid != 2 || o.Status != model.OrderStatusAssigned || o.UserID != u.ID || o.EmployeeID != 7 {
+		t.Fatalf("unexpected dispatched order %+v", o)
+	}
+	o, err = st.Order().Next(ctx, next)
+	check(t, err)
+	if o.OrderUid != 2 {
+		t.Fatalf("expected already assigned order, got %d", o.OrderUid)
+	}
+
+	queue, err = st.Order().Queue(ctx, next)
+	check(t, err)
+	if got := orderUIDs(queue); !slices.Equal(got, []int{1, 4}) {
+		t.Fatalf("assigned order is still queued: %v", got)
+	}
+
+	_, err = st.Order().Next(ctx, model.DispatchRequest{UserID: other.ID, Strategy: model.DispatchFIFO, Limit: 10})
+	expectNotFound(t, err)
+}
+
+func testOrderUpsert(t *testing.T, st store.Store) {
+	o1 := newOrder(1, "A", 1)
+	o1.Lines = newLines(2, 3)
+	results, err := st.Order().Upsert(ctx, []model.Order{o1, {OrderUid: 0}, newOrder(2, "A", 1), o1, {OrderUid: 3}}, 1)
+	check(t, err)
+	statuses := func(results []model.OrderImportResult) (s []model.OrderImportStatus) {
+		for _, r := range results {
+			s = append(s, r.Status)
+		}
+		return s
+	}
+	want := []model.OrderImportStatus{model.OrderImportCreated, model.OrderImportFailed, model.OrderImportCreated,
+		model.OrderImportFailed, model.OrderImportFailed}
+	if got := statuses(results); !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	_, err = st.Order().Transition(ctx, model.OrderTransition{OrderUID: 1, Status: model.OrderStatusAssigned, UserID: 5})
+	check(t, err)
+
+	changed := newOrder(2, "A", 1)
+	changed.ClientName = "Новое имя"
+	results, err = st.Order().Upsert(ctx, []model.Order{newOrder(1, "A", 1), changed}, 1)
+	check(t, err)
+	if got := statuses(results); !slices.Equal(got, []model.OrderImportStatus{model.OrderImportUnchanged, model.OrderImportUpdated}) {
+		t.Fatalf("unexpected statuses %v", got)
+	}
+	o1.Lines = o1.Lines[:1]
+	results, err = st.Order().Upsert(ctx, []model.Order{o1}, 1)
+	check(t, err)
+	if got := statuses(results); !slices.Equal(got, []model.OrderImportStatus{model.OrderImportUpdated}) {
+		t.Fatalf("unexpected statuses %v", got)
+	}
+
+	if got := orderByUID(t, st, 1); got.Status != model.OrderStatusAssigned || got.UserID != 5 {
+		t.Fatalf("import changed warehouse fields: %+v", got)
+	}
+	if got := orderByUID(t, st, 2); got.ClientName != "Новое имя" {
+		t.Fatalf("import did not update order: %+v", got)
+	}
+	lines, err := st.OrderLine().ByOrderUID(ctx, 1)
+	check(t, err)
+	if len(lines) != 1 {
+		t.Fatalf("removed line was not deleted: %+v", lines)
+	}
+
+	// строка без номера получает номер 2 и совпадает с явным номером
+	clash := newOrder(4, "A", 1)
+	clash.Lines = newLines(1, 1)
+	clash.Lines[0].LineNo = 2
+	results, err = st.Order().Upsert(ctx, []model.Order{clash, newOrder(5, "A", 1)}, 1)
+	check(t, err)
+	if got := statuses(results); !slices.Equal(got, []model.OrderImportStatus{model.OrderImportFailed, model.OrderImportCreated}) {
+		t.Fatalf("unexpected statuses %v", got)
+	}
+	orders, err := st.Order().ByOrderUID(ctx, 4)
+	check(t, err)
+	if len(orders) != 0 {
+		t.Fatalf("order with clashing lines was imported: %+v", orders)
+	}
+	if _, err := st.Order().Add(ctx, clash, 1); err == nil {
+		t.Fatal("expected error for clashing line numbers")
+	}
+
+	// смена одного водителя попадает в журнал как разница снимков
+	driver := newOrder(5, "A", 1)
+	driver.Driver = "Петров"
+	results, err = st.Order().Upsert(ctx, []model.Order{driver}, 1)
+	check(t, err)
+	if got := statuses(results); !slices.Equal(got, []model.OrderImportStatus{model.OrderImportUpdated}) {
+		t.Fatalf("unexpected statuses %v", got)
+	}
+	events, err := st.OrderEvent().ByOrderUID(ctx, 5)
+	check(t, err)
+	last := events[len(events)-1]
+	if last.Before == last.After || !strings.Contains(last.Before, `"driver":""`) || !strings.Contains(last.After, `"driver":"Петров"`) {
+		t.Fatalf("driver change is not in the journal: %+v", last)
+	}
+}
+
+func testOrderSearch(t *testing.T, st store.Store) {
+	o := newOrder(1, "A", 1)
+	o.ClientName, o.Driver = "ООО Ромашка", "Сидоров"
+	addOrder(t, st, o)
+	o = newOrder(2, "A", 10)
+	o.ClientName, o.Driver = "ИП Ромашкин", "Петров"
+	addOrder(t, st, o)
+	addOrder(t, st, newOrder(3, "A", 1))
+
+	search := func(s model.OrderSearch) []int {
+		t.Helper()
+		check(t, s.Normalize())
+		page, err := st.Order().Search(ctx, s)
+		check(t, err)
+		if page.Total != int64(len(page.Items)) {
+			t.Fatalf("total %d does not match %d hits", page.Total, len(page.Items))
+		}
+		uids := make([]int, 0)
+		for _, h := range page.Items {
+			uids = append(uids, h.Order.OrderUid)
+		}
+		slices.Sort(uids)
+		return uids
+	}
+
+	if got := search(model.OrderSearch{Q: "ромашк"}); !slices.Equal(got, []int{1, 2}) {
+		t.Fatalf("unexpected hits by client %v", got)
+	}
+	if got := search(model.OrderSearch{Q: "ромашк сидоров"}); !slices.Equal(got, []int{1}) {
+		t.Fatalf("expected all terms to match, got %v", got)
+	}
+	if got := search(model.OrderSearch{Q: "5003"}); !slices.Equal(got, []int{3}) {
+		t.Fatalf("unexpected hits by folio number %v", got)
+	}
+	if got := search(model.OrderSearch{Q: "ромашк", StartDT: "2024-03-05", FinishDT: "2024-03-31"}); !slices.Equal(got, []int{2}) {
+		t.Fatalf("unexpected hits in period %v", got)
+	}
+
+	// Total - число всех найденных заказов, а не размер страницы
+	page, err := st.Order().Search(ctx, model.OrderSearch{Q: "ромашк", Limit: 1})
+	check(t, err)
+	if page.Total != 2 || len(page.Items) != 1 || page.Limit != 1 {
+		t.Fatalf("unexpected page %+v", page)
+	}
+}
+
+func testAssemblyOrder(t *testing.T, st store.Store) {
+	u := addCollector(t, st, "a@example.com")
+	e, err := st.Employee().Add(ctx, model.Employee{Code: "E1", FirstName: "Петров", Name: "Петр"})
+	check(t, err)
+	team, err := st.Team().Add(ctx, model.Team{Name: "Смена 1"})
+	check(t, err)
+	_, err = st.EmployeeTeam().Add(ctx, model.EmployeeTeam{TeamID: team.ID, EmployeeID: e.ID})
+	check(t, err)
+
+	assemble := func(o model.Order) {
+		t.Helper()
+		o.Lines = newLines(2, 3)
+		addOrder(t, st, o)
+		_, err := st.Order().Transition(ctx, model.OrderTransition{OrderUID: uint(o.OrderUid), Status: model.OrderStatusAssigned,
+			UserID: u.ID, EmployeeID: e.ID})
+		check(t, err)
+		_, err = st.Order().ConfirmPick(ctx, model.PickConfirmation{OrderUID: uint(o.OrderUid), Lines: []model.LinePick{
+			{LineNo: 1, PickedQuantity: 2}, {LineNo: 2, PickedQuantity: 2, ShortageReason: model.ShortageDamaged}}})
+		check(t, err)
+	}
+	assemble(newOrder(1, "A", 1))
+	april := newOrder(2, "A", 20)
+	april.FolioDate = day(1).AddDate(0, 1, 0)
+	assemble(april)
+	addOrder(t, st, newOrder(3, "A", 2))
+
+	report, err := st.Order().AssemblyOrder(ctx, model.AssemblyFilter{StartDT: "2024-03-01", FinishDT: "2024-03-31"})
+	check(t, err)
+	if len(report) != 1 {
+		t.Fatalf("expected one assembled order in march, got %+v", report)
+	}
+	a := report[0]
+	if a.OrderUid != 1 || a.LineCount != 2 || a.UserName != "Иванов Иван Иванович" || a.EmployeeName != "Петров Петр " {
+		t.Fatalf("unexpected report row %+v", a)
+	}
+	if !a.Shortage || len(a.ShortageLines) != 1 || a.ShortageLines[0].LineNo != 2 {
+		t.Fatalf("unexpected shortage %+v", a)
+	}
+
+	report, err = st.Order().AssemblyOrder(ctx, model.AssemblyFilter{StartDT: "2024-03-01", FinishDT: "2024-03-31", TeamID: team.ID})
+	check(t, err)
+	if len(report) != 1 {
+		t.Fatalf("expected order of team employee, got %+v", report)
+	}
+	report, err = st.Order().AssemblyOrder(ctx, model.AssemblyFilter{StartDT: "2024-03-01", FinishDT: "2024-03-31", TeamID: team.ID + 1})
+	check(t, err)
+	if len(report) != 0 {
+		t.Fatalf("expected no orders of other team, got %+v", report)
+	}
+}
+
+func testAssemblyOrderByAssemblyDate(t *testing.T, st store.Store) {
+	// накладная выставлена в феврале, заказ собран в марте
+	o := newOrder(1, "A", 1)
+	o.FolioDate = day(1).AddDate(0, 0, -1)
+	addOrder(t, st, o)
+	_, err := st.Order().Transition(ctx, model.OrderTransition{OrderUID: 1, Status: model.OrderStatusAssigned, UserID: 5})
+	check(t, err)
+	for _, w := range []model.OrderWork{{Action: model.WorkStart, At: day(4)}, {Action: model.WorkFinish, At: day(4).Add(time.Hour)}} {
+		w.OrderUID, w.UserID = 1, 5
+		_, err := st.Order().Work(ctx, w)
+		check(t, err)
+	}
+
+	february := model.AssemblyFilter{StartDT: "2024-02-01", FinishDT: "2024-02-29"}
+	report, err := st.Order().AssemblyOrder(ctx, february)
+	check(t, err)
+	if len(report) != 1 {
+		t.Fatalf("expected order by folio date in february, got %+v", report)
+	}
+	february.ByAssemblyDate = true
+	report, err = st.Order().AssemblyOrder(ctx, february)
+	check(t, err)
+	if len(report) != 0 {
+		t.Fatalf("expected no orders assembled in february, got %+v", report)
+	}
+	report, err = st.Order().AssemblyOrder(ctx, model.AssemblyFilter{StartDT: "2024-03-01", FinishDT: "2024-03-31", ByAssemblyDate: true})
+	check(t, err)
+	if len(report) != 1 || !report[0].AssemblyDate.Equal(day(4).Add(time.Hour)) {
+		t.Fatalf("expected order assembled in march, got %+v", report)
+	}
+
+	// Повторная сборка после возврата в работу не переносит время сборки
+	addOrder(t, st, newOrder(2, "A", 1))
+	for _, status := range []model.OrderStatus{model.OrderStatusAssigned, model.OrderStatusAssembled, model.OrderStatusPicking, model.OrderStatusAssembled} {
+		_, err := st.Order().Transition(ctx, model.OrderTransition{OrderUID: 2, Status: status, UserID: 5})
+		check(t, err)
+	}
+	events, err := st.OrderEvent().ByOrderUID(ctx, 2)
+	check(t, err)
+	var first time.Time
+	for _, e := range events {
+		if e.ToStatus == model.OrderStatusAssembled && (first.IsZero() || e.CreatedAt.Before(first)) {
+			first = e.CreatedAt
+		}
+	}
+	report, err = st.Order().AssemblyOrder(ctx, model.AssemblyFilter{StartDT: "2024-03-01", FinishDT: "2024-03-31"})
+	check(t, err)
+	if len(report) != 1 || report[0].OrderUid != 2 || !report[0].AssemblyDate.Equal(first) {
+		t.Fatalf("expected first assembly time %v, got %+v", first, report)
+	}
+}
+
+func testOrderShares(t *testing.T, st store.Store) {
+	var employees []model.Employee
+	for i, name := range []string{"Иванов", "Петров", "Сидоров"} {
+		e, err := st.Employee().Add(ctx, model.Employee{Code: fmt.Sprintf("E%d", i+1), FirstName: name, Name: "Иван"})
+		check(t, err)
+		employees = append(employees, e)
+	}
+	team, err := st.Team().Add(ctx, model.Team{Name: "Смена 1"})
+	check(t, err)
+	for i, share := range []float64{2, 1} {
+		_, err := st.EmployeeTeam().Add(ctx, model.EmployeeTeam{TeamID: team.ID, EmployeeID: employees[i].ID, Share: share})
+		check(t, err)
+	}
+
+	assemble := func(uid int, assign model.OrderTransition) {
+		t.Helper()
+		addOrder(t, st, newOrder(uid, "A", 1))
+		assign.OrderUID, assign.Status = uint(uid), model.OrderStatusAssigned
+		_, err := st.Order().Transition(ctx, assign)
+		check(t, err)
+		_, err = st.Order().Transition(ctx, model.OrderTransition{OrderUID: uint(uid), Status: model.OrderStatusAssembled})
+		check(t, err)
+	}
+	assemble(1, model.OrderTransition{UserID: 1, EmployeeID: employees[0].ID, TeamID: team.ID})
+	assemble(2, model.OrderTransition{UserID: 1, EmployeeID: employees[2].ID})
+	addOrder(t, st, newOrder(3, "A", 1))
+
+	// Смена состава команды после сборки не меняет доли собранного заказа
+	check(t, st.EmployeeTeam().DeleteEmployeeTeam(ctx, employees[1].ID, team.ID))
+	_, err = st.EmployeeTeam().Add(ctx, model.EmployeeTeam{TeamID: team.ID, EmployeeID: employees[2].ID, Share: 5})
+	check(t, err)
+
+	shares, err := st.Payroll().Shares(ctx, []int{1, 2, 3})
+	check(t, err)
+	want := []model.OrderShare{
+		{OrderUID: 1, EmployeeID: employees[0].ID, EmployeeName: "Иванов Иван", Share: 2},
+		{OrderUID: 1, EmployeeID: employees[1].ID, EmployeeName: "Петров Иван", Share: 1},
+		{OrderUID: 2, EmployeeID: employees[2].ID, EmployeeName: "Сидоров Иван", Share: 1},
+	}
+	for i := range shares {
+		shares[i].ID = 0
+	}
+	slices.SortFunc(shares, func(a, b model.OrderShare) int {
+		if a.OrderUID != b.OrderUID {
+			return a.OrderUID - b.OrderUID
+		}
+		return int(a.EmployeeID) - int(b.EmployeeID)
+	})
+	if !slices.Equal(shares, want) {
+		t.Fatalf("got shares %+v, want %+v", shares, want)
+	}
+}
@@ -0,0 +1,86 @@
+// Package storetest - общий набор тестов поведения хранилища. Набор
+// выполняется для sqlstore и teststore, чтобы хранилище в памяти, на котором
+// тестируются обработчики, вело себя так же, как база.
+package storetest
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Run выполняет набор тестов. newStore должна возвращать пустое хранилище
+// для каждого теста.
+func Run(t *testing.T, newStore func(t *testing.T) store.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, st store.Store)
+	}{
+		{"Employee", testEmployee},
+		{"EmployeeList", testEmployeeList},
+		{"TeamMembers", testTeamMembers},
+		{"Project", testProject},
+		{"Role", testRole},
+		{"RolePermission", testRolePermission},
+		{"User", testUser},
+		{"UserLinks", testUserLinks},
+		{"RefreshToken", testRefreshToken},
+		{"Payroll", testPayroll},
+		{"SLA", testSLA},
+		{"WithTx", testWithTx},
+		{"CanceledContext", testCanceledContext},
+		{"OrderAdd", testOrderAdd},
+		{"OrderList", testOrderList},
+		{"OrderTransition", testOrderTransition},
+		{"OrderAssembledAt", testOrderAssembledAt},
+		{"OrderConfirmPick", testOrderConfirmPick},
+		{"OrderWork", testOrderWork},
+		{"OrderDispatch", testOrderDispatch},
+		{"OrderUpsert", testOrderUpsert},
+		{"OrderSearch", testOrderSearch},
+		{"AssemblyOrder", testAssemblyOrder},
+		{"AssemblyOrderByAssemblyDate", testAssemblyOrderByAssemblyDate},
+		{"OrderShares", testOrderShares},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+var ctx = context.Background()
+
+func check(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectError(t *testing.T, err, want error) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("expected %v, got %v", want, err)
+	}
+}
+
+func expectNotFound(t *testing.T, err error) {
+	t.Helper()
+	expectError(t, err, gorm.ErrRecordNotFound)
+}
+
+// day - начало дня марта 2024 года в часовом поясе склада.
+func day(d int) time.Time {
+	return time.Date(2024, time.March, d, 0, 0, 0, 0, model.Location)
+}
+
+func march() model.DateRange {
+	return model.DateRange{From: day(1), To: day(1).AddDate(0, 1, 0)}
+}
@@ -1,11 +1,15 @@
 package store
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type TeamRepository interface {
-	Add(model.Team) (model.Team, error)
-	ByID(uint) (model.Team, error)
-	All() ([]model.Team, error)
-	Update(model.Team) (model.Team, error)
-	Delete(uint) error
+	Add(context.Context, model.Team) (model.Team, error)
+	ByID(context.Context, uint) (model.Team, error)
+	All(context.Context) ([]model.Team, error)
+	List(context.Context, model.ListQuery) (model.Page[model.Team], error)
+	Update(context.Context, model.Team) (model.Team, error)
+	Delete(context.Context, uint) error
 }
\ No newline at end of file
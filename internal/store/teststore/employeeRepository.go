@@ -0,0 +1,69 @@
+package teststore
+
+import (
+	"cmp"
+	"context"
+	"eastwh/internal/model"
+	"slices"
+)
+
+type EmployeeRepository struct {
+	store *Store
+}
+
+func (r *EmployeeRepository) Add(ctx context.Context, u model.Employee) (model.Employee, error) {
+	return write(r.store, ctx, func(d *data) (model.Employee, error) {
+		row := u
+		row.Teams = nil
+		row, err := d.employees.insert(row)
+		u.Model = row.Model
+		return u, err
+	})
+}
+
+func (r *EmployeeRepository) All(ctx context.Context) ([]model.Employee, error) {
+	return read(r.store, ctx, func(d *data) ([]model.Employee, error) {
+		employees := d.employees.all()
+		slices.SortStableFunc(employees, func(a, b model.Employee) int {
+			if a.FirstName != b.FirstName {
+				return cmp.Compare(a.FirstName, b.FirstName)
+			}
+			return cmp.Compare(a.Name, b.Name)
+		})
+		return employees, nil
+	})
+}
+
+func (r *EmployeeRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.Employee], error) {
+	return read(r.store, ctx, func(d *data) (model.Page[model.Employee], error) {
+		return list(d.employees.all(), q, model.EmployeeListSpec)
+	})
+}
+
+func (r *EmployeeRepository) ByID(ctx context.Context, id uint) (model.Employee, error) {
+	return read(r.store, ctx, func(d *data) (model.Employee, error) {
+		return d.employees.byID(id)
+	})
+}
+
+func (r *EmployeeRepository) ByCode(ctx context.Context, code string) (model.Employee, error) {
+	return read(r.store, ctx, func(d *data) (model.Employee, error) {
+		return d.employees.first(func(e model.Employee) bool { return e.Code == code })
+	})
+}
+
+func (r *EmployeeRepository) Update(ctx context.Context, u model.Employee) (model.Employee, error) {
+	return write(r.store, ctx, func(d *data) (model.Employee, error) {
+		_, err := d.employees.update(byID[model.Employee](u.ID), func(e *model.Employee) {
+			e.Code, e.FirstName, e.Name, e.LastName = u.Code, u.FirstName, u.Name, u.LastName
+			e.INN, e.Phone = u.INN, u.Phone
+		})
+		return u, err
+	})
+}
+
+func (r *EmployeeRepository) Delete(ctx context.Context, id uint) error {
+	return exec(r.store, ctx, func(d *data) error {
+		return d.employees.deleteByID(id)
+	})
+}
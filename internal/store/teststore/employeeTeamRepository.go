@@ -0,0 +1,68 @@
+package teststore
+
+import (
+	"context"
+	"eastwh/internal/model"
+)
+
+type EmployeeTeamRepository struct {
+	store *Store
+}
+
+func (r *EmployeeTeamRepository) Add(ctx context.Context, u model.EmployeeTeam) (model.EmployeeTeam, error) {
+	return write(r.store, ctx, func(d *data) (model.EmployeeTeam, error) {
+		return d.employeeTeams.insert(u)
+	})
+}
+
+func (r *EmployeeTeamRepository) All(ctx context.Context) ([]model.EmployeeTeam, error) {
+	return read(r.store, ctx, func(d *data) ([]model.EmployeeTeam, error) {
+		return d.employeeTeams.all(), nil
+	})
+}
+
+func (r *EmployeeTeamRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.EmployeeTeam], error) {
+	return read(r.store, ctx, func(d *data) (model.Page[model.EmployeeTeam], error) {
+		return list(d.employeeTeams.all(), q, model.EmployeeTeamListSpec)
+	})
+}
+
+func (r *EmployeeTeamRepository) Update(ctx context.Context, et model.EmployeeTeam) (model.EmployeeTeam, error) {
+	return write(r.store, ctx, func(d *data) (model.EmployeeTeam, error) {
+		return d.employeeTeams.save(et)
+	})
+}
+
+func (r *EmployeeTeamRepository) Delete(ctx context.Context, id uint) error {
+	return exec(r.store, ctx, func(d *data) error {
+		d.employeeTeams.remove(byID[model.EmployeeTeam](id))
+		return nil
+	})
+}
+
+func (r *EmployeeTeamRepository) DeleteEmployeeTeam(ctx context.Context, employee_id, team_id uint) error {
+	return exec(r.store, ctx, func(d *data) error {
+		d.employeeTeams.remove(func(et model.EmployeeTeam) bool {
+			return et.TeamID == team_id && et.EmployeeID == employee_id
+		})
+		return nil
+	})
+}
+
+func (r *EmployeeTeamRepository) ByID(ctx context.Context, id uint) (model.EmployeeTeam, error) {
+	return read(r.store, ctx, func(d *data) (model.EmployeeTeam, error) {
+		return d.employeeTeams.byID(id)
+	})
+}
+
+func (r *EmployeeTeamRepository) ByEmployeeID(ctx context.Context, employeeID uint) ([]model.EmployeeTeam, error) {
+	return read(r.store, ctx, func(d *data) ([]model.EmployeeTeam, error) {
+		return d.employeeTeams.find(func(et model.EmployeeTeam) bool { return et.EmployeeID == employeeID }), nil
+	})
+}
+
+func (r *EmployeeTeamRepository) ByTeamID(ctx context.Context, teamID uint) ([]model.EmployeeTeam, error) {
+	return read(r.store, ctx, func(d *data) ([]model.EmployeeTeam, error) {
+		return d.employeeTeams.find(func(et model.EmployeeTeam) bool { return et.TeamID == teamID }), nil
+	})
+}
@@ -0,0 +1,187 @@
+package teststore
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// schemas - разобранные схемы моделей, по ним колонки списка
+// сопоставляются с полями записей.
+var schemas sync.Map
+
+// listCursor - позиция после последней записи страницы, в том же виде, что у sqlstore.
+type listCursor struct {
+	Value any  `json:"v"`
+	ID    uint `json:"id"`
+}
+
+// list возвращает страницу записей rows по параметрам q с той же сортировкой,
+// фильтрами и курсорами, что и list в sqlstore.
+func list[T any](rows []T, q model.ListQuery, spec model.ListSpec) (page model.Page[T], err error) {
+	if err = q.Normalize(spec); err != nil {
+		return page, err
+	}
+	column, desc, _ := q.Order(spec)
+	conds, _ := q.Conditions(spec)
+
+	s, err := schema.Parse(new(T), &schemas, schema.NamingStrategy{})
+	if err != nil {
+		return page, err
+	}
+	field := s.LookUpField(column)
+	if field == nil {
+		return page, errors.New("unknown column " + column)
+	}
+	value := func(row T, f *schema.Field) any {
+		v, _ := f.ValueOf(context.Background(), reflect.ValueOf(row))
+		return v
+	}
+
+	filtered := slices.DeleteFunc(slices.Clone(rows), func(row T) bool {
+		for _, c := range conds {
+			f := s.LookUpField(c.Column)
+			if f == nil || !slices.ContainsFunc(c.Values, func(v any) bool { return compareValues(value(row, f), v) == 0 }) {
+				return true
+			}
+		}
+		return false
+	})
+	less := func(a, b T) int {
+		c := compareValues(value(a, field), value(b, field))
+		if c == 0 {
+			c = cmp.Compare(idOf(a), idOf(b))
+		}
+		if desc {
+			return -c
+		}
+		return c
+	}
+	slices.SortStableFunc(filtered, less)
+
+	page.Total = int64(len(filtered))
+	page.Limit = q.Limit
+	page.Items = []T{}
+	switch {
+	case q.Page > 0:
+		page.Page = q.Page
+		offset := min((q.Page-1)*q.Limit, len(filtered))
+		page.Items = append(page.Items, filtered[offset:min(offset+q.Limit, len(filtered))]...)
+		return page, nil
+	case q.Cursor != "":
+		c, err := decodeCursor(q.Cursor, field)
+		if err != nil {
+			return page, err
+		}
+		filtered = slices.DeleteFunc(filtered, func(row T) bool {
+			pos := compareValues(value(row, field), c.Value)
+			if column == "id" || pos == 0 {
+				pos = cmp.Compare(idOf(row), c.ID)
+			}
+			if desc {
+				return pos >= 0
+			}
+			return pos <= 0
+		})
+	}
+
+	page.Items = append(page.Items, filtered[:min(q.Limit, len(filtered))]...)
+	if len(filtered) > q.Limit {
+		last := page.Items[q.Limit-1]
+		page.NextCursor, err = encodeCursor(listCursor{Value: value(last, field), ID: idOf(last)})
+	}
+	return page, err
+}
+
+func encodeCursor(c listCursor) (string, error) {
+	data, err := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data), err
+}
+
+func decodeCursor(s string, field *schema.Field) (c listCursor, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, store.ErrInvalidCursor
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		return c, store.ErrInvalidCursor
+	}
+
+	switch v := c.Value.(type) {
+	case json.Number:
+		if c.Value, err = v.Float64(); err != nil {
+			return c, store.ErrInvalidCursor
+		}
+	case string:
+		if field.FieldType == reflect.TypeOf(time.Time{}) {
+			if c.Value, err = time.Parse(time.RFC3339Nano, v); err != nil {
+				return c, store.ErrInvalidCursor
+			}
+		}
+	}
+	return c, nil
+}
+
+// compareValues сравнивает значения колонки. Числа сравниваются независимо
+// от типа, как в SQL; значения разных видов считаются неравными.
+func compareValues(a, b any) int {
+	switch x := sortable(a).(type) {
+	case float64:
+		if y, ok := sortable(b).(float64); ok {
+			return cmp.Compare(x, y)
+		}
+	case string:
+		if y, ok := sortable(b).(string); ok {
+			return cmp.Compare(x, y)
+		}
+	case bool:
+		if y, ok := sortable(b).(bool); ok {
+			return cmp.Compare(boolRank(x), boolRank(y))
+		}
+	case time.Time:
+		if y, ok := sortable(b).(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+	return -1
+}
+
+func sortable(v any) any {
+	if t, ok := v.(time.Time); ok {
+		return t
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	}
+	return v
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,32 @@
+package teststore
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"slices"
+	"time"
+)
+
+type OrderEventRepository struct {
+	store *Store
+}
+
+func (r *OrderEventRepository) ByOrderUID(ctx context.Context, orderUID uint) ([]model.OrderEvent, error) {
+	return read(r.store, ctx, func(d *data) ([]model.OrderEvent, error) {
+		events := d.orderEvents.find(func(e model.OrderEvent) bool { return e.OrderUID == orderUID })
+		slices.SortStableFunc(events, func(a, b model.OrderEvent) int { return a.CreatedAt.Compare(b.CreatedAt) })
+		return events, nil
+	})
+}
+
+func (r *OrderEventRepository) AssembledAt(ctx context.Context, orderUIDs []uint) (map[uint]time.Time, error) {
+	return read(r.store, ctx, func(d *data) (map[uint]time.Time, error) {
+		assembled := make(map[uint]time.Time, len(orderUIDs))
+		for _, e := range d.orderEvents.find(func(e model.OrderEvent) bool { return slices.Contains(orderUIDs, e.OrderUID) }) {
+			if e.ToStatus == model.OrderStatusAssembled && e.FromStatus != model.OrderStatusAssembled && e.CreatedAt.After(assembled[e.OrderUID]) {
+				assembled[e.OrderUID] = e.CreatedAt
+			}
+		}
+		return assembled, nil
+	})
+}
@@ -0,0 +1,46 @@
+package teststore
+
+import (
+	"cmp"
+	"context"
+	"eastwh/internal/model"
+	"slices"
+
+	"gorm.io/gorm"
+)
+
+type OrderLineRepository struct {
+	store *Store
+}
+
+func (r *OrderLineRepository) ByOrderUID(ctx context.Context, orderUID uint) ([]model.OrderLine, error) {
+	return read(r.store, ctx, func(d *data) ([]model.OrderLine, error) {
+		lines := d.orderLines.find(func(l model.OrderLine) bool { return l.OrderUID == orderUID })
+		slices.SortStableFunc(lines, func(a, b model.OrderLine) int {
+			if a.Location != b.Location {
+				return cmp.Compare(a.Location, b.Location)
+			}
+			return cmp.Compare(a.LineNo, b.LineNo)
+		})
+		return lines, nil
+	})
+}
+
+// ByBarcode ищет строку с товаром по EAN. При orderUID = 0 поиск ведется по всем
+// заказам и возвращается строка последнего из них.
+func (r *OrderLineRepository) ByBarcode(ctx context.Context, barcode string, orderUID uint) (model.OrderLine, error) {
+	return read(r.store, ctx, func(d *data) (model.OrderLine, error) {
+		lines := d.orderLines.find(func(l model.OrderLine) bool {
+			return l.Barcode == barcode && (orderUID == 0 || l.OrderUID == orderUID)
+		})
+		if len(lines) == 0 {
+			return model.OrderLine{}, gorm.ErrRecordNotFound
+		}
+		return slices.MinFunc(lines, func(a, b model.OrderLine) int {
+			if a.OrderUID != b.OrderUID {
+				return cmp.Compare(b.OrderUID, a.OrderUID)
+			}
+			return cmp.Compare(a.LineNo, b.LineNo)
+		}), nil
+	})
+}
@@ -0,0 +1,623 @@
+package teststore
+
+import (
+	"cmp"
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OrderRepository struct {
+	store *Store
+}
+
+// openStatuses - статусы несобранных заказов.
+var openStatuses = []model.OrderStatus{model.OrderStatusNew, model.OrderStatusAssigned, model.OrderStatusPicking}
+
+// Add создает заказ с его строками и запись о загрузке в журнале.
+func (r *OrderRepository) Add(ctx context.Context, u model.Order, actorUserID uint) (model.Order, error) {
+	if err := model.ValidateOrderLines(u.Lines); err != nil {
+		return u, err
+	}
+	return write(r.store, ctx, func(d *data) (model.Order, error) {
+		row, err := d.orders.insert(orderRow(u))
+		if err != nil {
+			return u, err
+		}
+		u.Model = row.Model
+
+		if len(u.Lines) > 0 {
+			u.Lines, _, _ = model.MergeOrderLines(uint(u.OrderUid), nil, u.Lines)
+			for i := range u.Lines {
+				if u.Lines[i], err = d.orderLines.insert(u.Lines[i]); err != nil {
+					return u, err
+				}
+			}
+		}
+
+		_, err = d.orderEvents.insert(model.NewOrderEvent(model.OrderEventImport, actorUserID, nil, u))
+		return u, err
+	})
+}
+
+// orderImportBatchSize - число заказов, загружаемых одним изменением, как в sqlstore.
+const orderImportBatchSize = 500
+
+// Upsert загружает заказы из ERP: новые создаются, у существующих обновляются
+// только поля ERP. Заказ, который не удалось сохранить, отмечается как failed
+// и не прерывает загрузку остальных.
+func (r *OrderRepository) Upsert(ctx context.Context, orders []model.Order, actorUserID uint) ([]model.OrderImportResult, error) {
+	results := make([]model.OrderImportResult, len(orders))
+	index := make(map[int]int, len(orders))
+	var pending []int
+
+	for i, o := range orders {
+		results[i] = model.OrderImportResult{OrderUID: o.OrderUid}
+		linesErr := model.ValidateOrderLines(o.Lines)
+		switch _, dup := index[o.OrderUid]; {
+		case o.OrderUid == 0:
+			results[i].Status = model.OrderImportFailed
+			results[i].Error = "order_uid is required"
+		case dup:
+			results[i].Status = model.OrderImportFailed
+			results[i].Error = "duplicate order_uid in batch"
+		case o.FolioDate.IsZero() || o.OrderDate.IsZero():
+			results[i].Status = model.OrderImportFailed
+			results[i].Error = "folio_date and order_date are required"
+		case linesErr != nil:
+			results[i].Status = model.OrderImportFailed
+			results[i].Error = linesErr.Error()
+		default:
+			index[o.OrderUid] = i
+			pending = append(pending, i)
+		}
+	}
+
+	model.ImportBatches(results, pending, orderImportBatchSize, func(batch []int) error {
+		return exec(r.store, ctx, func(d *data) error {
+			return d.upsertBatch(orders, batch, results, actorUserID)
+		})
+	})
+
+	return results, nil
+}
+
+func (d *data) upsertBatch(orders []model.Order, batch []int, results []model.OrderImportResult, actorUserID uint) error {
+	for _, i := range batch {
+		imported := orders[i]
+		uid := uint(imported.OrderUid)
+		current, err := d.orderByUID(uid)
+		found := err == nil
+
+		// Строки заказа обновляются, только если ERP их передала
+		linesChanged := false
+		if imported.Lines != nil {
+			existing := d.orderLines.find(func(l model.OrderLine) bool { return l.OrderUID == uid })
+			changedLines, removed, changed := model.MergeOrderLines(uid, existing, imported.Lines)
+			d.orderLines.remove(func(l model.OrderLine) bool { return slices.Contains(removed, l.ID) })
+			for _, l := range changedLines {
+				if _, err := d.orderLines.save(l); err != nil {
+					return err
+				}
+			}
+			linesChanged = changed
+		}
+
+		switch {
+		case !found:
+			// Поля склада у нового заказа не принимаются от ERP
+			row := model.Order{OrderUid: imported.OrderUid}
+			row.MergeERP(imported)
+			if row, err = d.orders.insert(row); err != nil {
+				return err
+			}
+			if _, err := d.orderEvents.insert(model.NewOrderEvent(model.OrderEventImport, actorUserID, nil, row)); err != nil {
+				return err
+			}
+			results[i].Status = model.OrderImportCreated
+		case current.ERPEqual(imported) && !linesChanged:
+			results[i].Status = model.OrderImportUnchanged
+		default:
+			row := current
+			row.MergeERP(imported)
+			if row, err = d.orders.save(row); err != nil {
+				return err
+			}
+			if _, err := d.orderEvents.insert(model.NewOrderEvent(model.OrderEventImport, actorUserID, &current, row)); err != nil {
+				return err
+			}
+			results[i].Status = model.OrderImportUpdated
+		}
+	}
+	return nil
+}
+
+// Transition переводит заказ в новый статус.
+func (r *OrderRepository) Transition(ctx context.Context, t model.OrderTransition) (model.Order, error) {
+	return write(r.store, ctx, func(d *data) (model.Order, error) {
+		order, err := d.orderByUID(t.OrderUID)
+		if err != nil {
+			return order, err
+		}
+
+		if !order.Status.CanTransitionTo(t.Status) {
+			return orderOut(order), fmt.Errorf("%w: %s -> %s", store.ErrIllegalTransition, order.Status, t.Status)
+		}
+
+		before := order
+		order.Apply(t)
+		if t.Status == model.OrderStatusAssembled {
+			order.StopWork(time.Now())
+		}
+		return d.saveOrder(model.NewOrderEvent(t.EventType(before.Status), t.ActorID, &before, order), order)
+	})
+}
+
+// ConfirmPick записывает собранное количество по строкам. Назначенный заказ
+// переходит в сборку, после подтверждения всех строк - в "собран" с признаком недостачи.
+func (r *OrderRepository) ConfirmPick(ctx context.Context, p model.PickConfirmation) (model.Order, error) {
+	return write(r.store, ctx, func(d *data) (model.Order, error) {
+		order, err := d.orderByUID(p.OrderUID)
+		if err != nil {
+			return order, err
+		}
+
+		if order.Status != model.OrderStatusAssigned && order.Status != model.OrderStatusPicking {
+			return orderOut(order), fmt.Errorf("%w: pick is not allowed in status %s", store.ErrIllegalTransition, order.Status)
+		}
+
+		lines := d.orderLines.find(func(l model.OrderLine) bool { return l.OrderUID == p.OrderUID })
+		changed, err := p.Apply(lines)
+		if err != nil {
+			return orderOut(order), fmt.Errorf("%w: %v", store.ErrInvalidPick, err)
+		}
+		for _, l := range changed {
+			_, err := d.orderLines.update(byID[model.OrderLine](l.ID), func(row *model.OrderLine) {
+				row.PickedQuantity, row.Picked, row.ShortageReason = l.PickedQuantity, l.Picked, l.ShortageReason
+			})
+			if err != nil {
+				return orderOut(order), err
+			}
+		}
+
+		before := order
+		if order.Status == model.OrderStatusAssigned {
+			order.Apply(model.OrderTransition{Status: model.OrderStatusPicking, UserID: p.UserID, EmployeeID: p.EmployeeID})
+		}
+
+		complete, shortage, err := model.PickRollup(lines)
+		if err != nil {
+			return orderOut(order), fmt.Errorf("%w: %v", store.ErrInvalidPick, err)
+		}
+		if complete {
+			order.Apply(model.OrderTransition{Status: model.OrderStatusAssembled, UserID: p.UserID, EmployeeID: p.EmployeeID})
+			order.Shortage = shortage
+			order.StopWork(time.Now())
+		}
+
+		event := model.NewOrderEvent(model.OrderEventPick, p.ActorID, &before, order)
+		if order.Status != before.Status {
+			return d.saveOrder(event, order)
+		}
+		_, err = d.orderEvents.insert(event)
+		return orderOut(order), err
+	})
+}
+
+// Work отмечает начало, паузу, продолжение или окончание сборки. Отмечать время
+// может только сборщик, на которого назначен заказ: новый заказ сборщик
+// получает через очередь выдачи.
+func (r *OrderRepository) Work(ctx context.Context, w model.OrderWork) (model.Order, error) {
+	return write(r.store, ctx, func(d *data) (model.Order, error) {
+		order, err := d.orderByUID(w.OrderUID)
+		if err != nil {
+			return order, err
+		}
+
+		switch {
+		case order.UserID == 0:
+			return orderOut(order), store.ErrNotAssigned
+		case order.UserID != w.UserID:
+			return orderOut(order), store.ErrOtherCollector
+		}
+
+		before := order
+		if err := order.ApplyWork(w); err != nil {
+			return orderOut(order), fmt.Errorf("%w: %v", store.ErrIllegalTransition, err)
+		}
+		return d.saveOrder(model.NewOrderEvent(w.EventType(), w.UserID, &before, order), order)
+	})
+}
+
+// ByBarcode ищет заказ по штрихкоду накладной: номеру фолио или уникальному номеру.
+// При совпадении у нескольких заказов возвращается последний загруженный.
+func (r *OrderRepository) ByBarcode(ctx context.Context, code int) (model.Order, error) {
+	return read(r.store, ctx, func(d *data) (model.Order, error) {
+		orders := d.orders.find(func(o model.Order) bool { return o.FolioNum == code || o.UnicumNum == code })
+		if len(orders) == 0 {
+			return model.Order{}, gorm.ErrRecordNotFound
+		}
+		return orderOut(orders[len(orders)-1]), nil
+	})
+}
+
+func (r *OrderRepository) ByUserID(ctx context.Context, userID uint) ([]model.Order, error) {
+	return r.find(ctx, func(d *data, o model.Order) bool { return o.UserID == userID })
+}
+
+func (r *OrderRepository) ByID(ctx context.Context, ID uint) ([]model.Order, error) {
+	return r.find(ctx, func(d *data, o model.Order) bool { return o.ID == ID })
+}
+
+func (r *OrderRepository) ByOrderUID(ctx context.Context, orderUID uint) ([]model.Order, error) {
+	return r.find(ctx, func(d *data, o model.Order) bool { return o.OrderUid == int(orderUID) })
+}
+
+func (r *OrderRepository) ByDateRange(ctx context.Context, period model.DateRange) ([]model.Order, error) {
+	return r.find(ctx, func(d *data, o model.Order) bool { return inRange(o.OrderDate, period) })
+}
+
+func (r *OrderRepository) All(ctx context.Context) ([]model.Order, error) {
+	return r.find(ctx, func(*data, model.Order) bool { return true })
+}
+
+func (r *OrderRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.Order], error) {
+	return read(r.store, ctx, func(d *data) (model.Page[model.Order], error) {
+		page, err := list(d.orders.all(), q, model.OrderListSpec)
+		ordersOut(page.Items)
+		return page, err
+	})
+}
+
+// ByAccessUser возвращает несобранные заказы проектов пользователя за период.
+func (r *OrderRepository) ByAccessUser(ctx context.Context, userID uint, period model.DateRange) ([]model.Order, error) {
+	return r.find(ctx, func(d *data, o model.Order) bool {
+		return slices.Contains(d.accessVidDocs(userID), o.VidDoc) && inRange(o.FolioDate, period) &&
+			slices.Contains(openStatuses, o.Status)
+	})
+}
+
+// Queue возвращает очередь сборки пользователя в порядке выдачи по стратегии.
+func (r *OrderRepository) Queue(ctx context.Context, q model.DispatchRequest) ([]model.Order, error) {
+	return read(r.store, ctx, func(d *data) ([]model.Order, error) {
+		orders := d.queue(q)
+		if q.Limit > 0 && len(orders) > q.Limit {
+			orders = orders[:q.Limit]
+		}
+		return ordersOut(orders), nil
+	})
+}
+
+// Next выдает сборщику следующий заказ из очереди. Если у сборщика уже есть
+// назначенный и не начатый заказ, возвращается он.
+func (r *OrderRepository) Next(ctx context.Context, q model.DispatchRequest) (model.Order, error) {
+	return write(r.store, ctx, func(d *data) (model.Order, error) {
+		assigned := d.orders.find(func(o model.Order) bool {
+			return o.UserID == q.UserID && o.Status == model.OrderStatusAssigned
+		})
+		if len(assigned) > 0 {
+			slices.SortStableFunc(assigned, func(a, b model.Order) int { return a.UpdatedAt.Compare(b.UpdatedAt) })
+			return orderOut(assigned[0]), nil
+		}
+
+		queue := d.queue(q)
+		if len(queue) == 0 {
+			return model.Order{}, gorm.ErrRecordNotFound
+		}
+
+		order := queue[0]
+		t := model.OrderTransition{Status: model.OrderStatusAssigned, UserID: q.UserID,
+			EmployeeID: q.EmployeeID, TeamID: q.TeamID, ActorID: q.UserID}
+		before := order
+		order.Apply(t)
+		return d.saveOrder(model.NewOrderEvent(t.EventType(before.Status), t.ActorID, &before, order), order)
+	})
+}
+
+// queue - новые заказы без сборщика по проектам пользователя, упорядоченные
+// по стратегии так же, как dispatchQuery в sqlstore.
+func (d *data) queue(q model.DispatchRequest) []model.Order {
+	vidDocs := d.accessVidDocs(q.UserID)
+	orders := d.orders.find(func(o model.Order) bool {
+		return o.Status == model.OrderStatusNew && o.UserID == 0 && slices.Contains(vidDocs, o.VidDoc) &&
+			(q.VidDoc == "" || o.VidDoc == q.VidDoc)
+	})
+
+	// Стратегии по клиенту и маршруту поднимают в начало заказы клиента
+	// или водителя последнего заказа сборщика
+	var first func(model.Order) bool
+	if q.Strategy == model.DispatchClient || q.Strategy == model.DispatchRoute {
+		if mine := d.orders.find(func(o model.Order) bool { return o.UserID == q.UserID }); len(mine) > 0 {
+			last := slices.MaxFunc(mine, func(a, b model.Order) int { return a.UpdatedAt.Compare(b.UpdatedAt) })
+			switch {
+			case q.Strategy == model.DispatchClient:
+				first = func(o model.Order) bool { return o.ClientId == last.ClientId }
+			case last.Driver != "":
+				first = func(o model.Order) bool { return o.Driver == last.Driver }
+			}
+		}
+	}
+
+	slices.SortStableFunc(orders, func(a, b model.Order) int {
+		if first != nil && first(a) != first(b) {
+			if first(a) {
+				return -1
+			}
+			return 1
+		}
+		if q.Strategy == model.DispatchPriority {
+			if a.Priority != b.Priority {
+				return cmp.Compare(b.Priority, a.Priority)
+			}
+			if c := compareShipBy(a.ShipBy, b.ShipBy); c != 0 {
+				return c
+			}
+		}
+		if c := a.OrderDate.Compare(b.OrderDate); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return orders
+}
+
+// compareShipBy упорядочивает заказы по обещанной отгрузке, заказы без нее - последними.
+func compareShipBy(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Compare(*b)
+}
+
+// accessVidDocs - виды документов проектов пользователя.
+func (d *data) accessVidDocs(userID uint) []string {
+	var ids []uint
+	for _, up := range d.userProjects.find(func(up model.UserProject) bool { return up.UserID == userID }) {
+		ids = append(ids, up.ProjectID)
+	}
+	vidDocs := make([]string, 0)
+	for _, p := range d.projects.find(func(p model.Project) bool { return slices.Contains(ids, p.ID) }) {
+		vidDocs = append(vidDocs, p.VidDoc)
+	}
+	return vidDocs
+}
+
+// AssemblyOrder строит отчет о собранных заказах. Время сборки берется из отметки
+// окончания, без нее - из журнала заказа, без записи в журнале - из updated_at.
+func (r *OrderRepository) AssemblyOrder(ctx context.Context, f model.AssemblyFilter) ([]model.AssemblyOrder, error) {
+	period, err := f.DateRange()
+	if err != nil {
+		return nil, err
+	}
+
+	return read(r.store, ctx, func(d *data) ([]model.AssemblyOrder, error) {
+		var teamEmployees []uint
+		for _, et := range d.employeeTeams.find(func(et model.EmployeeTeam) bool { return et.TeamID == f.TeamID }) {
+			teamEmployees = append(teamEmployees, et.EmployeeID)
+		}
+		assembled := []model.OrderStatus{model.OrderStatusAssembled, model.OrderStatusChecked, model.OrderStatusShipped}
+
+		orders := d.orders.find(func(o model.Order) bool {
+			date := o.FolioDate
+			if f.ByAssemblyDate {
+				date, _ = d.assembledAt(o)
+			}
+			return slices.Contains(assembled, o.Status) && inRange(date, period) &&
+				(f.VidDoc == "" || o.VidDoc == f.VidDoc) &&
+				(f.UserID == 0 || o.UserID == f.UserID) &&
+				(f.EmployeeID == 0 || o.EmployeeID == f.EmployeeID) &&
+				(f.TeamID == 0 || o.TeamID == f.TeamID || slices.Contains(teamEmployees, o.EmployeeID))
+		})
+
+		assemblyOrders := make([]model.AssemblyOrder, 0, len(orders))
+		for _, o := range ordersOut(orders) {
+			a := model.AssemblyOrder{
+				OrderUid: o.OrderUid, OrderDate: o.OrderDate, OrderSum: o.OrderSum, FolioNum: o.FolioNum,
+				FolioDate: o.FolioDate, UnicumNum: o.UnicumNum, FolioSum: o.FolioSum,
+				Status: o.Status, UserID: o.UserID, EmployeeID: o.EmployeeID, TeamID: o.TeamID,
+				CreatedAt: o.CreatedAt.In(model.Location), ClientName: o.ClientName, VidDoc: o.VidDoc,
+				StartAt: o.StartAt, FinishAt: o.FinishAt, WorkSeconds: o.WorkSeconds,
+				LineCount:    len(d.orderLines.find(func(l model.OrderLine) bool { return l.OrderUID == uint(o.OrderUid) })),
+				AssemblyDate: d.assemblyDate(o).In(model.Location),
+			}
+			if u, ok := d.users.joined(o.UserID); ok {
+				a.UserName = strings.Join([]string{u.FirstName, u.Name, u.LastName}, " ")
+			}
+			if e, ok := d.employees.joined(o.EmployeeID); ok {
+				a.EmployeeName = strings.Join([]string{e.FirstName, e.Name, e.LastName}, " ")
+			}
+			a.SetDuration()
+			assemblyOrders = append(assemblyOrders, a)
+		}
+		slices.SortStableFunc(assemblyOrders, func(a, b model.AssemblyOrder) int { return a.AssemblyDate.Compare(b.AssemblyDate) })
+
+		d.fillShortages(assemblyOrders)
+		return assemblyOrders, nil
+	})
+}
+
+// assemblyDate - время сборки заказа: отметка окончания или первый перевод
+// в "собран" по журналу, что раньше, иначе время последнего изменения.
+func (d *data) assemblyDate(o model.Order) time.Time {
+	if at, ok := d.assembledAt(o); ok {
+		return at
+	}
+	return o.UpdatedAt
+}
+
+// assembledAt - более раннее из отметки окончания сборки и первого перевода
+// в "собран" по журналу: повторная сборка не переносит заказ в другой период.
+func (d *data) assembledAt(o model.Order) (time.Time, bool) {
+	var assembledAt time.Time
+	if o.FinishAt != nil {
+		assembledAt = *o.FinishAt
+	}
+	for _, e := range d.orderEvents.find(func(e model.OrderEvent) bool { return e.OrderUID == uint(o.OrderUid) }) {
+		if e.ToStatus == model.OrderStatusAssembled && e.FromStatus != model.OrderStatusAssembled &&
+			(assembledAt.IsZero() || e.CreatedAt.Before(assembledAt)) {
+			assembledAt = e.CreatedAt
+		}
+	}
+	return assembledAt, !assembledAt.IsZero()
+}
+
+// fillShortages дополняет собранные заказы строками с недостачей.
+func (d *data) fillShortages(assemblyOrders []model.AssemblyOrder) {
+	for i := range assemblyOrders {
+		a := &assemblyOrders[i]
+		lines := d.orderLines.find(func(l model.OrderLine) bool {
+			return l.OrderUID == uint(a.OrderUid) && l.Picked && l.PickedQuantity < l.Quantity
+		})
+		slices.SortStableFunc(lines, func(x, y model.OrderLine) int { return cmp.Compare(x.LineNo, y.LineNo) })
+		if len(lines) > 0 {
+			a.ShortageLines = lines
+		}
+		a.Shortage = len(lines) > 0
+	}
+}
+
+func (r *OrderRepository) CheckedList(ctx context.Context, period model.DateRange, checkStatus bool) ([]model.Order, error) {
+	return r.find(ctx, func(d *data, o model.Order) bool {
+		return inRange(o.FolioDate, period) && o.Check == checkStatus
+	})
+}
+
+// Search ищет заказы по словам запроса так же, как sqlstore без полнотекстового
+// индекса: каждое слово должно найтись в тексте заказа или совпасть с номером
+// документа, из последних по id заказов выбираются самые релевантные.
+func (r *OrderRepository) Search(ctx context.Context, s model.OrderSearch) (model.Page[model.OrderSearchHit], error) {
+	terms := s.Terms()
+	period, ok, err := s.DateRange()
+	if err != nil {
+		return model.Page[model.OrderSearchHit]{}, err
+	}
+
+	return read(r.store, ctx, func(d *data) (model.Page[model.OrderSearchHit], error) {
+		orders := d.orders.find(func(o model.Order) bool {
+			if ok && !inRange(o.FolioDate, period) {
+				return false
+			}
+			for _, t := range terms {
+				if !matchTerm(o, t) {
+					return false
+				}
+			}
+			return true
+		})
+		slices.Reverse(orders)
+		page := model.Page[model.OrderSearchHit]{Total: int64(len(orders)), Limit: s.Limit}
+		if s.Limit > 0 && len(orders) > s.Limit {
+			orders = orders[:s.Limit]
+		}
+
+		hits := make([]model.OrderSearchHit, len(orders))
+		for i, o := range ordersOut(orders) {
+			hits[i] = model.OrderSearchHit{Order: o, Score: model.ScoreOrder(o, terms)}
+			hits[i].Highlight(terms)
+		}
+		sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+		page.Items = hits
+		return page, nil
+	})
+}
+
+// matchTerm - слово найдено в тексте заказа или совпадает с его номером.
+func matchTerm(o model.Order, term string) bool {
+	for _, text := range []string{o.ClientName, o.ClientAddress, o.Driver, o.Agent} {
+		if strings.Contains(strings.ToLower(text), term) {
+			return true
+		}
+	}
+	n, err := strconv.Atoi(term)
+	return err == nil && (o.FolioNum == n || o.UnicumNum == n)
+}
+
+// find возвращает заказы, для которых match возвращает true.
+func (r *OrderRepository) find(ctx context.Context, match func(*data, model.Order) bool) ([]model.Order, error) {
+	return read(r.store, ctx, func(d *data) ([]model.Order, error) {
+		return ordersOut(d.orders.find(func(o model.Order) bool { return match(d, o) })), nil
+	})
+}
+
+func (d *data) orderByUID(uid uint) (model.Order, error) {
+	return d.orders.first(func(o model.Order) bool { return o.OrderUid == int(uid) })
+}
+
+// saveOrder записывает состояние заказа вместе с событием журнала.
+func (d *data) saveOrder(event model.OrderEvent, order model.Order) (model.Order, error) {
+	order, err := d.orders.save(order)
+	if err != nil {
+		return orderOut(order), err
+	}
+	if event.ToStatus == model.OrderStatusAssembled && event.FromStatus != model.OrderStatusAssembled {
+		if err := d.saveOrderShares(order); err != nil {
+			return orderOut(order), err
+		}
+	}
+	_, err = d.orderEvents.insert(event)
+	return orderOut(order), err
+}
+
+// saveOrderShares фиксирует участников сборки заказа и их доли в оплате.
+func (d *data) saveOrderShares(order model.Order) error {
+	var members []model.EmployeeTeam
+	if order.TeamID != 0 {
+		members = d.employeeTeams.find(func(m model.EmployeeTeam) bool { return m.TeamID == order.TeamID })
+	}
+	names := make(map[uint]string)
+	for _, e := range d.employees.all() {
+		names[e.ID] = e.FullName()
+	}
+
+	d.orderShares.remove(func(s model.OrderShare) bool { return s.OrderUID == order.OrderUid })
+	for _, s := range model.AssemblyShares(order, members, names) {
+		if _, err := d.orderShares.insert(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orderRow - заказ в том виде, в каком он хранится: без строк и вычисляемых полей.
+func orderRow(o model.Order) model.Order {
+	o.Lines, o.SLA = nil, nil
+	return o
+}
+
+// orderOut готовит хранимый заказ к выдаче: копирует отметки времени, чтобы
+// AfterFind не изменил запись в таблице, и переводит даты в часовой пояс склада.
+func orderOut(o model.Order) model.Order {
+	for _, t := range []**time.Time{&o.StartAt, &o.FinishAt, &o.PausedAt, &o.ShipBy} {
+		if *t != nil {
+			c := **t
+			*t = &c
+		}
+	}
+	o.AfterFind(nil)
+	return o
+}
+
+func ordersOut(orders []model.Order) []model.Order {
+	for i := range orders {
+		orders[i] = orderOut(orders[i])
+	}
+	return orders
+}
+
+// inRange - время попадает в полуинтервал периода.
+func inRange(t time.Time, period model.DateRange) bool {
+	return !t.Before(period.From) && t.Before(period.To)
+}
@@ -0,0 +1,98 @@
+package teststore
+
+import (
+	"cmp"
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"slices"
+)
+
+type PayrollRepository struct {
+	store *Store
+}
+
+func (r *PayrollRepository) Tariffs(ctx context.Context) ([]model.Tariff, error) {
+	return read(r.store, ctx, func(d *data) ([]model.Tariff, error) {
+		tariffs := d.tariffs.all()
+		slices.SortStableFunc(tariffs, func(a, b model.Tariff) int { return cmp.Compare(a.VidDoc, b.VidDoc) })
+		return tariffs, nil
+	})
+}
+
+func (r *PayrollRepository) SetTariffs(ctx context.Context, tariffs []model.Tariff) ([]model.Tariff, error) {
+	return write(r.store, ctx, func(d *data) ([]model.Tariff, error) {
+		return d.tariffs.replace(tariffs)
+	})
+}
+
+func (r *PayrollRepository) Shares(ctx context.Context, orderUIDs []int) ([]model.OrderShare, error) {
+	return read(r.store, ctx, func(d *data) ([]model.OrderShare, error) {
+		return d.orderShares.find(func(s model.OrderShare) bool { return slices.Contains(orderUIDs, s.OrderUID) }), nil
+	})
+}
+
+func (r *PayrollRepository) Periods(ctx context.Context) ([]model.PayrollPeriod, error) {
+	return read(r.store, ctx, func(d *data) ([]model.PayrollPeriod, error) {
+		periods := d.payrollPeriods.all()
+		slices.SortStableFunc(periods, func(a, b model.PayrollPeriod) int { return cmp.Compare(b.StartDT, a.StartDT) })
+		return periods, nil
+	})
+}
+
+func (r *PayrollRepository) Overlapping(ctx context.Context, startDT, finishDT string) ([]model.PayrollPeriod, error) {
+	return read(r.store, ctx, func(d *data) ([]model.PayrollPeriod, error) {
+		periods := d.payrollPeriods.find(overlapping(startDT, finishDT))
+		slices.SortStableFunc(periods, func(a, b model.PayrollPeriod) int { return cmp.Compare(a.StartDT, b.StartDT) })
+		for i := range periods {
+			periods[i].Entries = d.payrollEntries.find(func(e model.PayrollEntry) bool { return e.PeriodID == periods[i].ID })
+		}
+		return periods, nil
+	})
+}
+
+func (r *PayrollRepository) Paid(ctx context.Context, orderUIDs []int) ([]int, error) {
+	return read(r.store, ctx, func(d *data) ([]int, error) {
+		var paid []int
+		for _, o := range d.payrollOrders.find(func(o model.PayrollOrder) bool { return slices.Contains(orderUIDs, o.OrderUID) }) {
+			paid = append(paid, o.OrderUID)
+		}
+		return paid, nil
+	})
+}
+
+func (r *PayrollRepository) Close(ctx context.Context, p model.PayrollPeriod) (model.PayrollPeriod, error) {
+	return write(r.store, ctx, func(d *data) (model.PayrollPeriod, error) {
+		if len(d.payrollPeriods.find(overlapping(p.StartDT, p.FinishDT))) > 0 {
+			return p, store.ErrPeriodClosed
+		}
+
+		row := p
+		row.Entries, row.Orders = nil, nil
+		row, err := d.payrollPeriods.insert(row)
+		if err != nil {
+			return p, err
+		}
+		p.Model = row.Model
+
+		for i := range p.Entries {
+			p.Entries[i].PeriodID = p.ID
+			if p.Entries[i], err = d.payrollEntries.insert(p.Entries[i]); err != nil {
+				return p, err
+			}
+		}
+		for i := range p.Orders {
+			p.Orders[i].PeriodID = p.ID
+			if p.Orders[i], err = d.payrollOrders.insert(p.Orders[i]); err != nil {
+				return p, err
+			}
+		}
+		return p, nil
+	})
+}
+
+func overlapping(startDT, finishDT string) func(model.PayrollPeriod) bool {
+	return func(p model.PayrollPeriod) bool {
+		return p.StartDT <= finishDT && p.FinishDT >= startDT
+	}
+}
@@ -0,0 +1,51 @@
+package teststore
+
+import (
+	"context"
+	"eastwh/internal/model"
+)
+
+type ProjectRepository struct {
+	store *Store
+}
+
+func (r *ProjectRepository) Add(ctx context.Context, u model.Project) (model.Project, error) {
+	return write(r.store, ctx, func(d *data) (model.Project, error) {
+		row := u
+		row.Users = nil
+		row, err := d.projects.insert(row)
+		u.Model = row.Model
+		return u, err
+	})
+}
+
+func (r *ProjectRepository) All(ctx context.Context) ([]model.Project, error) {
+	return read(r.store, ctx, func(d *data) ([]model.Project, error) {
+		return d.projects.all(), nil
+	})
+}
+
+func (r *ProjectRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.Project], error) {
+	return read(r.store, ctx, func(d *data) (model.Page[model.Project], error) {
+		return list(d.projects.all(), q, model.ProjectListSpec)
+	})
+}
+
+func (r *ProjectRepository) ByID(ctx context.Context, id uint) (model.Project, error) {
+	return read(r.store, ctx, func(d *data) (model.Project, error) {
+		return d.projects.byID(id)
+	})
+}
+
+func (r *ProjectRepository) Update(ctx context.Context, u model.Project) (model.Project, error) {
+	return write(r.store, ctx, func(d *data) (model.Project, error) {
+		_, err := d.projects.update(byID[model.Project](u.ID), func(p *model.Project) { p.Name = u.Name })
+		return u, err
+	})
+}
+
+func (r *ProjectRepository) Delete(ctx context.Context, id uint) error {
+	return exec(r.store, ctx, func(d *data) error {
+		return d.projects.deleteByID(id)
+	})
+}
@@ -0,0 +1,62 @@
+package teststore
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"time"
+)
+
+type RefreshTokenRepository struct {
+	store *Store
+}
+
+func (r *RefreshTokenRepository) Add(ctx context.Context, t model.RefreshToken) (model.RefreshToken, error) {
+	return write(r.store, ctx, func(d *data) (model.RefreshToken, error) {
+		return d.refreshTokens.insert(t)
+	})
+}
+
+func (r *RefreshTokenRepository) ByHash(ctx context.Context, hash string) (model.RefreshToken, error) {
+	return read(r.store, ctx, func(d *data) (model.RefreshToken, error) {
+		return d.refreshTokens.first(func(t model.RefreshToken) bool { return t.TokenHash == hash })
+	})
+}
+
+// Use помечает токен использованным, только если он еще не использован и не отозван.
+func (r *RefreshTokenRepository) Use(ctx context.Context, id uint) (bool, error) {
+	return write(r.store, ctx, func(d *data) (bool, error) {
+		now := time.Now()
+		n, err := d.refreshTokens.update(func(t model.RefreshToken) bool {
+			return t.ID == id && t.UsedAt == nil && t.RevokedAt == nil
+		}, func(t *model.RefreshToken) { t.UsedAt = &now })
+		return n == 1, err
+	})
+}
+
+func (r *RefreshTokenRepository) FamilyActive(ctx context.Context, familyID string) (bool, error) {
+	return read(r.store, ctx, func(d *data) (bool, error) {
+		now := time.Now()
+		active := d.refreshTokens.find(func(t model.RefreshToken) bool {
+			return t.FamilyID == familyID && t.RevokedAt == nil && t.ExpiresAt.After(now)
+		})
+		return len(active) > 0, nil
+	})
+}
+
+func (r *RefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.revoke(ctx, func(t model.RefreshToken) bool { return t.FamilyID == familyID })
+}
+
+func (r *RefreshTokenRepository) RevokeUser(ctx context.Context, userID uint) error {
+	return r.revoke(ctx, func(t model.RefreshToken) bool { return t.UserID == userID })
+}
+
+func (r *RefreshTokenRepository) revoke(ctx context.Context, match func(model.RefreshToken) bool) error {
+	return exec(r.store, ctx, func(d *data) error {
+		now := time.Now()
+		_, err := d.refreshTokens.update(func(t model.RefreshToken) bool {
+			return t.RevokedAt == nil && match(t)
+		}, func(t *model.RefreshToken) { t.RevokedAt = &now })
+		return err
+	})
+}
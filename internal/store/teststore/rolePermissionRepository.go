@@ -0,0 +1,44 @@
+package teststore
+
+import (
+	"cmp"
+	"context"
+	"eastwh/internal/model"
+	"slices"
+)
+
+type RolePermissionRepository struct {
+	store *Store
+}
+
+func (r *RolePermissionRepository) ByRoleID(ctx context.Context, roleID uint) ([]model.RolePermission, error) {
+	return read(r.store, ctx, func(d *data) ([]model.RolePermission, error) {
+		rp := d.rolePermissions.find(func(rp model.RolePermission) bool { return rp.RoleID == roleID })
+		slices.SortStableFunc(rp, func(a, b model.RolePermission) int { return cmp.Compare(a.Permission, b.Permission) })
+		return rp, nil
+	})
+}
+
+func (r *RolePermissionRepository) ByRoleIDs(ctx context.Context, roleIDs []uint) ([]model.RolePermission, error) {
+	if len(roleIDs) == 0 {
+		return nil, nil
+	}
+	return read(r.store, ctx, func(d *data) ([]model.RolePermission, error) {
+		return d.rolePermissions.find(func(rp model.RolePermission) bool { return slices.Contains(roleIDs, rp.RoleID) }), nil
+	})
+}
+
+// SetForRole полностью заменяет набор прав роли.
+func (r *RolePermissionRepository) SetForRole(ctx context.Context, roleID uint, permissions []model.Permission) ([]model.RolePermission, error) {
+	return write(r.store, ctx, func(d *data) (rp []model.RolePermission, err error) {
+		d.rolePermissions.remove(func(rp model.RolePermission) bool { return rp.RoleID == roleID })
+		for _, p := range permissions {
+			row, err := d.rolePermissions.insert(model.RolePermission{RoleID: roleID, Permission: p})
+			if err != nil {
+				return nil, err
+			}
+			rp = append(rp, row)
+		}
+		return rp, nil
+	})
+}
@@ -0,0 +1,59 @@
+package teststore
+
+import (
+	"context"
+	"eastwh/internal/model"
+)
+
+type RoleRepository struct {
+	store *Store
+}
+
+func (r *RoleRepository) Add(ctx context.Context, u model.Role) (model.Role, error) {
+	return write(r.store, ctx, func(d *data) (model.Role, error) {
+		row := u
+		row.Users = nil
+		row, err := d.roles.insert(row)
+		u.Model = row.Model
+		return u, err
+	})
+}
+
+func (r *RoleRepository) All(ctx context.Context) ([]model.Role, error) {
+	return read(r.store, ctx, func(d *data) ([]model.Role, error) {
+		return d.roles.all(), nil
+	})
+}
+
+func (r *RoleRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.Role], error) {
+	return read(r.store, ctx, func(d *data) (model.Page[model.Role], error) {
+		return list(d.roles.all(), q, model.RoleListSpec)
+	})
+}
+
+func (r *RoleRepository) ByID(ctx context.Context, id uint) (model.Role, error) {
+	return read(r.store, ctx, func(d *data) (model.Role, error) {
+		return d.roles.byID(id)
+	})
+}
+
+func (r *RoleRepository) ByName(ctx context.Context, name string) (model.Role, error) {
+	return read(r.store, ctx, func(d *data) (model.Role, error) {
+		return d.roles.first(func(role model.Role) bool { return role.Name == name })
+	})
+}
+
+func (r *RoleRepository) Update(ctx context.Context, u model.Role) (model.Role, error) {
+	return write(r.store, ctx, func(d *data) (model.Role, error) {
+		_, err := d.roles.update(byID[model.Role](u.ID), func(role *model.Role) {
+			role.Name, role.Description, role.Priority = u.Name, u.Description, u.Priority
+		})
+		return u, err
+	})
+}
+
+func (r *RoleRepository) Delete(ctx context.Context, id uint) error {
+	return exec(r.store, ctx, func(d *data) error {
+		return d.roles.deleteByID(id)
+	})
+}
@@ -0,0 +1,26 @@
+package teststore
+
+import (
+	"cmp"
+	"context"
+	"eastwh/internal/model"
+	"slices"
+)
+
+type SLARepository struct {
+	store *Store
+}
+
+func (r *SLARepository) Rules(ctx context.Context) ([]model.SLARule, error) {
+	return read(r.store, ctx, func(d *data) ([]model.SLARule, error) {
+		rules := d.slaRules.all()
+		slices.SortStableFunc(rules, func(a, b model.SLARule) int { return cmp.Compare(a.VidDoc, b.VidDoc) })
+		return rules, nil
+	})
+}
+
+func (r *SLARepository) SetRules(ctx context.Context, rules []model.SLARule) ([]model.SLARule, error) {
+	return write(r.store, ctx, func(d *data) ([]model.SLARule, error) {
+		return d.slaRules.replace(rules)
+	})
+}
@@ -0,0 +1,372 @@
+// Package teststore - хранилище в памяти для тестов. Репозитории повторяют
+// поведение sqlstore, проверенное общим набором тестов storetest, и позволяют
+// тестировать обработчики без базы данных.
+package teststore
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"eastwh/internal/store"
+	"sync"
+)
+
+type Store struct {
+	mu                       sync.Mutex
+	data                     *data
+	userRepository           *UserRepository
+	userTeamRepository       *UserTeamRepository
+	userRoleRepository       *UserRoleRepository
+	userProjectRepository    *UserProjectRepository
+	teamRepository           *TeamRepository
+	orderRepository          *OrderRepository
+	orderEventRepository     *OrderEventRepository
+	orderLineRepository      *OrderLineRepository
+	payrollRepository        *PayrollRepository
+	projectRepository        *ProjectRepository
+	refreshTokenRepository   *RefreshTokenRepository
+	employeeRepository       *EmployeeRepository
+	roleRepository           *RoleRepository
+	rolePermissionRepository *RolePermissionRepository
+	slaRepository            *SLARepository
+	employeeTeamRepository   *EmployeeTeamRepository
+}
+
+func New() *Store {
+	return &Store{
+		data: newData(),
+	}
+}
+
+// data - таблицы хранилища. Уникальные ключи соответствуют уникальным
+// индексам моделей.
+type data struct {
+	employees       table[model.Employee]
+	employeeTeams   table[model.EmployeeTeam]
+	orders          table[model.Order]
+	orderEvents     table[model.OrderEvent]
+	orderLines      table[model.OrderLine]
+	orderShares     table[model.OrderShare]
+	tariffs         table[model.Tariff]
+	payrollPeriods  table[model.PayrollPeriod]
+	payrollEntries  table[model.PayrollEntry]
+	payrollOrders   table[model.PayrollOrder]
+	projects        table[model.Project]
+	refreshTokens   table[model.RefreshToken]
+	roles           table[model.Role]
+	rolePermissions table[model.RolePermission]
+	slaRules        table[model.SLARule]
+	teams           table[model.Team]
+	users           table[model.User]
+	userProjects    table[model.UserProject]
+	userRoles       table[model.UserRole]
+	userTeams       table[model.UserTeam]
+}
+
+func newData() *data {
+	type orderLineKey struct {
+		orderUID uint
+		lineNo   int
+	}
+	type rolePermissionKey struct {
+		roleID     uint
+		permission model.Permission
+	}
+
+	return &data{
+		employees:     newTable(func(e model.Employee) any { return e.Code }),
+		employeeTeams: newTable[model.EmployeeTeam](),
+		orders:        newTable(func(o model.Order) any { return o.OrderUid }),
+		orderEvents:   newTable[model.OrderEvent](),
+		orderLines: newTable(func(l model.OrderLine) any {
+			return orderLineKey{l.OrderUID, l.LineNo}
+		}),
+		orderShares:    newTable[model.OrderShare](),
+		tariffs:        newTable(func(t model.Tariff) any { return t.VidDoc }),
+		payrollPeriods: newTable[model.PayrollPeriod](),
+		payrollEntries: newTable[model.PayrollEntry](),
+		payrollOrders:  newTable(func(o model.PayrollOrder) any { return o.OrderUID }),
+		projects:       newTable(func(p model.Project) any { return p.Name }),
+		refreshTokens:  newTable(func(t model.RefreshToken) any { return t.TokenHash }),
+		roles:          newTable(func(r model.Role) any { return r.Name }),
+		rolePermissions: newTable(func(rp model.RolePermission) any {
+			return rolePermissionKey{rp.RoleID, rp.Permission}
+		}),
+		slaRules:     newTable(func(r model.SLARule) any { return r.VidDoc }),
+		teams:        newTable(func(t model.Team) any { return t.Name }),
+		users:        newTable(func(u model.User) any { return u.Email }),
+		userProjects: newTable[model.UserProject](),
+		userRoles:    newTable[model.UserRole](),
+		userTeams:    newTable[model.UserTeam](),
+	}
+}
+
+func (d *data) clone() *data {
+	return &data{
+		employees:       d.employees.clone(),
+		employeeTeams:   d.employeeTeams.clone(),
+		orders:          d.orders.clone(),
+		orderEvents:     d.orderEvents.clone(),
+		orderLines:      d.orderLines.clone(),
+		orderShares:     d.orderShares.clone(),
+		tariffs:         d.tariffs.clone(),
+		payrollPeriods:  d.payrollPeriods.clone(),
+		payrollEntries:  d.payrollEntries.clone(),
+		payrollOrders:   d.payrollOrders.clone(),
+		projects:        d.projects.clone(),
+		refreshTokens:   d.refreshTokens.clone(),
+		roles:           d.roles.clone(),
+		rolePermissions: d.rolePermissions.clone(),
+		slaRules:        d.slaRules.clone(),
+		teams:           d.teams.clone(),
+		users:           d.users.clone(),
+		userProjects:    d.userProjects.clone(),
+		userRoles:       d.userRoles.clone(),
+		userTeams:       d.userTeams.clone(),
+	}
+}
+
+// WithTx выполняет fn над копией данных и при успехе заменяет ими данные
+// хранилища. Пока транзакция не завершена, остальные обращения к хранилищу
+// ждут ее окончания, поэтому внутри fn работать можно только с переданным ей хранилищем.
+func (s *Store) WithTx(ctx context.Context, fn func(store.Store) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &Store{data: s.data.clone()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	s.data = tx.data
+	return nil
+}
+
+// read выполняет fn над данными хранилища. Отмененный контекст прерывает
+// обращение, как прерывал бы запрос к базе.
+func read[T any](s *Store, ctx context.Context, fn func(d *data) (T, error)) (result T, err error) {
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.data)
+}
+
+// write выполняет fn над копией данных и сохраняет изменения, только если fn
+// завершилась без ошибки: так изменения нескольких таблиц применяются целиком.
+func write[T any](s *Store, ctx context.Context, fn func(d *data) (T, error)) (result T, err error) {
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.data.clone()
+	if result, err = fn(d); err != nil {
+		return result, err
+	}
+	s.data = d
+	return result, nil
+}
+
+// exec - write для изменений, которые ничего не возвращают.
+func exec(s *Store, ctx context.Context, fn func(d *data) error) error {
+	_, err := write(s, ctx, func(d *data) (struct{}, error) {
+		return struct{}{}, fn(d)
+	})
+	return err
+}
+
+func (s *Store) User() store.UserRepository {
+	if s.userRepository != nil {
+		return s.userRepository
+	}
+
+	s.userRepository = &UserRepository{
+		store: s,
+	}
+
+	return s.userRepository
+}
+
+func (s *Store) Team() store.TeamRepository {
+	if s.teamRepository != nil {
+		return s.teamRepository
+	}
+
+	s.teamRepository = &TeamRepository{
+		store: s,
+	}
+
+	return s.teamRepository
+}
+
+func (s *Store) Order() store.OrderRepository {
+	if s.orderRepository != nil {
+		return s.orderRepository
+	}
+
+	s.orderRepository = &OrderRepository{
+		store: s,
+	}
+
+	return s.orderRepository
+}
+
+func (s *Store) OrderEvent() store.OrderEventRepository {
+	if s.orderEventRepository != nil {
+		return s.orderEventRepository
+	}
+
+	s.orderEventRepository = &OrderEventRepository{
+		store: s,
+	}
+
+	return s.orderEventRepository
+}
+
+func (s *Store) OrderLine() store.OrderLineRepository {
+	if s.orderLineRepository != nil {
+		return s.orderLineRepository
+	}
+
+	s.orderLineRepository = &OrderLineRepository{
+		store: s,
+	}
+
+	return s.orderLineRepository
+}
+
+func (s *Store) Project() store.ProjectRepository {
+	if s.projectRepository != nil {
+		return s.projectRepository
+	}
+
+	s.projectRepository = &ProjectRepository{
+		store: s,
+	}
+
+	return s.projectRepository
+}
+
+func (s *Store) RefreshToken() store.RefreshTokenRepository {
+	if s.refreshTokenRepository != nil {
+		return s.refreshTokenRepository
+	}
+
+	s.refreshTokenRepository = &RefreshTokenRepository{
+		store: s,
+	}
+
+	return s.refreshTokenRepository
+}
+
+func (s *Store) Role() store.RoleRepository {
+	if s.roleRepository != nil {
+		return s.roleRepository
+	}
+
+	s.roleRepository = &RoleRepository{
+		store: s,
+	}
+
+	return s.roleRepository
+}
+
+func (s *Store) RolePermission() store.RolePermissionRepository {
+	if s.rolePermissionRepository != nil {
+		return s.rolePermissionRepository
+	}
+
+	s.rolePermissionRepository = &RolePermissionRepository{
+		store: s,
+	}
+
+	return s.rolePermissionRepository
+}
+
+func (s *Store) Employee() store.EmployeeRepository {
+	if s.employeeRepository != nil {
+		return s.employeeRepository
+	}
+
+	s.employeeRepository = &EmployeeRepository{
+		store: s,
+	}
+
+	return s.employeeRepository
+}
+
+func (s *Store) UserTeam() store.UserTeamRepository {
+	if s.userTeamRepository != nil {
+		return s.userTeamRepository
+	}
+
+	s.userTeamRepository = &UserTeamRepository{
+		store: s,
+	}
+
+	return s.userTeamRepository
+}
+
+func (s *Store) UserRole() store.UserRoleRepository {
+	if s.userRoleRepository != nil {
+		return s.userRoleRepository
+	}
+
+	s.userRoleRepository = &UserRoleRepository{
+		store: s,
+	}
+
+	return s.userRoleRepository
+}
+
+func (s *Store) UserProject() store.UserProjectRepository {
+	if s.userProjectRepository != nil {
+		return s.userProjectRepository
+	}
+
+	s.userProjectRepository = &UserProjectRepository{
+		store: s,
+	}
+
+	return s.userProjectRepository
+}
+
+func (s *Store) EmployeeTeam() store.EmployeeTeamRepository {
+	if s.employeeTeamRepository != nil {
+		return s.employeeTeamRepository
+	}
+
+	s.employeeTeamRepository = &EmployeeTeamRepository{
+		store: s,
+	}
+
+	return s.employeeTeamRepository
+}
+
+func (s *Store) Payroll() store.PayrollRepository {
+	if s.payrollRepository != nil {
+		return s.payrollRepository
+	}
+
+	s.payrollRepository = &PayrollRepository{
+		store: s,
+	}
+
+	return s.payrollRepository
+}
+
+func (s *Store) SLA() store.SLARepository {
+	if s.slaRepository != nil {
+		return s.slaRepository
+	}
+
+	s.slaRepository = &SLARepository{
+		store: s,
+	}
+
+	return s.slaRepository
+}
@@ -0,0 +1,14 @@
+package teststore_test
+
+import (
+	"eastwh/internal/store"
+	"eastwh/internal/store/storetest"
+	"eastwh/internal/store/teststore"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(*testing.T) store.Store {
+		return teststore.New()
+	})
+}
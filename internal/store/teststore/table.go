@@ -0,0 +1,226 @@
+package teststore
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// table - записи одной таблицы в порядке id. Мягко удаленные записи остаются
+// в таблице, как в базе: они не попадают в выборки, но занимают уникальные ключи.
+type table[T any] struct {
+	rows   []T
+	nextID uint
+	// unique - ключи уникальных индексов записи
+	unique []func(T) any
+}
+
+func newTable[T any](unique ...func(T) any) table[T] {
+	return table[T]{unique: unique}
+}
+
+func (t table[T]) clone() table[T] {
+	t.rows = slices.Clone(t.rows)
+	return t
+}
+
+// insert присваивает записи id и время создания, как Create в gorm.
+func (t *table[T]) insert(row T) (T, error) {
+	v := reflect.ValueOf(&row).Elem()
+	id := rowID(v)
+	if id == 0 {
+		id = t.nextID + 1
+		v.FieldByName("ID").SetUint(uint64(id))
+	} else if _, found := t.index(id); found {
+		return row, gorm.ErrDuplicatedKey
+	}
+	t.nextID = max(t.nextID, id)
+
+	now := time.Now()
+	for _, name := range []string{"CreatedAt", "UpdatedAt"} {
+		if f := v.FieldByName(name); f.IsValid() && f.Interface().(time.Time).IsZero() {
+			f.Set(reflect.ValueOf(now))
+		}
+	}
+	if err := t.checkUnique(row, id); err != nil {
+		return row, err
+	}
+
+	i, _ := t.index(id)
+	t.rows = slices.Insert(t.rows, i, row)
+	return row, nil
+}
+
+// all возвращает неудаленные записи.
+func (t *table[T]) all() []T {
+	return t.find(func(T) bool { return true })
+}
+
+// find возвращает неудаленные записи, для которых match возвращает true.
+// Пустой результат - пустой срез, как у Find в gorm.
+func (t *table[T]) find(match func(T) bool) []T {
+	rows := make([]T, 0)
+	for _, row := range t.rows {
+		if !deleted(row) && match(row) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// findWithDeleted - find, включая мягко удаленные записи. Так читаются таблицы
+// связей many2many в Preload и таблицы в запросах без условия deleted_at.
+func (t *table[T]) findWithDeleted(match func(T) bool) []T {
+	rows := make([]T, 0)
+	for _, row := range t.rows {
+		if match(row) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// first возвращает первую по id неудаленную запись или gorm.ErrRecordNotFound.
+func (t *table[T]) first(match func(T) bool) (T, error) {
+	for _, row := range t.rows {
+		if !deleted(row) && match(row) {
+			return row, nil
+		}
+	}
+	var zero T
+	return zero, gorm.ErrRecordNotFound
+}
+
+func (t *table[T]) byID(id uint) (T, error) {
+	return t.first(byID[T](id))
+}
+
+// joined - запись с id, в том числе мягко удаленная, как в LEFT JOIN без условия deleted_at.
+func (t *table[T]) joined(id uint) (T, bool) {
+	i, found := t.index(id)
+	if !found {
+		var zero T
+		return zero, false
+	}
+	return t.rows[i], true
+}
+
+// update применяет fn к неудаленным записям, для которых match возвращает true,
+// и отмечает время изменения. Возвращает число измененных записей.
+func (t *table[T]) update(match func(T) bool, fn func(*T)) (int, error) {
+	n := 0
+	for i := range t.rows {
+		if deleted(t.rows[i]) || !match(t.rows[i]) {
+			continue
+		}
+		row := t.rows[i]
+		fn(&row)
+		if f := reflect.ValueOf(&row).Elem().FieldByName("UpdatedAt"); f.IsValid() {
+			f.Set(reflect.ValueOf(time.Now()))
+		}
+		if err := t.checkUnique(row, idOf(row)); err != nil {
+			return n, err
+		}
+		t.rows[i] = row
+		n++
+	}
+	return n, nil
+}
+
+// save записывает row целиком: существующую запись с тем же id заменяет,
+// иначе добавляет новую, как Save в gorm.
+func (t *table[T]) save(row T) (T, error) {
+	id := idOf(row)
+	i, found := t.index(id)
+	if id == 0 || !found {
+		return t.insert(row)
+	}
+
+	v := reflect.ValueOf(&row).Elem()
+	if f := v.FieldByName("UpdatedAt"); f.IsValid() {
+		f.Set(reflect.ValueOf(time.Now()))
+	}
+	if err := t.checkUnique(row, id); err != nil {
+		return row, err
+	}
+	t.rows[i] = row
+	return row, nil
+}
+
+// deleteByID мягко удаляет запись с id или возвращает gorm.ErrRecordNotFound.
+func (t *table[T]) deleteByID(id uint) error {
+	if _, err := t.byID(id); err != nil {
+		return err
+	}
+	t.softDelete(byID[T](id))
+	return nil
+}
+
+// softDelete отмечает записи удаленными, как Delete в gorm для моделей с DeletedAt.
+func (t *table[T]) softDelete(match func(T) bool) {
+	at := reflect.ValueOf(gorm.DeletedAt{Time: time.Now(), Valid: true})
+	for i := range t.rows {
+		if !deleted(t.rows[i]) && match(t.rows[i]) {
+			reflect.ValueOf(&t.rows[i]).Elem().FieldByName("DeletedAt").Set(at)
+		}
+	}
+}
+
+// remove удаляет записи безвозвратно, включая мягко удаленные, как DELETE в SQL.
+func (t *table[T]) remove(match func(T) bool) {
+	t.rows = slices.DeleteFunc(t.rows, match)
+}
+
+// replace заменяет записи таблицы целиком, как replaceAll в sqlstore:
+// старые записи удаляются безвозвратно, новые получают id заново.
+func (t *table[T]) replace(rows []T) ([]T, error) {
+	t.rows = nil
+	for i := range rows {
+		reflect.ValueOf(&rows[i]).Elem().FieldByName("ID").SetUint(0)
+		row, err := t.insert(rows[i])
+		if err != nil {
+			return rows, err
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// index - позиция записи с id или позиция, куда ее нужно вставить.
+func (t *table[T]) index(id uint) (int, bool) {
+	return slices.BinarySearchFunc(t.rows, id, func(row T, id uint) int {
+		return cmp.Compare(idOf(row), id)
+	})
+}
+
+func (t *table[T]) checkUnique(row T, id uint) error {
+	for _, key := range t.unique {
+		k := key(row)
+		for _, other := range t.rows {
+			if idOf(other) != id && key(other) == k {
+				return gorm.ErrDuplicatedKey
+			}
+		}
+	}
+	return nil
+}
+
+func byID[T any](id uint) func(T) bool {
+	return func(row T) bool { return idOf(row) == id }
+}
+
+func rowID(v reflect.Value) uint {
+	return uint(v.FieldByName("ID").Uint())
+}
+
+func idOf[T any](row T) uint {
+	return rowID(reflect.ValueOf(row))
+}
+
+func deleted[T any](row T) bool {
+	f := reflect.ValueOf(row).FieldByName("DeletedAt")
+	return f.IsValid() && f.Interface().(gorm.DeletedAt).Valid
+}
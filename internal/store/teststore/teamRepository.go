@@ -0,0 +1,69 @@
+package teststore
+
+import (
+	"context"
+	"eastwh/internal/model"
+	"slices"
+)
+
+type TeamRepository struct {
+	store *Store
+}
+
+func (r *TeamRepository) Add(ctx context.Context, u model.Team) (model.Team, error) {
+	return write(r.store, ctx, func(d *data) (model.Team, error) {
+		row := u
+		row.Users, row.Employees, row.TeamUsers = nil, nil, nil
+		row, err := d.teams.insert(row)
+		u.Model = row.Model
+		return u, err
+	})
+}
+
+func (r *TeamRepository) ByID(ctx context.Context, id uint) (model.Team, error) {
+	return read(r.store, ctx, func(d *data) (model.Team, error) {
+		return d.teams.byID(id)
+	})
+}
+
+func (r *TeamRepository) All(ctx context.Context) ([]model.Team, error) {
+	return read(r.store, ctx, func(d *data) ([]model.Team, error) {
+		teams := d.teams.all()
+		for i := range teams {
+			teams[i].Employees = d.teamEmployees(teams[i].ID)
+		}
+		return teams, nil
+	})
+}
+
+func (r *TeamRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.Team], error) {
+	return read(r.store, ctx, func(d *data) (model.Page[model.Team], error) {
+		page, err := list(d.teams.all(), q, model.TeamListSpec)
+		for i := range page.Items {
+			page.Items[i].Employees = d.teamEmployees(page.Items[i].ID)
+		}
+		return page, err
+	})
+}
+
+func (r *TeamRepository) Update(ctx context.Context, u model.Team) (model.Team, error) {
+	return write(r.store, ctx, func(d *data) (model.Team, error) {
+		_, err := d.teams.update(byID[model.Team](u.ID), func(t *model.Team) { t.Name = u.Name })
+		return u, err
+	})
+}
+
+func (r *TeamRepository) Delete(ctx context.Context, id uint) error {
+	return exec(r.store, ctx, func(d *data) error {
+		return d.teams.deleteByID(id)
+	})
+}
+
+// teamEmployees - сотрудники команды, как Preload("Employees").
+func (d *data) teamEmployees(teamID uint) []model.Employee {
+	var ids []uint
+	for _, et := range d.employeeTeams.findWithDeleted(func(et model.EmployeeTeam) bool { return et.TeamID == teamID }) {
+		ids = append(ids, et.EmployeeID)
+	}
+	return d.employees.find(func(e model.Employee) bool { return slices.Contains(ids, e.ID) })
+}
@@ -0,0 +1,70 @@
+package teststore
+
+import (
+	"context"
+	"eastwh/internal/model"
+)
+
+type UserProjectRepository struct {
+	store *Store
+}
+
+func (r *UserProjectRepository) Add(ctx context.Context, u model.UserProject) (model.UserProject, error) {
+	return write(r.store, ctx, func(d *data) (model.UserProject, error) {
+		return d.userProjects.insert(u)
+	})
+}
+
+func (r *UserProjectRepository) Update(ctx context.Context, userproject model.UserProject) (model.UserProject, error) {
+	return write(r.store, ctx, func(d *data) (model.UserProject, error) {
+		_, err := d.userProjects.update(byID[model.UserProject](userproject.ID), func(up *model.UserProject) {
+			up.UserID, up.ProjectID = userproject.UserID, userproject.ProjectID
+		})
+		return userproject, err
+	})
+}
+
+func (r *UserProjectRepository) Delete(ctx context.Context, id uint) error {
+	return exec(r.store, ctx, func(d *data) error {
+		return d.userProjects.deleteByID(id)
+	})
+}
+
+func (r *UserProjectRepository) DeleteUserProject(ctx context.Context, user_id, project_id uint) error {
+	return exec(r.store, ctx, func(d *data) error {
+		d.userProjects.remove(func(up model.UserProject) bool {
+			return up.UserID == user_id && up.ProjectID == project_id
+		})
+		return nil
+	})
+}
+
+func (r *UserProjectRepository) ByID(ctx context.Context, Id uint) (model.UserProject, error) {
+	return read(r.store, ctx, func(d *data) (model.UserProject, error) {
+		return d.userProjects.byID(Id)
+	})
+}
+
+func (r *UserProjectRepository) ByUserID(ctx context.Context, userID uint) ([]model.UserProject, error) {
+	return read(r.store, ctx, func(d *data) ([]model.UserProject, error) {
+		return d.userProjects.find(func(up model.UserProject) bool { return up.UserID == userID }), nil
+	})
+}
+
+func (r *UserProjectRepository) ByProjectID(ctx context.Context, projectID uint) ([]model.UserProject, error) {
+	return read(r.store, ctx, func(d *data) ([]model.UserProject, error) {
+		return d.userProjects.find(func(up model.UserProject) bool { return up.ProjectID == projectID }), nil
+	})
+}
+
+func (r *UserProjectRepository) All(ctx context.Context) ([]model.UserProject, error) {
+	return read(r.store, ctx, func(d *data) ([]model.UserProject, error) {
+		return d.userProjects.all(), nil
+	})
+}
+
+func (r *UserProjectRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.UserProject], error) {
+	return read(r.store, ctx, func(d *data) (model.Page[model.UserProject], error) {
+		return list(d.userProjects.all(), q, model.UserProjectListSpec)
+	})
+}
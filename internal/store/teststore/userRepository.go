@@ -0,0 +1,211 @@
+package teststore
+
+import (
+	"context"
+	"crypto/rand"
+	"eastwh/internal/model"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type UserRepository struct {
+	store *Store
+}
+
+func (r *UserRepository) Add(ctx context.Context, u model.User) (model.User, error) {
+	hash, err := hashPassword(u.Password)
+	if err != nil {
+		return u, err
+	}
+	return write(r.store, ctx, func(d *data) (model.User, error) {
+		row := u
+		row.Password = hash
+		row.Teams, row.Roles, row.Projects, row.TeamUsers = nil, nil, nil, nil
+		row, err := d.users.insert(row)
+		u.Model = row.Model
+		u.Password = ""
+		return u, err
+	})
+}
+
+func (r *UserRepository) Login(ctx context.Context, email, password string) (model.User, error) {
+	return write(r.store, ctx, func(d *data) (model.User, error) {
+		user, err := d.users.first(func(u model.User) bool { return u.Email == email })
+		if err != nil {
+			return user, err
+		}
+
+		if !checkPassword(user.Password, password) {
+			return user, errors.New("Invalid password")
+		}
+
+		d.users.update(byID[model.User](user.ID), func(u *model.User) { u.LoggedIn = true })
+		user, err = d.users.byID(user.ID)
+		user.Password = ""
+		return user, err
+	})
+}
+
+func (r *UserRepository) Logout(ctx context.Context, id uint) error {
+	return r.set(ctx, id, func(u *model.User) { u.LoggedIn, u.Token = false, "" })
+}
+
+func (r *UserRepository) UpdateToken(ctx context.Context, id uint, token string) error {
+	return r.set(ctx, id, func(u *model.User) { u.Token = token })
+}
+
+func (r *UserRepository) Restore(ctx context.Context, email string) (string, error) {
+	pass, err := generateTemporaryPassword()
+	if err != nil {
+		return "", err
+	}
+	hash, err := hashPassword(pass)
+	if err != nil {
+		return "", err
+	}
+
+	return write(r.store, ctx, func(d *data) (string, error) {
+		user, err := d.users.first(func(u model.User) bool { return u.Email == email })
+		if err != nil {
+			return "", err
+		}
+		_, err = d.users.update(byID[model.User](user.ID), func(u *model.User) { u.Password, u.Restore = hash, true })
+		return pass, err
+	})
+}
+
+func (r *UserRepository) ChangePassword(ctx context.Context, id uint, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	return r.set(ctx, id, func(u *model.User) { u.Password, u.Restore = hash, false })
+}
+
+func (r *UserRepository) All(ctx context.Context) ([]model.User, error) {
+	return read(r.store, ctx, func(d *data) ([]model.User, error) {
+		users := d.users.all()
+		for i := range users {
+			users[i].Projects = d.userProjectsOf(users[i].ID)
+			users[i].Password, users[i].Token = "", ""
+		}
+		return users, nil
+	})
+}
+
+func (r *UserRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.User], error) {
+	return read(r.store, ctx, func(d *data) (model.Page[model.User], error) {
+		page, err := list(d.users.all(), q, model.UserListSpec)
+		for i := range page.Items {
+			page.Items[i].Projects = d.userProjectsOf(page.Items[i].ID)
+			page.Items[i].Password, page.Items[i].Token = "", ""
+		}
+		return page, err
+	})
+}
+
+func (r *UserRepository) Profile(ctx context.Context, id uint) (model.User, error) {
+	return read(r.store, ctx, func(d *data) (model.User, error) {
+		u, err := d.users.byID(id)
+		if err != nil {
+			return model.User{}, err
+		}
+
+		teamIDs := make([]uint, 0)
+		for _, ut := range d.userTeams.findWithDeleted(func(ut model.UserTeam) bool { return ut.UserID == id }) {
+			teamIDs = append(teamIDs, ut.TeamID)
+		}
+		u.Teams = d.teams.find(func(t model.Team) bool { return slices.Contains(teamIDs, t.ID) })
+		for i := range u.Teams {
+			u.Teams[i].Employees = d.teamEmployees(u.Teams[i].ID)
+		}
+		u.Projects = d.userProjectsOf(id)
+		u.Password = ""
+		return u, nil
+	})
+}
+
+// EmployeeByUserID возвращает сотрудников команд пользователя.
+func (r *UserRepository) EmployeeByUserID(ctx context.Context, id uint) ([]model.UserEmployee, error) {
+	return read(r.store, ctx, func(d *data) ([]model.UserEmployee, error) {
+		u := make([]model.UserEmployee, 0)
+		for _, ut := range d.userTeams.findWithDeleted(func(ut model.UserTeam) bool { return ut.UserID == id }) {
+			for _, et := range d.employeeTeams.findWithDeleted(func(et model.EmployeeTeam) bool { return et.TeamID == ut.TeamID }) {
+				e, ok := d.employees.joined(et.EmployeeID)
+				if !ok {
+					continue
+				}
+				u = append(u, model.UserEmployee{ID: e.ID, Name: strings.Join([]string{e.FirstName, e.Name, e.LastName}, " ")})
+			}
+		}
+		return u, nil
+	})
+}
+
+func (r *UserRepository) Update(ctx context.Context, u model.User) (model.User, error) {
+	err := r.set(ctx, u.ID, func(user *model.User) {
+		user.FirstName, user.LastName, user.Name, user.Phone = u.FirstName, u.LastName, u.Name, u.Phone
+	})
+	if err != nil {
+		return model.User{}, err
+	}
+
+	u.Password = ""
+	return u, nil
+}
+
+func (r *UserRepository) ByID(ctx context.Context, id uint) (model.User, error) {
+	return read(r.store, ctx, func(d *data) (model.User, error) {
+		return d.users.byID(id)
+	})
+}
+
+func (r *UserRepository) ByEmail(ctx context.Context, email string) (model.User, error) {
+	return read(r.store, ctx, func(d *data) (model.User, error) {
+		return d.users.first(func(u model.User) bool { return u.Email == email })
+	})
+}
+
+func (r *UserRepository) BlockedUser(ctx context.Context, id uint, blocked bool) error {
+	return r.set(ctx, id, func(u *model.User) { u.Blocked = blocked })
+}
+
+// set изменяет пользователя с id; отсутствие пользователя не ошибка, как у UPDATE.
+func (r *UserRepository) set(ctx context.Context, id uint, fn func(*model.User)) error {
+	return exec(r.store, ctx, func(d *data) error {
+		_, err := d.users.update(byID[model.User](id), fn)
+		return err
+	})
+}
+
+// userProjectsOf - проекты пользователя, как Preload("Projects").
+func (d *data) userProjectsOf(userID uint) []model.Project {
+	var ids []uint
+	for _, up := range d.userProjects.findWithDeleted(func(up model.UserProject) bool { return up.UserID == userID }) {
+		ids = append(ids, up.ProjectID)
+	}
+	return d.projects.find(func(p model.Project) bool { return slices.Contains(ids, p.ID) })
+}
+
+// hashPassword хеширует пароль с минимальной стоимостью: тестам не нужна
+// стойкость хеша, а bcrypt со стоимостью по умолчанию заметно замедляет их.
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	return string(hash), err
+}
+
+func checkPassword(existingHash, incomingPass string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(existingHash), []byte(incomingPass)) == nil
+}
+
+func generateTemporaryPassword() (string, error) {
+	bytes := make([]byte, 6)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(bytes), nil
+}
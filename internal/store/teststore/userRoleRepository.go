@@ -0,0 +1,61 @@
+package teststore
+
+import (
+	"context"
+	"eastwh/internal/model"
+)
+
+type UserRoleRepository struct {
+	store *Store
+}
+
+func (r *UserRoleRepository) Add(ctx context.Context, u model.UserRole) (model.UserRole, error) {
+	return write(r.store, ctx, func(d *data) (model.UserRole, error) {
+		return d.userRoles.insert(u)
+	})
+}
+
+func (r *UserRoleRepository) Update(ctx context.Context, userrole model.UserRole) (model.UserRole, error) {
+	return write(r.store, ctx, func(d *data) (model.UserRole, error) {
+		_, err := d.userRoles.update(byID[model.UserRole](userrole.ID), func(ur *model.UserRole) {
+			ur.UserID, ur.RoleID = userrole.UserID, userrole.RoleID
+		})
+		return userrole, err
+	})
+}
+
+func (r *UserRoleRepository) Delete(ctx context.Context, id uint) error {
+	return exec(r.store, ctx, func(d *data) error {
+		return d.userRoles.deleteByID(id)
+	})
+}
+
+func (r *UserRoleRepository) ByID(ctx context.Context, ID uint) (model.UserRole, error) {
+	return read(r.store, ctx, func(d *data) (model.UserRole, error) {
+		return d.userRoles.byID(ID)
+	})
+}
+
+func (r *UserRoleRepository) ByUserID(ctx context.Context, userID uint) ([]model.UserRole, error) {
+	return read(r.store, ctx, func(d *data) ([]model.UserRole, error) {
+		return d.userRoles.find(func(ur model.UserRole) bool { return ur.UserID == userID }), nil
+	})
+}
+
+func (r *UserRoleRepository) ByRoleID(ctx context.Context, roleID uint) ([]model.UserRole, error) {
+	return read(r.store, ctx, func(d *data) ([]model.UserRole, error) {
+		return d.userRoles.find(func(ur model.UserRole) bool { return ur.RoleID == roleID }), nil
+	})
+}
+
+func (r *UserRoleRepository) All(ctx context.Context) ([]model.UserRole, error) {
+	return read(r.store, ctx, func(d *data) ([]model.UserRole, error) {
+		return d.userRoles.all(), nil
+	})
+}
+
+func (r *UserRoleRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.UserRole], error) {
+	return read(r.store, ctx, func(d *data) (model.Page[model.UserRole], error) {
+		return list(d.userRoles.all(), q, model.UserRoleListSpec)
+	})
+}
@@ -0,0 +1,75 @@
+package teststore
+
+import (
+	"context"
+	"eastwh/internal/model"
+)
+
+type UserTeamRepository struct {
+	store *Store
+}
+
+func (r *UserTeamRepository) Add(ctx context.Context, u model.UserTeam) (model.UserTeam, error) {
+	return write(r.store, ctx, func(d *data) (model.UserTeam, error) {
+		row := u
+		row.Team, row.User = model.Team{}, model.User{}
+		row, err := d.userTeams.insert(row)
+		u.Model = row.Model
+		return u, err
+	})
+}
+
+func (r *UserTeamRepository) Update(ctx context.Context, userteam model.UserTeam) (model.UserTeam, error) {
+	return write(r.store, ctx, func(d *data) (model.UserTeam, error) {
+		row := userteam
+		row.Team, row.User = model.Team{}, model.User{}
+		row, err := d.userTeams.save(row)
+		userteam.Model = row.Model
+		return userteam, err
+	})
+}
+
+func (r *UserTeamRepository) Delete(ctx context.Context, id uint) error {
+	return exec(r.store, ctx, func(d *data) error {
+		return d.userTeams.deleteByID(id)
+	})
+}
+
+func (r *UserTeamRepository) DeleteUserTeam(ctx context.Context, team_id, user_id uint) error {
+	return exec(r.store, ctx, func(d *data) error {
+		d.userTeams.remove(func(ut model.UserTeam) bool {
+			return ut.TeamID == team_id && ut.UserID == user_id
+		})
+		return nil
+	})
+}
+
+func (r *UserTeamRepository) ByID(ctx context.Context, ID uint) (model.UserTeam, error) {
+	return read(r.store, ctx, func(d *data) (model.UserTeam, error) {
+		return d.userTeams.byID(ID)
+	})
+}
+
+func (r *UserTeamRepository) ByUserID(ctx context.Context, userID uint) ([]model.UserTeam, error) {
+	return read(r.store, ctx, func(d *data) ([]model.UserTeam, error) {
+		return d.userTeams.find(func(ut model.UserTeam) bool { return ut.UserID == userID }), nil
+	})
+}
+
+func (r *UserTeamRepository) ByTeamID(ctx context.Context, teamID uint) ([]model.UserTeam, error) {
+	return read(r.store, ctx, func(d *data) ([]model.UserTeam, error) {
+		return d.userTeams.find(func(ut model.UserTeam) bool { return ut.TeamID == teamID }), nil
+	})
+}
+
+func (r *UserTeamRepository) All(ctx context.Context) ([]model.UserTeam, error) {
+	return read(r.store, ctx, func(d *data) ([]model.UserTeam, error) {
+		return d.userTeams.all(), nil
+	})
+}
+
+func (r *UserTeamRepository) List(ctx context.Context, q model.ListQuery) (model.Page[model.UserTeam], error) {
+	return read(r.store, ctx, func(d *data) (model.Page[model.UserTeam], error) {
+		return list(d.userTeams.all(), q, model.UserTeamListSpec)
+	})
+}
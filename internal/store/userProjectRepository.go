@@ -1,14 +1,18 @@
 package store
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type UserProjectRepository interface {
-	Add(model.UserProject) (model.UserProject, error)
-	All() ([]model.UserProject, error)
-	ByID(uint) (model.UserProject, error)
-	ByUserID(uint) ([]model.UserProject, error)
-	ByProjectID(uint) ([]model.UserProject, error)
-	Update(model.UserProject) (model.UserProject, error)
-	Delete(uint) error
-	DeleteUserProject(uint, uint) error
+	Add(context.Context, model.UserProject) (model.UserProject, error)
+	All(context.Context) ([]model.UserProject, error)
+	List(context.Context, model.ListQuery) (model.Page[model.UserProject], error)
+	ByID(context.Context, uint) (model.UserProject, error)
+	ByUserID(context.Context, uint) ([]model.UserProject, error)
+	ByProjectID(context.Context, uint) ([]model.UserProject, error)
+	Update(context.Context, model.UserProject) (model.UserProject, error)
+	Delete(context.Context, uint) error
+	DeleteUserProject(context.Context, uint, uint) error
 }
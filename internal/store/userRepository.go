@@ -1,19 +1,23 @@
 package store
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type UserRepository interface {
-	Add(model.User) (model.User, error)
-	Login(string, string) (model.User, error)
-	Logout(uint) error
-	Restore(string) (string, error)
-	ChangePassword(uint, string) error
-	All() ([]model.User, error)
-	Profile(uint) (model.User, error)
-	Update(model.User) (model.User, error)
-	ByID(uint) (model.User, error)
-	ByEmail(string) (model.User, error)
-	UpdateToken(uint, string) error
-	BlockedUser(uint, bool) error
-	EmployeeByUserID(uint) ([]model.UserEmployee, error)
+	Add(context.Context, model.User) (model.User, error)
+	Login(context.Context, string, string) (model.User, error)
+	Logout(context.Context, uint) error
+	Restore(context.Context, string) (string, error)
+	ChangePassword(context.Context, uint, string) error
+	All(context.Context) ([]model.User, error)
+	List(context.Context, model.ListQuery) (model.Page[model.User], error)
+	Profile(context.Context, uint) (model.User, error)
+	Update(context.Context, model.User) (model.User, error)
+	ByID(context.Context, uint) (model.User, error)
+	ByEmail(context.Context, string) (model.User, error)
+	UpdateToken(context.Context, uint, string) error
+	BlockedUser(context.Context, uint, bool) error
+	EmployeeByUserID(context.Context, uint) ([]model.UserEmployee, error)
 }
@@ -1,13 +1,17 @@
 package store
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type UserRoleRepository interface {
-	Add(model.UserRole) (model.UserRole, error)
-	All() ([]model.UserRole, error)
-	ByID(uint) (model.UserRole, error)
-	ByUserID(uint) ([]model.UserRole, error)
-	ByRoleID(uint) ([]model.UserRole, error)
-	Update(model.UserRole) (model.UserRole, error)
-	Delete(uint) error
+	Add(context.Context, model.UserRole) (model.UserRole, error)
+	All(context.Context) ([]model.UserRole, error)
+	List(context.Context, model.ListQuery) (model.Page[model.UserRole], error)
+	ByID(context.Context, uint) (model.UserRole, error)
+	ByUserID(context.Context, uint) ([]model.UserRole, error)
+	ByRoleID(context.Context, uint) ([]model.UserRole, error)
+	Update(context.Context, model.UserRole) (model.UserRole, error)
+	Delete(context.Context, uint) error
 }
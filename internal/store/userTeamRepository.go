@@ -1,14 +1,18 @@
 package store
 
-import "eastwh/internal/model"
+import (
+	"context"
+	"eastwh/internal/model"
+)
 
 type UserTeamRepository interface {
-	Add(model.UserTeam) (model.UserTeam, error)
-	All() ([]model.UserTeam, error)
-	ByID(uint) (model.UserTeam, error)
-	ByUserID(uint) ([]model.UserTeam, error)
-	ByTeamID(uint) ([]model.UserTeam, error)
-	Update(model.UserTeam) (model.UserTeam, error)
-	Delete(uint) error
-	DeleteUserTeam(uint, uint) error
+	Add(context.Context, model.UserTeam) (model.UserTeam, error)
+	All(context.Context) ([]model.UserTeam, error)
+	List(context.Context, model.ListQuery) (model.Page[model.UserTeam], error)
+	ByID(context.Context, uint) (model.UserTeam, error)
+	ByUserID(context.Context, uint) ([]model.UserTeam, error)
+	ByTeamID(context.Context, uint) ([]model.UserTeam, error)
+	Update(context.Context, model.UserTeam) (model.UserTeam, error)
+	Delete(context.Context, uint) error
+	DeleteUserTeam(context.Context, uint, uint) error
 }